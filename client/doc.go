@@ -0,0 +1,4 @@
+// Package client fetches the metrics of the NGINX stub_status page.
+//
+// For the NGINX Plus API, use github.com/nginx/nginx-plus-go-client/v2/client.
+package client
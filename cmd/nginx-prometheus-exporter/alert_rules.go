@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// alertRulesConfig holds the settings of the exporter the generated alerting rules
+// depend on.
+type alertRulesConfig struct {
+	constLabels   map[string]string
+	namespace     string
+	plusNamespace string
+	plus          bool
+	// plusStubStatus reports whether the config metrics of the NGINX collector are
+	// exported along with the NGINX Plus metrics (--nginx.plus.stub-status-uri).
+	plusStubStatus bool
+}
+
+type ruleFile struct {
+	Groups []ruleGroup `yaml:"groups"`
+}
+
+type ruleGroup struct {
+	Name  string      `yaml:"name"`
+	Rules []alertRule `yaml:"rules"`
+}
+
+type alertRule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels"`
+	Annotations map[string]string `yaml:"annotations"`
+}
+
+// printAlertRules : alert-rules 서브커맨드. exporter 의 namespace 와 const label 에 맞춘 Prometheus alerting rule 파일을 출력한다.
+func printAlertRules(w io.Writer, config alertRulesConfig) error {
+	content, err := yaml.Marshal(alertRules(config))
+	if err != nil {
+		return fmt.Errorf("failed to encode alerting rules: %w", err)
+	}
+	if _, err := w.Write(content); err != nil {
+		return fmt.Errorf("failed to write alerting rules: %w", err)
+	}
+	return nil
+}
+
+// alertRules : NGINX 또는 NGINX Plus 의 metric 에 대한 기본 alerting rule 을 만든다. 인증서 만료를 알리는 rule 은 exporter 가
+// 인증서 만료 시각을 수집하지 않으므로 만들지 않는다.
+func alertRules(config alertRulesConfig) ruleFile {
+	selector := labelSelector(config.constLabels)
+	rule := func(name, expr, duration, severity, summary, description string) alertRule {
+		return alertRule{
+			Alert:  name,
+			Expr:   expr,
+			For:    duration,
+			Labels: map[string]string{"severity": severity},
+			Annotations: map[string]string{
+				"summary":     summary,
+				"description": description,
+			},
+		}
+	}
+
+	var rules []alertRule
+	if config.plus {
+		ns := config.plusNamespace
+		rules = append(rules,
+			rule("NginxPlusDown", ns+"_up"+selector+" == 0", "1m", "critical",
+				"NGINX Plus is down",
+				"The exporter at {{ $labels.instance }} cannot scrape the NGINX Plus API."),
+			rule("NginxPlusDroppedConnections", "increase("+ns+"_connections_dropped"+selector+"[5m]) > 0", "", "warning",
+				"NGINX Plus is dropping client connections",
+				"NGINX Plus at {{ $labels.instance }} dropped {{ $value }} client connections in the last 5 minutes."),
+			rule("NginxPlusUpstreamServerUnhealthy", ns+"_upstream_server_health_checks_last_passed"+selector+" == 0", "5m", "warning",
+				"NGINX Plus upstream server fails its health checks",
+				"Server {{ $labels.server }} of upstream {{ $labels.upstream }} at {{ $labels.instance }} has failed its health checks for 5 minutes."),
+		)
+		// 설정 파일의 수정 시각은 NGINX collector 만 수집하므로, stub_status 를 함께 수집할 때만 비교할 수 있다.
+		if config.plusStubStatus {
+			rules = append(rules,
+				rule("NginxConfigReloadPending",
+					"max by (job, instance) ("+config.namespace+"_config_last_modified_seconds"+selector+") > on (job, instance) max by (job, instance) ("+ns+"_nginx_load_timestamp"+selector+")",
+					"15m", "warning",
+					"NGINX config changed without a reload",
+					"NGINX Plus at {{ $labels.instance }} has not been reloaded for 15 minutes since a config file was modified."),
+			)
+		}
+	} else {
+		ns := config.namespace
+		rules = append(rules,
+			rule("NginxDown", ns+"_up"+selector+" == 0", "1m", "critical",
+				"NGINX is down",
+				"The exporter at {{ $labels.instance }} cannot scrape the NGINX stub_status page."),
+			rule("NginxDroppedConnections",
+				"increase("+ns+"_connections_accepted"+selector+"[5m]) - increase("+ns+"_connections_handled"+selector+"[5m]) > 0",
+				"", "warning",
+				"NGINX is dropping client connections",
+				"NGINX at {{ $labels.instance }} accepted {{ $value }} more connections than it handled in the last 5 minutes, usually because of worker_connections."),
+			rule("NginxUpstreamHealthCheckFailing", ns+"_upstream_health_check_status"+selector+" == 0", "5m", "warning",
+				"NGINX proxy target is unreachable",
+				"Proxy target {{ $labels.target }} of {{ $labels.file }} at {{ $labels.instance }} has refused TCP connections for 5 minutes."),
+		)
+	}
+
+	return ruleFile{Groups: []ruleGroup{{Name: "nginx-prometheus-exporter", Rules: rules}}}
+}
+
+// labelSelector returns a PromQL label selector matching the const labels, or "" when
+// there are none.
+func labelSelector(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	matchers := make([]string, 0, len(names))
+	for _, name := range names {
+		matchers = append(matchers, fmt.Sprintf("%v=%q", name, labels[name]))
+	}
+	return "{" + strings.Join(matchers, ",") + "}"
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestAlertRules(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		config    alertRulesConfig
+		wantExprs map[string]string
+	}{
+		{
+			name:   "nginx",
+			config: alertRulesConfig{namespace: "edge", plusNamespace: "nginxplus", constLabels: map[string]string{"env": "prod", "dc": "a\"b"}},
+			wantExprs: map[string]string{
+				"NginxDown":                       `edge_up{dc="a\"b",env="prod"} == 0`,
+				"NginxDroppedConnections":         `increase(edge_connections_accepted{dc="a\"b",env="prod"}[5m]) - increase(edge_connections_handled{dc="a\"b",env="prod"}[5m]) > 0`,
+				"NginxUpstreamHealthCheckFailing": `edge_upstream_health_check_status{dc="a\"b",env="prod"} == 0`,
+			},
+		},
+		{
+			name:   "nginx plus",
+			config: alertRulesConfig{namespace: "nginx", plusNamespace: "plus", plus: true},
+			wantExprs: map[string]string{
+				"NginxPlusDown":                    `plus_up == 0`,
+				"NginxPlusDroppedConnections":      `increase(plus_connections_dropped[5m]) > 0`,
+				"NginxPlusUpstreamServerUnhealthy": `plus_upstream_server_health_checks_last_passed == 0`,
+			},
+		},
+		{
+			name:   "nginx plus with stub_status",
+			config: alertRulesConfig{namespace: "nginx", plusNamespace: "plus", plus: true, plusStubStatus: true},
+			wantExprs: map[string]string{
+				"NginxPlusDown":                    `plus_up == 0`,
+				"NginxPlusDroppedConnections":      `increase(plus_connections_dropped[5m]) > 0`,
+				"NginxPlusUpstreamServerUnhealthy": `plus_upstream_server_health_checks_last_passed == 0`,
+				"NginxConfigReloadPending":         `max by (job, instance) (nginx_config_last_modified_seconds) > on (job, instance) max by (job, instance) (plus_nginx_load_timestamp)`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			if err := printAlertRules(&out, tt.config); err != nil {
+				t.Fatalf("printAlertRules() returned error: %v", err)
+			}
+			var file ruleFile
+			if err := yaml.UnmarshalStrict(out.Bytes(), &file); err != nil {
+				t.Fatalf("printAlertRules() wrote an invalid rules file: %v", err)
+			}
+			if len(file.Groups) != 1 {
+				t.Fatalf("printAlertRules() wrote %d groups, want 1", len(file.Groups))
+			}
+
+			got := make(map[string]string)
+			for _, rule := range file.Groups[0].Rules {
+				got[rule.Alert] = rule.Expr
+				if rule.Labels["severity"] == "" || rule.Annotations["summary"] == "" {
+					t.Errorf("rule %v has no severity or summary", rule.Alert)
+				}
+			}
+			if len(got) != len(tt.wantExprs) {
+				t.Errorf("printAlertRules() wrote rules %v, want %v", got, tt.wantExprs)
+			}
+			for alert, want := range tt.wantExprs {
+				if got[alert] != want {
+					t.Errorf("expr of %v = %v, want %v", alert, got[alert], want)
+				}
+			}
+		})
+	}
+}
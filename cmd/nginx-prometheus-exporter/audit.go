@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/nginx/nginx-prometheus-exporter/collector"
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+)
+
+// Output formats of the audit subcommand.
+const (
+	auditFormatTable = "table"
+	auditFormatJSON  = "json"
+)
+
+// auditSeverityNone disables failing the audit subcommand on findings.
+const auditSeverityNone = "none"
+
+// audit : audit 서브커맨드. nginx_config_security_findings 와 같은 규칙으로 설정을 검사하여 결과를 출력하고, failSeverity 이상의
+// finding 이 있으면 true 를 반환한다.
+func audit(w io.Writer, configPath string, format string, failSeverity string) (bool, error) {
+	cfg, err := nginxconf.Parse(configPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse nginx config: %w", err)
+	}
+	findings := collector.SecurityFindings(cfg)
+
+	if format == auditFormatJSON {
+		if findings == nil {
+			findings = []collector.SecurityFinding{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(findings); err != nil {
+			return false, fmt.Errorf("failed to write findings: %w", err)
+		}
+	} else {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "SOURCE\tSEVERITY\tRULE\tCONTEXT\tMESSAGE")
+		for _, f := range findings {
+			fmt.Fprintf(tw, "%v:%v\t%v\t%v\t%v\t%v\n", f.File, f.Line, f.Severity, f.Rule, f.Context, f.Message)
+		}
+		if err := tw.Flush(); err != nil {
+			return false, fmt.Errorf("failed to write findings: %w", err)
+		}
+	}
+
+	if failSeverity == auditSeverityNone {
+		return false, nil
+	}
+	threshold := collector.SecuritySeverityRank(failSeverity)
+	for _, f := range findings {
+		if collector.SecuritySeverityRank(f.Severity) >= threshold {
+			return true, nil
+		}
+	}
+	return false, nil
+}
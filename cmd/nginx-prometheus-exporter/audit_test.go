@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nginx/nginx-prometheus-exporter/collector"
+)
+
+func TestAudit(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "nginx.conf")
+	config := `http {
+    server {
+        listen 443 ssl;
+        server_name example.com;
+        add_header Strict-Transport-Security "max-age=31536000";
+        location /files { autoindex on; }
+    }
+}
+`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		failSeverity string
+		wantFailed   bool
+	}{
+		{name: "fail on low", failSeverity: collector.SecuritySeverityLow, wantFailed: true},
+		{name: "fail on medium", failSeverity: collector.SecuritySeverityMedium, wantFailed: true},
+		{name: "fail on high", failSeverity: collector.SecuritySeverityHigh, wantFailed: false},
+		{name: "never fail", failSeverity: auditSeverityNone, wantFailed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			failed, err := audit(&out, path, auditFormatTable, tt.failSeverity)
+			if err != nil {
+				t.Fatalf("audit() returned error: %v", err)
+			}
+			if failed != tt.wantFailed {
+				t.Errorf("audit() = %v, want %v", failed, tt.wantFailed)
+			}
+			if !strings.Contains(out.String(), path+":6") || !strings.Contains(out.String(), "location:/files") {
+				t.Errorf("audit() output misses the autoindex finding:\n%v", out.String())
+			}
+		})
+	}
+
+	var out bytes.Buffer
+	if _, err := audit(&out, path, auditFormatJSON, auditSeverityNone); err != nil {
+		t.Fatalf("audit() returned error: %v", err)
+	}
+	var findings []collector.SecurityFinding
+	if err := json.Unmarshal(out.Bytes(), &findings); err != nil {
+		t.Fatalf("audit() wrote invalid JSON: %v", err)
+	}
+	want := []collector.SecurityFinding{
+		{Rule: collector.SecurityRuleServerTokens, Severity: collector.SecuritySeverityLow, File: path, Line: 2, Context: "server:example.com", Message: "server_tokens is not set and defaults to on, which discloses the NGINX version"},
+		{Rule: collector.SecurityRuleAutoindex, Severity: collector.SecuritySeverityMedium, File: path, Line: 6, Context: "location:/files", Message: "autoindex on lists the contents of directories"},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("audit() wrote %d findings, want %d: %+v", len(findings), len(want), findings)
+	}
+	for i := range want {
+		if findings[i] != want[i] {
+			t.Errorf("finding %d = %+v, want %+v", i, findings[i], want[i])
+		}
+	}
+
+	if _, err := audit(&out, filepath.Join(t.TempDir(), "missing.conf"), auditFormatTable, auditSeverityNone); err == nil {
+		t.Error("audit() returned no error for a missing config")
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// overflowLabelValue replaces the values of limited labels beyond the limit.
+const overflowLabelValue = "other"
+
+// cardinalityGatherer caps the number of unique values of high-risk labels, such as
+// file or target. The first limit values seen of a label are kept for the lifetime
+// of the exporter; series with other values are aggregated into a series with the
+// value "other".
+type cardinalityGatherer struct {
+	next     prometheus.Gatherer
+	overflow *prometheus.CounterVec
+	labels   map[string]bool
+	seen     map[string]map[string]bool
+	limit    int
+	mutex    sync.Mutex
+}
+
+func newCardinalityGatherer(next prometheus.Gatherer, labels []string, limit int, overflow *prometheus.CounterVec) *cardinalityGatherer {
+	g := &cardinalityGatherer{
+		next:     next,
+		overflow: overflow,
+		labels:   make(map[string]bool, len(labels)),
+		seen:     make(map[string]map[string]bool, len(labels)),
+		limit:    limit,
+	}
+	for _, label := range labels {
+		g.labels[label] = true
+		g.seen[label] = make(map[string]bool)
+	}
+	return g
+}
+
+// Gather implements prometheus.Gatherer.
+func (g *cardinalityGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.next.Gather()
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	result := make([]*dto.MetricFamily, 0, len(families))
+	for _, family := range families {
+		result = append(result, g.limitFamily(family))
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to gather metrics: %w", err)
+	}
+	return result, nil
+}
+
+// limitFamily : 제한을 넘는 label 값을 other 로 바꾸고, 같아진 series 를 합친다. 바뀐 series 가 없으면 family 를 그대로 반환한다.
+func (g *cardinalityGatherer) limitFamily(family *dto.MetricFamily) *dto.MetricFamily {
+	metrics := make([]*dto.Metric, 0, len(family.GetMetric()))
+	index := make(map[string]int, len(family.GetMetric()))
+	changed := false
+	for _, metric := range family.GetMetric() {
+		pairs, overflowed := g.limitLabels(metric.GetLabel())
+		if !overflowed {
+			index[labelsKey(pairs)] = len(metrics)
+			metrics = append(metrics, metric)
+			continue
+		}
+		changed = true
+
+		key := labelsKey(pairs)
+		if i, ok := index[key]; ok {
+			metrics[i] = mergeMetrics(metrics[i], metric)
+			continue
+		}
+		limited, _ := proto.Clone(metric).(*dto.Metric)
+		limited.Label = pairs
+		index[key] = len(metrics)
+		metrics = append(metrics, limited)
+	}
+	if !changed {
+		return family
+	}
+	return &dto.MetricFamily{Name: family.Name, Help: family.Help, Type: family.Type, Unit: family.Unit, Metric: metrics}
+}
+
+// limitLabels : 제한 대상 label 의 값이 제한을 넘으면 other 로 바꾼 label 목록을 반환한다.
+func (g *cardinalityGatherer) limitLabels(pairs []*dto.LabelPair) ([]*dto.LabelPair, bool) {
+	var limited []*dto.LabelPair
+	for i, pair := range pairs {
+		if !g.labels[pair.GetName()] {
+			continue
+		}
+		seen := g.seen[pair.GetName()]
+		if seen[pair.GetValue()] {
+			continue
+		}
+		if len(seen) < g.limit {
+			seen[pair.GetValue()] = true
+			continue
+		}
+
+		if limited == nil {
+			limited = make([]*dto.LabelPair, len(pairs))
+			copy(limited, pairs)
+		}
+		limited[i] = &dto.LabelPair{Name: pair.Name, Value: proto.String(overflowLabelValue)}
+		g.overflow.WithLabelValues(pair.GetName()).Inc()
+	}
+	if limited == nil {
+		return pairs, false
+	}
+	return limited, true
+}
+
+func labelsKey(pairs []*dto.LabelPair) string {
+	var key strings.Builder
+	for _, pair := range pairs {
+		key.WriteString(pair.GetName() + "\xff" + pair.GetValue() + "\xff")
+	}
+	return key.String()
+}
+
+// mergeMetrics : 두 series 의 값을 더한 새 series 를 반환한다. summary 의 quantile 과
+// bucket 경계가 다른 histogram 의 bucket 은 합칠 수 없으므로 버린다.
+func mergeMetrics(a *dto.Metric, b *dto.Metric) *dto.Metric {
+	merged, _ := proto.Clone(a).(*dto.Metric)
+	switch {
+	case merged.Counter != nil:
+		merged.Counter.Value = proto.Float64(merged.GetCounter().GetValue() + b.GetCounter().GetValue())
+	case merged.Gauge != nil:
+		merged.Gauge.Value = proto.Float64(merged.GetGauge().GetValue() + b.GetGauge().GetValue())
+	case merged.Untyped != nil:
+		merged.Untyped.Value = proto.Float64(merged.GetUntyped().GetValue() + b.GetUntyped().GetValue())
+	case merged.Summary != nil:
+		merged.Summary.SampleCount = proto.Uint64(merged.GetSummary().GetSampleCount() + b.GetSummary().GetSampleCount())
+		merged.Summary.SampleSum = proto.Float64(merged.GetSummary().GetSampleSum() + b.GetSummary().GetSampleSum())
+		merged.Summary.Quantile = nil
+	case merged.Histogram != nil:
+		h := merged.GetHistogram()
+		h.SampleCount = proto.Uint64(h.GetSampleCount() + b.GetHistogram().GetSampleCount())
+		h.SampleSum = proto.Float64(h.GetSampleSum() + b.GetHistogram().GetSampleSum())
+		other := b.GetHistogram().GetBucket()
+		if len(h.GetBucket()) != len(other) {
+			h.Bucket = nil
+			break
+		}
+		for i, bucket := range h.GetBucket() {
+			if bucket.GetUpperBound() != other[i].GetUpperBound() {
+				h.Bucket = nil
+				break
+			}
+			bucket.CumulativeCount = proto.Uint64(bucket.GetCumulativeCount() + other[i].GetCumulativeCount())
+		}
+	}
+	return merged
+}
+
+// newLabelOverflowCounter creates the counter of label values replaced by "other".
+func newLabelOverflowCounter(namespace string, constLabels map[string]string) *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   namespace,
+		Name:        "exporter_label_overflow_total",
+		Help:        fmt.Sprintf("Number of label values replaced by %q because the label reached its limit of unique values", overflowLabelValue),
+		ConstLabels: constLabels,
+	}, []string{"label"})
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCardinalityGatherer(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	health := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "nginx_upstream_health", Help: "Health"}, []string{"file", "target"})
+	health.WithLabelValues("a.conf", "10.0.0.1:80").Set(1)
+	health.WithLabelValues("a.conf", "10.0.0.2:80").Set(1)
+	health.WithLabelValues("a.conf", "10.0.0.3:80").Set(0)
+	health.WithLabelValues("a.conf", "10.0.0.4:80").Set(1)
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "nginx_requests_total", Help: "Requests"}, []string{"vhost"})
+	requests.WithLabelValues("a.example.com").Add(5)
+	registry.MustRegister(health, requests)
+
+	overflow := newLabelOverflowCounter("nginx", nil)
+	g := newCardinalityGatherer(registry, []string{"target"}, 2, overflow)
+
+	expected := `
+# HELP nginx_requests_total Requests
+# TYPE nginx_requests_total counter
+nginx_requests_total{vhost="a.example.com"} 5
+# HELP nginx_upstream_health Health
+# TYPE nginx_upstream_health gauge
+nginx_upstream_health{file="a.conf",target="10.0.0.1:80"} 1
+nginx_upstream_health{file="a.conf",target="10.0.0.2:80"} 1
+nginx_upstream_health{file="a.conf",target="other"} 1
+`
+	for range 2 {
+		if err := testutil.GatherAndCompare(g, strings.NewReader(expected)); err != nil {
+			t.Error(err)
+		}
+	}
+	if got := testutil.ToFloat64(overflow.WithLabelValues("target")); got != 4 {
+		t.Errorf("nginx_exporter_label_overflow_total{label=\"target\"} = %v, want 4", got)
+	}
+}
+
+func TestCardinalityGathererHistogram(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	durations := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "nginx_request_duration_seconds", Help: "Durations", Buckets: []float64{0.1, 1}}, []string{"vhost"})
+	durations.WithLabelValues("a").Observe(0.05)
+	durations.WithLabelValues("b").Observe(0.5)
+	durations.WithLabelValues("c").Observe(2)
+	registry.MustRegister(durations)
+
+	g := newCardinalityGatherer(registry, []string{"vhost"}, 1, newLabelOverflowCounter("nginx", nil))
+
+	expected := `
+# HELP nginx_request_duration_seconds Durations
+# TYPE nginx_request_duration_seconds histogram
+nginx_request_duration_seconds_bucket{vhost="a",le="0.1"} 1
+nginx_request_duration_seconds_bucket{vhost="a",le="1"} 1
+nginx_request_duration_seconds_bucket{vhost="a",le="+Inf"} 1
+nginx_request_duration_seconds_sum{vhost="a"} 0.05
+nginx_request_duration_seconds_count{vhost="a"} 1
+nginx_request_duration_seconds_bucket{vhost="other",le="0.1"} 0
+nginx_request_duration_seconds_bucket{vhost="other",le="1"} 1
+nginx_request_duration_seconds_bucket{vhost="other",le="+Inf"} 2
+nginx_request_duration_seconds_sum{vhost="other"} 2.5
+nginx_request_duration_seconds_count{vhost="other"} 2
+`
+	if err := testutil.GatherAndCompare(g, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
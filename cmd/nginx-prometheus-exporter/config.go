@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nginx/nginx-prometheus-exporter/collector"
+
+	"gopkg.in/yaml.v2"
+)
+
+// exporterConfig is the exporter configuration file (--exporter.config-file). It holds
+// settings that do not fit command-line flags.
+type exporterConfig struct {
+	Plugins     []execPluginConfig `yaml:"plugins"`
+	GRPCPlugins []grpcPluginConfig `yaml:"grpc_plugins"`
+	Endpoints   []endpointConfig   `yaml:"endpoints"`
+	// MetricRelabelConfigs are applied to all metrics before they are exposed.
+	MetricRelabelConfigs []relabelConfig `yaml:"metric_relabel_configs"`
+	// MetricRenames maps metric names to the names they are exposed with, after
+	// relabeling.
+	MetricRenames map[string]string `yaml:"metric_renames"`
+	// MetricsPaths are additional telemetry paths that serve a subset of the metrics.
+	MetricsPaths []metricsPathConfig `yaml:"metrics_paths"`
+	// Probes are synthetic requests sent periodically to the local NGINX.
+	Probes []probeConfig `yaml:"probes"`
+	// UserAgentClasses classify the user agents of the access log.
+	UserAgentClasses []userAgentClassConfig `yaml:"user_agent_classes"`
+	// Targets are NGINX or NGINX Plus instances scraped with their own credentials.
+	Targets []targetConfig `yaml:"targets"`
+	// TargetStore is an etcd or Consul KV prefix listing more targets, which is polled
+	// for changes.
+	TargetStore *targetStoreConfig `yaml:"target_store"`
+}
+
+type execPluginConfig struct {
+	Name     string        `yaml:"name"`
+	Command  []string      `yaml:"command"`
+	Interval time.Duration `yaml:"interval"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+type grpcPluginConfig struct {
+	Name    string        `yaml:"name"`
+	Command []string      `yaml:"command"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type endpointConfig struct {
+	Labels map[string]string `yaml:"labels"`
+	Name   string            `yaml:"name"`
+	URL    string            `yaml:"url"`
+	Prefix string            `yaml:"prefix"`
+}
+
+// metricsPathConfig is an additional telemetry path that serves only the metrics
+// whose names match one of Include, if set, and none of Exclude.
+type metricsPathConfig struct {
+	Path    string   `yaml:"path"`
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+// probeConfig is a synthetic probe of a location or virtual host of the local NGINX.
+type probeConfig struct {
+	Headers        map[string]string `yaml:"headers"`
+	Name           string            `yaml:"name"`
+	URL            string            `yaml:"url"`
+	Path           string            `yaml:"path"`
+	Method         string            `yaml:"method"`
+	Host           string            `yaml:"host"`
+	ExpectedStatus int               `yaml:"expected_status"`
+	Interval       time.Duration     `yaml:"interval"`
+	Timeout        time.Duration     `yaml:"timeout"`
+}
+
+// targetConfig is a scrape target, a URI or unix domain socket path like
+// --nginx.scrape-uri, with the credentials sent with every request to it. Its
+// name, if set, replaces the URI as the value of the addr label.
+type targetConfig struct {
+	BasicAuth       *basicAuthConfig `yaml:"basic_auth"`
+	TLSConfig       *tlsConfig       `yaml:"tls_config"`
+	Name            string           `yaml:"name"`
+	URL             string           `yaml:"url"`
+	BearerTokenFile string           `yaml:"bearer_token_file"`
+}
+
+// tlsConfig overrides the CA and client certificate of --nginx.ssl-ca-cert,
+// --nginx.ssl-client-cert and --nginx.ssl-client-key for a target.
+type tlsConfig struct {
+	CAFile   string `yaml:"ca_file"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+// targetStoreConfig is a prefix of an etcd or Consul KV store whose keys each hold a
+// target, a URI or a targets entry in YAML.
+type targetStoreConfig struct {
+	Type   string `yaml:"type"`
+	URL    string `yaml:"url"`
+	Prefix string `yaml:"prefix"`
+	// TokenFile holds the Consul ACL token or the etcd auth token.
+	TokenFile       string        `yaml:"token_file"`
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+}
+
+type basicAuthConfig struct {
+	Username     string `yaml:"username"`
+	PasswordFile string `yaml:"password_file"`
+}
+
+type userAgentClassConfig struct {
+	Name  string `yaml:"name"`
+	Regex string `yaml:"regex"`
+}
+
+// loadExporterConfig : exporter 설정 파일을 읽는다. 알 수 없는 필드가 있으면 오류를 반환한다.
+func loadExporterConfig(path string) (*exporterConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exporter config file: %w", err)
+	}
+	cfg := &exporterConfig{}
+	if err := yaml.UnmarshalStrict(content, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse exporter config file %v: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// execPlugins returns the exec plugins of the config.
+func (c *exporterConfig) execPlugins() []collector.ExecPlugin {
+	plugins := make([]collector.ExecPlugin, 0, len(c.Plugins))
+	for _, p := range c.Plugins {
+		plugins = append(plugins, collector.ExecPlugin(p))
+	}
+	return plugins
+}
+
+// syntheticProbes returns the synthetic probes of the config.
+func (c *exporterConfig) syntheticProbes() []collector.SyntheticProbe {
+	probes := make([]collector.SyntheticProbe, 0, len(c.Probes))
+	for _, p := range c.Probes {
+		probes = append(probes, collector.SyntheticProbe(p))
+	}
+	return probes
+}
+
+// userAgentClasses returns the user agent classes of the config.
+func (c *exporterConfig) userAgentClasses() []collector.UserAgentClass {
+	classes := make([]collector.UserAgentClass, 0, len(c.UserAgentClasses))
+	for _, class := range c.UserAgentClasses {
+		classes = append(classes, collector.UserAgentClass(class))
+	}
+	return classes
+}
+
+// relabelRules compiles the metric relabel rules of the config.
+func (c *exporterConfig) relabelRules() ([]relabelRule, error) {
+	rules := make([]relabelRule, 0, len(c.MetricRelabelConfigs))
+	for i, rc := range c.MetricRelabelConfigs {
+		rule, err := rc.compile()
+		if err != nil {
+			return nil, fmt.Errorf("metric_relabel_configs[%d]: %w", i, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+)
+
+// discoverLocalScrapeURI : configPath 의 설정을 읽어 scrape 할 URI 를 찾는다.
+func discoverLocalScrapeURI(configPath string, plus bool) (string, error) {
+	cfg, err := nginxconf.Parse(configPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse NGINX config: %w", err)
+	}
+	return discoverScrapeURI(cfg, plus)
+}
+
+// discoverScrapeURI : --nginx.scrape-uri 를 지정하지 않았을 때 scrape 할 URI 를 NGINX 설정에서 찾는다. stub_status(plus 이면
+// api) 가 있는 첫 location 의 경로와, 그 location 을 감싸는 server 블록의 listen 으로 URI 를 만든다. 정규식 location 과
+// named location 은 경로를 알 수 없으므로 건너뛴다.
+func discoverScrapeURI(cfg *nginxconf.Config, plus bool) (string, error) {
+	name := "stub_status"
+	if plus {
+		name = "api"
+	}
+
+	var uri string
+	var err error
+	cfg.Walk(func(d *nginxconf.Directive, parents []*nginxconf.Directive) {
+		if uri != "" || err != nil || d.Name != name || len(parents) == 0 || parents[len(parents)-1].Name != "location" {
+			return
+		}
+		path, ok := locationPath(parents[len(parents)-1])
+		if !ok {
+			return
+		}
+		for i := len(parents) - 2; i >= 0; i-- {
+			if parents[i].Name == "server" {
+				uri, err = serverURI(parents[i], path)
+				break
+			}
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	if uri == "" {
+		return "", fmt.Errorf("no location with %v found", name)
+	}
+	return uri, nil
+}
+
+// locationPath returns the path matched by a prefix or exact location.
+func locationPath(location *nginxconf.Directive) (string, bool) {
+	switch {
+	case len(location.Args) == 1 && strings.HasPrefix(location.Args[0], "/"):
+		return location.Args[0], true
+	case len(location.Args) == 2 && (location.Args[0] == "=" || location.Args[0] == "^~"):
+		return location.Args[1], true
+	}
+	return "", false
+}
+
+// serverURI : server 블록의 listen 중 TLS 가 아닌 첫 listen 으로, 없으면 TLS listen 으로 path 의 URI 를 만든다. listen 이 없으면
+// NGINX 의 기본값인 80 번 포트로 요청한다.
+func serverURI(server *nginxconf.Directive, path string) (string, error) {
+	var listen *nginxconf.Directive
+	tls := false
+	for _, d := range server.Block {
+		if d.Name != "listen" || len(d.Args) == 0 || slices.Contains(d.Args[1:], "quic") {
+			continue
+		}
+		ssl := slices.Contains(d.Args[1:], "ssl")
+		if listen == nil || tls && !ssl {
+			listen, tls = d, ssl
+		}
+	}
+	if listen == nil {
+		return "http://127.0.0.1:80" + path, nil
+	}
+	uri, err := listenURI(listen.Args[0], tls, path)
+	if err != nil {
+		return "", fmt.Errorf("%w in %v:%d", err, listen.File, listen.Line)
+	}
+	return uri, nil
+}
+
+// listenURI : listen 주소로 path 의 URI 를 만든다. 모든 주소에서 받는 listen 은 loopback 주소로 요청한다.
+func listenURI(addr string, tls bool, path string) (string, error) {
+	if socket, ok := strings.CutPrefix(addr, "unix:"); ok {
+		return "unix:" + socket + ":" + path, nil
+	}
+
+	scheme, port := "http", "80"
+	if tls {
+		scheme, port = "https", "443"
+	}
+	host := addr
+	if _, err := strconv.Atoi(addr); err == nil {
+		host, port = "", addr
+	} else if strings.Contains(addr, ":") {
+		var err error
+		if host, port, err = net.SplitHostPort(addr); err != nil {
+			return "", fmt.Errorf("invalid listen address %q: %w", addr, err)
+		}
+	}
+	switch host {
+	case "", "*", "0.0.0.0":
+		host = "127.0.0.1"
+	case "::":
+		host = "::1"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port) + path, nil
+}
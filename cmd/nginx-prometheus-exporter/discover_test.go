@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+)
+
+func TestDiscoverScrapeURI(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  string
+		want    string
+		plus    bool
+		wantErr bool
+	}{
+		{
+			name:   "port only",
+			config: `http { server { listen 8080; location = /stub_status { stub_status; } } }`,
+			want:   "http://127.0.0.1:8080/stub_status",
+		},
+		{
+			name:   "address and port",
+			config: `http { server { listen 10.0.0.1:8081 default_server; location /basic_status { stub_status on; } } }`,
+			want:   "http://10.0.0.1:8081/basic_status",
+		},
+		{
+			name:   "IPv6 wildcard",
+			config: `http { server { listen [::]:8080; location /status { stub_status; } } }`,
+			want:   "http://[::1]:8080/status",
+		},
+		{
+			name:   "plain listen preferred over TLS",
+			config: `http { server { listen 8443 ssl; listen 443 quic; listen 127.0.0.1:8080; location /status { stub_status; } } }`,
+			want:   "http://127.0.0.1:8080/status",
+		},
+		{
+			name:   "TLS only",
+			config: `http { server { listen 8443 ssl; location /status { stub_status; } } }`,
+			want:   "https://127.0.0.1:8443/status",
+		},
+		{
+			name:   "unix socket",
+			config: `http { server { listen unix:/var/run/nginx-status.sock; location /status { stub_status; } } }`,
+			want:   "unix:/var/run/nginx-status.sock:/status",
+		},
+		{
+			name:   "no listen",
+			config: `http { server { location /status { stub_status; } } }`,
+			want:   "http://127.0.0.1:80/status",
+		},
+		{
+			name:   "nested and regex locations",
+			config: `http { server { listen 8080; location ~ ^/metrics { stub_status; } location /admin { location = /admin/status { stub_status; } } } }`,
+			want:   "http://127.0.0.1:8080/admin/status",
+		},
+		{
+			name:   "plus api",
+			config: `http { server { listen 8080; location /stub_status { stub_status; } location /api { api write=off; } } }`,
+			plus:   true,
+			want:   "http://127.0.0.1:8080/api",
+		},
+		{
+			name:    "no stub_status",
+			config:  `http { server { listen 8080; location /api { api; } } }`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid listen",
+			config:  `http { server { listen 10.0.0.1:80:80; location /status { stub_status; } } }`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "nginx.conf")
+			if err := os.WriteFile(path, []byte(tt.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := nginxconf.Parse(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := discoverScrapeURI(cfg, tt.plus)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("discoverScrapeURI() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("discoverScrapeURI() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,1259 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	plusclient "github.com/nginx/nginx-plus-go-client/v2/client"
+	"github.com/nginx/nginx-prometheus-exporter/client"
+	"github.com/nginx/nginx-prometheus-exporter/collector"
+	"github.com/nginx/nginx-prometheus-exporter/plugin"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors/version"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/promslog"
+	"github.com/prometheus/common/promslog/flag"
+	common_version "github.com/prometheus/common/version"
+
+	"github.com/prometheus/exporter-toolkit/web"
+	"github.com/prometheus/exporter-toolkit/web/kingpinflag"
+	"gopkg.in/yaml.v2"
+)
+
+// positiveDuration is a wrapper of time.Duration to ensure only positive values are accepted.
+// Timeout Flag에 음수가 들어오는 것을 방지하기 위해 사용한다.
+type positiveDuration struct{ time.Duration }
+
+func (pd *positiveDuration) Set(s string) error {
+	dur, err := parsePositiveDuration(s)
+	if err != nil {
+		return err
+	}
+
+	pd.Duration = dur.Duration
+	return nil
+}
+
+func parsePositiveDuration(s string) (positiveDuration, error) {
+	dur, err := time.ParseDuration(s)
+	if err != nil {
+		return positiveDuration{}, fmt.Errorf("failed to parse duration %q: %w", s, err)
+	}
+	if dur < 0 {
+		return positiveDuration{}, fmt.Errorf("negative duration %v is not valid", dur)
+	}
+	return positiveDuration{dur}, nil
+}
+
+func createPositiveDurationFlag(s kingpin.Settings) (target *time.Duration) {
+	target = new(time.Duration)
+	s.SetValue(&positiveDuration{Duration: *target})
+	return
+}
+
+func parseUnixSocketAddress(address string) (string, string, error) {
+	addressParts := strings.Split(address, ":")
+	addressPartsLength := len(addressParts)
+
+	if addressPartsLength > 3 || addressPartsLength < 1 {
+		return "", "", errors.New("address for unix domain socket has wrong format")
+	}
+
+	unixSocketPath := addressParts[1]
+	requestPath := ""
+	if addressPartsLength == 3 {
+		requestPath = addressParts[2]
+	}
+	return unixSocketPath, requestPath, nil
+}
+
+var labelNameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// variableLabelFlags holds the user-defined variable label names of a kind of NGINX Plus object
+// and the label values of each object of that kind.
+type variableLabelFlags struct {
+	names  *[]string
+	values map[string]string
+}
+
+func addVariableLabelFlags(kind string, object string) *variableLabelFlags {
+	f := &variableLabelFlags{values: map[string]string{}}
+	f.names = kingpin.Flag("nginx.plus."+kind+"-label", fmt.Sprintf("Name of a variable label added to NGINX Plus %s metrics. Repeatable for multiple labels.", object)).
+		Envar(convertFlagToEnvar("plus." + kind + "-labels")).Strings()
+	kingpin.Flag("nginx.plus."+kind+"-label-values", fmt.Sprintf("Variable label values of a %s in the format name=value1,value2, in the order of the label names. Repeatable for multiple objects.", object)).
+		Envar(convertFlagToEnvar("plus." + kind + "-label-values")).StringMapVar(&f.values)
+	return f
+}
+
+// labelNames returns the configured label names, or nil if there are none.
+func (f *variableLabelFlags) labelNames() ([]string, error) {
+	if len(*f.names) == 0 {
+		return nil, nil
+	}
+	for _, name := range *f.names {
+		if !labelNameRe.MatchString(name) {
+			return nil, fmt.Errorf("invalid label name %q", name)
+		}
+	}
+	return *f.names, nil
+}
+
+// labelValues returns the label values of each object.
+func (f *variableLabelFlags) labelValues() (map[string][]string, error) {
+	values := make(map[string][]string, len(f.values))
+	for object, v := range f.values {
+		labelValues := strings.Split(v, ",")
+		if len(labelValues) != len(*f.names) {
+			return nil, fmt.Errorf("expected %d label values for %q, got %d", len(*f.names), object, len(labelValues))
+		}
+		values[object] = labelValues
+	}
+	return values, nil
+}
+
+var (
+	constLabels   = map[string]string{}
+	scrapeURIsSet bool
+
+	// Command-line flags.
+	webConfig     = kingpinflag.AddFlags(kingpin.CommandLine, ":9113")
+	metricsPath   = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").Envar("TELEMETRY_PATH").String()
+	nginxPlus     = kingpin.Flag("nginx.plus", "Start the exporter for NGINX Plus. By default, the exporter is started for NGINX.").Default("false").Envar("NGINX_PLUS").Bool()
+	scrapeURIs    = kingpin.Flag("nginx.scrape-uri", "A URI or unix domain socket path for scraping NGINX or NGINX Plus metrics. For NGINX, the stub_status page must be available through the URI. For NGINX Plus -- the API. Repeatable for multiple URIs. When unset, the location serving stub_status (the api with --nginx.plus) is discovered from nginx.config-path, and the default is scraped only if there is none. The default is not scraped when the exporter config file has targets.").Default("http://127.0.0.1:8080/stub_status").Envar("SCRAPE_URI").HintOptions("http://127.0.0.1:8080/stub_status", "http://127.0.0.1:8080/api").IsSetByUser(&scrapeURIsSet).Strings()
+	scrapeURIFile = kingpin.Flag("nginx.scrape-uri-file", "Path to a file listing URIs or unix domain socket paths to scrape like nginx.scrape-uri, one per line, optionally prefixed with name= to use the name instead of the URI as the addr label.").Default("").Envar("SCRAPE_URI_FILE").String()
+	sslVerify     = kingpin.Flag("nginx.ssl-verify", "Perform SSL certificate verification.").Default("false").Envar("SSL_VERIFY").Bool()
+	sslCaCert     = kingpin.Flag("nginx.ssl-ca-cert", "Path to the PEM encoded CA certificate file used to validate the servers SSL certificate.").Default("").Envar("SSL_CA_CERT").String()
+	sslClientCert = kingpin.Flag("nginx.ssl-client-cert", "Path to the PEM encoded client certificate file to use when connecting to the server.").Default("").Envar("SSL_CLIENT_CERT").String()
+	sslClientKey  = kingpin.Flag("nginx.ssl-client-key", "Path to the PEM encoded client certificate key file to use when connecting to the server.").Default("").Envar("SSL_CLIENT_KEY").String()
+	scrapeHTTP3   = kingpin.Flag("nginx.http3", "Scrape https URIs over HTTP/3 (QUIC), e.g. for NGINX exposing the API only on a QUIC listener. Falls back to HTTP/1.1 or HTTP/2 when the QUIC connection fails. Cannot be combined with nginx.ssh-jump or nginx.socks5-proxy.").Default("false").Envar("HTTP3").Bool()
+	sslMinVersion = kingpin.Flag("nginx.ssl-min-version", "Minimum TLS version of the connections to the server. One of: [TLS10, TLS11, TLS12, TLS13]").Default("TLS12").Envar("SSL_MIN_VERSION").Enum("TLS10", "TLS11", "TLS12", "TLS13")
+	sslCiphers    = kingpin.Flag("nginx.ssl-cipher-suite", "IANA name of a cipher suite allowed for TLS 1.2 and older connections to the server, e.g. TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384. Repeatable for multiple cipher suites. The secure cipher suites of Go are allowed when unset. TLS 1.3 cipher suites are not configurable.").Envar("SSL_CIPHER_SUITES").Strings()
+
+	// Custom command-line flags.
+	sshJump               = kingpin.Flag("nginx.ssh-jump", "SSH jump host as user@host[:port]. When set, NGINX and NGINX Plus are scraped through an SSH tunnel to it using key authentication.").Default("").Envar("SSH_JUMP").String()
+	sshKey                = kingpin.Flag("nginx.ssh-key", "Path to the private key used to authenticate to the SSH jump host.").Default("").Envar("SSH_KEY").String()
+	sshKnownHosts         = kingpin.Flag("nginx.ssh-known-hosts", "Path to the known_hosts file used to verify the SSH jump host. Defaults to ~/.ssh/known_hosts.").Default("").Envar("SSH_KNOWN_HOSTS").String()
+	socks5Proxy           = kingpin.Flag("nginx.socks5-proxy", "SOCKS5 proxy as [socks5://][user:password@]host:port used for scrapes and upstream health checks. Cannot be combined with nginx.ssh-jump.").Default("").Envar("SOCKS5_PROXY").String()
+	maxResponseSize       = kingpin.Flag("nginx.max-response-size", "Maximum size of a response of NGINX or NGINX Plus after gzip decompression, e.g. 32MiB. Responses are requested with Accept-Encoding: gzip and decompressed transparently. Disabled when 0.").Default("32MiB").Envar("MAX_RESPONSE_SIZE").Bytes()
+	exporterConfigFile    = kingpin.Flag("exporter.config-file", "Path to the exporter configuration file (YAML), e.g. for plugins and external endpoints. See the README for its format.").Default("").Envar("EXPORTER_CONFIG_FILE").String()
+	labelValueLimit       = kingpin.Flag("exporter.label-value-limit", "Maximum number of unique values of each label of exporter.limited-label. Series with further values are aggregated into a series with the value \"other\". Disabled when 0.").Default("0").Envar("LABEL_VALUE_LIMIT").Int()
+	limitedLabels         = kingpin.Flag("exporter.limited-label", "Label whose unique values are limited by exporter.label-value-limit. Repeatable for multiple labels.").Default("file", "target", "vhost").Envar("LIMITED_LABELS").Strings()
+	statusPageEnabled     = kingpin.Flag("web.status-page", "Serve a status page at /status with the scrape targets and their last scrape results, the upstreams of the NGINX config with their health and the recent health transitions.").Default("false").Bool()
+	healthCheckTokenFile  = kingpin.Flag("web.health-check-token-file", "Path to a file containing a token. When set, POST /api/healthcheck/run with the header \"Authorization: Bearer <token>\" runs the background upstream health checks of all NGINX targets, including those of the target store, now and responds with their results. NGINX Plus targets are not included. Requires --nginx.health-check-interval.").Default("").String()
+	quitTokenFile         = kingpin.Flag("web.quit-token-file", "Path to a file containing a token. When set, POST /-/quit with the header \"Authorization: Bearer <token>\" shuts the exporter down gracefully, like SIGTERM.").Default("").String()
+	reloadTokenFile       = kingpin.Flag("web.reload-token-file", "Path to a file containing a token. When set, POST /-/nginx/reload with the header \"Authorization: Bearer <token>\" tests the NGINX config with nginx -t and, only if it is valid, reloads NGINX by sending SIGHUP to its master process. Requires --nginx.binary-path and --web.reload-pid-file or --nginx.pid-file.").Default("").String()
+	reloadPIDFile         = kingpin.Flag("web.reload-pid-file", "Path to the PID file of the NGINX master process reloaded by web.reload-token-file. Unlike nginx.pid-file, it does not enable the cgroup metrics. Defaults to nginx.pid-file.").Default("").String()
+	listenConfigs         = kingpin.Flag("web.listen-config", "Web config file of one of the web.listen-address addresses as address=path, used for that address instead of web.config.file, e.g. to serve TLS on an external address and plain HTTP on a unix domain socket. An empty path serves the address without TLS and authentication. Repeatable for multiple addresses. Cannot be combined with web.systemd-socket.").Strings()
+	restrictedTLS         = kingpin.Flag("web.restricted-tls", "Refuse to start unless every listen address except unix domain sockets serves TLS 1.2 or newer with the ECDHE AES-GCM cipher suites and the NIST P-256, P-384 or P-521 curves only, listed explicitly in its web config file. TLS 1.3 is only allowed in the FIPS 140 mode of Go (GODEBUG=fips140=on), which restricts its cipher suites.").Default("false").Envar("RESTRICTED_TLS").Bool()
+	accessLog             = kingpin.Flag("web.access-log", "Log every request to the exporter's HTTP server with its method, path, remote address, duration and status.").Default("false").Envar("ACCESS_LOG").Bool()
+	sampleTimestamps      = kingpin.Flag("exporter.sample-timestamps", "Attach the time a result was collected in the background, by probes, background upstream health checks, exec plugins or the top paths of the access log, to its samples. Without it, Prometheus uses the scrape time and marks series stale as usual.").Default("false").Envar("SAMPLE_TIMESTAMPS").Bool()
+	metricsTimeout        = createPositiveDurationFlag(kingpin.Flag("web.metrics-timeout", "Maximum duration of a request to the telemetry path. Scrapes that take longer, e.g. because of a hung upstream health check, are answered with 503 Service Unavailable. Disabled when 0.").Default("0s").Envar("METRICS_TIMEOUT").HintOptions("10s", "30s"))
+	timeout               = createPositiveDurationFlag(kingpin.Flag("nginx.timeout", "A timeout for scraping metrics from NGINX or NGINX Plus.").Default("5s").Envar("TIMEOUT").HintOptions("5s", "10s", "30s", "1m", "5m"))
+	dnsCacheMaxTTL        = createPositiveDurationFlag(kingpin.Flag("nginx.dns-cache-max-ttl", "Cache the DNS records of upstream health check targets for their TTL, but at most this long. Disabled when 0.").Default("0s").Envar("DNS_CACHE_MAX_TTL").HintOptions("30s", "1m", "5m"))
+	healthCheckInterval   = createPositiveDurationFlag(kingpin.Flag("nginx.health-check-interval", "Run the upstream health checks in the background at this interval, spread evenly over it, instead of during every scrape. Disabled when 0.").Default("0s").Envar("HEALTH_CHECK_INTERVAL").HintOptions("15s", "30s", "1m"))
+	healthCheckJitter     = createPositiveDurationFlag(kingpin.Flag("nginx.health-check-jitter", "Random delay of up to this duration added to every background upstream health check.").Default("0s").Envar("HEALTH_CHECK_JITTER").HintOptions("1s", "5s"))
+	healthCheckDualStack  = kingpin.Flag("nginx.health-check-dual-stack", "Check upstream health check targets whose host name has both IPv4 and IPv6 addresses over each address family and export nginx_upstream_health_check_family_status, so that an outage of one family is detected while the other still works. Cannot be combined with nginx.socks5-proxy.").Default("false").Envar("HEALTH_CHECK_DUAL_STACK").Bool()
+	healthCheckMaxTargets = kingpin.Flag("nginx.health-check-max-targets", "Maximum number of upstream health check targets. When the NGINX config has more, e.g. a generated config with thousands of servers, only the first ones in the order of the config files are checked and the rest is counted in nginx_upstream_health_check_targets_overflow. Unlimited when 0.").Default("0").Envar("HEALTH_CHECK_MAX_TARGETS").Int()
+	healthCheckCACert     = kingpin.Flag("nginx.health-check-ca-cert", "Path to a PEM encoded CA bundle. When set, upstream health check targets of https proxy_pass directives are checked with a TLS handshake whose certificate must be signed by one of these CAs, independently of the nginx.ssl-* scrape settings.").Default("").Envar("HEALTH_CHECK_CA_CERT").String()
+	healthCheckServerName = kingpin.Flag("nginx.health-check-server-name", "Name sent as TLS server name and expected in the certificates of https upstream health check targets instead of their host. Enables the TLS handshake like nginx.health-check-ca-cert, with the system CAs when that is unset.").Default("").Envar("HEALTH_CHECK_SERVER_NAME").String()
+	healthStateFile       = kingpin.Flag("nginx.health-state-file", "Path of a file the last upstream health check results and transition counters are saved to and restored from on startup. Disabled when empty.").Default("").Envar("HEALTH_STATE_FILE").String()
+	nginxConfigPath       = kingpin.Flag("nginx.config-path", "Path to the NGINX configuration file.").Default(collector.DefaultConfigPath).Envar("CONFIG_PATH").String()
+	ingressCompanion      = kingpin.Flag("nginx.ingress-companion", "Run next to ingress-nginx or NGINX Ingress Controller: detect the controller from the status server in nginx.config-path, scrape it when nginx.scrape-uri is unset and add the ingress_class label to every metric.").Default("false").Envar("INGRESS_COMPANION").Bool()
+	ingressClass          = kingpin.Flag("nginx.ingress-class", "Ingress class of the ingress_class label in ingress companion mode. Read from the --ingress-class argument of the controller process, which requires sharing its PID namespace, when empty, or nginx.").Default("").Envar("INGRESS_CLASS").String()
+	configLabelMode       = kingpin.Flag("nginx.config-label-mode", "How the file label of config and upstream health metrics is rendered after resolving symlinks. One of: [absolute, relative, basename]").Default(collector.ConfigLabelModeAbsolute).Envar("CONFIG_LABEL_MODE").Enum(collector.ConfigLabelModeAbsolute, collector.ConfigLabelModeRelative, collector.ConfigLabelModeBasename)
+	configStrict          = kingpin.Flag("nginx.config-strict", "Report config constructs the exporter cannot model as nginx_exporter_config_warnings_total instead of silently skipping them. Included files that cannot be parsed are skipped rather than failing the whole config.").Default("false").Envar("CONFIG_STRICT").Bool()
+	configSource          = kingpin.Flag("nginx.config-source", "Remote source of the NGINX configuration, for running the exporter apart from NGINX. Either an http(s) URL serving the output of nginx -T, or the path to a tarball of the directory of nginx.config-path. Local config files are used when empty.").Default("").Envar("CONFIG_SOURCE").String()
+	configRefresh         = createPositiveDurationFlag(kingpin.Flag("nginx.config-refresh-interval", "Read and parse the NGINX config files, including the permission audit of the certificates they reference, in the background at this interval instead of during every scrape. Scrapes serve the config metrics and upstream health check targets of the last read. Disabled when 0.").Default("0s").Envar("CONFIG_REFRESH_INTERVAL").HintOptions("30s", "1m", "5m"))
+	nginxBinaryPath       = kingpin.Flag("nginx.binary-path", "Path to the NGINX binary used to export build information (nginx -V). Disabled when empty.").Default("").Envar("NGINX_BINARY_PATH").String()
+	upstreamTCPStates     = kingpin.Flag("nginx.upstream-tcp-states", "Export the number of ESTABLISHED, SYN_SENT and TIME_WAIT sockets of the host toward every proxy target of the local NGINX config, read from /proc/net/tcp and /proc/net/tcp6 (Linux only).").Default("false").Envar("UPSTREAM_TCP_STATES").Bool()
+	conntrack             = kingpin.Flag("nginx.conntrack", "Export the size and limit of the netfilter connection tracking table of the host and its entries toward every proxy target of the local NGINX config (Linux only). Reading the entries requires CAP_NET_ADMIN.").Default("false").Envar("CONNTRACK").Bool()
+	securityAudit         = kingpin.Flag("nginx.security-audit", "Export risky settings of the NGINX config as nginx_config_security_findings: server_tokens on, ssl_protocols allowing TLSv1 or TLSv1.1, TLS servers without a Strict-Transport-Security header and autoindex on.").Default("false").Envar("SECURITY_AUDIT").Bool()
+	listenBacklog         = kingpin.Flag("nginx.listen-backlog", "Export the accept queue length and backlog of the sockets of every listen directive of the local NGINX config and the listen overflows of the host (Linux only).").Default("false").Envar("LISTEN_BACKLOG").Bool()
+	vhostProbeURL         = kingpin.Flag("nginx.vhost-probe-url", "Base URL of the local NGINX listener, e.g. http://127.0.0.1:80. When set, every server_name of the NGINX config is probed in the background with a request to it with the name as Host header.").Default("").Envar("VHOST_PROBE_URL").String()
+	vhostProbePath        = kingpin.Flag("nginx.vhost-probe-path", "Path requested from every virtual host by the vhost probes.").Default("/").Envar("VHOST_PROBE_PATH").String()
+	vhostProbeInterval    = createPositiveDurationFlag(kingpin.Flag("nginx.vhost-probe-interval", "Interval of the vhost probes.").Default(collector.DefaultProbeInterval.String()).Envar("VHOST_PROBE_INTERVAL").HintOptions("30s", "1m", "5m"))
+	upstreamProbeURL      = kingpin.Flag("nginx.upstream-probe-url", "Base URL of the local NGINX listener, e.g. http://127.0.0.1:80. When set, every upstream of the NGINX config is checked in the background both directly and through every location whose proxy_pass points at it.").Default("").Envar("UPSTREAM_PROBE_URL").String()
+	upstreamProbePath     = kingpin.Flag("nginx.upstream-probe-path", "Path requested below the path of every location by the upstream probes. The location path itself is requested when empty.").Default("").Envar("UPSTREAM_PROBE_PATH").String()
+	upstreamProbeInterval = createPositiveDurationFlag(kingpin.Flag("nginx.upstream-probe-interval", "Interval of the upstream probes.").Default(collector.DefaultProbeInterval.String()).Envar("UPSTREAM_PROBE_INTERVAL").HintOptions("30s", "1m", "5m"))
+	probeMaxIdleConns     = kingpin.Flag("nginx.probe-max-idle-conns", "Maximum number of idle connections per virtual host kept open between the background vhost, upstream and synthetic probes and reused by the next probe, instead of a new TCP and TLS handshake every interval. The keepalive_timeout of NGINX must exceed the probe interval for a connection to be reused. Disabled when 0.").Default("0").Envar("PROBE_MAX_IDLE_CONNS").Int()
+	accessLogPath         = kingpin.Flag("nginx.access-log", "Path of the NGINX access log to follow for request metrics. Disabled when empty.").Default("").Envar("ACCESS_LOG_PATH").String()
+	accessLogFormat       = kingpin.Flag("nginx.access-log-format", "log_format the access log of nginx.access-log is written with.").Default(collector.DefaultAccessLogFormat).Envar("ACCESS_LOG_FORMAT").String()
+	accessLogVhostLimit   = kingpin.Flag("nginx.access-log-vhost-limit", "Number of distinct values of the vhost label of the access log metrics. Later virtual hosts are counted as other.").Default(strconv.Itoa(collector.DefaultVhostLimit)).Envar("ACCESS_LOG_VHOST_LIMIT").Int()
+	geoIPDatabase         = kingpin.Flag("nginx.geoip-database", "Path of a MaxMind GeoLite2 or GeoIP2 Country or City database. When set, a country label of $remote_addr is added to the request counters of the access log.").Default("").Envar("GEOIP_DATABASE").String()
+	geoIPCountryLimit     = kingpin.Flag("nginx.geoip-country-limit", "Number of distinct values of the country label. Later countries are counted as other.").Default(strconv.Itoa(collector.DefaultGeoIPCountryLimit)).Envar("GEOIP_COUNTRY_LIMIT").Int()
+	accessLogTopPaths     = kingpin.Flag("nginx.access-log-top-paths", "Number of most requested and slowest paths of the access log exported for every window of nginx.access-log-top-window. Disabled when 0.").Default("0").Envar("ACCESS_LOG_TOP_PATHS").Int()
+	accessLogTopWindow    = createPositiveDurationFlag(kingpin.Flag("nginx.access-log-top-window", "Window over which the top paths of the access log are computed.").Default(collector.DefaultTopPathsWindow.String()).Envar("ACCESS_LOG_TOP_WINDOW").HintOptions("1m", "5m"))
+	accessLogQueueSize    = kingpin.Flag("nginx.access-log-queue-size", "Maximum number of lines of the access log read but not yet processed. Further lines are dropped and counted in nginx_exporter_log_lines_dropped_total.").Default(strconv.Itoa(collector.DefaultLogQueueSize)).Envar("ACCESS_LOG_QUEUE_SIZE").Int()
+	accessLogMemoryLimit  = kingpin.Flag("nginx.access-log-memory-limit", "Maximum size of the lines of the access log read but not yet processed, and of a single line, e.g. 16MiB. Further lines are dropped and counted in nginx_exporter_log_lines_dropped_total.").Default("16MiB").Envar("ACCESS_LOG_MEMORY_LIMIT").Bytes()
+	nginxPIDFile          = kingpin.Flag("nginx.pid-file", "Path to the PID file of the NGINX master process. When set, the CPU and memory usage and limits of its cgroup (cgroup v1 or v2, Linux only) are exported. NGINX and the exporter must share the PID namespace.").Default("").Envar("NGINX_PID_FILE").String()
+	cgroupRoot            = kingpin.Flag("nginx.cgroup-root", "Mount point of the cgroup filesystem used with nginx.pid-file.").Default(collector.DefaultCgroupRoot).Envar("CGROUP_ROOT").String()
+	nginxNamespace        = kingpin.Flag("nginx.namespace", "Namespace (metric name prefix) of the NGINX metrics.").Default(collector.DefaultNamespace).Envar("NGINX_NAMESPACE").String()
+
+	// Subcommands.
+	_          = kingpin.Command("serve", "Start the exporter. This is the default command.").Default()
+	targetsCmd = kingpin.Command("targets", "Print the upstreams and proxy targets extracted from the NGINX config (--nginx.config-path) with their source file and line, then exit.")
+	probeCmd   = kingpin.Command("probe", "Scrape the stub_status page or, with --nginx.plus, the NGINX Plus API once, print the parsed stats and exit. Exits non-zero on failure.")
+	probeURI   = probeCmd.Arg("uri", "A URI or unix domain socket path to scrape.").Required().String()
+
+	auditCmd          = kingpin.Command("audit", "Audit the NGINX config (--nginx.config-path) with the rules of --nginx.security-audit, print the findings and exit. Exits non-zero when a finding is at or above --fail-severity.")
+	auditFormat       = auditCmd.Flag("format", "Output format of the findings.").Default(auditFormatTable).Enum(auditFormatTable, auditFormatJSON)
+	auditFailSeverity = auditCmd.Flag("fail-severity", "Lowest severity of a finding that makes the audit fail. One of: [low, medium, high, none]").Default(collector.SecuritySeverityLow).Enum(collector.SecuritySeverityLow, collector.SecuritySeverityMedium, collector.SecuritySeverityHigh, auditSeverityNone)
+	alertRulesCmd     = kingpin.Command("alert-rules", "Print a starter Prometheus alerting rules file for the metrics of the exporter, matching --nginx.namespace, --nginx.plus.namespace, --prometheus.const-label and --nginx.plus, then exit.")
+
+	generateConfigCmd    = kingpin.Command("generate-config", "Print an NGINX config snippet with a server block serving stub_status or, with --nginx.plus, the NGINX Plus API and dashboard to the loopback addresses only, then exit. Include it in the http block.")
+	generateConfigListen = generateConfigCmd.Flag("listen", "Address of the listen directive of the server block.").Default("127.0.0.1:8080").String()
+	generateConfigAllow  = generateConfigCmd.Flag("allow", "Address or CIDR allowed to access the status location besides the loopback addresses, e.g. the pod network of a remote exporter. Repeatable.").Strings()
+
+	// NGINX Plus command-line flags.
+	plusNamespace            = kingpin.Flag("nginx.plus.namespace", "Namespace (metric name prefix) of the NGINX Plus metrics.").Default(collector.DefaultPlusNamespace).Envar("PLUS_NAMESPACE").String()
+	plusAPIVersion           = kingpin.Flag("nginx.plus.api-version", "NGINX Plus API version to use. Older versions are tried when NGINX Plus does not support it.").Default(strconv.Itoa(plusclient.APIVersion)).Envar("PLUS_API_VERSION").Int()
+	plusAPIKeyFile           = kingpin.Flag("nginx.plus.api-key-file", "Path to a file containing the API key sent with every NGINX Plus API request.").Default("").Envar("PLUS_API_KEY_FILE").String()
+	plusAPIKeyHeader         = kingpin.Flag("nginx.plus.api-key-header", "Name of the header carrying the NGINX Plus API key.").Default("X-API-Key").Envar("PLUS_API_KEY_HEADER").String()
+	plusSkipEndpoints        = kingpin.Flag("nginx.plus.skip-endpoint", "NGINX Plus API endpoint group which is not collected. Repeatable for multiple groups. One of: ["+strings.Join(collector.PlusEndpoints, ", ")+"]").Envar("PLUS_SKIP_ENDPOINTS").Enums(collector.PlusEndpoints...)
+	plusResponseCodes        = kingpin.Flag("nginx.plus.response-codes", "Export responses by individual status code for server zones, location zones and upstream peers in addition to the 1xx-5xx classes. Use --no-nginx.plus.response-codes to reduce cardinality.").Default("true").Envar("PLUS_RESPONSE_CODES").Bool()
+	plusStubStatusURI        = kingpin.Flag("nginx.plus.stub-status-uri", "A URI or unix domain socket path of the stub_status page of the same instance, additionally scraped with the NGINX collector when --nginx.plus is set.").Default("").Envar("PLUS_STUB_STATUS_URI").String()
+	plusPeerServiceLabels    = kingpin.Flag("nginx.plus.peer-service-labels", "Add the service and server_name labels to upstream peer metrics, identifying peers resolved with the service= parameter.").Default("false").Envar("PLUS_PEER_SERVICE_LABELS").Bool()
+	plusCollectSlabs         = kingpin.Flag("nginx.plus.collect-slabs", "Collect the slab allocator usage of shared memory zones.").Default("false").Envar("PLUS_COLLECT_SLABS").Bool()
+	plusSlabSlotDetail       = kingpin.Flag("nginx.plus.slab-slot-detail", "Export the slab allocator usage of every slot size. Requires --nginx.plus.collect-slabs.").Default("false").Envar("PLUS_SLAB_SLOT_DETAIL").Bool()
+	plusCollectKeyvals       = kingpin.Flag("nginx.plus.collect-keyvals", "Collect the number of entries and the memory usage of keyval zones.").Default("false").Envar("PLUS_COLLECT_KEYVALS").Bool()
+	plusCollectLocationZones = kingpin.Flag("nginx.plus.collect-location-zones", "Collect the metrics of location zones, the locations with a status_zone directive.").Default("false").Envar("PLUS_COLLECT_LOCATION_ZONES").Bool()
+	plusFetchConcurrency     = kingpin.Flag("nginx.plus.fetch-concurrency", "Maximum number of NGINX Plus API endpoints fetched at the same time.").Default("4").Envar("PLUS_FETCH_CONCURRENCY").Int()
+	plusServerZoneInclude    = kingpin.Flag("nginx.plus.server-zone-include", "Regular expression of the HTTP and stream server zone names to collect.").Default("").Envar("PLUS_SERVER_ZONE_INCLUDE").String()
+	plusServerZoneExclude    = kingpin.Flag("nginx.plus.server-zone-exclude", "Regular expression of the HTTP and stream server zone names not to collect.").Default("").Envar("PLUS_SERVER_ZONE_EXCLUDE").String()
+	plusUpstreamInclude      = kingpin.Flag("nginx.plus.upstream-include", "Regular expression of the HTTP and stream upstream names to collect.").Default("").Envar("PLUS_UPSTREAM_INCLUDE").String()
+	plusUpstreamExclude      = kingpin.Flag("nginx.plus.upstream-exclude", "Regular expression of the HTTP and stream upstream names not to collect.").Default("").Envar("PLUS_UPSTREAM_EXCLUDE").String()
+	plusUpstreamsFile        = kingpin.Flag("nginx.plus.upstreams-file", "Path to a file listing the names of the HTTP and stream upstreams to collect, one per line. The file is reloaded when it changes.").Default("").Envar("PLUS_UPSTREAMS_FILE").String()
+	plusCacheZoneInclude     = kingpin.Flag("nginx.plus.cache-zone-include", "Regular expression of the cache zone names to collect.").Default("").Envar("PLUS_CACHE_ZONE_INCLUDE").String()
+	plusCacheZoneExclude     = kingpin.Flag("nginx.plus.cache-zone-exclude", "Regular expression of the cache zone names not to collect.").Default("").Envar("PLUS_CACHE_ZONE_EXCLUDE").String()
+	serverZoneLabels         = addVariableLabelFlags("server-zone", "server zone")
+	upstreamLabels           = addVariableLabelFlags("upstream", "upstream")
+	upstreamPeerLabels       = addVariableLabelFlags("upstream-peer", "upstream peer (upstream/server)")
+	cacheZoneLabels          = addVariableLabelFlags("cache-zone", "cache zone")
+	streamServerZoneLabels   = addVariableLabelFlags("stream-server-zone", "stream server zone")
+	streamUpstreamLabels     = addVariableLabelFlags("stream-upstream", "stream upstream")
+	streamUpstreamPeerLabels = addVariableLabelFlags("stream-upstream-peer", "stream upstream peer (upstream/server)")
+)
+
+const exporterName = "nginx_exporter"
+
+func main() {
+	kingpin.Flag("prometheus.const-label", "Label that will be used in every metric. Format is label=value. It can be repeated multiple times.").Envar("CONST_LABELS").StringMapVar(&constLabels)
+
+	// convert deprecated flags to new format
+	for i, arg := range os.Args {
+		if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && len(arg) > 2 {
+			newArg := "-" + arg
+			fmt.Printf("the flag format is deprecated and will be removed in a future release, please use the new format: %s\n", newArg)
+			os.Args[i] = newArg
+		}
+	}
+
+	config := &promslog.Config{} // Log 설정을 위한 구조체 생성
+
+	flag.AddFlags(kingpin.CommandLine, config) // log관련 flag 추가
+	kingpin.Version(common_version.Print(exporterName))
+	kingpin.HelpFlag.Short('h')
+
+	addMissingEnvironmentFlags(kingpin.CommandLine)
+
+	command := kingpin.Parse()
+	logger := promslog.New(config)
+
+	if command == targetsCmd.FullCommand() {
+		if err := printTargets(os.Stdout, *nginxConfigPath); err != nil {
+			logger.Error("printing targets failed", "error", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if command == alertRulesCmd.FullCommand() {
+		// 긴 expr 과 description 이 여러 줄로 접히지 않도록 한다. 출력 후 종료하므로 yaml 의 전역 설정을 바꿔도 된다.
+		yaml.FutureLineWrap()
+		if err := printAlertRules(os.Stdout, alertRulesConfig{
+			constLabels:    constLabels,
+			namespace:      *nginxNamespace,
+			plusNamespace:  *plusNamespace,
+			plus:           *nginxPlus,
+			plusStubStatus: *plusStubStatusURI != "",
+		}); err != nil {
+			logger.Error("printing alerting rules failed", "error", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if command == generateConfigCmd.FullCommand() {
+		if err := generateConfig(os.Stdout, statusSnippetConfig{
+			listen: *generateConfigListen,
+			allow:  *generateConfigAllow,
+			plus:   *nginxPlus,
+		}); err != nil {
+			logger.Error("generating config failed", "error", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if command == auditCmd.FullCommand() {
+		failed, err := audit(os.Stdout, *nginxConfigPath, *auditFormat, *auditFailSeverity)
+		if err != nil {
+			logger.Error("auditing config failed", "error", err.Error())
+			os.Exit(1)
+		}
+		if failed {
+			logger.Error("config audit found risky settings", "fail_severity", *auditFailSeverity)
+			os.Exit(1)
+		}
+		return
+	}
+
+	transport, err := newTransport()
+	if err != nil {
+		logger.Error("creating HTTP transport failed", "error", err.Error())
+		os.Exit(1)
+	}
+
+	if command == probeCmd.FullCommand() {
+		if err := probe(context.Background(), os.Stdout, transport, *probeURI, *nginxPlus); err != nil {
+			logger.Error("probe failed", "uri", *probeURI, "error", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	logger.Info("nginx-prometheus-exporter", "version", common_version.Info())
+	logger.Info("build context", "build_context", common_version.BuildContext())
+
+	// exporter의 이름 및 버전 등의 정보를 /metrics 경로에 함께 노출하도록 등록
+	prometheus.MustRegister(version.NewCollector(exporterName))
+
+	exporterCfg := &exporterConfig{}
+	if *exporterConfigFile != "" {
+		if exporterCfg, err = loadExporterConfig(*exporterConfigFile); err != nil {
+			logger.Error("loading exporter config failed", "error", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	// SCRAPE_URI 환경 변수는 IsSetByUser 에 반영되지 않으므로 따로 확인한다.
+	uris, urisSet := *scrapeURIs, scrapeURIsSet || os.Getenv("SCRAPE_URI") != ""
+	// scrape 할 주소를 지정하지 않은 sidecar 는 로컬 NGINX 설정의 stub_status location 을 scrape 한다.
+	discoverURI := !urisSet && *scrapeURIFile == "" && len(exporterCfg.Targets) == 0
+	if exporterCfg.TargetStore != nil && discoverURI {
+		// target store 의 target 만 scrape 한다.
+		uris, discoverURI = nil, false
+	}
+	if *ingressCompanion {
+		// ingress controller 가 생성한 설정에서 controller 의 status server 를 찾고, ingress class 로 metric 을 구분한다.
+		ingress, err := detectLocalIngressController(*nginxConfigPath, *nginxPlus)
+		if err != nil {
+			logger.Error("detecting ingress controller failed", "config", *nginxConfigPath, "error", err.Error())
+			os.Exit(1)
+		}
+		class := *ingressClass
+		if class == "" {
+			class = findIngressClass("/proc")
+		}
+		if _, ok := constLabels["ingress_class"]; !ok {
+			constLabels["ingress_class"] = class
+		}
+		if discoverURI {
+			uris = []string{ingress.scrapeURI}
+		}
+		logger.Info("running as ingress controller companion", "controller", ingress.name, "ingress_class", constLabels["ingress_class"], "uri", ingress.scrapeURI)
+	} else if discoverURI && *configSource == "" {
+		if uri, err := discoverLocalScrapeURI(*nginxConfigPath, *nginxPlus); err != nil {
+			logger.Info("scrape URI discovery failed, using the default", "uri", uris[0], "error", err.Error())
+		} else {
+			logger.Info("discovered scrape URI from the NGINX config", "uri", uri)
+			uris = []string{uri}
+		}
+	}
+	targets, err := scrapeTargets(uris, urisSet, *scrapeURIFile, exporterCfg.Targets)
+	if err != nil {
+		logger.Error("invalid target configuration", "error", err.Error())
+		os.Exit(1)
+	}
+	if len(targets) == 0 && exporterCfg.TargetStore == nil {
+		logger.Error("no scrape addresses provided")
+		os.Exit(1)
+	}
+
+	for _, namespace := range []string{*nginxNamespace, *plusNamespace} {
+		if !labelNameRe.MatchString(namespace) {
+			logger.Error("invalid metric namespace", "namespace", namespace)
+			os.Exit(1)
+		}
+	}
+
+	// collector 또는 HTTP handler 의 panic 이 exporter 전체를 종료시키지 않도록 복구하고, 그 횟수를 노출한다.
+	panics := newPanicsCounter(*nginxNamespace, constLabels)
+	prometheus.MustRegister(panics)
+
+	status := &statusPage{configPath: *nginxConfigPath}
+
+	// target은 여러 개일 수 있으므로, 각각에 대해 collector를 등록한다.
+	// 여러 개일 경우, constLabels에 addr라는 레이블을 추가하여 구분할 수 있도록 한다.
+	// target store 의 target 은 바뀔 수 있으므로, target store 가 있으면 항상 addr 레이블을 추가한다.
+	addrLabel := len(targets) > 1 || exporterCfg.TargetStore != nil
+	if !addrLabel {
+		registerCollector(logger, panics, status, transport, targets[0], constLabels, *nginxPlus)
+	} else {
+		for _, target := range targets {
+			// add scrape URI to const labels
+			labels := maps.Clone(constLabels)
+			labels["addr"] = target.addr()
+
+			registerCollector(logger, panics, status, transport, target, labels, *nginxPlus)
+		}
+	}
+
+	// 같은 컨테이너 또는 PID namespace 의 NGINX 가 속한 cgroup 의 CPU, 메모리 사용량과 제한을 수집한다.
+	if *nginxPIDFile != "" {
+		prometheus.MustRegister(recoverCollector(collector.NewCgroupCollector(*nginxPIDFile,
+			collector.WithNamespace(*nginxNamespace),
+			collector.WithConstLabels(constLabels),
+			collector.WithLogger(logger),
+			collector.WithCgroupRoot(*cgroupRoot),
+		), logger, panics))
+	}
+
+	// NGINX Plus 수집과 함께, 같은 인스턴스의 stub_status도 OSS collector로 수집한다.
+	if *nginxPlus && *plusStubStatusURI != "" {
+		labels := constLabels
+		if addrLabel {
+			labels = maps.Clone(constLabels)
+			labels["addr"] = *plusStubStatusURI
+		}
+		registerCollector(logger, panics, status, transport, targetConfig{URL: *plusStubStatusURI}, labels, false)
+	}
+
+	// graceful shutdown을 위해 signal.NotifyContext를 사용한다.
+	// 인자로 받은 os.Interrupt, os.Kill, syscall.SIGTERM 시그널을 감지 시, 자동으로 취소되는 context이다.
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill, syscall.SIGTERM)
+	defer cancel()
+
+	// 중앙 설정 관리 도구가 파일 배포 없이 target 을 관리할 수 있도록, etcd 또는 Consul 의 prefix 에서 target 을 읽고 변경을
+	// 주기적으로 반영한다.
+	var targetStore *storeTargets
+	if exporterCfg.TargetStore != nil {
+		storeCfg := *exporterCfg.TargetStore
+		store, err := newKVStore(storeCfg, &http.Client{Timeout: targetStoreTimeout})
+		if err != nil {
+			logger.Error("invalid target store configuration", "error", err.Error())
+			os.Exit(1)
+		}
+		staticAddrs := make(map[string]bool, len(targets))
+		for _, t := range targets {
+			staticAddrs[t.addr()] = true
+		}
+		targetStore = newStoreTargets(store, storeCfg.Prefix, staticAddrs, func(t targetConfig) (targetCollector, error) {
+			labels := maps.Clone(constLabels)
+			labels["addr"] = t.addr()
+			return newTargetCollector(logger, transport, t, labels, *nginxPlus)
+		}, *nginxNamespace, constLabels, logger)
+		// target store 를 읽지 못해도 시작하고, 다음 주기에 다시 읽는다.
+		if err := targetStore.refresh(ctx); err != nil {
+			logger.Error("reading targets from the target store failed", "error", err.Error())
+		}
+		prometheus.MustRegister(recoverCollector(targetStore, logger, panics))
+		go targetStore.run(ctx, cmp.Or(storeCfg.RefreshInterval, defaultTargetStoreRefreshInterval))
+	}
+
+	// NGINX 를 거쳐 server_name 별로 요청을 보내, backend 의 TCP 연결만으로는 알 수 없는 vhost 의 오류를 확인한다.
+	if *vhostProbeURL != "" {
+		vhostProber, err := collector.NewVhostProber(collector.VhostProbeConfig{
+			Transport: transport,
+			URL:       *vhostProbeURL,
+			Path:      *vhostProbePath,
+			Interval:  *vhostProbeInterval,
+			Timeout:   *timeout,
+		},
+			collector.WithNamespace(*nginxNamespace),
+			collector.WithConstLabels(constLabels),
+			collector.WithLogger(logger),
+			collector.WithSampleTimestamps(*sampleTimestamps),
+			collector.WithConfigPath(*nginxConfigPath),
+			collector.WithProbeKeepAlive(*probeMaxIdleConns),
+		)
+		if err != nil {
+			logger.Error("invalid vhost probe configuration", "error", err.Error())
+			os.Exit(1)
+		}
+		vhostProber.Start(ctx)
+		prometheus.MustRegister(recoverCollector(vhostProber, logger, panics))
+	}
+
+	// upstream 에 직접 연결한 결과와 NGINX location 을 거친 결과를 함께 노출하여, 잘못 라우팅된 location 과 죽은 backend 를 구분한다.
+	if *upstreamProbeURL != "" {
+		upstreamProbe := collector.UpstreamProbeConfig{
+			Transport: transport,
+			URL:       *upstreamProbeURL,
+			Path:      *upstreamProbePath,
+			Interval:  *upstreamProbeInterval,
+			Timeout:   *timeout,
+		}
+		if *socks5Proxy != "" {
+			upstreamProbe.Dial = transport.DialContext
+		}
+		upstreamProber, err := collector.NewUpstreamProber(upstreamProbe,
+			collector.WithNamespace(*nginxNamespace),
+			collector.WithConstLabels(constLabels),
+			collector.WithLogger(logger),
+			collector.WithSampleTimestamps(*sampleTimestamps),
+			collector.WithConfigPath(*nginxConfigPath),
+			collector.WithProbeKeepAlive(*probeMaxIdleConns),
+		)
+		if err != nil {
+			logger.Error("invalid upstream probe configuration", "error", err.Error())
+			os.Exit(1)
+		}
+		upstreamProber.Start(ctx)
+		prometheus.MustRegister(recoverCollector(upstreamProber, logger, panics))
+	}
+
+	// exporter 설정 파일의 plugin, endpoint 의 metric 을 /metrics 에 함께 노출하고, 노출 전에 relabel 규칙, label 값 개수 제한, 이름 변경을 적용한다.
+	gatherers := prometheus.Gatherers{prometheus.DefaultGatherer}
+	var relabelRules []relabelRule
+	var metricRenames map[string]string
+	var metricsPaths []metricsPathConfig
+	var userAgentClasses []collector.UserAgentClass
+	if *exporterConfigFile != "" {
+		if plugins := exporterCfg.execPlugins(); len(plugins) > 0 {
+			pluginGatherer, err := collector.NewExecPluginGatherer(plugins,
+				collector.WithNamespace(*nginxNamespace),
+				collector.WithConstLabels(constLabels),
+				collector.WithLogger(logger),
+				collector.WithSampleTimestamps(*sampleTimestamps),
+			)
+			if err != nil {
+				logger.Error("invalid exec plugin configuration", "error", err.Error())
+				os.Exit(1)
+			}
+			pluginGatherer.Start(ctx)
+			gatherers = append(gatherers, pluginGatherer)
+		}
+		// 설정 파일에 정의한 location, vhost 별 probe 를 각자의 주기로 로컬 NGINX 에 보낸다.
+		if probes := exporterCfg.syntheticProbes(); len(probes) > 0 {
+			syntheticProber, err := collector.NewSyntheticProber(probes, transport,
+				collector.WithNamespace(*nginxNamespace),
+				collector.WithConstLabels(constLabels),
+				collector.WithLogger(logger),
+				collector.WithSampleTimestamps(*sampleTimestamps),
+				collector.WithProbeKeepAlive(*probeMaxIdleConns),
+			)
+			if err != nil {
+				logger.Error("invalid probe configuration", "error", err.Error())
+				os.Exit(1)
+			}
+			syntheticProber.Start(ctx)
+			prometheus.MustRegister(recoverCollector(syntheticProber, logger, panics))
+		}
+		for _, p := range exporterCfg.GRPCPlugins {
+			pluginClient, err := plugin.Start(p.Name, p.Command, p.Timeout)
+			if err != nil {
+				logger.Error("starting gRPC plugin failed", "plugin", p.Name, "error", err.Error())
+				os.Exit(1)
+			}
+			defer pluginClient.Close()
+			gatherers = append(gatherers, &logErrorsGatherer{next: pluginClient, logger: logger.With("plugin", p.Name)})
+		}
+		// 다른 포트로 노출되는 Prometheus endpoint 도 scrape 시마다 가져와 함께 노출한다.
+		for _, e := range exporterCfg.Endpoints {
+			httpClient, requestAddr, err := newHTTPClient(transport, e.URL, false)
+			if err != nil {
+				logger.Error("creating HTTP client failed", "uri", e.URL, "error", err.Error())
+				os.Exit(1)
+			}
+			endpointGatherer, err := collector.NewEndpointGatherer(collector.Endpoint{
+				Labels: e.Labels,
+				Name:   e.Name,
+				URL:    requestAddr,
+				Prefix: e.Prefix,
+			}, httpClient,
+				collector.WithNamespace(*nginxNamespace),
+				collector.WithConstLabels(constLabels),
+			)
+			if err != nil {
+				logger.Error("invalid endpoint configuration", "error", err.Error())
+				os.Exit(1)
+			}
+			gatherers = append(gatherers, &logErrorsGatherer{next: endpointGatherer, logger: logger.With("endpoint", e.Name)})
+		}
+
+		if relabelRules, err = exporterCfg.relabelRules(); err != nil {
+			logger.Error("invalid metric relabel configuration", "error", err.Error())
+			os.Exit(1)
+		}
+		if err := validateRenames(exporterCfg.MetricRenames); err != nil {
+			logger.Error("invalid metric renames", "error", err.Error())
+			os.Exit(1)
+		}
+		metricRenames = exporterCfg.MetricRenames
+		if err := validateMetricsPaths(exporterCfg.MetricsPaths, *metricsPath, quitPath, reloadPath, statusPath, healthCheckRunPath, "/"); err != nil {
+			logger.Error("invalid metrics paths", "error", err.Error())
+			os.Exit(1)
+		}
+		metricsPaths = exporterCfg.MetricsPaths
+		userAgentClasses = exporterCfg.userAgentClasses()
+	}
+
+	// access log 를 tail 하여 OSS NGINX 의 stub_status 로는 알 수 없는 요청별 metric 을 수집한다.
+	if *accessLogPath != "" {
+		accessLogCollector, err := collector.NewAccessLogCollector(collector.AccessLogConfig{
+			Path:              *accessLogPath,
+			Format:            *accessLogFormat,
+			GeoIPDatabase:     *geoIPDatabase,
+			GeoIPCountryLimit: *geoIPCountryLimit,
+			VhostLimit:        *accessLogVhostLimit,
+			UserAgentClasses:  userAgentClasses,
+			TopPaths:          *accessLogTopPaths,
+			TopPathsWindow:    *accessLogTopWindow,
+			QueueSize:         *accessLogQueueSize,
+			MemoryLimit:       int64(*accessLogMemoryLimit),
+		},
+			collector.WithNamespace(*nginxNamespace),
+			collector.WithConstLabels(constLabels),
+			collector.WithLogger(logger),
+			collector.WithSampleTimestamps(*sampleTimestamps),
+		)
+		if err != nil {
+			logger.Error("invalid access log configuration", "error", err.Error())
+			os.Exit(1)
+		}
+		accessLogCollector.Start(ctx)
+		prometheus.MustRegister(recoverCollector(accessLogCollector, logger, panics))
+	}
+
+	var gatherer prometheus.Gatherer = gatherers
+	if len(relabelRules) > 0 {
+		gatherer = &relabelGatherer{next: gatherer, rules: relabelRules}
+	}
+	if *labelValueLimit > 0 {
+		overflow := newLabelOverflowCounter(*nginxNamespace, constLabels)
+		prometheus.MustRegister(overflow)
+		gatherer = newCardinalityGatherer(gatherer, *limitedLabels, *labelValueLimit, overflow)
+	}
+	if len(metricRenames) > 0 {
+		gatherer = &renameGatherer{next: gatherer, renames: metricRenames}
+	}
+
+	http.Handle(*metricsPath, newMetricsHandler(gatherer, *metricsTimeout))
+	// 추가 metric 경로는 scrape 주기가 다른 Prometheus job 이 필요한 metric 만 가져갈 수 있도록 이름으로 거른 metric 을 노출한다.
+	landingLinks := []web.LandingLinks{
+		{
+			Address: *metricsPath,
+			Text:    "Metrics",
+		},
+	}
+	for _, p := range metricsPaths {
+		filtered, err := newFilterGatherer(gatherer, p.Include, p.Exclude)
+		if err != nil {
+			logger.Error("invalid metrics path configuration", "path", p.Path, "error", err.Error())
+			os.Exit(1)
+		}
+		http.Handle(p.Path, newMetricsHandler(filtered, *metricsTimeout))
+		landingLinks = append(landingLinks, web.LandingLinks{Address: p.Path, Text: "Metrics (" + p.Path + ")"})
+	}
+
+	// orchestration 시스템이 HTTP 로 exporter 를 종료할 수 있도록, signal 과 같은 context 를 취소하는 endpoint 를 제공한다.
+	if *quitTokenFile != "" {
+		token, err := readSecretFile(*quitTokenFile)
+		if err != nil {
+			logger.Error("loading quit token failed", "error", err.Error())
+			os.Exit(1)
+		}
+		http.Handle(quitPath, quitHandler(token, cancel))
+	}
+
+	// 설정 배포 pipeline 이 검증을 거친 reload 만 할 수 있도록, nginx -t 가 통과한 경우에만 master 프로세스에 SIGHUP 을 보낸다.
+	if *reloadTokenFile != "" {
+		pidFile := cmp.Or(*reloadPIDFile, *nginxPIDFile)
+		if *nginxBinaryPath == "" || pidFile == "" {
+			logger.Error("--web.reload-token-file requires --nginx.binary-path and --web.reload-pid-file or --nginx.pid-file")
+			os.Exit(1)
+		}
+		token, err := readSecretFile(*reloadTokenFile)
+		if err != nil {
+			logger.Error("loading reload token failed", "error", err.Error())
+			os.Exit(1)
+		}
+		reloader := newNginxReloader(*nginxBinaryPath, *nginxConfigPath, pidFile, *nginxNamespace, constLabels, logger)
+		prometheus.MustRegister(reloader)
+		http.Handle(reloadPath, reloadHandler(token, reloader))
+	}
+
+	// backend 배포 직후처럼 다음 주기를 기다리지 않고 health check 결과를 확인할 수 있도록 한다.
+	if *healthCheckTokenFile != "" {
+		if *healthCheckInterval == 0 {
+			logger.Error("--web.health-check-token-file requires --nginx.health-check-interval")
+			os.Exit(1)
+		}
+		token, err := readSecretFile(*healthCheckTokenFile)
+		if err != nil {
+			logger.Error("loading health check token failed", "error", err.Error())
+			os.Exit(1)
+		}
+		// NGINX Plus collectors are not included, NGINX Plus runs the health checks of its upstreams itself.
+		runners := make([]healthCheckRunner, 0, len(status.health)+1)
+		for _, source := range status.health {
+			runners = append(runners, source)
+		}
+		if targetStore != nil {
+			runners = append(runners, targetStore)
+		}
+		http.Handle(healthCheckRunPath, healthCheckRunHandler(token, runners))
+	}
+
+	if *statusPageEnabled {
+		http.Handle(statusPath, status)
+		landingLinks = append(landingLinks, web.LandingLinks{Address: statusPath, Text: "Status"})
+	}
+
+	if *metricsPath != "/" && *metricsPath != "" {
+		landingConfig := web.LandingConfig{
+			Name:        "NGINX Prometheus Exporter",
+			Description: "Prometheus Exporter for NGINX and NGINX Plus",
+			HeaderColor: "#039900",
+			Version:     common_version.Info(),
+			Links:       landingLinks,
+		}
+		landingPage, err := web.NewLandingPage(landingConfig)
+		if err != nil {
+			logger.Error("failed to create landing page", "error", err.Error())
+			os.Exit(1)
+		}
+		http.Handle("/", landingPage)
+	}
+
+	// listen 주소마다 다른 TLS 설정을 사용할 수 있다.
+	if len(*listenConfigs) > 0 && webConfig.WebSystemdSocket != nil && *webConfig.WebSystemdSocket {
+		logger.Error("web.listen-config cannot be combined with web.systemd-socket")
+		os.Exit(1)
+	}
+	listenAddressConfigs, err := parseListenConfigs(*listenConfigs, *webConfig.WebListenAddresses)
+	if err != nil {
+		logger.Error("invalid listen configuration", "error", err.Error())
+		os.Exit(1)
+	}
+	if *restrictedTLS {
+		if err := checkListenersRestrictedTLS(webConfig, listenAddressConfigs); err != nil {
+			logger.Error("TLS configuration is not restricted", "error", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	srv := &http.Server{ // HTTP 서버 인스턴스 생성
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	var handler http.Handler = http.DefaultServeMux
+	if *accessLog {
+		handler = accessLogHandler(handler, logger)
+	}
+	srv.Handler = recoverHandler(handler, logger, panics)
+	servers := listenServers(srv, webConfig, listenAddressConfigs)
+
+	// 별도의 goroutine에서 HTTP 서버를 시작.
+	// 이후 <-ctx.Done()이 올 때 까지 대기.
+	go func() {
+		if err := listenAndServe(servers, webConfig, listenAddressConfigs, logger); err != nil {
+			if errors.Is(err, http.ErrServerClosed) {
+				logger.Info("HTTP server closed", "error", err.Error())
+				os.Exit(0)
+			}
+			logger.Error("HTTP server failed", "error", err.Error())
+			os.Exit(1)
+		}
+	}()
+
+	<-ctx.Done() // Context에 Done 시그널을 보내 goroutine을 종료하고, 대기 중이던 메인 goroutine이 진행된다.
+	logger.Info("shutting down")
+
+	// 서버가 종료 신호를 받았을 때 클라 요청을 안전하게 마무리하고 종료하기 위해, 서버 종료 작업에 최대 5초의 제한 시간을 둔 컨텍스트를 생성.
+	srvCtx, srvCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer srvCancel()
+	for _, server := range servers {
+		_ = server.Shutdown(srvCtx)
+	}
+}
+
+func registerCollector(logger *slog.Logger, panics prometheus.Counter, status *statusPage, transport *http.Transport,
+	target targetConfig, labels map[string]string, plus bool,
+) {
+	c, err := newTargetCollector(logger, transport, target, labels, plus)
+	if err != nil {
+		logger.Error("creating collector failed", "uri", target.URL, "error", err.Error())
+		os.Exit(1)
+	}
+	prometheus.MustRegister(recoverCollector(c, logger, panics))
+	status.addTarget(target.addr(), c)
+	if health, ok := c.(healthStatusSource); ok {
+		status.addHealth(health)
+	}
+}
+
+// targetCollector is the NGINX or NGINX Plus collector of a scrape target.
+type targetCollector interface {
+	prometheus.Collector
+	scrapeStatusSource
+}
+
+// newTargetCollector : target 의 TLS 설정과 인증 정보로 HTTP client 를 만들고, NGINX 또는 NGINX Plus collector 를 생성한다.
+func newTargetCollector(logger *slog.Logger, transport *http.Transport, target targetConfig, labels map[string]string, plus bool) (targetCollector, error) {
+	var err error
+	if transport, err = target.transport(transport); err != nil {
+		return nil, fmt.Errorf("loading target TLS configuration failed: %w", err)
+	}
+	httpClient, requestAddr, err := newHTTPClient(transport, target.URL, plus)
+	if err != nil {
+		return nil, fmt.Errorf("creating HTTP client failed: %w", err)
+	}
+	if httpClient.Transport, err = target.roundTripper(httpClient.Transport); err != nil {
+		return nil, fmt.Errorf("loading target credentials failed: %w", err)
+	}
+
+	if plus {
+		newPlusClient := func(apiVersion int) (*plusclient.NginxClient, error) {
+			plusClient, err := plusclient.NewNginxClient(requestAddr, plusclient.WithHTTPClient(httpClient), plusclient.WithAPIVersion(apiVersion))
+			if err != nil {
+				return nil, fmt.Errorf("could not create Nginx Plus Client: %w", err)
+			}
+			return plusClient, nil
+		}
+		plusClient, err := newPlusClient(*plusAPIVersion)
+		if err != nil {
+			return nil, err
+		}
+		plusCollector, err := newNginxPlusCollector(plusClient, newPlusClient, labels, logger)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NGINX Plus collector configuration: %w", err)
+		}
+		return plusCollector, nil
+	}
+
+	// 여기서 Nginx Client를 사용하여 stub_status를 수집한다.
+	ossClient := client.NewNginxClient(httpClient, requestAddr)
+	// SOCKS5 프록시를 사용하는 경우, upstream health check 도 같은 프록시를 통해 수행한다.
+	healthCheck := collector.HealthCheckConfig{
+		Interval:   *healthCheckInterval,
+		Jitter:     *healthCheckJitter,
+		DualStack:  *healthCheckDualStack,
+		MaxTargets: *healthCheckMaxTargets,
+	}
+	if *socks5Proxy != "" {
+		healthCheck.Dial = transport.DialContext
+	}
+	if *dnsCacheMaxTTL > 0 {
+		healthCheck.Dial = collector.NewCachingDialer(healthCheck.Dial, *dnsCacheMaxTTL)
+	}
+	// https 로 proxy 하는 target 은 scrape 의 TLS 설정과 별개로, 지정한 CA 와 이름으로 인증서를 검증한다.
+	if *healthCheckCACert != "" || *healthCheckServerName != "" {
+		healthCheck.TLS = &tls.Config{ServerName: *healthCheckServerName, MinVersion: tls.VersionTLS12}
+		if *healthCheckCACert != "" {
+			pool, err := loadCACertPool(*healthCheckCACert)
+			if err != nil {
+				return nil, err
+			}
+			healthCheck.TLS.RootCAs = pool
+		}
+	}
+	return collector.NewNginxCollector(ossClient,
+		collector.WithNamespace(*nginxNamespace),
+		collector.WithConstLabels(labels),
+		collector.WithLogger(logger),
+		collector.WithSampleTimestamps(*sampleTimestamps),
+		collector.WithConfigPath(*nginxConfigPath),
+		collector.WithBinaryPath(*nginxBinaryPath),
+		collector.WithConfigLabelMode(*configLabelMode),
+		collector.WithConfigStrict(*configStrict),
+		collector.WithConfigSource(*configSource, newConfigSourceClient(transport), *timeout),
+		collector.WithConfigRefreshInterval(*configRefresh),
+		collector.WithHealthChecks(healthCheck),
+		collector.WithHealthStateFile(*healthStateFile),
+		collector.WithUpstreamTCPStates(*upstreamTCPStates),
+		collector.WithConntrack(*conntrack),
+		collector.WithListenBacklog(*listenBacklog),
+		collector.WithSecurityAudit(*securityAudit),
+	), nil
+}
+
+// newConfigSourceClient : 원격 설정 소스를 읽는 HTTP client 를 생성한다.
+// 설정 소스는 scrape 대상과 다른 주소이므로, unix domain socket 으로 연결하는 scrape client 대신 기본 transport 를 사용한다.
+func newConfigSourceClient(transport *http.Transport) *http.Client {
+	return &http.Client{
+		Transport: &userAgentRoundTripper{
+			agent: fmt.Sprintf("NGINX-Prometheus-Exporter/v%v", common_version.Version),
+			rt:    transport.Clone(),
+		},
+	}
+}
+
+// newMetricsHandler : gatherer 의 metric 을 노출하는 handler 를 생성한다. promhttp.Handler 와 같이 handler 자체의 metric 도 기록한다.
+// gather 오류가 있거나 collector 가 panic 한 scrape 는 500 으로, timeout 을 넘긴 scrape 는 503 으로 응답한다. timeout 이 0 이면
+// 제한하지 않는다. plugin, endpoint 처럼 exporter 가 통제할 수 없는 metric 의 오류는 각 gatherer 가 로그로 남기고 걸러낸다.
+// include, exclude query parameter 가 있으면 metric 이름으로 거른 metric 만 노출하여, scrape 주기가 다른 Prometheus job 이
+// 같은 경로에서 필요한 metric 만 가져갈 수 있다.
+func newMetricsHandler(gatherer prometheus.Gatherer, timeout time.Duration) http.Handler {
+	handlerFor := func(gatherer prometheus.Gatherer) http.Handler {
+		return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{
+			ErrorHandling: promhttp.HTTPErrorOnError,
+			Timeout:       timeout,
+		})
+	}
+	unfiltered := handlerFor(gatherer)
+	return promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		include, exclude := query["include"], query["exclude"]
+		if len(include) == 0 && len(exclude) == 0 {
+			unfiltered.ServeHTTP(w, r)
+			return
+		}
+		filtered, err := newFilterGatherer(gatherer, include, exclude)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		handlerFor(filtered).ServeHTTP(w, r)
+	}))
+}
+
+// logErrorsGatherer : gRPC plugin, 외부 endpoint 의 gather 오류를 로그로 남기고 가져온 metric 만 노출하여, exporter 가
+// 통제할 수 없는 source 하나의 오류로 전체 scrape 가 실패하지 않도록 한다.
+type logErrorsGatherer struct {
+	next   prometheus.Gatherer
+	logger *slog.Logger
+}
+
+// Gather implements prometheus.Gatherer.
+func (g *logErrorsGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.next.Gather()
+	if err != nil {
+		g.logger.Warn("error gathering metrics", "error", err.Error())
+	}
+	return families, nil
+}
+
+// newTransport creates the HTTP transport with the TLS settings configured by flags.
+func newTransport() (*http.Transport, error) {
+	cipherSuites, err := parseCipherSuites(*sslCiphers)
+	if err != nil {
+		return nil, err
+	}
+	// #nosec G402
+	sslConfig := &tls.Config{
+		InsecureSkipVerify: !*sslVerify,
+		MinVersion:         tlsVersions[*sslMinVersion],
+		CipherSuites:       cipherSuites,
+	}
+	if *sslCaCert != "" {
+		sslCaCertPool, err := loadCACertPool(*sslCaCert)
+		if err != nil {
+			return nil, err
+		}
+		sslConfig.RootCAs = sslCaCertPool
+	}
+
+	if *sslClientCert != "" && *sslClientKey != "" {
+		clientCert, err := tls.LoadX509KeyPair(*sslClientCert, *sslClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate failed: %w", err)
+		}
+		sslConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	transport := &http.Transport{
+		TLSClientConfig: sslConfig,
+	}
+
+	if *sshJump != "" && *socks5Proxy != "" {
+		return nil, errors.New("nginx.ssh-jump and nginx.socks5-proxy cannot be used together")
+	}
+	// family 별 검사는 host 를 직접 해석하므로, 프록시가 해석하는 주소와 다를 수 있다.
+	if *healthCheckDualStack && *socks5Proxy != "" {
+		return nil, errors.New("nginx.health-check-dual-stack cannot be used with nginx.socks5-proxy")
+	}
+	// QUIC 은 UDP 로 직접 연결하므로 SSH 터널이나 SOCKS5 프록시를 거칠 수 없다.
+	if *scrapeHTTP3 && (*sshJump != "" || *socks5Proxy != "") {
+		return nil, errors.New("nginx.http3 cannot be used with nginx.ssh-jump or nginx.socks5-proxy")
+	}
+	if *sshJump != "" {
+		dialer, err := newSSHDialer(*sshJump, *sshKey, *sshKnownHosts)
+		if err != nil {
+			return nil, err
+		}
+		transport.DialContext = dialer.DialContext
+	}
+	if *socks5Proxy != "" {
+		dialer, err := newSOCKS5Dialer(*socks5Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.DialContext = dialer.DialContext
+	}
+
+	return transport, nil
+}
+
+// loadCACertPool : PEM 형식의 CA 인증서 파일을 읽어 cert pool 을 생성한다.
+func loadCACertPool(path string) (*x509.CertPool, error) {
+	caCert, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading CA cert failed: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, errors.New("parsing CA cert file failed")
+	}
+	return pool, nil
+}
+
+// newHTTPClient creates the HTTP client for scraping addr and returns it with the
+// address to request, which differs from addr for unix domain sockets.
+func newHTTPClient(transport *http.Transport, addr string, plus bool) (*http.Client, string, error) {
+	// collector마다 DialContext를 재설정할 수 있으므로, 공유 transport를 복제하여 사용한다.
+	transport = transport.Clone()
+	if strings.HasPrefix(addr, "unix:") {
+		socketPath, requestPath, err := parseUnixSocketAddress(addr)
+		if err != nil {
+			return nil, "", fmt.Errorf("parsing unix domain socket scrape address failed: %w", err)
+		}
+
+		// scrape-uri가 unix 경로로 시작하는 경우, transport.DialContext를 재설정한다.
+		// 즉, 표준 TCP 연결 대신, 유닉스 도메인 소켓을 사용하도록 지시한다.
+		dial := transport.DialContext
+		if dial == nil {
+			dial = (&net.Dialer{}).DialContext
+		}
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dial(ctx, "unix", socketPath)
+		}
+		addr = "http://unix" + requestPath
+	}
+
+	var rt http.RoundTripper = transport
+	if *scrapeHTTP3 && strings.HasPrefix(addr, "https://") {
+		rt = newHTTP3RoundTripper(transport)
+	}
+	if *maxResponseSize > 0 {
+		rt = &responseLimitRoundTripper{rt: rt, limit: int64(*maxResponseSize)}
+	}
+
+	userAgent := fmt.Sprintf("NGINX-Prometheus-Exporter/v%v", common_version.Version)
+
+	// HTTP 클라를 생성하는데, 다른 점이 있다면, userAgentRoundTripper를 사용한다는 것이다.
+	// userAgentRoundTripper는 HTTP 요청에 User-Agent 헤더를 추가하는 역할을 한다.
+	httpClient := &http.Client{
+		Timeout: *timeout,
+		Transport: &userAgentRoundTripper{
+			agent: userAgent,
+			rt:    rt,
+		},
+	}
+
+	if plus && *plusAPIKeyFile != "" {
+		apiKey, err := readSecretFile(*plusAPIKeyFile)
+		if err != nil {
+			return nil, "", fmt.Errorf("loading NGINX Plus API key failed: %w", err)
+		}
+		httpClient.Transport = &headerRoundTripper{
+			rt:     httpClient.Transport,
+			header: *plusAPIKeyHeader,
+			value:  apiKey,
+		}
+	}
+
+	return httpClient, addr, nil
+}
+
+// newNginxPlusCollector creates the NGINX Plus collector with the variable labels and filters configured by flags.
+func newNginxPlusCollector(plusClient *plusclient.NginxClient, newPlusClient func(int) (*plusclient.NginxClient, error), labels map[string]string, logger *slog.Logger) (*collector.NginxPlusCollector, error) {
+	kinds := []*variableLabelFlags{
+		upstreamLabels, serverZoneLabels, upstreamPeerLabels,
+		streamUpstreamLabels, streamServerZoneLabels, streamUpstreamPeerLabels,
+		cacheZoneLabels,
+	}
+	names := make([][]string, len(kinds))
+	values := make([]map[string][]string, len(kinds))
+	for i, kind := range kinds {
+		var err error
+		if names[i], err = kind.labelNames(); err != nil {
+			return nil, err
+		}
+		if values[i], err = kind.labelValues(); err != nil {
+			return nil, err
+		}
+	}
+
+	config := collector.NginxPlusCollectorConfig{
+		SkipEndpoints:        *plusSkipEndpoints,
+		SkipResponseCodes:    !*plusResponseCodes,
+		PeerServiceLabels:    *plusPeerServiceLabels,
+		CollectSlabs:         *plusCollectSlabs,
+		SlabSlotDetail:       *plusSlabSlotDetail,
+		CollectKeyvals:       *plusCollectKeyvals,
+		CollectLocationZones: *plusCollectLocationZones,
+		FetchConcurrency:     *plusFetchConcurrency,
+		Timeout:              *timeout,
+		UpstreamsFile:        *plusUpstreamsFile,
+		NewClient:            newPlusClient,
+	}
+	var err error
+	if config.ServerZoneFilter, err = collector.NewNameFilter(*plusServerZoneInclude, *plusServerZoneExclude); err != nil {
+		return nil, fmt.Errorf("server zone filter: %w", err)
+	}
+	if config.UpstreamFilter, err = collector.NewNameFilter(*plusUpstreamInclude, *plusUpstreamExclude); err != nil {
+		return nil, fmt.Errorf("upstream filter: %w", err)
+	}
+	if config.CacheZoneFilter, err = collector.NewNameFilter(*plusCacheZoneInclude, *plusCacheZoneExclude); err != nil {
+		return nil, fmt.Errorf("cache zone filter: %w", err)
+	}
+
+	variableLabelNames := collector.NewVariableLabelNames(names[0], names[1], names[2], names[3], names[4], names[5], names[6])
+	plusCollector := collector.NewNginxPlusCollector(plusClient,
+		collector.WithNamespace(*plusNamespace),
+		collector.WithVariableLabelNames(variableLabelNames),
+		collector.WithConstLabels(labels),
+		collector.WithLogger(logger),
+		collector.WithPlusConfig(config),
+	)
+	plusCollector.UpdateUpstreamServerLabels(values[0])
+	plusCollector.UpdateServerZoneLabels(values[1])
+	plusCollector.UpdateUpstreamServerPeerLabels(values[2])
+	plusCollector.UpdateStreamUpstreamServerLabels(values[3])
+	plusCollector.UpdateStreamServerZoneLabels(values[4])
+	plusCollector.UpdateStreamUpstreamServerPeerLabels(values[5])
+	plusCollector.UpdateCacheZoneLabels(values[6])
+
+	return plusCollector, nil
+}
+
+// RTT(Round Trip Time) : 패킷이 클라이언트와 서버 사이를 왕복하는데 걸리는 시간
+// 즉, RoundTrip은 HTTP 요청을 보내고 응답을 받는 과정을 의미한다.
+// userAgentRoundTripper 기존 http.RoundTripper를 감싸서, 요청을 보내기 전에 User-Agent 헤더를 추가한다.
+// 더불어 구현한 method는 모두 RoundTripper Interface에 속하기 위한 메서드이다. 즉, 코드에서 메서드를 직접 호출하지 않아도 사용되는 것이다.
+
+type userAgentRoundTripper struct {
+	rt    http.RoundTripper
+	agent string
+}
+
+func (rt *userAgentRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = cloneRequest(req)
+	req.Header.Set("User-Agent", rt.agent)
+	roundTrip, err := rt.rt.RoundTrip(req)
+	if err != nil {
+		return nil, fmt.Errorf("round trip failed: %w", err)
+	}
+	return roundTrip, nil
+}
+
+// headerRoundTripper 기존 http.RoundTripper를 감싸서, 요청에 고정된 헤더(예: API key)를 추가한다.
+type headerRoundTripper struct {
+	rt     http.RoundTripper
+	header string
+	value  string
+}
+
+func (rt *headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = cloneRequest(req)
+	req.Header.Set(rt.header, rt.value)
+	roundTrip, err := rt.rt.RoundTrip(req)
+	if err != nil {
+		return nil, fmt.Errorf("round trip failed: %w", err)
+	}
+	return roundTrip, nil
+}
+
+// accessLogHandler : exporter HTTP 서버로 들어오는 요청마다 접근 로그를 남긴다.
+func accessLogHandler(next http.Handler, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		logger.Info("http request", "method", r.Method, "path", r.URL.Path, "remote_addr", r.RemoteAddr,
+			"duration", time.Since(start), "status", rec.status)
+	})
+}
+
+// statusRecorder 기존 http.ResponseWriter를 감싸서, 응답 상태 코드를 기록한다.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying ResponseWriter.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// readSecretFile : API key, token 등의 secret 파일을 읽어 앞뒤 공백을 제거한 값을 반환한다.
+func readSecretFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	secret := strings.TrimSpace(string(content))
+	if secret == "" {
+		return "", fmt.Errorf("secret file %s is empty", path)
+	}
+	return secret, nil
+}
+
+func cloneRequest(req *http.Request) *http.Request {
+	r := new(http.Request)
+	*r = *req // 얕은 복사
+
+	// 깊은 복사
+	r.Header = make(http.Header, len(req.Header))
+	for key, values := range req.Header {
+		newValues := make([]string, len(values))
+		copy(newValues, values)
+		r.Header[key] = newValues
+	}
+	return r
+}
+
+// addMissingEnvironmentFlags sets Envar on any flag which has
+// the "web." prefix which doesn't already have an Envar set.
+func addMissingEnvironmentFlags(ka *kingpin.Application) {
+	for _, f := range ka.Model().Flags {
+		if strings.HasPrefix(f.Name, "web.") && f.Envar == "" {
+			retrievedFlag := ka.GetFlag(f.Name)
+			if retrievedFlag != nil {
+				retrievedFlag.Envar(convertFlagToEnvar(strings.TrimPrefix(f.Name, "web.")))
+			}
+		}
+	}
+}
+
+func convertFlagToEnvar(f string) string {
+	env := strings.ToUpper(f)
+	for _, s := range []string{"-", "."} {
+		env = strings.ReplaceAll(env, s, "_")
+	}
+	return env
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	plusclient "github.com/nginx/nginx-plus-go-client/v2/client"
+	"github.com/nginx/nginx-prometheus-exporter/client"
+	"github.com/nginx/nginx-prometheus-exporter/collector"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/exporter-toolkit/web/kingpinflag"
+)
+
+func TestParsePositiveDuration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		testInput string
+		want      positiveDuration
+		wantErr   bool
+	}{
+		{
+			"ParsePositiveDuration returns a positiveDuration",
+			"15ms",
+			positiveDuration{15 * time.Millisecond},
+			false,
+		},
+		{
+			"ParsePositiveDuration returns error for trying to parse negative value",
+			"-15ms",
+			positiveDuration{},
+			true,
+		},
+		{
+			"ParsePositiveDuration returns error for trying to parse empty string",
+			"",
+			positiveDuration{},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := parsePositiveDuration(tt.testInput)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parsePositiveDuration() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePositiveDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUnixSocketAddress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		testInput       string
+		wantSocketPath  string
+		wantRequestPath string
+		wantErr         bool
+	}{
+		{
+			"Normal unix socket address",
+			"unix:/path/to/socket",
+			"/path/to/socket",
+			"",
+			false,
+		},
+		{
+			"Normal unix socket address with location",
+			"unix:/path/to/socket:/with/location",
+			"/path/to/socket",
+			"/with/location",
+			false,
+		},
+		{
+			"Unix socket address with trailing ",
+			"unix:/trailing/path:",
+			"/trailing/path",
+			"",
+			false,
+		},
+		{
+			"Unix socket address with too many colons",
+			"unix:/too:/many:colons:",
+			"",
+			"",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			socketPath, requestPath, err := parseUnixSocketAddress(tt.testInput)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseUnixSocketAddress() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(socketPath, tt.wantSocketPath) {
+				t.Errorf("socket path: parseUnixSocketAddress() = %v, want %v", socketPath, tt.wantSocketPath)
+			}
+			if !reflect.DeepEqual(requestPath, tt.wantRequestPath) {
+				t.Errorf("request path: parseUnixSocketAddress() = %v, want %v", requestPath, tt.wantRequestPath)
+			}
+		})
+	}
+}
+
+func TestAddMissingEnvironmentFlags(t *testing.T) {
+	t.Parallel()
+	expectedMatches := map[string]string{
+		"non-matching-flag":  "",
+		"web.missing-env":    "MISSING_ENV",
+		"web.has-env":        "HAS_ENV_ALREADY",
+		"web.listen-address": "LISTEN_ADDRESS",
+		"web.config.file":    "CONFIG_FILE",
+	}
+	kingpinflag.AddFlags(kingpin.CommandLine, ":9113")
+	kingpin.Flag("non-matching-flag", "").String()
+	kingpin.Flag("web.missing-env", "").String()
+	kingpin.Flag("web.has-env", "").Envar("HAS_ENV_ALREADY").String()
+	addMissingEnvironmentFlags(kingpin.CommandLine)
+
+	// using Envar() on a flag returned from GetFlag()
+	// adds an additional flag, which is processed correctly
+	// at runtime but means that we need to check for a match
+	// instead of checking the envar of each matching flag name
+	for k, v := range expectedMatches {
+		matched := false
+		for _, f := range kingpin.CommandLine.Model().Flags {
+			if f.Name == k && f.Envar == v {
+				matched = true
+			}
+		}
+		if !matched {
+			t.Errorf("missing %s envar for %s", v, k)
+		}
+	}
+}
+
+func TestConvertFlagToEnvar(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		input  string
+		output string
+	}{
+		{
+			input:  "dot.separate",
+			output: "DOT_SEPARATE",
+		},
+		{
+			input:  "underscore_separate",
+			output: "UNDERSCORE_SEPARATE",
+		},
+		{
+			input:  "mixed_separate_options",
+			output: "MIXED_SEPARATE_OPTIONS",
+		},
+	}
+
+	for _, c := range cases {
+		res := convertFlagToEnvar(c.input)
+		if res != c.output {
+			t.Errorf("expected %s to resolve to %s but got %s", c.input, c.output, res)
+		}
+	}
+}
+
+func TestVariableLabelFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		values     map[string]string
+		wantValues map[string][]string
+		name       string
+		names      []string
+		wantNames  []string
+		wantErr    bool
+	}{
+		{
+			name:       "no labels",
+			values:     map[string]string{},
+			wantValues: map[string][]string{},
+		},
+		{
+			name:       "labels with values",
+			names:      []string{"team", "env"},
+			values:     map[string]string{"backend": "payments,prod"},
+			wantNames:  []string{"team", "env"},
+			wantValues: map[string][]string{"backend": {"payments", "prod"}},
+		},
+		{
+			name:    "wrong number of values",
+			names:   []string{"team", "env"},
+			values:  map[string]string{"backend": "payments"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid label name",
+			names:   []string{"team-name"},
+			values:  map[string]string{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			f := &variableLabelFlags{names: &tt.names, values: tt.values}
+			names, err := f.labelNames()
+			if err == nil {
+				var values map[string][]string
+				values, err = f.labelValues()
+				if err == nil && (!reflect.DeepEqual(names, tt.wantNames) || !reflect.DeepEqual(values, tt.wantValues)) {
+					t.Errorf("variableLabelFlags = %v %v, want %v %v", names, values, tt.wantNames, tt.wantValues)
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("variableLabelFlags error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHeaderRoundTripper(t *testing.T) {
+	t.Parallel()
+
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("X-API-Key")
+	}))
+	defer server.Close()
+
+	keyFile := filepath.Join(t.TempDir(), "api-key")
+	if err := os.WriteFile(keyFile, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	apiKey, err := readSecretFile(keyFile)
+	if err != nil {
+		t.Fatalf("readSecretFile() returned error: %v", err)
+	}
+
+	httpClient := &http.Client{Transport: &headerRoundTripper{rt: http.DefaultTransport, header: "X-API-Key", value: apiKey}}
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if got != "secret" {
+		t.Errorf("X-API-Key header = %q, want %q", got, "secret")
+	}
+}
+
+func TestProbe(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stub_status" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("Active connections: 1 \nserver accepts handled requests\n 5 5 7 \nReading: 0 Writing: 1 Waiting: 0 \n"))
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	if err := probe(t.Context(), &out, &http.Transport{}, server.URL+"/stub_status", false); err != nil {
+		t.Fatalf("probe() returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), `"Requests": 7`) {
+		t.Errorf("probe() output = %v, want the parsed stub_status stats", out.String())
+	}
+
+	if err := probe(t.Context(), io.Discard, &http.Transport{}, server.URL+"/missing", false); err == nil {
+		t.Error("probe() did not return an error for a failed scrape")
+	}
+}
+
+func TestPlusWithStubStatus(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/stub_status":
+			_, _ = w.Write([]byte("Active connections: 1 \nserver accepts handled requests\n 5 5 7 \nReading: 0 Writing: 1 Waiting: 0 \n"))
+		case r.URL.Path == "/api/9/connections":
+			_, _ = w.Write([]byte(`{"accepted":5,"dropped":0,"active":2,"idle":3}`))
+		case strings.HasPrefix(r.URL.Path, "/api/"):
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	logger := slog.New(slog.DiscardHandler)
+	ossCollector := collector.NewNginxCollector(client.NewNginxClient(server.Client(), server.URL+"/stub_status"), collector.WithLogger(logger))
+
+	plusClient, err := plusclient.NewNginxClient(server.URL+"/api", plusclient.WithHTTPClient(server.Client()), plusclient.WithAPIVersion(9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plusCollector := collector.NewNginxPlusCollector(plusClient, collector.WithLogger(logger), collector.WithPlusConfig(collector.NginxPlusCollectorConfig{
+		SkipEndpoints: []string{collector.PlusEndpointWorkers, collector.PlusEndpointLicense},
+	}))
+
+	// Both collectors are registered to the same registry, which fails to gather colliding metrics.
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(ossCollector, plusCollector)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+
+	values := make(map[string]float64)
+	for _, family := range families {
+		if metrics := family.GetMetric(); len(metrics) == 1 && metrics[0].GetGauge() != nil {
+			values[family.GetName()] = metrics[0].GetGauge().GetValue()
+		}
+	}
+	for name, want := range map[string]float64{
+		"nginx_up":                     1,
+		"nginx_connections_active":     1,
+		"nginxplus_up":                 1,
+		"nginxplus_connections_active": 2,
+	} {
+		if got, ok := values[name]; !ok || got != want {
+			t.Errorf("%s = %v (gathered %v), want %v", name, got, ok, want)
+		}
+	}
+}
+
+func TestAccessLogHandler(t *testing.T) {
+	t.Parallel()
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	handler := accessLogHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}), logger)
+
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/missing", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("access log is not valid JSON: %v", err)
+	}
+	for key, want := range map[string]any{"method": "GET", "path": "/missing", "remote_addr": "192.0.2.1:1234", "status": float64(http.StatusNotFound)} {
+		if entry[key] != want {
+			t.Errorf("access log %v = %v, want %v", key, entry[key], want)
+		}
+	}
+	if _, ok := entry["duration"]; !ok {
+		t.Error("access log does not contain the duration")
+	}
+}
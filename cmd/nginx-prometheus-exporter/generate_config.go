@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"text/template"
+)
+
+// statusSnippetConfig holds the settings of the NGINX config snippet printed by the
+// generate-config subcommand.
+type statusSnippetConfig struct {
+	listen string
+	// allow holds addresses or CIDRs allowed to access the status location besides
+	// the loopback addresses.
+	allow []string
+	plus  bool
+}
+
+var statusSnippetTemplate = template.Must(template.New("snippet").Parse(`# NGINX status endpoint for nginx-prometheus-exporter. Include this file in the http block.
+# Scrape it with: nginx-prometheus-exporter{{if .Plus}} --nginx.plus{{end}} --nginx.scrape-uri={{.ScrapeURI}}
+server {
+    listen {{.Listen}};
+    server_name localhost;
+    access_log off;
+{{- if .Plus}}
+
+    location /api {
+        api write=off;
+{{- template "allow" .}}
+    }
+
+    location = /dashboard.html {
+        root /usr/share/nginx/html;
+{{- template "allow" .}}
+    }
+{{- else}}
+
+    location = /stub_status {
+        stub_status;
+{{- template "allow" .}}
+    }
+{{- end}}
+
+    location / {
+        return 404;
+    }
+}
+{{define "allow"}}
+{{- range .Allow}}
+        allow {{.}};
+{{- end}}
+        deny all;
+{{- end -}}
+`))
+
+// generateConfig : generate-config 서브커맨드. loopback 주소(와 allow)에서만 접근할 수 있는 stub_status 또는 NGINX Plus
+// API 의 server 블록을 출력한다. 함께 출력하는 --nginx.scrape-uri 는 config 에서 discovery 한 결과와 같다.
+func generateConfig(w io.Writer, config statusSnippetConfig) error {
+	if config.listen == "" || strings.ContainsAny(config.listen, " \t\n;{}") {
+		return fmt.Errorf("invalid listen address %q", config.listen)
+	}
+	allow := []string{"127.0.0.1", "::1"}
+	if strings.HasPrefix(config.listen, "unix:") {
+		// unix domain socket 으로 받은 요청의 주소는 unix: 이다.
+		allow = append(allow, "unix:")
+	}
+	for _, a := range config.allow {
+		if _, _, err := net.ParseCIDR(a); err != nil && net.ParseIP(a) == nil {
+			return fmt.Errorf("invalid allowed address %q", a)
+		}
+		allow = append(allow, a)
+	}
+
+	path := "/stub_status"
+	if config.plus {
+		path = "/api"
+	}
+	scrapeURI, err := listenURI(config.listen, false, path)
+	if err != nil {
+		return err
+	}
+
+	if err := statusSnippetTemplate.Execute(w, struct {
+		Listen    string
+		ScrapeURI string
+		Allow     []string
+		Plus      bool
+	}{Listen: config.listen, ScrapeURI: scrapeURI, Allow: allow, Plus: config.plus}); err != nil {
+		return fmt.Errorf("failed to write config snippet: %w", err)
+	}
+	return nil
+}
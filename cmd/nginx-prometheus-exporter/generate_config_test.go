@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+)
+
+func TestGenerateConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		wantScrapeURI string
+		wantContains  []string
+		config        statusSnippetConfig
+	}{
+		{
+			name:          "nginx",
+			config:        statusSnippetConfig{listen: "127.0.0.1:8080", allow: []string{"10.0.0.0/8", "fd00::1"}},
+			wantScrapeURI: "http://127.0.0.1:8080/stub_status",
+			wantContains:  []string{"allow 127.0.0.1;", "allow 10.0.0.0/8;", "allow fd00::1;", "deny all;"},
+		},
+		{
+			name:          "nginx plus",
+			config:        statusSnippetConfig{listen: "8080", plus: true},
+			wantScrapeURI: "http://127.0.0.1:8080/api",
+			wantContains:  []string{"api write=off;", "location = /dashboard.html"},
+		},
+		{
+			name:          "unix socket",
+			config:        statusSnippetConfig{listen: "unix:/run/nginx-status.sock"},
+			wantScrapeURI: "unix:/run/nginx-status.sock:/stub_status",
+			wantContains:  []string{"allow unix:;"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			if err := generateConfig(&out, tt.config); err != nil {
+				t.Fatalf("generateConfig() returned error: %v", err)
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("generateConfig() output does not contain %q:\n%v", want, out.String())
+				}
+			}
+			if !strings.Contains(out.String(), "--nginx.scrape-uri="+tt.wantScrapeURI+"\n") {
+				t.Errorf("generateConfig() output does not mention scrape URI %v:\n%v", tt.wantScrapeURI, out.String())
+			}
+
+			// 출력한 snippet 에서 discovery 한 URI 가 안내한 URI 와 같아야 한다.
+			path := filepath.Join(t.TempDir(), "nginx.conf")
+			if err := os.WriteFile(path, []byte("http {\n"+out.String()+"}\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := nginxconf.Parse(path)
+			if err != nil {
+				t.Fatalf("generated snippet does not parse: %v", err)
+			}
+			got, err := discoverScrapeURI(cfg, tt.config.plus)
+			if err != nil || got != tt.wantScrapeURI {
+				t.Errorf("discoverScrapeURI() of the snippet = %q, %v, want %q", got, err, tt.wantScrapeURI)
+			}
+		})
+	}
+}
+
+func TestGenerateConfigInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		config statusSnippetConfig
+	}{
+		{name: "empty listen", config: statusSnippetConfig{}},
+		{name: "listen with directive", config: statusSnippetConfig{listen: "8080; return 200"}},
+		{name: "listen with too many colons", config: statusSnippetConfig{listen: "a:b:c"}},
+		{name: "invalid allow", config: statusSnippetConfig{listen: "8080", allow: []string{"all"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := generateConfig(&bytes.Buffer{}, tt.config); err == nil {
+				t.Error("generateConfig() returned no error")
+			}
+		})
+	}
+}
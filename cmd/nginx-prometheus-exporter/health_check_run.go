@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/nginx/nginx-prometheus-exporter/collector"
+)
+
+// healthCheckRunPath is the path of the endpoint that runs the upstream health checks
+// now.
+const healthCheckRunPath = "/api/healthcheck/run"
+
+// healthCheckRunner is a collector whose background health checks can be run on
+// demand.
+type healthCheckRunner interface {
+	RunHealthChecks() ([]collector.UpstreamHealth, error)
+}
+
+type healthCheckRunResult struct {
+	CheckedAt time.Time `json:"checked_at"`
+	File      string    `json:"file"`
+	Target    string    `json:"target"`
+	Healthy   bool      `json:"healthy"`
+}
+
+type healthCheckRunResponse struct {
+	Results []healthCheckRunResult `json:"results"`
+}
+
+// healthCheckRunHandler : Bearer token 이 일치하는 POST 요청을 받으면, 다음 주기를 기다리지 않고 모든 collector 의 background
+// health check 를 즉시 실행하여 그 결과를 JSON 으로 응답한다. 결과는 다음 scrape 부터 metric 에 반영된다.
+func healthCheckRunHandler(token string, runners []healthCheckRunner) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorizeAdminRequest(w, r, token) {
+			return
+		}
+
+		response := healthCheckRunResponse{Results: []healthCheckRunResult{}}
+		for _, runner := range runners {
+			health, err := runner.RunHealthChecks()
+			if errors.Is(err, collector.ErrNoBackgroundHealthChecks) {
+				http.Error(w, "Background health checks are disabled, set --nginx.health-check-interval", http.StatusConflict)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			for _, h := range health {
+				response.Results = append(response.Results, healthCheckRunResult{CheckedAt: h.CheckedAt, File: h.File, Target: h.Target, Healthy: h.Healthy})
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	})
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nginx/nginx-prometheus-exporter/collector"
+)
+
+func TestHealthCheckRunHandler(t *testing.T) {
+	t.Parallel()
+
+	checkedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	healthy := fakeStatusSource{health: []collector.UpstreamHealth{
+		{File: "nginx.conf", Target: "10.0.0.1:8080", Healthy: true, CheckedAt: checkedAt},
+		{File: "nginx.conf", Target: "10.0.0.2:8080", CheckedAt: checkedAt},
+	}}
+	disabled := fakeStatusSource{runErr: collector.ErrNoBackgroundHealthChecks}
+
+	tests := []struct {
+		name        string
+		method      string
+		auth        string
+		runners     []healthCheckRunner
+		wantStatus  int
+		wantResults int
+	}{
+		{name: "results", method: http.MethodPost, auth: "Bearer secret", runners: []healthCheckRunner{healthy, healthy}, wantStatus: http.StatusOK, wantResults: 4},
+		{name: "no collectors", method: http.MethodPost, auth: "Bearer secret", wantStatus: http.StatusOK},
+		{name: "background checks disabled", method: http.MethodPost, auth: "Bearer secret", runners: []healthCheckRunner{disabled}, wantStatus: http.StatusConflict},
+		{name: "wrong token", method: http.MethodPost, auth: "Bearer wrong", runners: []healthCheckRunner{healthy}, wantStatus: http.StatusUnauthorized},
+		{name: "GET", method: http.MethodGet, auth: "Bearer secret", runners: []healthCheckRunner{healthy}, wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(tt.method, healthCheckRunPath, nil)
+			req.Header.Set("Authorization", tt.auth)
+			rec := httptest.NewRecorder()
+			healthCheckRunHandler("secret", tt.runners).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var response healthCheckRunResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+			}
+			if len(response.Results) != tt.wantResults {
+				t.Fatalf("got %d results, want %d", len(response.Results), tt.wantResults)
+			}
+			if tt.wantResults > 0 && (response.Results[1].Target != "10.0.0.2:8080" || response.Results[1].Healthy || !response.Results[1].CheckedAt.Equal(checkedAt)) {
+				t.Errorf("result = %+v, want the failed check of 10.0.0.2:8080", response.Results[1])
+			}
+		})
+	}
+}
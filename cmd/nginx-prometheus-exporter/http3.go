@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+const (
+	// http3HandshakeTimeout bounds the QUIC handshake, so that a listener without QUIC
+	// leaves time for the fallback within the scrape timeout.
+	http3HandshakeTimeout = 2 * time.Second
+	// http3RetryInterval is how long requests use the fallback after HTTP/3 failed.
+	http3RetryInterval = 5 * time.Minute
+)
+
+// http3RoundTripper 요청을 HTTP/3 로 보내고, QUIC 연결에 실패하면 fallback transport 의 HTTP/1.1 또는 HTTP/2 로 다시 보낸다.
+// 실패한 뒤 http3RetryInterval 동안은 매 요청마다 handshake 를 기다리지 않도록 바로 fallback 을 사용한다.
+type http3RoundTripper struct {
+	retryAt  time.Time
+	h3       http.RoundTripper
+	fallback http.RoundTripper
+	mutex    sync.Mutex
+}
+
+func newHTTP3RoundTripper(transport *http.Transport) *http3RoundTripper {
+	return &http3RoundTripper{
+		h3: &http3.Transport{
+			TLSClientConfig: transport.TLSClientConfig.Clone(),
+			QUICConfig:      &quic.Config{HandshakeIdleTimeout: http3HandshakeTimeout},
+		},
+		fallback: transport,
+	}
+}
+
+func (rt *http3RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mutex.Lock()
+	useHTTP3 := !time.Now().Before(rt.retryAt)
+	rt.mutex.Unlock()
+
+	// body 를 다시 만들 수 없는 요청은 fallback 할 수 없으므로 HTTP/3 를 시도하지 않는다.
+	if useHTTP3 && (req.Body == nil || req.GetBody != nil) {
+		resp, err := rt.h3.RoundTrip(req)
+		if err == nil {
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			return nil, fmt.Errorf("HTTP/3 round trip failed: %w", err)
+		}
+		rt.mutex.Lock()
+		rt.retryAt = time.Now().Add(http3RetryInterval)
+		rt.mutex.Unlock()
+
+		if req.GetBody != nil {
+			req = cloneRequest(req)
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, fmt.Errorf("failed to reset request body: %w", err)
+			}
+		}
+	}
+
+	resp, err := rt.fallback.RoundTrip(req)
+	if err != nil {
+		return nil, fmt.Errorf("round trip failed: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+func TestHTTP3RoundTripper(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Proto", r.Proto)
+	})
+	tcpServer := httptest.NewTLSServer(handler)
+	t.Cleanup(tcpServer.Close)
+
+	// 같은 인증서로 다른 UDP 포트에서 HTTP/3 를 제공한다.
+	udpConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quicServer := &http3.Server{Handler: handler, TLSConfig: http3.ConfigureTLSConfig(&tls.Config{
+		Certificates: tcpServer.TLS.Certificates,
+		MinVersion:   tls.VersionTLS13,
+	})}
+	go func() {
+		_ = quicServer.Serve(udpConn)
+	}()
+	t.Cleanup(func() {
+		_ = quicServer.Close()
+	})
+
+	transport := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS12}} // #nosec G402
+
+	tests := []struct {
+		name      string
+		url       string
+		wantProto string
+		wantRetry bool
+	}{
+		{
+			name:      "QUIC listener",
+			url:       "https://" + udpConn.LocalAddr().String(),
+			wantProto: "HTTP/3.0",
+		},
+		{
+			name:      "fallback without QUIC listener",
+			url:       tcpServer.URL,
+			wantProto: "HTTP/1.1",
+			wantRetry: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rt := newHTTP3RoundTripper(transport)
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := (&http.Client{Transport: rt, Timeout: 10 * time.Second}).Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			_ = resp.Body.Close()
+
+			if got := resp.Header.Get("X-Proto"); got != tt.wantProto {
+				t.Errorf("request was served over %v, want %v", got, tt.wantProto)
+			}
+			if got := !rt.retryAt.IsZero(); got != tt.wantRetry {
+				t.Errorf("HTTP/3 disabled for retry interval = %v, want %v", got, tt.wantRetry)
+			}
+		})
+	}
+}
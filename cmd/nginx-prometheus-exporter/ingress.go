@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+)
+
+// Ingress controllers detected by the ingress companion mode.
+const (
+	ingressNginx           = "ingress-nginx"
+	nginxIngressController = "nginx-ingress"
+)
+
+// defaultIngressClass is the default ingress class of both ingress controllers.
+const defaultIngressClass = "nginx"
+
+// ingressNginxStatusPorts are the ports of the status server of ingress-nginx, 10246
+// since 0.26 and 18080 before.
+var ingressNginxStatusPorts = []string{"10246", "18080"}
+
+// nginxIngressControllerSockets are the unix domain sockets of the stub_status and
+// NGINX Plus API servers of NGINX Ingress Controller.
+var nginxIngressControllerSockets = []string{"unix:/var/lib/nginx/nginx-status.sock", "unix:/var/lib/nginx/nginx-plus-api.sock"}
+
+var errNoIngressController = errors.New("no status server of ingress-nginx or NGINX Ingress Controller found")
+
+// ingressController is an ingress controller detected from the nginx.conf it generated.
+type ingressController struct {
+	name      string
+	scrapeURI string
+}
+
+// detectLocalIngressController : configPath 의 설정을 읽어 ingress controller 를 찾는다.
+func detectLocalIngressController(configPath string, plus bool) (ingressController, error) {
+	cfg, err := nginxconf.Parse(configPath)
+	if err != nil {
+		return ingressController{}, fmt.Errorf("failed to parse NGINX config: %w", err)
+	}
+	return detectIngressController(cfg, plus)
+}
+
+// detectIngressController : ingress controller 가 생성한 nginx.conf 에서, controller 가 알려진 주소로 만드는 stub_status(plus
+// 이면 api) server 를 찾는다. ingress-nginx 는 127.0.0.1:10246 의 /nginx_status 를, NGINX Ingress Controller 는
+// /var/lib/nginx 의 unix domain socket 을 사용한다.
+func detectIngressController(cfg *nginxconf.Config, plus bool) (ingressController, error) {
+	name := "stub_status"
+	if plus {
+		name = "api"
+	}
+
+	var found ingressController
+	cfg.Walk(func(d *nginxconf.Directive, parents []*nginxconf.Directive) {
+		if found.name != "" || d.Name != name || len(parents) < 2 || parents[len(parents)-1].Name != "location" {
+			return
+		}
+		path, ok := locationPath(parents[len(parents)-1])
+		server := parents[len(parents)-2]
+		if !ok || server.Name != "server" {
+			return
+		}
+		for _, listen := range server.Block {
+			if listen.Name != "listen" || len(listen.Args) == 0 {
+				continue
+			}
+			controller := ingressControllerOf(listen.Args[0])
+			if controller == "" {
+				continue
+			}
+			uri, err := listenURI(listen.Args[0], false, path)
+			if err != nil {
+				continue
+			}
+			found = ingressController{name: controller, scrapeURI: uri}
+			return
+		}
+	})
+	if found.name == "" {
+		return ingressController{}, errNoIngressController
+	}
+	return found, nil
+}
+
+// ingressControllerOf returns the ingress controller listening on the status address,
+// or "" when the address is not one of theirs.
+func ingressControllerOf(addr string) string {
+	if slices.Contains(nginxIngressControllerSockets, addr) {
+		return nginxIngressController
+	}
+	port := addr
+	if _, p, err := net.SplitHostPort(addr); err == nil {
+		port = p
+	}
+	if slices.Contains(ingressNginxStatusPorts, port) {
+		return ingressNginx
+	}
+	return ""
+}
+
+// findIngressClass : 같은 PID namespace 에서 실행 중인 controller 프로세스의 --ingress-class(NGINX Ingress Controller 는
+// -ingress-class) 인자를 찾는다. 찾지 못하면 두 controller 의 기본값인 nginx 를 반환한다.
+func findIngressClass(procRoot string) string {
+	cmdlines, _ := filepath.Glob(filepath.Join(procRoot, "*", "cmdline"))
+	for _, path := range cmdlines {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		args := strings.Split(string(bytes.TrimRight(content, "\x00")), "\x00")
+		for i, arg := range args {
+			flag, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			if flag != "ingress-class" || !strings.HasPrefix(arg, "-") {
+				continue
+			}
+			if !hasValue && i+1 < len(args) {
+				value = args[i+1]
+			}
+			if value != "" {
+				return value
+			}
+		}
+	}
+	return defaultIngressClass
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+)
+
+func TestDetectIngressController(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  string
+		want    ingressController
+		plus    bool
+		wantErr bool
+	}{
+		{
+			name: "ingress-nginx",
+			config: `http {
+    init_by_lua_block { local cfg = {} }
+    server { listen 80 default_server; location / { content_by_lua_block { ngx.exit(404) } } }
+    server {
+        listen 127.0.0.1:10246;
+        location /healthz { return 200; }
+        location /nginx_status { stub_status on; }
+    }
+}`,
+			want: ingressController{name: ingressNginx, scrapeURI: "http://127.0.0.1:10246/nginx_status"},
+		},
+		{
+			name:   "NGINX Ingress Controller",
+			config: `http { server { listen 8080; location /stub_status { stub_status; } } server { listen unix:/var/lib/nginx/nginx-status.sock; location /stub_status { stub_status; } } }`,
+			want:   ingressController{name: nginxIngressController, scrapeURI: "unix:/var/lib/nginx/nginx-status.sock:/stub_status"},
+		},
+		{
+			name:   "NGINX Ingress Controller with NGINX Plus",
+			config: `http { server { listen unix:/var/lib/nginx/nginx-plus-api.sock; location /api { api write=on; } } }`,
+			plus:   true,
+			want:   ingressController{name: nginxIngressController, scrapeURI: "unix:/var/lib/nginx/nginx-plus-api.sock:/api"},
+		},
+		{
+			name:    "plain NGINX",
+			config:  `http { server { listen 8080; location /stub_status { stub_status; } } }`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "nginx.conf")
+			if err := os.WriteFile(path, []byte(tt.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := nginxconf.Parse(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := detectIngressController(cfg, tt.plus)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("detectIngressController() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("detectIngressController() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindIngressClass(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		cmdlines map[string]string
+		want     string
+	}{
+		{
+			name: "ingress-nginx",
+			cmdlines: map[string]string{
+				"1":  "/usr/bin/dumb-init\x00--\x00/nginx-ingress-controller\x00--ingress-class=internal\x00",
+				"20": "nginx: master process /usr/bin/nginx -c /etc/nginx/nginx.conf\x00",
+			},
+			want: "internal",
+		},
+		{
+			name:     "NGINX Ingress Controller",
+			cmdlines: map[string]string{"1": "/nginx-ingress\x00-nginx-plus=false\x00-ingress-class\x00public\x00"},
+			want:     "public",
+		},
+		{
+			name:     "default",
+			cmdlines: map[string]string{"1": "/nginx-ingress-controller\x00--election-id=ingress-nginx-leader\x00"},
+			want:     defaultIngressClass,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			procRoot := t.TempDir()
+			for pid, cmdline := range tt.cmdlines {
+				if err := os.Mkdir(filepath.Join(procRoot, pid), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(procRoot, pid, "cmdline"), []byte(cmdline), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := findIngressClass(procRoot); got != tt.want {
+				t.Errorf("findIngressClass() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
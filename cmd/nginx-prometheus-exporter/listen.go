@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/exporter-toolkit/web"
+)
+
+// unixListenPrefix marks listen addresses that are unix domain socket paths.
+const unixListenPrefix = "unix:"
+
+// listenServers returns the servers listenAndServe serves the listen addresses with:
+// srv itself when web.ListenAndServe serves all of them, or a clone of srv for every
+// address otherwise. All of them must be shut down to stop serving.
+func listenServers(srv *http.Server, flags *web.FlagConfig, listenConfigs map[string]string) []*http.Server {
+	if !separateListeners(flags, listenConfigs) {
+		return []*http.Server{srv}
+	}
+	// exporter-toolkit 은 serve 할 때 server 의 Handler 와 TLSConfig 를 web config 에 맞게 바꾸므로, listener 마다 srv 를
+	// 복제한 server 로 serve 한다. 복제는 serve 를 시작하기 전에 모두 마친다.
+	servers := make([]*http.Server, len(*flags.WebListenAddresses))
+	for i := range servers {
+		servers[i] = cloneServer(srv)
+	}
+	return servers
+}
+
+// separateListeners : unix domain socket 이나 listen 주소별 web config 가 있으면, web.ListenAndServe 대신 listen 주소마다
+// 따로 serve 한다.
+func separateListeners(flags *web.FlagConfig, listenConfigs map[string]string) bool {
+	if flags.WebSystemdSocket != nil && *flags.WebSystemdSocket {
+		return false
+	}
+	return hasUnixListenAddress(*flags.WebListenAddresses) || len(listenConfigs) > 0
+}
+
+// listenAndServe starts the servers returned by listenServers like web.ListenAndServe,
+// but additionally accepts listen addresses of the form unix:/path/to/socket, so that
+// the exporter can be reached only through a local socket proxied by NGINX or a node
+// agent. listenConfigs maps listen addresses to the web config file used for them
+// instead of flags.WebConfigFile, so that every address can have its own TLS settings.
+func listenAndServe(servers []*http.Server, flags *web.FlagConfig, listenConfigs map[string]string, logger *slog.Logger) error {
+	if !separateListeners(flags, listenConfigs) {
+		if err := web.ListenAndServe(servers[0], flags, logger); err != nil {
+			return fmt.Errorf("failed to serve: %w", err)
+		}
+		return nil
+	}
+
+	listeners := make([]net.Listener, 0, len(*flags.WebListenAddresses))
+	for _, address := range *flags.WebListenAddresses {
+		listener, err := listen(address)
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return err
+		}
+		listeners = append(listeners, listener)
+	}
+
+	errs := make(chan error, len(listeners))
+	for i, address := range *flags.WebListenAddresses {
+		config := listenConfig(flags, listenConfigs, address)
+		listenerFlags := *flags
+		listenerFlags.WebConfigFile = &config
+		go func() {
+			errs <- web.Serve(listeners[i], servers[i], &listenerFlags, logger)
+		}()
+	}
+	// 한 listener 라도 실패하거나 종료되면 반환한다. server 를 Shutdown 한 경우 http.ErrServerClosed 이다.
+	if err := <-errs; err != nil {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+	return nil
+}
+
+// listenConfig returns the web config file of a listen address.
+func listenConfig(flags *web.FlagConfig, listenConfigs map[string]string, address string) string {
+	if config, ok := listenConfigs[address]; ok {
+		return config
+	}
+	return *flags.WebConfigFile
+}
+
+// cloneServer : srv 와 같은 설정의 server 를 만든다.
+func cloneServer(srv *http.Server) *http.Server {
+	return &http.Server{
+		Handler:           srv.Handler,
+		ReadTimeout:       srv.ReadTimeout,
+		ReadHeaderTimeout: srv.ReadHeaderTimeout,
+		WriteTimeout:      srv.WriteTimeout,
+		IdleTimeout:       srv.IdleTimeout,
+		MaxHeaderBytes:    srv.MaxHeaderBytes,
+		ErrorLog:          srv.ErrorLog,
+	}
+}
+
+// parseListenConfigs : address=path 형식의 --web.listen-config 값을 파싱한다. address 는 --web.listen-address 중 하나여야
+// 하며, path 가 비어 있으면 그 address 는 TLS 와 인증 없이 serve 한다.
+func parseListenConfigs(values, addresses []string) (map[string]string, error) {
+	configs := make(map[string]string, len(values))
+	for _, value := range values {
+		address, path, ok := strings.Cut(value, "=")
+		if !ok || address == "" {
+			return nil, fmt.Errorf("invalid listen config %q, expected address=path", value)
+		}
+		if !slices.Contains(addresses, address) {
+			return nil, fmt.Errorf("listen config for %v, which is not a listen address", address)
+		}
+		if _, ok := configs[address]; ok {
+			return nil, fmt.Errorf("duplicate listen config for %v", address)
+		}
+		if err := web.Validate(path); err != nil {
+			return nil, fmt.Errorf("invalid web config file %v of %v: %w", path, address, err)
+		}
+		configs[address] = path
+	}
+	return configs, nil
+}
+
+func hasUnixListenAddress(addresses []string) bool {
+	for _, address := range addresses {
+		if strings.HasPrefix(address, unixListenPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// listen : unix: 로 시작하는 주소는 unix domain socket 으로, 나머지는 TCP 로 listen 한다.
+// 이전 실행에서 남은 socket 파일은 삭제한 뒤 listen 한다.
+func listen(address string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(address, unixListenPrefix)
+	if !ok {
+		if strings.HasPrefix(address, "vsock://") {
+			return nil, errors.New("vsock listen addresses cannot be combined with unix domain sockets or web.listen-config")
+		}
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on %v: %w", address, err)
+		}
+		return listener, nil
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("%v exists and is not a unix domain socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %v: %w", path, err)
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %v: %w", path, err)
+	}
+	return listener, nil
+}
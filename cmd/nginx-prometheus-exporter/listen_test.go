@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/exporter-toolkit/web"
+)
+
+func TestListen(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	socket := filepath.Join(dir, "exporter.sock")
+
+	// A socket left behind by a previous run is replaced.
+	stale, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unixListener, ok := stale.(*net.UnixListener); ok {
+		unixListener.SetUnlinkOnClose(false)
+	}
+	_ = stale.Close()
+
+	listener, err := listen("unix:" + socket)
+	if err != nil {
+		t.Fatalf("listen() returned error: %v", err)
+	}
+	defer listener.Close()
+	if listener.Addr().Network() != "unix" {
+		t.Errorf("listen() network = %v, want unix", listener.Addr().Network())
+	}
+
+	regular := filepath.Join(dir, "regular")
+	if err := os.WriteFile(regular, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listen("unix:" + regular); err == nil {
+		t.Error("listen() returned no error for a path that is not a socket")
+	}
+
+	tcp, err := listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen() returned error: %v", err)
+	}
+	defer tcp.Close()
+	if tcp.Addr().Network() != "tcp" {
+		t.Errorf("listen() network = %v, want tcp", tcp.Addr().Network())
+	}
+}
+
+func TestParseListenConfigs(t *testing.T) {
+	t.Parallel()
+
+	addresses := []string{":9113", "unix:/run/exporter.sock"}
+	configs, err := parseListenConfigs([]string{"unix:/run/exporter.sock="}, addresses)
+	if err != nil {
+		t.Fatalf("parseListenConfigs() returned error: %v", err)
+	}
+	if path, ok := configs["unix:/run/exporter.sock"]; !ok || path != "" {
+		t.Errorf("parseListenConfigs() = %v, want an empty config for the socket", configs)
+	}
+
+	for _, values := range [][]string{
+		{"unix:/run/exporter.sock"},
+		{":9114=web.yml"},
+		{":9113=", ":9113="},
+		{":9113=" + filepath.Join(t.TempDir(), "missing.yml")},
+	} {
+		if _, err := parseListenConfigs(values, addresses); err == nil {
+			t.Errorf("parseListenConfigs(%q) returned no error", values)
+		}
+	}
+}
+
+func TestListenAndServeListenConfigs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	// The password of admin is "secret".
+	webConfigFile := filepath.Join(dir, "web.yml")
+	if err := os.WriteFile(webConfigFile, []byte("basic_auth_users:\n  admin: $2a$04$vLFIaJRqemMmT/91.jop5utXFSw0vmw0ifO1dkm/q5vZOALjapKMy\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	public, private := filepath.Join(dir, "public.sock"), filepath.Join(dir, "private.sock")
+	addresses := []string{"unix:" + public, "unix:" + private}
+	configs, err := parseListenConfigs([]string{"unix:" + private + "="}, addresses)
+	if err != nil {
+		t.Fatalf("parseListenConfigs() returned error: %v", err)
+	}
+
+	srv := &http.Server{
+		Handler:           http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte("ok")) }),
+		ReadHeaderTimeout: time.Second,
+	}
+	flags := &web.FlagConfig{WebListenAddresses: &addresses, WebConfigFile: &webConfigFile}
+	servers := listenServers(srv, flags, configs)
+	if len(servers) != len(addresses) {
+		t.Fatalf("listenServers() returned %d servers, want one per listen address", len(servers))
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- listenAndServe(servers, flags, configs, slog.New(slog.DiscardHandler))
+	}()
+
+	get := func(socket string) int {
+		t.Helper()
+		client := &http.Client{Transport: &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}}}
+		for range 100 {
+			resp, err := client.Get("http://exporter/metrics")
+			if err == nil {
+				_ = resp.Body.Close()
+				return resp.StatusCode
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("failed to connect to %v", socket)
+		return 0
+	}
+	// The default web config requires basic authentication, the private socket is served without it.
+	if code := get(public); code != http.StatusUnauthorized {
+		t.Errorf("public socket responded with %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := get(private); code != http.StatusOK {
+		t.Errorf("private socket responded with %d, want %d", code, http.StatusOK)
+	}
+
+	for _, server := range servers {
+		if err := server.Shutdown(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("listenAndServe() returned %v, want %v", err, http.ErrServerClosed)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// validateMetricsPaths : 추가 metric 경로가 절대 경로이고, 서로 또는 exporter 의 다른 경로와 겹치지 않는지 확인한다.
+func validateMetricsPaths(paths []metricsPathConfig, reserved ...string) error {
+	seen := make(map[string]bool, len(paths)+len(reserved))
+	for _, path := range reserved {
+		seen[path] = true
+	}
+	for i, p := range paths {
+		if !strings.HasPrefix(p.Path, "/") {
+			return fmt.Errorf("metrics_paths[%d]: path %q must start with /", i, p.Path)
+		}
+		if seen[p.Path] {
+			return fmt.Errorf("metrics_paths[%d]: path %q is already in use", i, p.Path)
+		}
+		seen[p.Path] = true
+	}
+	return nil
+}
+
+// filterGatherer gathers the metrics of a gatherer whose names match include, if set,
+// and do not match exclude.
+type filterGatherer struct {
+	next    prometheus.Gatherer
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// newFilterGatherer : include, exclude 정규식을 metric 이름 전체와 비교하도록 컴파일한다.
+func newFilterGatherer(next prometheus.Gatherer, include []string, exclude []string) (*filterGatherer, error) {
+	g := &filterGatherer{next: next}
+	var err error
+	if g.include, err = compileNamePatterns(include); err != nil {
+		return nil, err
+	}
+	if g.exclude, err = compileNamePatterns(exclude); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func compileNamePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid metric name pattern %q: %w", pattern, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchesAny(res []*regexp.Regexp, name string) bool {
+	for _, re := range res {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Gather implements prometheus.Gatherer.
+func (g *filterGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.next.Gather()
+
+	result := make([]*dto.MetricFamily, 0, len(families))
+	for _, family := range families {
+		if len(g.include) > 0 && !matchesAny(g.include, family.GetName()) {
+			continue
+		}
+		if matchesAny(g.exclude, family.GetName()) {
+			continue
+		}
+		result = append(result, family)
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to gather metrics: %w", err)
+	}
+	return result, nil
+}
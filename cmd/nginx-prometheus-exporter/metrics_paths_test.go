@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestFilterGatherer(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	for _, name := range []string{"nginx_up", "nginx_connections_active", "nginx_connections_reading", "nginx_upstream_server_health"} {
+		registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: name, Help: name}))
+	}
+
+	if _, err := newFilterGatherer(prometheus.NewRegistry(), []string{"("}, nil); err == nil {
+		t.Error("newFilterGatherer() returned no error for an invalid pattern")
+	}
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{
+			name: "no filters",
+			want: []string{"nginx_connections_active", "nginx_connections_reading", "nginx_up", "nginx_upstream_server_health"},
+		},
+		{
+			name:    "include",
+			include: []string{"nginx_connections_.*", "nginx_up"},
+			want:    []string{"nginx_connections_active", "nginx_connections_reading", "nginx_up"},
+		},
+		{
+			name:    "include is anchored",
+			include: []string{"connections"},
+			want:    []string{},
+		},
+		{
+			name:    "include and exclude",
+			include: []string{"nginx_connections_.*"},
+			exclude: []string{".*_reading"},
+			want:    []string{"nginx_connections_active"},
+		},
+		{
+			name:    "exclude",
+			exclude: []string{"nginx_upstream_.*"},
+			want:    []string{"nginx_connections_active", "nginx_connections_reading", "nginx_up"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			g, err := newFilterGatherer(registry, test.include, test.exclude)
+			if err != nil {
+				t.Fatalf("newFilterGatherer() returned error: %v", err)
+			}
+			families, err := g.Gather()
+			if err != nil {
+				t.Fatalf("Gather() returned error: %v", err)
+			}
+			got := make([]string, 0, len(families))
+			for _, family := range families {
+				got = append(got, family.GetName())
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("Gather() = %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Errorf("Gather() = %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateMetricsPaths(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		paths   []metricsPathConfig
+		wantErr bool
+	}{
+		{
+			name:  "valid",
+			paths: []metricsPathConfig{{Path: "/metrics/stub"}, {Path: "/metrics/health"}},
+		},
+		{
+			name:    "relative path",
+			paths:   []metricsPathConfig{{Path: "metrics/stub"}},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate path",
+			paths:   []metricsPathConfig{{Path: "/metrics/stub"}, {Path: "/metrics/stub"}},
+			wantErr: true,
+		},
+		{
+			name:    "telemetry path",
+			paths:   []metricsPathConfig{{Path: "/metrics"}},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateMetricsPaths(test.paths, "/metrics", quitPath, "/")
+			if (err != nil) != test.wantErr {
+				t.Errorf("validateMetricsPaths() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestMetricsHandlerQueryFilter(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	for _, name := range []string{"nginx_up", "nginx_connections_active", "nginx_connections_reading", "nginx_config_last_modified_seconds"} {
+		registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: name, Help: name}))
+	}
+	handler := newMetricsHandler(registry, 0)
+
+	tests := []struct {
+		name     string
+		query    string
+		want     []string
+		wantCode int
+	}{
+		{
+			name:     "no filters",
+			want:     []string{"nginx_config_last_modified_seconds", "nginx_connections_active", "nginx_connections_reading", "nginx_up"},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "include and exclude",
+			query:    "?include=nginx_connections_.*&include=nginx_config_.*&exclude=nginx_config_.*",
+			want:     []string{"nginx_connections_active", "nginx_connections_reading"},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "exclude",
+			query:    "?exclude=nginx_connections_.*",
+			want:     []string{"nginx_config_last_modified_seconds", "nginx_up"},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "invalid pattern",
+			query:    "?include=" + url.QueryEscape("("),
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics"+tt.query, nil))
+			if rec.Code != tt.wantCode {
+				t.Fatalf("handler responded with %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var got []string
+			for _, line := range strings.Split(rec.Body.String(), "\n") {
+				if name, ok := strings.CutPrefix(line, "# TYPE "); ok {
+					got = append(got, strings.Fields(name)[0])
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("handler exposed %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type invalidMetricCollector struct{}
+
+func (invalidMetricCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (invalidMetricCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.NewInvalidMetric(prometheus.NewDesc("nginx_invalid", "Invalid", nil, nil), errors.New("invalid metric"))
+}
+
+func TestMetricsHandlerGatherError(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(invalidMetricCollector{})
+	registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "nginx_up", Help: "nginx_up"}))
+	handler := newMetricsHandler(registry, 0)
+
+	// Errors of the exporter's own collectors fail the scrape, also when the metrics are filtered.
+	for _, query := range []string{"", "?include=nginx_up"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics"+query, nil))
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("handler responded to %q with %d, want %d", query, rec.Code, http.StatusInternalServerError)
+		}
+	}
+}
+
+func TestLogErrorsGatherer(t *testing.T) {
+	t.Parallel()
+
+	up := &dto.MetricFamily{Name: proto.String("nginx_exporter_endpoint_up")}
+	g := &logErrorsGatherer{
+		next: prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+			return []*dto.MetricFamily{up}, errors.New("connection refused")
+		}),
+		logger: slog.New(slog.DiscardHandler),
+	}
+	families, err := g.Gather()
+	if err != nil {
+		t.Errorf("Gather() returned error: %v", err)
+	}
+	if len(families) != 1 || families[0] != up {
+		t.Errorf("Gather() = %v, want the gathered families", families)
+	}
+}
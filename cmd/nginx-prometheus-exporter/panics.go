@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// errCollectorPanic is reported by collectors wrapped with recoverCollector when they panic.
+var errCollectorPanic = errors.New("collector panicked")
+
+var collectorPanicDesc = prometheus.NewDesc(exporterName+"_collector_panic", "A collector panicked during the scrape.", nil, nil)
+
+func newPanicsCounter(namespace string, constLabels map[string]string) prometheus.Counter {
+	return prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   namespace,
+		Name:        "exporter_panics_total",
+		Help:        "Number of panics recovered in collectors and HTTP handlers",
+		ConstLabels: constLabels,
+	})
+}
+
+// recoveringCollector recovers panics of a collector, so that one malformed config
+// file or API response fails only the scrape instead of the whole exporter.
+type recoveringCollector struct {
+	prometheus.Collector
+	logger *slog.Logger
+	panics prometheus.Counter
+}
+
+func recoverCollector(c prometheus.Collector, logger *slog.Logger, panics prometheus.Counter) prometheus.Collector {
+	return &recoveringCollector{Collector: c, logger: logger, panics: panics}
+}
+
+// Collect : collector 의 panic 을 복구하여 stack 과 함께 로그를 남기고, scrape 가 실패하도록 invalid metric 을 전송한다.
+func (c *recoveringCollector) Collect(ch chan<- prometheus.Metric) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.logger.Error("collector panicked", "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+			c.panics.Inc()
+			ch <- prometheus.NewInvalidMetric(collectorPanicDesc, fmt.Errorf("%w: %v", errCollectorPanic, r))
+		}
+	}()
+	c.Collector.Collect(ch)
+}
+
+// recoverHandler : HTTP handler 의 panic 을 복구하여 stack 과 함께 로그를 남기고, 500 을 응답한다.
+func recoverHandler(next http.Handler, logger *slog.Logger, panics prometheus.Counter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// 연결을 끊기 위한 http.ErrAbortHandler 는 그대로 다시 panic 한다.
+			if err, ok := rec.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(rec)
+			}
+			logger.Error("HTTP handler panicked", "path", r.URL.Path, "panic", fmt.Sprint(rec), "stack", string(debug.Stack()))
+			panics.Inc()
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
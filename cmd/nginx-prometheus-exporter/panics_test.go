@@ -0,0 +1,82 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type panickingCollector struct{}
+
+func (panickingCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (panickingCollector) Collect(chan<- prometheus.Metric) {
+	panic("malformed response")
+}
+
+func TestRecoverCollector(t *testing.T) {
+	t.Parallel()
+
+	panics := newPanicsCounter("nginx", nil)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(recoverCollector(panickingCollector{}, slog.New(slog.DiscardHandler), panics))
+	up := prometheus.NewGauge(prometheus.GaugeOpts{Name: "nginx_up", Help: "Up"})
+	registry.MustRegister(up)
+
+	rec := httptest.NewRecorder()
+	newMetricsHandler(prometheus.Gatherers{registry}, 0).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %v, want %v", rec.Code, http.StatusInternalServerError)
+	}
+	if got := testutil.ToFloat64(panics); got != 1 {
+		t.Errorf("nginx_exporter_panics_total = %v, want 1", got)
+	}
+}
+
+func TestRecoverHandler(t *testing.T) {
+	t.Parallel()
+
+	panics := newPanicsCounter("nginx", nil)
+	handler := recoverHandler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}), slog.New(slog.DiscardHandler), panics)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %v, want %v", rec.Code, http.StatusInternalServerError)
+	}
+	if got := testutil.ToFloat64(panics); got != 1 {
+		t.Errorf("nginx_exporter_panics_total = %v, want 1", got)
+	}
+}
+
+type blockingCollector struct {
+	release chan struct{}
+}
+
+func (blockingCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (c blockingCollector) Collect(chan<- prometheus.Metric) {
+	<-c.release
+}
+
+func TestMetricsHandlerTimeout(t *testing.T) {
+	t.Parallel()
+
+	c := blockingCollector{release: make(chan struct{})}
+	defer close(c.release)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	rec := httptest.NewRecorder()
+	newMetricsHandler(registry, 10*time.Millisecond).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %v, want %v", rec.Code, http.StatusServiceUnavailable)
+	}
+}
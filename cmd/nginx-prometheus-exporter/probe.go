@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	plusclient "github.com/nginx/nginx-plus-go-client/v2/client"
+	"github.com/nginx/nginx-prometheus-exporter/client"
+)
+
+// probe : probe 서브커맨드. uri 를 한 번 수집하여 파싱된 stats 를 JSON 으로 출력한다.
+func probe(ctx context.Context, w io.Writer, transport *http.Transport, uri string, plus bool) error {
+	httpClient, requestAddr, err := newHTTPClient(transport, uri, plus)
+	if err != nil {
+		return err
+	}
+
+	var stats any
+	if plus {
+		plusClient, err := plusclient.NewNginxClient(requestAddr, plusclient.WithHTTPClient(httpClient), plusclient.WithAPIVersion(*plusAPIVersion))
+		if err != nil {
+			return fmt.Errorf("could not create Nginx Plus Client: %w", err)
+		}
+		if stats, err = plusClient.GetStats(ctx); err != nil {
+			return fmt.Errorf("failed to get NGINX Plus stats: %w", err)
+		}
+	} else {
+		if stats, err = client.NewNginxClient(httpClient, requestAddr).GetStubStats(); err != nil {
+			return fmt.Errorf("failed to get stub_status stats: %w", err)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(stats); err != nil {
+		return fmt.Errorf("failed to write stats: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// quitPath is the path of the endpoint that shuts the exporter down.
+const quitPath = "/-/quit"
+
+// quitHandler : Bearer token 이 일치하는 POST 요청을 받으면 quit 을 한 번 호출하여, SIGTERM 과 같은 graceful shutdown 을 시작한다.
+func quitHandler(token string, quit func()) http.Handler {
+	var once sync.Once
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorizeAdminRequest(w, r, token) {
+			return
+		}
+
+		_, _ = w.Write([]byte("Requesting termination... Goodbye!\n"))
+		once.Do(quit)
+	})
+}
+
+// authorizeAdminRequest : 관리용 endpoint 의 요청이 POST 이고 Bearer token 이 일치하는지 확인한다. 아니면 오류를 응답하고
+// false 를 반환한다.
+func authorizeAdminRequest(w http.ResponseWriter, r *http.Request, token string) bool {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Only POST requests allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
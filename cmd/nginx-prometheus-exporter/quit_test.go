@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQuitHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		method     string
+		auth       string
+		wantStatus int
+		wantQuit   bool
+	}{
+		{name: "valid token", method: http.MethodPost, auth: "Bearer secret", wantStatus: http.StatusOK, wantQuit: true},
+		{name: "wrong token", method: http.MethodPost, auth: "Bearer wrong", wantStatus: http.StatusUnauthorized},
+		{name: "no token", method: http.MethodPost, wantStatus: http.StatusUnauthorized},
+		{name: "GET", method: http.MethodGet, auth: "Bearer secret", wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			quit := false
+			handler := quitHandler("secret", func() { quit = true })
+			req := httptest.NewRequest(tt.method, quitPath, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if quit != tt.wantQuit {
+				t.Errorf("quit called = %v, want %v", quit, tt.wantQuit)
+			}
+		})
+	}
+}
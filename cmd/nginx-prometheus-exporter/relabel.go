@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// Relabel actions, a subset of those of Prometheus' metric_relabel_configs.
+const (
+	relabelReplace   = "replace"
+	relabelKeep      = "keep"
+	relabelDrop      = "drop"
+	relabelLabelDrop = "labeldrop"
+	relabelLabelKeep = "labelkeep"
+)
+
+const metricNameLabel = "__name__"
+
+// relabelConfig is a relabel rule of the exporter config file. The fields have the
+// same meaning as in Prometheus' metric_relabel_configs.
+type relabelConfig struct {
+	Separator    *string  `yaml:"separator"`
+	Replacement  *string  `yaml:"replacement"`
+	Regex        string   `yaml:"regex"`
+	Action       string   `yaml:"action"`
+	TargetLabel  string   `yaml:"target_label"`
+	SourceLabels []string `yaml:"source_labels"`
+}
+
+type relabelRule struct {
+	regex        *regexp.Regexp
+	action       string
+	separator    string
+	targetLabel  string
+	replacement  string
+	sourceLabels []string
+}
+
+// compile : relabel 설정의 기본값을 채우고 검증하여 relabelRule 을 만든다.
+func (c relabelConfig) compile() (relabelRule, error) {
+	rule := relabelRule{
+		action:       c.Action,
+		separator:    ";",
+		targetLabel:  c.TargetLabel,
+		replacement:  "$1",
+		sourceLabels: c.SourceLabels,
+	}
+	if rule.action == "" {
+		rule.action = relabelReplace
+	}
+	if c.Separator != nil {
+		rule.separator = *c.Separator
+	}
+	if c.Replacement != nil {
+		rule.replacement = *c.Replacement
+	}
+	expr := c.Regex
+	if expr == "" {
+		expr = "(.*)"
+	}
+	regex, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return relabelRule{}, fmt.Errorf("invalid relabel regex %q: %w", c.Regex, err)
+	}
+	rule.regex = regex
+
+	switch rule.action {
+	case relabelReplace:
+		if rule.targetLabel == metricNameLabel {
+			return relabelRule{}, errors.New("relabel rules cannot replace the metric name, use metric_renames instead")
+		}
+		if !labelNameRe.MatchString(rule.targetLabel) {
+			return relabelRule{}, fmt.Errorf("invalid relabel target_label %q", rule.targetLabel)
+		}
+		fallthrough
+	case relabelKeep, relabelDrop:
+		if len(rule.sourceLabels) == 0 {
+			return relabelRule{}, fmt.Errorf("relabel action %v requires source_labels", rule.action)
+		}
+	case relabelLabelDrop, relabelLabelKeep:
+	default:
+		return relabelRule{}, fmt.Errorf("unknown relabel action %q", rule.action)
+	}
+	return rule, nil
+}
+
+// apply : series 의 label(__name__ 포함)에 rule 을 적용한다. series 를 버려야 하면 false 를 반환한다.
+func (r relabelRule) apply(labels map[string]string) bool {
+	switch r.action {
+	case relabelLabelDrop, relabelLabelKeep:
+		for name := range labels {
+			if name == metricNameLabel {
+				continue
+			}
+			if r.regex.MatchString(name) == (r.action == relabelLabelDrop) {
+				delete(labels, name)
+			}
+		}
+		return true
+	}
+
+	values := make([]string, len(r.sourceLabels))
+	for i, name := range r.sourceLabels {
+		values[i] = labels[name]
+	}
+	value := strings.Join(values, r.separator)
+
+	switch r.action {
+	case relabelKeep:
+		return r.regex.MatchString(value)
+	case relabelDrop:
+		return !r.regex.MatchString(value)
+	}
+
+	match := r.regex.FindStringSubmatchIndex(value)
+	if match == nil {
+		return true
+	}
+	if target := string(r.regex.ExpandString(nil, r.replacement, value, match)); target != "" {
+		labels[r.targetLabel] = target
+	} else {
+		delete(labels, r.targetLabel)
+	}
+	return true
+}
+
+// relabelGatherer applies relabel rules to the metrics of a gatherer before they are
+// exposed.
+type relabelGatherer struct {
+	next  prometheus.Gatherer
+	rules []relabelRule
+}
+
+// Gather implements prometheus.Gatherer. Series that become identical after
+// relabeling are dropped, except for the first one, and reported in the error.
+func (g *relabelGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.next.Gather()
+
+	var errs []error
+	result := make([]*dto.MetricFamily, 0, len(families))
+	for _, family := range families {
+		relabeled := &dto.MetricFamily{Name: family.Name, Help: family.Help, Type: family.Type, Unit: family.Unit}
+		seen := make(map[string]bool, len(family.GetMetric()))
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string, len(metric.GetLabel())+1)
+			for _, pair := range metric.GetLabel() {
+				labels[pair.GetName()] = pair.GetValue()
+			}
+			labels[metricNameLabel] = family.GetName()
+
+			if !g.applyRules(labels) {
+				continue
+			}
+			delete(labels, metricNameLabel)
+
+			pairs := make([]*dto.LabelPair, 0, len(labels))
+			var key strings.Builder
+			for _, name := range slices.Sorted(maps.Keys(labels)) {
+				pairs = append(pairs, &dto.LabelPair{Name: proto.String(name), Value: proto.String(labels[name])})
+				key.WriteString(name + "\xff" + labels[name] + "\xff")
+			}
+			if seen[key.String()] {
+				errs = append(errs, fmt.Errorf("duplicate series of %v after relabeling: %v", family.GetName(), labels))
+				continue
+			}
+			seen[key.String()] = true
+
+			// 원본 metric 은 다른 gather 에서 재사용될 수 있으므로, label 만 바꾼 새 metric 을 만든다.
+			relabeled.Metric = append(relabeled.Metric, &dto.Metric{
+				Label:       pairs,
+				Gauge:       metric.Gauge,
+				Counter:     metric.Counter,
+				Summary:     metric.Summary,
+				Untyped:     metric.Untyped,
+				Histogram:   metric.Histogram,
+				TimestampMs: metric.TimestampMs,
+			})
+		}
+		if len(relabeled.Metric) > 0 {
+			result = append(result, relabeled)
+		}
+	}
+
+	if err = errors.Join(append([]error{err}, errs...)...); err != nil {
+		return result, fmt.Errorf("failed to relabel metrics: %w", err)
+	}
+	return result, nil
+}
+
+func (g *relabelGatherer) applyRules(labels map[string]string) bool {
+	for _, rule := range g.rules {
+		if !rule.apply(labels) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gopkg.in/yaml.v2"
+)
+
+func TestRelabelGatherer(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	config := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "nginx_config_upstreams", Help: "Upstreams"}, []string{"file", "kind"})
+	config.WithLabelValues("/etc/nginx/nginx.conf", "http").Set(1)
+	config.WithLabelValues("/etc/nginx/conf.d/a.conf", "http").Set(2)
+	config.WithLabelValues("/etc/nginx/conf.d/b.conf", "http").Set(3)
+	up := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "nginx_up", Help: "Up"}, []string{"file"})
+	up.WithLabelValues("x").Set(1)
+	registry.MustRegister(config, up)
+
+	var configs []relabelConfig
+	err := yaml.UnmarshalStrict([]byte(`
+- source_labels: [__name__, file]
+  regex: nginx_config_.*;/etc/nginx/conf\.d/b\.conf
+  action: drop
+- source_labels: [file]
+  regex: .*/(.*)
+  target_label: file
+- regex: kind
+  action: labeldrop
+`), &configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := make([]relabelRule, 0, len(configs))
+	for _, c := range configs {
+		rule, err := c.compile()
+		if err != nil {
+			t.Fatalf("compile() returned error: %v", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	expected := `
+# HELP nginx_config_upstreams Upstreams
+# TYPE nginx_config_upstreams gauge
+nginx_config_upstreams{file="a.conf"} 2
+nginx_config_upstreams{file="nginx.conf"} 1
+# HELP nginx_up Up
+# TYPE nginx_up gauge
+nginx_up{file="x"} 1
+`
+	g := &relabelGatherer{next: registry, rules: rules}
+	if err := testutil.GatherAndCompare(g, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRelabelGathererDuplicates(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	config := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "nginx_config_upstreams", Help: "Upstreams"}, []string{"file"})
+	config.WithLabelValues("a.conf").Set(1)
+	config.WithLabelValues("b.conf").Set(2)
+	registry.MustRegister(config)
+
+	rule, err := relabelConfig{Regex: "file", Action: relabelLabelDrop}.compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	families, err := (&relabelGatherer{next: registry, rules: []relabelRule{rule}}).Gather()
+	if err == nil {
+		t.Error("Gather() returned no error for series that became identical")
+	}
+	if len(families) != 1 || len(families[0].GetMetric()) != 1 {
+		t.Errorf("Gather() = %v, want a single series", families)
+	}
+}
+
+func TestRelabelConfigCompileInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		config relabelConfig
+	}{
+		{name: "invalid regex", config: relabelConfig{SourceLabels: []string{"file"}, Regex: "(", Action: relabelDrop}},
+		{name: "unknown action", config: relabelConfig{SourceLabels: []string{"file"}, Action: "hashmod"}},
+		{name: "no source labels", config: relabelConfig{Action: relabelKeep}},
+		{name: "no target label", config: relabelConfig{SourceLabels: []string{"file"}}},
+		{name: "metric name target", config: relabelConfig{SourceLabels: []string{"file"}, TargetLabel: "__name__"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := tt.config.compile(); err == nil {
+				t.Error("compile() returned no error")
+			}
+		})
+	}
+}
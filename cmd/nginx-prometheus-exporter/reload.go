@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// reloadPath is the path of the endpoint that reloads NGINX.
+const reloadPath = "/-/nginx/reload"
+
+// nginxTestTimeout bounds `nginx -t`, which loads all certificates of the config.
+const nginxTestTimeout = 30 * time.Second
+
+// errConfigInvalid is returned by nginxReloader.reload when `nginx -t` rejects the config.
+var errConfigInvalid = errors.New("NGINX config is invalid")
+
+// nginxReloader reloads NGINX by sending SIGHUP to its master process, after `nginx -t`
+// accepts the config file NGINX runs with.
+type nginxReloader struct {
+	attempts   prometheus.Counter
+	failures   prometheus.Counter
+	logger     *slog.Logger
+	signal     func(pid int) error
+	binaryPath string
+	configPath string
+	pidFile    string
+	mutex      sync.Mutex
+}
+
+func newNginxReloader(binaryPath, configPath, pidFile, namespace string, constLabels map[string]string, logger *slog.Logger) *nginxReloader {
+	return &nginxReloader{
+		attempts: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "exporter_reloads_total",
+			Help:        "Number of NGINX reloads requested over HTTP",
+			ConstLabels: constLabels,
+		}),
+		failures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "exporter_reload_failures_total",
+			Help:        "Number of NGINX reloads requested over HTTP that failed the config test or the signal",
+			ConstLabels: constLabels,
+		}),
+		logger:     logger,
+		signal:     sendHangup,
+		binaryPath: binaryPath,
+		configPath: configPath,
+		pidFile:    pidFile,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (r *nginxReloader) Describe(ch chan<- *prometheus.Desc) {
+	r.attempts.Describe(ch)
+	r.failures.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (r *nginxReloader) Collect(ch chan<- prometheus.Metric) {
+	r.attempts.Collect(ch)
+	r.failures.Collect(ch)
+}
+
+// reload : nginx -t 로 설정을 검증하고, 통과한 경우에만 PID 파일의 master 프로세스에 SIGHUP 을 보낸다. 동시에 요청된 reload 는
+// 차례로 실행한다.
+func (r *nginxReloader) reload(ctx context.Context) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.attempts.Inc()
+	if err := r.doReload(ctx); err != nil {
+		r.failures.Inc()
+		r.logger.Error("NGINX reload failed", "error", err.Error())
+		return err
+	}
+	r.logger.Info("NGINX reloaded")
+	return nil
+}
+
+func (r *nginxReloader) doReload(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, nginxTestTimeout)
+	defer cancel()
+
+	// nginx -t 는 결과를 stderr 로 출력한다. 컴파일된 기본 경로가 아닌, NGINX 가 사용하는 설정 파일을 검사한다.
+	out, err := exec.CommandContext(ctx, r.binaryPath, "-t", "-c", r.configPath).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %w\n%s", errConfigInvalid, err, strings.TrimSpace(string(out)))
+	}
+
+	content, err := os.ReadFile(r.pidFile)
+	if err != nil {
+		return fmt.Errorf("failed to read PID file: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil || pid <= 0 {
+		return fmt.Errorf("invalid PID file %v: %q", r.pidFile, strings.TrimSpace(string(content)))
+	}
+	if err := r.signal(pid); err != nil {
+		return fmt.Errorf("failed to signal NGINX master process %d: %w", pid, err)
+	}
+	return nil
+}
+
+func sendHangup(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("failed to find process: %w", err)
+	}
+	if err := process.Signal(syscall.SIGHUP); err != nil {
+		return fmt.Errorf("failed to send SIGHUP: %w", err)
+	}
+	return nil
+}
+
+// reloadHandler : Bearer token 이 일치하는 POST 요청을 받으면 NGINX 를 reload 한다. 설정 검증에 실패하면 nginx -t 의 출력과 함께
+// 422 를 응답한다.
+func reloadHandler(token string, reloader *nginxReloader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorizeAdminRequest(w, r, token) {
+			return
+		}
+
+		err := reloader.reload(r.Context())
+		switch {
+		case errors.Is(err, errConfigInvalid):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			_, _ = w.Write([]byte("NGINX reloaded\n"))
+		}
+	})
+}
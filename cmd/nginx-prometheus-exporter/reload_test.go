@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestReloadHandler(t *testing.T) {
+	t.Parallel()
+
+	validBinary, err := exec.LookPath("true")
+	if err != nil {
+		t.Skip("true is not available")
+	}
+	invalidBinary, err := exec.LookPath("false")
+	if err != nil {
+		t.Skip("false is not available")
+	}
+
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "nginx.pid")
+	if err := os.WriteFile(pidFile, []byte("1234\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	badPIDFile := filepath.Join(dir, "bad.pid")
+	if err := os.WriteFile(badPIDFile, []byte("nginx\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		signalErr    error
+		name         string
+		binaryPath   string
+		pidFile      string
+		auth         string
+		wantStatus   int
+		wantSignal   int
+		wantAttempts float64
+		wantFailures float64
+	}{
+		{name: "valid config", binaryPath: validBinary, pidFile: pidFile, auth: "Bearer secret", wantStatus: http.StatusOK, wantSignal: 1234, wantAttempts: 1},
+		{name: "invalid config", binaryPath: invalidBinary, pidFile: pidFile, auth: "Bearer secret", wantStatus: http.StatusUnprocessableEntity, wantAttempts: 1, wantFailures: 1},
+		{name: "invalid PID file", binaryPath: validBinary, pidFile: badPIDFile, auth: "Bearer secret", wantStatus: http.StatusInternalServerError, wantAttempts: 1, wantFailures: 1},
+		{name: "signal failure", binaryPath: validBinary, pidFile: pidFile, auth: "Bearer secret", signalErr: errors.New("no such process"), wantStatus: http.StatusInternalServerError, wantSignal: 1234, wantAttempts: 1, wantFailures: 1},
+		{name: "wrong token", binaryPath: validBinary, pidFile: pidFile, auth: "Bearer wrong", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reloader := newNginxReloader(tt.binaryPath, "/etc/nginx/nginx.conf", tt.pidFile, "nginx", nil, slog.New(slog.DiscardHandler))
+			signaled := 0
+			reloader.signal = func(pid int) error {
+				signaled = pid
+				return tt.signalErr
+			}
+			req := httptest.NewRequest(http.MethodPost, reloadPath, nil)
+			req.Header.Set("Authorization", tt.auth)
+			rec := httptest.NewRecorder()
+			reloadHandler("secret", reloader).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v: %v", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if signaled != tt.wantSignal {
+				t.Errorf("signaled PID = %v, want %v", signaled, tt.wantSignal)
+			}
+			if got := testutil.ToFloat64(reloader.attempts); got != tt.wantAttempts {
+				t.Errorf("attempts = %v, want %v", got, tt.wantAttempts)
+			}
+			if got := testutil.ToFloat64(reloader.failures); got != tt.wantFailures {
+				t.Errorf("failures = %v, want %v", got, tt.wantFailures)
+			}
+		})
+	}
+}
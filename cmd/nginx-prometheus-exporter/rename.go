@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+var metricNameRe = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// renameGatherer renames the metrics of a gatherer before they are exposed, e.g. to
+// keep the metric names of an older exporter.
+type renameGatherer struct {
+	next    prometheus.Gatherer
+	renames map[string]string
+}
+
+// validateRenames : 바꿀 이름이 올바른 metric 이름인지 검사한다.
+func validateRenames(renames map[string]string) error {
+	for from, to := range renames {
+		if !metricNameRe.MatchString(to) {
+			return fmt.Errorf("invalid metric name %q for %v", to, from)
+		}
+	}
+	return nil
+}
+
+// Gather implements prometheus.Gatherer. Families renamed to the name of another
+// family are merged with it when they have the same type.
+func (g *renameGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.next.Gather()
+
+	errs := []error{err}
+	byName := make(map[string]*dto.MetricFamily, len(families))
+	for _, family := range families {
+		name := family.GetName()
+		if to, ok := g.renames[name]; ok {
+			name = to
+		}
+
+		existing, ok := byName[name]
+		if !ok {
+			byName[name] = &dto.MetricFamily{Name: proto.String(name), Help: family.Help, Type: family.Type, Unit: family.Unit, Metric: family.Metric}
+			continue
+		}
+		if existing.GetType() != family.GetType() {
+			errs = append(errs, fmt.Errorf("cannot rename %v to %v of type %v", family.GetName(), name, existing.GetType()))
+			continue
+		}
+		existing.Metric = append(slices.Clip(existing.Metric), family.Metric...)
+	}
+
+	result := make([]*dto.MetricFamily, 0, len(byName))
+	for _, family := range byName {
+		result = append(result, family)
+	}
+	slices.SortFunc(result, func(a, b *dto.MetricFamily) int {
+		return strings.Compare(a.GetName(), b.GetName())
+	})
+
+	if err := errors.Join(errs...); err != nil {
+		return result, fmt.Errorf("failed to rename metrics: %w", err)
+	}
+	return result, nil
+}
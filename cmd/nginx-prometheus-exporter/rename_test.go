@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRenameGatherer(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	active := prometheus.NewGauge(prometheus.GaugeOpts{Name: "nginx_connections_active", Help: "Active client connections"})
+	active.Set(3)
+	legacy := prometheus.NewGauge(prometheus.GaugeOpts{Name: "nginx_legacy", Help: "Legacy", ConstLabels: prometheus.Labels{"source": "legacy"}})
+	legacy.Set(1)
+	accepted := prometheus.NewCounter(prometheus.CounterOpts{Name: "nginx_connections_accepted", Help: "Accepted client connections"})
+	registry.MustRegister(active, legacy, accepted)
+
+	g := &renameGatherer{next: registry, renames: map[string]string{
+		"nginx_connections_active":   "inhouse_nginx_active",
+		"nginx_legacy":               "inhouse_nginx_active",
+		"nginx_connections_accepted": "nginx_connections_active",
+	}}
+
+	expected := `
+# HELP inhouse_nginx_active Active client connections
+# TYPE inhouse_nginx_active gauge
+inhouse_nginx_active 3
+inhouse_nginx_active{source="legacy"} 1
+# HELP nginx_connections_active Accepted client connections
+# TYPE nginx_connections_active counter
+nginx_connections_active 0
+`
+	if err := testutil.GatherAndCompare(g, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRenameGathererTypeConflict(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		prometheus.NewGauge(prometheus.GaugeOpts{Name: "a", Help: "A"}),
+		prometheus.NewCounter(prometheus.CounterOpts{Name: "b", Help: "B"}),
+	)
+
+	g := &renameGatherer{next: registry, renames: map[string]string{"b": "a"}}
+	families, err := g.Gather()
+	if err == nil {
+		t.Error("Gather() returned no error for a rename to a metric of another type")
+	}
+	if len(families) != 1 || families[0].GetName() != "a" || families[0].GetType().String() != "GAUGE" {
+		t.Errorf("Gather() = %v, want only the gauge a", families)
+	}
+}
+
+func TestValidateRenames(t *testing.T) {
+	t.Parallel()
+
+	if err := validateRenames(map[string]string{"nginx_up": "nginx:up_total"}); err != nil {
+		t.Errorf("validateRenames() returned error for a valid name: %v", err)
+	}
+	if err := validateRenames(map[string]string{"nginx_up": "nginx-up"}); err == nil {
+		t.Error("validateRenames() returned no error for an invalid name")
+	}
+}
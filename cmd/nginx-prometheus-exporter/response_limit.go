@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// responseLimitRoundTripper 기존 http.RoundTripper를 감싸서, 응답 body 가 limit 바이트를 넘으면 오류를 반환한다.
+// http.Transport 는 Accept-Encoding: gzip 을 보내고 응답의 압축을 직접 풀기 때문에, limit 은 압축을 푼 크기에 적용된다.
+// 따라서 작은 gzip 응답이 매우 큰 body 로 풀리는 경우도 막는다.
+type responseLimitRoundTripper struct {
+	rt    http.RoundTripper
+	limit int64
+}
+
+func (rt *responseLimitRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rt.rt.RoundTrip(req)
+	if err != nil {
+		return nil, fmt.Errorf("round trip failed: %w", err)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, rt.limit+1))
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if int64(len(body)) > rt.limit {
+		return nil, fmt.Errorf("response body of %v exceeds %d bytes", req.URL.Redacted(), rt.limit)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	return resp, nil
+}
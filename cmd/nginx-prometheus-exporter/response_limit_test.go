@@ -0,0 +1,81 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseLimitRoundTripper(t *testing.T) {
+	t.Parallel()
+
+	const stubStatus = "Active connections: 1 \nserver accepts handled requests\n 5 5 7 \nReading: 0 Writing: 1 Waiting: 0 \n"
+
+	// 요청이 gzip 을 허용하면 압축하여 응답한다.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := strings.Repeat("a", 4096)
+		if r.URL.Path == "/small" {
+			body = stubStatus
+		}
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			_, _ = io.WriteString(w, body)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_, _ = io.WriteString(gz, body)
+		_ = gz.Close()
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "decompressed response within the limit",
+			path: "/small",
+			want: stubStatus,
+		},
+		{
+			name:    "decompressed response over the limit",
+			path:    "/large",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transport, ok := http.DefaultTransport.(*http.Transport)
+			if !ok {
+				t.Fatal("http.DefaultTransport is not an *http.Transport")
+			}
+			client := &http.Client{Transport: &responseLimitRoundTripper{rt: transport.Clone(), limit: 1024}}
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("request error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !resp.Uncompressed || string(body) != tt.want {
+				t.Errorf("response = %q, uncompressed %v, want the decompressed %q", body, resp.Uncompressed, tt.want)
+			}
+		})
+	}
+}
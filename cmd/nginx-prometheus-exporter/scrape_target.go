@@ -0,0 +1,252 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// scrapeTargets : --nginx.scrape-uri, --nginx.scrape-uri-file 과 설정 파일의 targets 를 합친다. 파일이나 설정 파일에
+// target 이 있으면, 직접 지정하지 않은 --nginx.scrape-uri 의 기본값은 scrape 하지 않는다.
+func scrapeTargets(uris []string, urisSet bool, uriFile string, configured []targetConfig) ([]targetConfig, error) {
+	var fileTargets []targetConfig
+	if uriFile != "" {
+		var err error
+		if fileTargets, err = readScrapeURIFile(uriFile); err != nil {
+			return nil, err
+		}
+	}
+
+	var targets []targetConfig
+	if urisSet || len(fileTargets)+len(configured) == 0 {
+		for _, uri := range uris {
+			targets = append(targets, targetConfig{URL: uri})
+		}
+	}
+	targets = append(targets, fileTargets...)
+	for i, t := range configured {
+		if err := t.validate(); err != nil {
+			return nil, fmt.Errorf("targets[%d]: %w", i, err)
+		}
+		targets = append(targets, t)
+	}
+
+	expanded := make([]targetConfig, 0, len(targets))
+	for _, t := range targets {
+		urls, err := expandTargetURL(t.URL)
+		if err != nil {
+			return nil, err
+		}
+		if t.Name != "" && len(urls) > 1 {
+			return nil, fmt.Errorf("target %q with name %q expands to %d URIs", t.URL, t.Name, len(urls))
+		}
+		for _, url := range urls {
+			t.URL = url
+			expanded = append(expanded, t)
+		}
+	}
+	if len(expanded) > maxExpandedTargets {
+		return nil, fmt.Errorf("%d scrape targets exceed the limit of %d", len(expanded), maxExpandedTargets)
+	}
+	targets = expanded
+
+	// addr label 이 같은 target 은 같은 metric 을 등록하므로 허용하지 않는다.
+	seen := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		if seen[t.addr()] {
+			return nil, fmt.Errorf("duplicate scrape target %q", t.addr())
+		}
+		seen[t.addr()] = true
+	}
+	return targets, nil
+}
+
+// maxExpandedTargets is the maximum number of scrape targets after expanding their
+// ranges, which protects against typos like [1-10000].
+const maxExpandedTargets = 4096
+
+// targetRangeRe matches a numeric range of a target URI, [1-20] or {01..12}.
+var targetRangeRe = regexp.MustCompile(`\[(\d+)-(\d+)\]|\{(\d+)\.\.(\d+)\}`)
+
+// expandTargetURL : URI 의 숫자 범위를 펼친다. 여러 범위는 곱집합으로 펼치고, 시작 값이 0 으로 시작하면(예: {01..12}) 그 길이로
+// 0 을 채운다. IPv6 주소의 [::1] 은 숫자 범위가 아니므로 그대로 둔다.
+func expandTargetURL(uri string) ([]string, error) {
+	loc := targetRangeRe.FindStringSubmatchIndex(uri)
+	if loc == nil {
+		return []string{uri}, nil
+	}
+	var first, last string
+	if loc[2] >= 0 {
+		first, last = uri[loc[2]:loc[3]], uri[loc[4]:loc[5]]
+	} else {
+		first, last = uri[loc[6]:loc[7]], uri[loc[8]:loc[9]]
+	}
+	from, errFrom := strconv.Atoi(first)
+	to, errTo := strconv.Atoi(last)
+	if errFrom != nil || errTo != nil || from > to || to-from >= maxExpandedTargets {
+		return nil, fmt.Errorf("invalid range %q in target %q", uri[loc[0]:loc[1]], uri)
+	}
+	width := 0
+	if len(first) > 1 && first[0] == '0' {
+		width = len(first)
+	}
+
+	// 나머지 범위는 재귀적으로 펼친다.
+	rest, err := expandTargetURL(uri[loc[1]:])
+	if err != nil {
+		return nil, err
+	}
+	if (to-from+1)*len(rest) > maxExpandedTargets {
+		return nil, fmt.Errorf("target %q expands to more than %d URIs", uri, maxExpandedTargets)
+	}
+	urls := make([]string, 0, (to-from+1)*len(rest))
+	for n := from; n <= to; n++ {
+		for _, suffix := range rest {
+			urls = append(urls, fmt.Sprintf("%v%0*d%v", uri[:loc[0]], width, n, suffix))
+		}
+	}
+	return urls, nil
+}
+
+// readScrapeURIFile : 한 줄에 하나씩 URI 또는 unix domain socket 경로를 읽는다. name=uri 형식이면 name 이 target 의 이름이
+// 된다. 빈 줄과 # 으로 시작하는 줄은 무시한다.
+func readScrapeURIFile(path string) ([]targetConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scrape URI file: %w", err)
+	}
+	var targets []targetConfig
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		target := targetConfig{URL: line}
+		// URI 의 query string 에도 = 가 있을 수 있으므로, = 앞에 : 나 / 가 없을 때만 이름으로 본다.
+		if name, uri, ok := strings.Cut(line, "="); ok && !strings.ContainsAny(name, ":/") {
+			target = targetConfig{Name: strings.TrimSpace(name), URL: strings.TrimSpace(uri)}
+		}
+		if target.URL == "" || (target.Name == "" && target.URL != line) {
+			return nil, fmt.Errorf("invalid scrape URI %q in %v line %d", line, path, i+1)
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// addr returns the value of the addr label of the target, its name or else its URL.
+func (t targetConfig) addr() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.URL
+}
+
+func (t targetConfig) validate() error {
+	if t.URL == "" {
+		return errors.New("url is required")
+	}
+	if t.BearerTokenFile != "" && t.BasicAuth != nil {
+		return errors.New("bearer_token_file and basic_auth cannot be used together")
+	}
+	if t.BasicAuth != nil && (t.BasicAuth.Username == "" || t.BasicAuth.PasswordFile == "") {
+		return errors.New("basic_auth requires username and password_file")
+	}
+	if t.TLSConfig != nil && (t.TLSConfig.CertFile == "") != (t.TLSConfig.KeyFile == "") {
+		return errors.New("tls_config requires both cert_file and key_file")
+	}
+	return nil
+}
+
+// transport : target 에 tls_config 가 있으면, 공유 transport 를 복제하여 target 의 CA 와 client 인증서로 교체한다. 인증서
+// 검증 여부는 --nginx.ssl-verify 를 따른다.
+func (t targetConfig) transport(transport *http.Transport) (*http.Transport, error) {
+	if t.TLSConfig == nil {
+		return transport, nil
+	}
+	transport = transport.Clone()
+	// #nosec G402
+	sslConfig := &tls.Config{}
+	if transport.TLSClientConfig != nil {
+		sslConfig = transport.TLSClientConfig.Clone()
+	}
+	if t.TLSConfig.CAFile != "" {
+		pool, err := loadCACertPool(t.TLSConfig.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		sslConfig.RootCAs = pool
+	}
+	if t.TLSConfig.CertFile != "" {
+		clientCert, err := tls.LoadX509KeyPair(t.TLSConfig.CertFile, t.TLSConfig.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate failed: %w", err)
+		}
+		sslConfig.Certificates = []tls.Certificate{clientCert}
+	}
+	transport.TLSClientConfig = sslConfig
+	return transport, nil
+}
+
+// roundTripper : target 에 credential 이 있으면 rt 를 credentialsRoundTripper 로 감싼다. 잘못된 파일을 시작 시 알 수 있도록
+// 파일을 한 번 읽어 본다.
+func (t targetConfig) roundTripper(rt http.RoundTripper) (http.RoundTripper, error) {
+	switch {
+	case t.BearerTokenFile != "":
+		if _, err := readSecretFile(t.BearerTokenFile); err != nil {
+			return nil, fmt.Errorf("loading bearer token failed: %w", err)
+		}
+		return &credentialsRoundTripper{rt: rt, tokenFile: t.BearerTokenFile}, nil
+	case t.BasicAuth != nil:
+		if _, err := readSecretFile(t.BasicAuth.PasswordFile); err != nil {
+			return nil, fmt.Errorf("loading basic auth password failed: %w", err)
+		}
+		return &credentialsRoundTripper{rt: rt, username: t.BasicAuth.Username, passwordFile: t.BasicAuth.PasswordFile}, nil
+	}
+	return rt, nil
+}
+
+// credentialsRoundTripper 기존 http.RoundTripper를 감싸서, 요청마다 bearer token 또는 basic auth 비밀번호 파일을 다시 읽어
+// Authorization 헤더를 추가한다. 따라서 파일을 교체하면 재시작 없이 새 credential 이 사용된다.
+type credentialsRoundTripper struct {
+	rt           http.RoundTripper
+	tokenFile    string
+	username     string
+	passwordFile string
+}
+
+func (rt *credentialsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = cloneRequest(req)
+	if rt.tokenFile != "" {
+		token, err := readSecretFile(rt.tokenFile)
+		if err != nil {
+			closeRequestBody(req)
+			return nil, fmt.Errorf("loading bearer token failed: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else {
+		password, err := readSecretFile(rt.passwordFile)
+		if err != nil {
+			closeRequestBody(req)
+			return nil, fmt.Errorf("loading basic auth password failed: %w", err)
+		}
+		req.SetBasicAuth(rt.username, password)
+	}
+	roundTrip, err := rt.rt.RoundTrip(req)
+	if err != nil {
+		return nil, fmt.Errorf("round trip failed: %w", err)
+	}
+	return roundTrip, nil
+}
+
+// closeRequestBody : RoundTripper 는 오류가 나도 요청 body 를 닫아야 한다.
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestScrapeTargets(t *testing.T) {
+	t.Parallel()
+
+	defaultURIs := []string{"http://127.0.0.1:8080/stub_status"}
+	configured := []targetConfig{{URL: "https://lb-a/api", BearerTokenFile: "/token"}}
+
+	tests := []struct {
+		name       string
+		uris       []string
+		configured []targetConfig
+		want       []targetConfig
+		urisSet    bool
+		wantErr    bool
+	}{
+		{
+			name: "flag only",
+			uris: defaultURIs,
+			want: []targetConfig{{URL: "http://127.0.0.1:8080/stub_status"}},
+		},
+		{
+			name:       "config targets replace the default URI",
+			uris:       defaultURIs,
+			configured: configured,
+			want:       configured,
+		},
+		{
+			name:       "config targets are added to a URI set by the user",
+			uris:       defaultURIs,
+			configured: configured,
+			urisSet:    true,
+			want:       append([]targetConfig{{URL: "http://127.0.0.1:8080/stub_status"}}, configured...),
+		},
+		{
+			name:       "duplicate target",
+			uris:       defaultURIs,
+			configured: []targetConfig{{URL: "http://127.0.0.1:8080/stub_status"}},
+			urisSet:    true,
+			wantErr:    true,
+		},
+		{
+			name:       "named target with range",
+			configured: []targetConfig{{Name: "edge", URL: "http://10.0.0.[1-2]:8080/stub_status"}},
+			wantErr:    true,
+		},
+		{
+			name:       "missing url",
+			configured: []targetConfig{{BearerTokenFile: "/token"}},
+			wantErr:    true,
+		},
+		{
+			name:       "bearer token and basic auth",
+			configured: []targetConfig{{URL: "https://lb-a/api", BearerTokenFile: "/token", BasicAuth: &basicAuthConfig{Username: "u", PasswordFile: "/password"}}},
+			wantErr:    true,
+		},
+		{
+			name:       "client certificate without key",
+			configured: []targetConfig{{URL: "https://lb-a/api", TLSConfig: &tlsConfig{CertFile: "/cert.pem"}}},
+			wantErr:    true,
+		},
+		{
+			name:       "basic auth without password file",
+			configured: []targetConfig{{URL: "https://lb-a/api", BasicAuth: &basicAuthConfig{Username: "u"}}},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := scrapeTargets(tt.uris, tt.urisSet, "", tt.configured)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("scrapeTargets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scrapeTargets() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandTargetURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		uri     string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "no range",
+			uri:  "http://[::1]:8080/stub_status",
+			want: []string{"http://[::1]:8080/stub_status"},
+		},
+		{
+			name: "bracket range",
+			uri:  "http://10.0.0.[1-3]:8080/stub_status",
+			want: []string{"http://10.0.0.1:8080/stub_status", "http://10.0.0.2:8080/stub_status", "http://10.0.0.3:8080/stub_status"},
+		},
+		{
+			name: "zero padded brace range",
+			uri:  "http://node-{08..10}.edge/stub_status",
+			want: []string{"http://node-08.edge/stub_status", "http://node-09.edge/stub_status", "http://node-10.edge/stub_status"},
+		},
+		{
+			name: "port range",
+			uri:  "http://edge-[1-2]:{8080..8081}/stub_status",
+			want: []string{
+				"http://edge-1:8080/stub_status", "http://edge-1:8081/stub_status",
+				"http://edge-2:8080/stub_status", "http://edge-2:8081/stub_status",
+			},
+		},
+		{
+			name:    "reversed range",
+			uri:     "http://10.0.0.[20-1]:8080/stub_status",
+			wantErr: true,
+		},
+		{
+			name:    "too many targets",
+			uri:     "http://10.0.[0-255].[0-255]:8080/stub_status",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := expandTargetURL(tt.uri)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandTargetURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandTargetURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadScrapeURIFile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    []targetConfig
+		wantErr bool
+	}{
+		{
+			name: "uris and names",
+			content: `# edge
+http://10.0.0.1:8080/stub_status
+
+lb-b = http://10.0.0.2:8080/stub_status
+http://10.0.0.3:8080/stub_status?a=b
+unix:/var/run/nginx.sock:/stub_status
+`,
+			want: []targetConfig{
+				{URL: "http://10.0.0.1:8080/stub_status"},
+				{Name: "lb-b", URL: "http://10.0.0.2:8080/stub_status"},
+				{URL: "http://10.0.0.3:8080/stub_status?a=b"},
+				{URL: "unix:/var/run/nginx.sock:/stub_status"},
+			},
+		},
+		{
+			name:    "name without uri",
+			content: "lb-a=\n",
+			wantErr: true,
+		},
+		{
+			name:    "uri without name",
+			content: "=http://10.0.0.1:8080/stub_status\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "targets")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readScrapeURIFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readScrapeURIFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readScrapeURIFile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScrapeTargetsFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "targets")
+	if err := os.WriteFile(path, []byte("lb-a=http://10.0.0.1:8080/stub_status\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := scrapeTargets([]string{"http://127.0.0.1:8080/stub_status"}, false, path, nil)
+	if err != nil {
+		t.Fatalf("scrapeTargets() returned error: %v", err)
+	}
+	want := []targetConfig{{Name: "lb-a", URL: "http://10.0.0.1:8080/stub_status"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("scrapeTargets() = %+v, want %+v", got, want)
+	}
+
+	if _, err := scrapeTargets(nil, false, filepath.Join(t.TempDir(), "missing"), nil); err == nil {
+		t.Error("scrapeTargets() returned no error for a missing scrape URI file")
+	}
+}
+
+func TestCredentialsRoundTripper(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	passwordFile := filepath.Join(dir, "password")
+	for file, content := range map[string]string{tokenFile: "token-a\n", passwordFile: "password-b\n"} {
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		target targetConfig
+		want   string
+	}{
+		{
+			name:   "no credentials",
+			target: targetConfig{},
+			want:   "",
+		},
+		{
+			name:   "bearer token",
+			target: targetConfig{BearerTokenFile: tokenFile},
+			want:   "Bearer token-a",
+		},
+		{
+			name:   "basic auth",
+			target: targetConfig{BasicAuth: &basicAuthConfig{Username: "exporter", PasswordFile: passwordFile}},
+			want:   "Basic ZXhwb3J0ZXI6cGFzc3dvcmQtYg==",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got string
+			server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got = r.Header.Get("Authorization")
+			}))
+			defer server.Close()
+
+			rt, err := tt.target.roundTripper(http.DefaultTransport)
+			if err != nil {
+				t.Fatalf("roundTripper() returned error: %v", err)
+			}
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := (&http.Client{Transport: rt}).Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+
+			if got != tt.want {
+				t.Errorf("Authorization header = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCredentialsRoundTripperMissingFile(t *testing.T) {
+	t.Parallel()
+
+	target := targetConfig{BearerTokenFile: filepath.Join(t.TempDir(), "missing")}
+	if _, err := target.roundTripper(http.DefaultTransport); err == nil {
+		t.Error("roundTripper() returned no error for a missing token file")
+	}
+}
+
+func TestTargetTransport(t *testing.T) {
+	t.Parallel()
+
+	var peerCertificates int
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		peerCertificates = len(r.TLS.PeerCertificates)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	// 서버 인증서를 target 의 CA 및 client 인증서로 사용한다.
+	serverCert := server.TLS.Certificates[0]
+	key, err := x509.MarshalPKCS8PrivateKey(serverCert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: serverCert.Certificate[0]})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key})
+	for file, content := range map[string][]byte{certFile: certPEM, keyFile: keyPEM} {
+		if err := os.WriteFile(file, content, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	shared := &http.Transport{TLSClientConfig: &tls.Config{MinVersion: tls.VersionTLS12}}
+	target := targetConfig{TLSConfig: &tlsConfig{CAFile: certFile, CertFile: certFile, KeyFile: keyFile}}
+	transport, err := target.transport(shared)
+	if err != nil {
+		t.Fatalf("transport() returned error: %v", err)
+	}
+	if transport == shared || shared.TLSClientConfig.RootCAs != nil {
+		t.Error("transport() modified the shared transport")
+	}
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		t.Fatalf("request with the target CA and client certificate failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if peerCertificates != 1 {
+		t.Errorf("server got %d client certificates, want 1", peerCertificates)
+	}
+
+	if _, err := (targetConfig{TLSConfig: &tlsConfig{CAFile: keyFile}}).transport(shared); err == nil {
+		t.Error("transport() returned no error for an invalid CA file")
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/proxy"
+)
+
+// newSOCKS5Dialer : socks5://[user:password@]host:port 또는 host:port 형식의 SOCKS5 프록시 dialer 를 생성한다.
+func newSOCKS5Dialer(uri string) (proxy.ContextDialer, error) {
+	if !strings.Contains(uri, "://") {
+		uri = "socks5://" + uri
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SOCKS5 proxy %q: %w", uri, err)
+	}
+	if u.Scheme != "socks5" && u.Scheme != "socks5h" {
+		return nil, fmt.Errorf("unsupported SOCKS5 proxy scheme %q", u.Scheme)
+	}
+	if _, _, err := net.SplitHostPort(u.Host); err != nil {
+		return nil, fmt.Errorf("invalid SOCKS5 proxy address %q: %w", u.Host, err)
+	}
+
+	var auth *proxy.Auth
+	if u.User != nil {
+		password, _ := u.User.Password()
+		auth = &proxy.Auth{User: u.User.Username(), Password: password}
+	}
+
+	dialer, err := proxy.SOCKS5("tcp", u.Host, auth, proxy.Direct)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SOCKS5 dialer: %w", err)
+	}
+	contextDialer, ok := dialer.(proxy.ContextDialer)
+	if !ok {
+		return nil, fmt.Errorf("SOCKS5 dialer for %v does not support contexts", u.Host)
+	}
+	return contextDialer, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshDialer dials connections through an SSH jump host. The SSH connection is
+// shared by all dials and re-established when it breaks.
+type sshDialer struct {
+	client *ssh.Client
+	config *ssh.ClientConfig
+	addr   string
+	mutex  sync.Mutex
+}
+
+// newSSHDialer : user@host[:port] 형식의 jump host 에 key 인증으로 접속하는 dialer 를 생성한다.
+func newSSHDialer(jump string, keyFile string, knownHostsFile string) (*sshDialer, error) {
+	user, host, ok := strings.Cut(jump, "@")
+	if !ok || user == "" || host == "" {
+		return nil, fmt.Errorf("invalid SSH jump host %q, expected user@host[:port]", jump)
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "22")
+	}
+
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH key: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH key %v: %w", keyFile, err)
+	}
+
+	if knownHostsFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the default known_hosts file: %w", err)
+		}
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SSH known hosts: %w", err)
+	}
+
+	return &sshDialer{
+		addr: host,
+		config: &ssh.ClientConfig{
+			User:            user,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         *timeout,
+		},
+	}, nil
+}
+
+// DialContext dials addr from the jump host, reconnecting to the jump host once if
+// the existing SSH connection is broken. A connection refused by the jump host, e.g.
+// because addr is down, keeps the SSH connection.
+func (d *sshDialer) DialContext(ctx context.Context, network string, addr string) (net.Conn, error) {
+	var err error
+	for range 2 {
+		var client *ssh.Client
+		if client, err = d.sshClient(); err != nil {
+			return nil, err
+		}
+
+		var conn net.Conn
+		if conn, err = client.DialContext(ctx, network, addr); err == nil {
+			return conn, nil
+		}
+		// 채널 열기가 거부된 것은 대상의 문제이므로, 정상인 SSH 연결을 끊지 않는다.
+		var openErr *ssh.OpenChannelError
+		if ctx.Err() != nil || errors.As(err, &openErr) {
+			break
+		}
+		d.reset(client)
+	}
+	return nil, fmt.Errorf("failed to dial %v through SSH jump host %v: %w", addr, d.addr, err)
+}
+
+// sshClient : jump host 와의 SSH 연결을 반환한다. 연결이 없으면 새로 맺는다.
+func (d *sshDialer) sshClient() (*ssh.Client, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.client != nil {
+		return d.client, nil
+	}
+	client, err := ssh.Dial("tcp", d.addr, d.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SSH jump host %v: %w", d.addr, err)
+	}
+	d.client = client
+	return client, nil
+}
+
+// reset : 끊어진 SSH 연결을 닫아 다음 dial 에서 다시 연결하도록 한다.
+func (d *sshDialer) reset(client *ssh.Client) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.client == client {
+		_ = client.Close()
+		d.client = nil
+	}
+}
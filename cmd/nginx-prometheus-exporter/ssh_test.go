@@ -0,0 +1,59 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestNewSSHDialer(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "id_ed25519")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	knownHosts := filepath.Join(dir, "known_hosts")
+	if err := os.WriteFile(knownHosts, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		jump     string
+		keyFile  string
+		wantAddr string
+		wantErr  bool
+	}{
+		{name: "default port", jump: "nginx@bastion", keyFile: keyFile, wantAddr: "bastion:22"},
+		{name: "explicit port", jump: "nginx@bastion:2222", keyFile: keyFile, wantAddr: "bastion:2222"},
+		{name: "missing user", jump: "bastion", keyFile: keyFile, wantErr: true},
+		{name: "missing key", jump: "nginx@bastion", keyFile: filepath.Join(dir, "missing"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			d, err := newSSHDialer(tt.jump, tt.keyFile, knownHosts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newSSHDialer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (d.addr != tt.wantAddr || d.config.User != "nginx") {
+				t.Errorf("newSSHDialer() = %v@%v, want nginx@%v", d.config.User, d.addr, tt.wantAddr)
+			}
+		})
+	}
+}
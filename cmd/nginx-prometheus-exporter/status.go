@@ -0,0 +1,142 @@
+package main
+
+import (
+	_ "embed"
+	"html/template"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nginx/nginx-prometheus-exporter/collector"
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+)
+
+// statusPath is the path of the status page.
+const statusPath = "/status"
+
+//go:embed status.html
+var statusPageHTML string
+
+var statusTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"since": func(now, t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return now.Sub(t).Truncate(time.Second).String() + " ago"
+	},
+}).Parse(statusPageHTML))
+
+// scrapeStatusSource is a collector that reports the result of its last scrape.
+type scrapeStatusSource interface {
+	ScrapeStatus() collector.ScrapeStatus
+}
+
+// healthStatusSource is a collector that reports its upstream health check results,
+// and runs its health checks on demand for the health check run endpoint.
+type healthStatusSource interface {
+	healthCheckRunner
+	UpstreamHealth() []collector.UpstreamHealth
+	HealthTransitions() []collector.HealthTransition
+}
+
+type statusTarget struct {
+	source scrapeStatusSource
+	addr   string
+}
+
+// statusPage serves a page with the scrape targets, the upstreams of the NGINX config
+// with their health and the recent health transitions. Collectors are added before
+// the HTTP server starts.
+type statusPage struct {
+	targets    []statusTarget
+	health     []healthStatusSource
+	configPath string
+}
+
+func (p *statusPage) addTarget(addr string, source scrapeStatusSource) {
+	p.targets = append(p.targets, statusTarget{addr: addr, source: source})
+}
+
+func (p *statusPage) addHealth(source healthStatusSource) {
+	p.health = append(p.health, source)
+}
+
+type statusTargetRow struct {
+	Addr string
+	collector.ScrapeStatus
+}
+
+type statusUpstream struct {
+	Name    string
+	File    string
+	Servers []statusServer
+}
+
+type statusServer struct {
+	Health  *collector.UpstreamHealth
+	Address string
+}
+
+type statusData struct {
+	Now         time.Time
+	ConfigError string
+	Targets     []statusTargetRow
+	Upstreams   []statusUpstream
+	Health      []collector.UpstreamHealth
+	Transitions []collector.HealthTransition
+}
+
+func (p *statusPage) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	data := p.data()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := statusTemplate.Execute(w, data); err != nil {
+		http.Error(w, "Failed to render status page", http.StatusInternalServerError)
+	}
+}
+
+// data : 페이지에 표시할 값을 모은다. upstream 의 서버는 같은 주소의 health check 결과와 연결하며, 여러 파일에서 검사한
+// 주소는 실패한 결과를 우선한다.
+func (p *statusPage) data() statusData {
+	data := statusData{Now: time.Now()}
+	for _, t := range p.targets {
+		data.Targets = append(data.Targets, statusTargetRow{Addr: t.addr, ScrapeStatus: t.source.ScrapeStatus()})
+	}
+
+	byTarget := make(map[string]*collector.UpstreamHealth)
+	for _, source := range p.health {
+		data.Health = append(data.Health, source.UpstreamHealth()...)
+		data.Transitions = append(data.Transitions, source.HealthTransitions()...)
+	}
+	for i, h := range data.Health {
+		if current, ok := byTarget[h.Target]; !ok || current.Healthy && !h.Healthy {
+			byTarget[h.Target] = &data.Health[i]
+		}
+	}
+	// 최근 transition 이 위에 오도록 한다.
+	slices.SortStableFunc(data.Transitions, func(a, b collector.HealthTransition) int {
+		return b.At.Compare(a.At)
+	})
+
+	cfg, err := nginxconf.Parse(p.configPath)
+	if err != nil {
+		data.ConfigError = err.Error()
+		return data
+	}
+	upstreams := make(map[string]int)
+	for _, t := range collector.ConfigTargets(cfg, p.configPath) {
+		switch t.Kind {
+		case collector.ConfigTargetUpstream:
+			upstreams[t.Value] = len(data.Upstreams)
+			data.Upstreams = append(data.Upstreams, statusUpstream{Name: t.Value, File: t.File})
+		case collector.ConfigTargetServer:
+			name, _ := strings.CutPrefix(t.Context, "upstream:")
+			i, ok := upstreams[name]
+			if !ok {
+				continue
+			}
+			data.Upstreams[i].Servers = append(data.Upstreams[i].Servers, statusServer{Address: t.Value, Health: byTarget[t.Value]})
+		}
+	}
+	return data
+}
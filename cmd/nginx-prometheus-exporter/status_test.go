@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nginx/nginx-prometheus-exporter/collector"
+)
+
+type fakeStatusSource struct {
+	scrape      collector.ScrapeStatus
+	health      []collector.UpstreamHealth
+	transitions []collector.HealthTransition
+	runErr      error
+}
+
+func (s fakeStatusSource) ScrapeStatus() collector.ScrapeStatus { return s.scrape }
+
+func (s fakeStatusSource) UpstreamHealth() []collector.UpstreamHealth { return s.health }
+
+func (s fakeStatusSource) HealthTransitions() []collector.HealthTransition { return s.transitions }
+
+func (s fakeStatusSource) RunHealthChecks() ([]collector.UpstreamHealth, error) {
+	return s.health, s.runErr
+}
+
+func TestStatusPage(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "nginx.conf")
+	config := `http {
+    upstream backend {
+        server 10.0.0.1:8080;
+        server 10.0.0.2:8080;
+    }
+    upstream empty {
+    }
+    server {
+        location / { proxy_pass http://backend; }
+    }
+}
+`
+	if err := os.WriteFile(configPath, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	source := fakeStatusSource{
+		scrape: collector.ScrapeStatus{At: now, Error: "connection refused <edge>"},
+		health: []collector.UpstreamHealth{
+			{File: "nginx.conf", Target: "10.0.0.1:8080", Healthy: true, Transitions: 2, CheckedAt: now},
+			{File: "other.conf", Target: "10.0.0.1:8080", Healthy: false, CheckedAt: now},
+		},
+		transitions: []collector.HealthTransition{
+			{At: now.Add(-time.Minute), File: "nginx.conf", Target: "10.0.0.1:8080", Healthy: false},
+			{At: now, File: "nginx.conf", Target: "10.0.0.1:8080", Healthy: true},
+		},
+	}
+	page := &statusPage{configPath: configPath}
+	page.addTarget("http://127.0.0.1:8080/stub_status", source)
+	page.addHealth(source)
+
+	data := page.data()
+	if data.ConfigError != "" {
+		t.Fatalf("data() returned config error: %v", data.ConfigError)
+	}
+	if len(data.Upstreams) != 2 || len(data.Upstreams[0].Servers) != 2 || len(data.Upstreams[1].Servers) != 0 {
+		t.Fatalf("data() returned upstreams %+v, want backend with 2 servers and empty", data.Upstreams)
+	}
+	servers := data.Upstreams[0].Servers
+	if servers[0].Health == nil || servers[0].Health.Healthy {
+		t.Errorf("health of %v = %+v, want the failed check of another file", servers[0].Address, servers[0].Health)
+	}
+	if servers[1].Health != nil {
+		t.Errorf("health of %v = %+v, want none", servers[1].Address, servers[1].Health)
+	}
+	if !data.Transitions[0].Healthy {
+		t.Errorf("first transition = %+v, want the most recent one", data.Transitions[0])
+	}
+
+	rec := httptest.NewRecorder()
+	page.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, statusPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status page returned %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"http://127.0.0.1:8080/stub_status", "connection refused &lt;edge&gt;", "10.0.0.2:8080", "not checked", "no servers"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("status page does not contain %q", want)
+		}
+	}
+}
+
+func TestStatusPageConfigError(t *testing.T) {
+	t.Parallel()
+
+	page := &statusPage{configPath: filepath.Join(t.TempDir(), "missing.conf")}
+	rec := httptest.NewRecorder()
+	page.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, statusPath, nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "The NGINX config cannot be read") {
+		t.Errorf("status page returned %d without the config error:\n%v", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nginx/nginx-prometheus-exporter/collector"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v2"
+)
+
+// Types of target stores.
+const (
+	targetStoreConsul = "consul"
+	targetStoreEtcd   = "etcd"
+)
+
+// defaultTargetStoreRefreshInterval is the interval the target store is polled at when
+// refresh_interval is not set.
+const defaultTargetStoreRefreshInterval = 30 * time.Second
+
+// targetStoreTimeout bounds one request to the target store.
+const targetStoreTimeout = 10 * time.Second
+
+// kvStore lists the keys and values under the prefix of an etcd or Consul KV store.
+type kvStore interface {
+	list(ctx context.Context) (map[string][]byte, error)
+}
+
+// newKVStore : target_store 설정에 맞는 KV store client 를 만든다. token_file 은 요청마다 다시 읽는다.
+func newKVStore(config targetStoreConfig, client *http.Client) (kvStore, error) {
+	if config.URL == "" || config.Prefix == "" {
+		return nil, errors.New("target_store requires url and prefix")
+	}
+	if config.TokenFile != "" {
+		if _, err := readSecretFile(config.TokenFile); err != nil {
+			return nil, fmt.Errorf("loading target store token failed: %w", err)
+		}
+	}
+	base := strings.TrimSuffix(config.URL, "/")
+	switch config.Type {
+	case targetStoreConsul:
+		return &consulStore{client: client, url: base, prefix: config.Prefix, tokenFile: config.TokenFile}, nil
+	case targetStoreEtcd:
+		return &etcdStore{client: client, url: base, prefix: config.Prefix, tokenFile: config.TokenFile}, nil
+	}
+	return nil, fmt.Errorf("unknown target_store type %q, must be %v or %v", config.Type, targetStoreConsul, targetStoreEtcd)
+}
+
+// consulStore reads a prefix of the Consul KV store with the HTTP API.
+type consulStore struct {
+	client    *http.Client
+	url       string
+	prefix    string
+	tokenFile string
+}
+
+func (s *consulStore) list(ctx context.Context) (map[string][]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url+(&url.URL{Path: "/v1/kv/" + strings.TrimPrefix(s.prefix, "/")}).EscapedPath()+"?recurse=true", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Consul request: %w", err)
+	}
+	if err := setStoreToken(req, "X-Consul-Token", s.tokenFile); err != nil {
+		return nil, err
+	}
+	var entries []struct {
+		Key   string `json:"Key"`
+		Value []byte `json:"Value"`
+	}
+	// 키가 하나도 없는 prefix 는 404 로 응답한다.
+	if err := doStoreRequest(s.client, req, &entries, http.StatusNotFound); err != nil {
+		return nil, err
+	}
+	kvs := make(map[string][]byte, len(entries))
+	for _, e := range entries {
+		// 폴더 키는 값이 없다.
+		if e.Value != nil {
+			kvs[e.Key] = e.Value
+		}
+	}
+	return kvs, nil
+}
+
+// etcdStore reads a prefix of etcd with the JSON gateway of the v3 API.
+type etcdStore struct {
+	client    *http.Client
+	url       string
+	prefix    string
+	tokenFile string
+}
+
+func (s *etcdStore) list(ctx context.Context) (map[string][]byte, error) {
+	body, err := json.Marshal(map[string]string{
+		"key":       base64.StdEncoding.EncodeToString([]byte(s.prefix)),
+		"range_end": base64.StdEncoding.EncodeToString(prefixRangeEnd(s.prefix)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode etcd request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url+"/v3/kv/range", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create etcd request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := setStoreToken(req, "Authorization", s.tokenFile); err != nil {
+		return nil, err
+	}
+	var response struct {
+		KVs []struct {
+			Key   []byte `json:"key"`
+			Value []byte `json:"value"`
+		} `json:"kvs"`
+	}
+	if err := doStoreRequest(s.client, req, &response); err != nil {
+		return nil, err
+	}
+	kvs := make(map[string][]byte, len(response.KVs))
+	for _, kv := range response.KVs {
+		kvs[string(kv.Key)] = kv.Value
+	}
+	return kvs, nil
+}
+
+// prefixRangeEnd returns the end of the etcd key range of all keys with the prefix,
+// the prefix with its last byte incremented.
+func prefixRangeEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// 모든 바이트가 0xff 이면 prefix 이후의 모든 키를 읽는다.
+	return []byte{0}
+}
+
+func setStoreToken(req *http.Request, header string, tokenFile string) error {
+	if tokenFile == "" {
+		return nil
+	}
+	token, err := readSecretFile(tokenFile)
+	if err != nil {
+		return fmt.Errorf("loading target store token failed: %w", err)
+	}
+	req.Header.Set(header, token)
+	return nil
+}
+
+// doStoreRequest : 요청을 보내 JSON 응답을 v 로 읽는다. empty 에 있는 status code 는 빈 응답으로 취급한다.
+func doStoreRequest(client *http.Client, req *http.Request, v any, empty ...int) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request target store: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if slices.Contains(empty, resp.StatusCode) {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("target store returned %v: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode target store response: %w", err)
+	}
+	return nil
+}
+
+// parseStoreTargets : prefix 아래의 키마다 하나의 target 을 읽는다. 값은 URI 이거나 targets 항목과 같은 YAML 이며, name 이
+// 없으면 prefix 를 뺀 키가 name 이 된다. 잘못된 키는 오류로 모아 반환하고 나머지 target 은 사용한다.
+func parseStoreTargets(prefix string, kvs map[string][]byte) ([]targetConfig, error) {
+	var targets []targetConfig
+	var errs []error
+	for _, key := range slices.Sorted(maps.Keys(kvs)) {
+		value := bytes.TrimSpace(kvs[key])
+		if len(value) == 0 {
+			continue
+		}
+		var t targetConfig
+		if uri := string(value); strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://") || strings.HasPrefix(uri, "unix:") {
+			t.URL = uri
+		} else if err := yaml.UnmarshalStrict(value, &t); err != nil {
+			errs = append(errs, fmt.Errorf("target store key %v: %w", key, err))
+			continue
+		}
+		if t.Name == "" {
+			t.Name = strings.TrimPrefix(strings.TrimPrefix(key, prefix), "/")
+		}
+		if err := t.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("target store key %v: %w", key, err))
+			continue
+		}
+		targets = append(targets, t)
+	}
+	return targets, errors.Join(errs...)
+}
+
+// storeTarget is a target of the target store with its collector.
+type storeTarget struct {
+	collector targetCollector
+	config    targetConfig
+}
+
+// storeTargets collects the targets listed in a target store. The targets change
+// with the store, so it is an unchecked collector.
+type storeTargets struct {
+	store         kvStore
+	logger        *slog.Logger
+	newCollector  func(targetConfig) (targetCollector, error)
+	targets       map[string]storeTarget
+	staticAddrs   map[string]bool
+	targetsDesc   *prometheus.Desc
+	refreshDesc   *prometheus.Desc
+	failuresDesc  *prometheus.Desc
+	prefix        string
+	lastRefresh   time.Time
+	refreshErrors float64
+	mutex         sync.RWMutex
+}
+
+func newStoreTargets(store kvStore, prefix string, staticAddrs map[string]bool, newCollector func(targetConfig) (targetCollector, error),
+	namespace string, constLabels map[string]string, logger *slog.Logger,
+) *storeTargets {
+	return &storeTargets{
+		store:        store,
+		logger:       logger,
+		newCollector: newCollector,
+		targets:      make(map[string]storeTarget),
+		staticAddrs:  staticAddrs,
+		prefix:       prefix,
+		targetsDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "exporter_store_targets"),
+			"Number of scrape targets read from the target store", nil, constLabels),
+		refreshDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "exporter_store_last_refresh_timestamp_seconds"),
+			"Time of the last successful read of the target store", nil, constLabels),
+		failuresDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "exporter_store_refresh_failures_total"),
+			"Number of failed reads of the target store", nil, constLabels),
+	}
+}
+
+// run : interval 마다 target store 를 다시 읽어 target 을 갱신한다. ctx 가 취소되면 모든 collector 를 닫는다.
+func (s *storeTargets) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.mutex.Lock()
+			for addr, t := range s.targets {
+				closeCollector(t.collector)
+				delete(s.targets, addr)
+			}
+			s.mutex.Unlock()
+			return
+		case <-ticker.C:
+			if err := s.refresh(ctx); err != nil {
+				s.logger.Error("refreshing targets from the target store failed", "error", err.Error())
+			}
+		}
+	}
+}
+
+// refresh : target store 를 읽어 새 target 의 collector 를 만들고, 사라지거나 바뀐 target 의 collector 는 닫는다. store 를
+// 읽지 못하면 기존 target 을 그대로 유지한다.
+func (s *storeTargets) refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, targetStoreTimeout)
+	defer cancel()
+
+	kvs, err := s.store.list(ctx)
+	if err != nil {
+		s.mutex.Lock()
+		s.refreshErrors++
+		s.mutex.Unlock()
+		return err
+	}
+	targets, parseErr := parseStoreTargets(s.prefix, kvs)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastRefresh = time.Now()
+
+	errs := []error{parseErr}
+	seen := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		addr := t.addr()
+		if s.staticAddrs[addr] || seen[addr] {
+			errs = append(errs, fmt.Errorf("duplicate scrape target %q", addr))
+			continue
+		}
+		seen[addr] = true
+		if current, ok := s.targets[addr]; ok {
+			if reflect.DeepEqual(current.config, t) {
+				continue
+			}
+			closeCollector(current.collector)
+			delete(s.targets, addr)
+		}
+		c, err := s.newCollector(t)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("target %q: %w", addr, err))
+			continue
+		}
+		s.targets[addr] = storeTarget{collector: c, config: t}
+		s.logger.Info("added target from the target store", "addr", addr)
+	}
+	for addr, t := range s.targets {
+		if !seen[addr] {
+			closeCollector(t.collector)
+			delete(s.targets, addr)
+			s.logger.Info("removed target of the target store", "addr", addr)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func closeCollector(c targetCollector) {
+	if closer, ok := c.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
+// Describe implements prometheus.Collector. It sends no descriptors, which makes the
+// collector unchecked.
+func (s *storeTargets) Describe(chan<- *prometheus.Desc) {}
+
+// Collect : 모든 target 의 collector 를 동시에 수집한다.
+func (s *storeTargets) Collect(ch chan<- prometheus.Metric) {
+	s.mutex.RLock()
+	collectors := make([]targetCollector, 0, len(s.targets))
+	for _, t := range s.targets {
+		collectors = append(collectors, t.collector)
+	}
+	ch <- prometheus.MustNewConstMetric(s.targetsDesc, prometheus.GaugeValue, float64(len(s.targets)))
+	ch <- prometheus.MustNewConstMetric(s.failuresDesc, prometheus.CounterValue, s.refreshErrors)
+	if !s.lastRefresh.IsZero() {
+		ch <- prometheus.MustNewConstMetric(s.refreshDesc, prometheus.GaugeValue, float64(s.lastRefresh.UnixNano())/1e9)
+	}
+	s.mutex.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, c := range collectors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Collect(ch)
+		}()
+	}
+	wg.Wait()
+}
+
+// RunHealthChecks : target store 의 target 중 background health check 를 하는 collector 의 health check 를 즉시 실행한다.
+// startup 이후에 추가된 target 도 포함되도록, 실행할 때의 target 을 사용한다.
+func (s *storeTargets) RunHealthChecks() ([]collector.UpstreamHealth, error) {
+	s.mutex.RLock()
+	addrs := slices.Sorted(maps.Keys(s.targets))
+	runners := make([]healthCheckRunner, 0, len(addrs))
+	for _, addr := range addrs {
+		if runner, ok := s.targets[addr].collector.(healthCheckRunner); ok {
+			runners = append(runners, runner)
+		}
+	}
+	s.mutex.RUnlock()
+
+	var health []collector.UpstreamHealth
+	for _, runner := range runners {
+		h, err := runner.RunHealthChecks()
+		if err != nil {
+			return nil, err
+		}
+		health = append(health, h...)
+	}
+	return health, nil
+}
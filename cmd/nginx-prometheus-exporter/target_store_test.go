@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nginx/nginx-prometheus-exporter/collector"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestKVStore(t *testing.T) {
+	t.Parallel()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	b64 := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+
+	consul := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/nginx/targets/" || r.URL.Query().Get("recurse") != "true" || r.Header.Get("X-Consul-Token") != "secret" {
+			http.Error(w, "unexpected request "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"Key":"nginx/targets/","Value":null},{"Key":"nginx/targets/edge-1","Value":"` + b64("http://10.0.0.1/stub_status") + `"}]`))
+	}))
+	t.Cleanup(consul.Close)
+
+	etcd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.URL.Path != "/v3/kv/range" ||
+			req["key"] != b64("/nginx/targets/") || req["range_end"] != b64("/nginx/targets0") || r.Header.Get("Authorization") != "secret" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"kvs":[{"key":"` + b64("/nginx/targets/edge-1") + `","value":"` + b64("http://10.0.0.1/stub_status") + `"}]}`))
+	}))
+	t.Cleanup(etcd.Close)
+
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.NotFound(w, nil)
+	}))
+	t.Cleanup(empty.Close)
+
+	tests := []struct {
+		want    map[string][]byte
+		name    string
+		config  targetStoreConfig
+		wantErr bool
+	}{
+		{
+			name:   "consul",
+			config: targetStoreConfig{Type: targetStoreConsul, URL: consul.URL + "/", Prefix: "nginx/targets/", TokenFile: tokenFile},
+			want:   map[string][]byte{"nginx/targets/edge-1": []byte("http://10.0.0.1/stub_status")},
+		},
+		{
+			name:   "etcd",
+			config: targetStoreConfig{Type: targetStoreEtcd, URL: etcd.URL, Prefix: "/nginx/targets/", TokenFile: tokenFile},
+			want:   map[string][]byte{"/nginx/targets/edge-1": []byte("http://10.0.0.1/stub_status")},
+		},
+		{
+			name:   "consul empty prefix",
+			config: targetStoreConfig{Type: targetStoreConsul, URL: empty.URL, Prefix: "nginx/targets/"},
+			want:   map[string][]byte{},
+		},
+		{
+			name:    "etcd error",
+			config:  targetStoreConfig{Type: targetStoreEtcd, URL: empty.URL, Prefix: "/nginx/targets/"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store, err := newKVStore(tt.config, http.DefaultClient)
+			if err != nil {
+				t.Fatalf("newKVStore() returned error: %v", err)
+			}
+			got, err := store.list(t.Context())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("list() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("list() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewKVStoreInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, config := range []targetStoreConfig{
+		{Type: "zookeeper", URL: "http://127.0.0.1:2181", Prefix: "/nginx"},
+		{Type: targetStoreConsul, Prefix: "nginx"},
+		{Type: targetStoreEtcd, URL: "http://127.0.0.1:2379"},
+		{Type: targetStoreEtcd, URL: "http://127.0.0.1:2379", Prefix: "/nginx", TokenFile: filepath.Join(t.TempDir(), "missing")},
+	} {
+		if _, err := newKVStore(config, http.DefaultClient); err == nil {
+			t.Errorf("newKVStore(%+v) returned no error", config)
+		}
+	}
+}
+
+func TestParseStoreTargets(t *testing.T) {
+	t.Parallel()
+
+	targets, err := parseStoreTargets("/nginx/targets/", map[string][]byte{
+		"/nginx/targets/edge-1":  []byte("http://10.0.0.1:8080/stub_status\n"),
+		"/nginx/targets/edge-2":  []byte("url: https://10.0.0.2/api\nbearer_token_file: /etc/token\n"),
+		"/nginx/targets/renamed": []byte("name: edge-3\nurl: unix:/run/nginx.sock:/stub_status\n"),
+		"/nginx/targets/empty":   []byte(" "),
+		"/nginx/targets/bad":     []byte("url: [\n"),
+		"/nginx/targets/no-url":  []byte("name: edge-4\n"),
+	})
+	want := []targetConfig{
+		{Name: "edge-1", URL: "http://10.0.0.1:8080/stub_status"},
+		{Name: "edge-2", URL: "https://10.0.0.2/api", BearerTokenFile: "/etc/token"},
+		{Name: "edge-3", URL: "unix:/run/nginx.sock:/stub_status"},
+	}
+	if !reflect.DeepEqual(targets, want) {
+		t.Errorf("parseStoreTargets() = %+v, want %+v", targets, want)
+	}
+	if err == nil || !strings.Contains(err.Error(), "/nginx/targets/bad") || !strings.Contains(err.Error(), "/nginx/targets/no-url") {
+		t.Errorf("parseStoreTargets() error = %v, want errors of the bad and no-url keys", err)
+	}
+}
+
+type fakeKVStore struct {
+	err error
+	kvs map[string][]byte
+}
+
+func (s *fakeKVStore) list(context.Context) (map[string][]byte, error) {
+	return s.kvs, s.err
+}
+
+type fakeTargetCollector struct {
+	gauge  prometheus.Gauge
+	closed bool
+}
+
+func (c *fakeTargetCollector) Describe(ch chan<- *prometheus.Desc) { c.gauge.Describe(ch) }
+
+func (c *fakeTargetCollector) Collect(ch chan<- prometheus.Metric) { c.gauge.Collect(ch) }
+
+func (c *fakeTargetCollector) ScrapeStatus() collector.ScrapeStatus { return collector.ScrapeStatus{} }
+
+func (c *fakeTargetCollector) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestStoreTargetsRefresh(t *testing.T) {
+	t.Parallel()
+
+	var mutex sync.Mutex
+	created := make(map[string]*fakeTargetCollector)
+	newCollector := func(target targetConfig) (targetCollector, error) {
+		if target.URL == "http://broken" {
+			return nil, errors.New("broken target")
+		}
+		c := &fakeTargetCollector{gauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "nginx_up",
+			Help:        "Status of the last metric scrape",
+			ConstLabels: prometheus.Labels{"addr": target.addr()},
+		})}
+		c.gauge.Set(1)
+		mutex.Lock()
+		created[target.URL] = c
+		mutex.Unlock()
+		return c, nil
+	}
+
+	store := &fakeKVStore{kvs: map[string][]byte{
+		"nginx/edge-1": []byte("http://10.0.0.1/stub_status"),
+		"nginx/edge-2": []byte("http://10.0.0.2/stub_status"),
+		"nginx/static": []byte("http://10.0.0.3/stub_status"),
+		"nginx/broken": []byte("http://broken"),
+	}}
+	targets := newStoreTargets(store, "nginx/", map[string]bool{"static": true}, newCollector, "nginx", nil, slog.New(slog.DiscardHandler))
+
+	if err := targets.refresh(t.Context()); err == nil || !strings.Contains(err.Error(), "static") || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("refresh() error = %v, want errors of the static and broken targets", err)
+	}
+	if got := testutil.CollectAndCount(targets, "nginx_up"); got != 2 {
+		t.Errorf("collected %d nginx_up metrics, want 2", got)
+	}
+	edge1 := created["http://10.0.0.1/stub_status"]
+
+	// edge-1 is removed, edge-2 changes its URL and a failing store keeps the targets.
+	store.kvs = map[string][]byte{"nginx/edge-2": []byte("http://10.0.0.20/stub_status")}
+	if err := targets.refresh(t.Context()); err != nil {
+		t.Errorf("refresh() returned error: %v", err)
+	}
+	if !edge1.closed || !created["http://10.0.0.2/stub_status"].closed || created["http://10.0.0.20/stub_status"] == nil {
+		t.Error("refresh() did not replace the removed and changed targets")
+	}
+	store.err = errors.New("connection refused")
+	if err := targets.refresh(t.Context()); err == nil {
+		t.Error("refresh() returned no error for a failing store")
+	}
+
+	expected := `
+# HELP nginx_exporter_store_refresh_failures_total Number of failed reads of the target store
+# TYPE nginx_exporter_store_refresh_failures_total counter
+nginx_exporter_store_refresh_failures_total 1
+# HELP nginx_exporter_store_targets Number of scrape targets read from the target store
+# TYPE nginx_exporter_store_targets gauge
+nginx_exporter_store_targets 1
+# HELP nginx_up Status of the last metric scrape
+# TYPE nginx_up gauge
+nginx_up{addr="edge-2"} 1
+`
+	if err := testutil.CollectAndCompare(targets, strings.NewReader(expected),
+		"nginx_up", "nginx_exporter_store_targets", "nginx_exporter_store_refresh_failures_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+type fakeHealthTargetCollector struct {
+	*fakeTargetCollector
+	health []collector.UpstreamHealth
+}
+
+func (c fakeHealthTargetCollector) RunHealthChecks() ([]collector.UpstreamHealth, error) {
+	return c.health, nil
+}
+
+func TestStoreTargetsRunHealthChecks(t *testing.T) {
+	t.Parallel()
+
+	// The NGINX targets run their health checks, the NGINX Plus target has none.
+	newCollector := func(target targetConfig) (targetCollector, error) {
+		c := &fakeTargetCollector{gauge: prometheus.NewGauge(prometheus.GaugeOpts{Name: "nginx_up"})}
+		if target.URL == "http://10.0.0.3/api" {
+			return c, nil
+		}
+		return fakeHealthTargetCollector{fakeTargetCollector: c, health: []collector.UpstreamHealth{
+			{Target: target.addr() + ":8080", Healthy: true},
+		}}, nil
+	}
+	store := &fakeKVStore{kvs: map[string][]byte{
+		"nginx/edge-2": []byte("http://10.0.0.2/stub_status"),
+		"nginx/edge-1": []byte("http://10.0.0.1/stub_status"),
+		"nginx/plus":   []byte("http://10.0.0.3/api"),
+	}}
+	targets := newStoreTargets(store, "nginx/", nil, newCollector, "nginx", nil, slog.New(slog.DiscardHandler))
+	if err := targets.refresh(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	health, err := targets.RunHealthChecks()
+	if err != nil {
+		t.Fatalf("RunHealthChecks() returned error: %v", err)
+	}
+	var got []string
+	for _, h := range health {
+		got = append(got, h.Target)
+	}
+	if want := []string{"edge-1:8080", "edge-2:8080"}; !slices.Equal(got, want) {
+		t.Errorf("RunHealthChecks() checked %v, want %v", got, want)
+	}
+}
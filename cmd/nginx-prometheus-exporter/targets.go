@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nginx/nginx-prometheus-exporter/collector"
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+)
+
+// printTargets : targets 서브커맨드. 설정에서 추출한 upstream 및 proxy target 을 파일, 라인과 함께 출력한다.
+func printTargets(w io.Writer, configPath string) error {
+	cfg, err := nginxconf.Parse(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse nginx config: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SOURCE\tKIND\tCONTEXT\tTARGET\tADDRESSES\tHEALTH CHECKED")
+	for _, t := range collector.ConfigTargets(cfg, configPath) {
+		addresses := "-"
+		switch {
+		case t.Kind == collector.ConfigTargetProxyPass && len(t.Addresses) == 0:
+			addresses = "unresolved"
+		case len(t.Addresses) > 0:
+			addresses = strings.Join(t.Addresses, ",")
+		}
+		fmt.Fprintf(tw, "%v:%v\t%v\t%v\t%v\t%v\t%v\n", t.File, t.Line, t.Kind, t.Context, t.Value, addresses, t.HealthChecked)
+	}
+	for _, m := range cfg.MissingIncludes {
+		fmt.Fprintf(tw, "%v:%v\tmissing_include\t-\t%v\t-\tfalse\n", m.File, m.Line, m.Pattern)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write targets: %w", err)
+	}
+	return nil
+}
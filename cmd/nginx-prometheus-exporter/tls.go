@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/fips140"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/prometheus/exporter-toolkit/web"
+	"gopkg.in/yaml.v2"
+)
+
+// tlsVersions are the values of --nginx.ssl-min-version, named like the min_version of
+// the exporter-toolkit web config file.
+var tlsVersions = map[string]uint16{
+	"TLS10": tls.VersionTLS10,
+	"TLS11": tls.VersionTLS11,
+	"TLS12": tls.VersionTLS12,
+	"TLS13": tls.VersionTLS13,
+}
+
+// parseCipherSuites : IANA 이름의 cipher suite 목록을 ID 로 변환한다. Go 가 안전하지 않다고 분류한 cipher suite 는 허용하지
+// 않는다.
+func parseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	supported := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		supported[suite.Name] = suite.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := supported[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported or insecure cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// restrictedCipherSuites are the TLS 1.2 cipher suites allowed by --web.restricted-tls,
+// the ECDHE AES-GCM suites approved by NIST SP 800-52r2.
+var restrictedCipherSuites = map[uint16]bool{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256: true,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384: true,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256:   true,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384:   true,
+}
+
+// restrictedCurves are the curves allowed by --web.restricted-tls.
+var restrictedCurves = map[tls.CurveID]bool{
+	tls.CurveP256: true,
+	tls.CurveP384: true,
+	tls.CurveP521: true,
+}
+
+// checkRestrictedTLS : web config file 이 TLS 를 사용하고, 허용된 protocol, cipher suite, curve 만 사용하는지 검사한다.
+// 설정하지 않은 값은 Go 의 기본값이 사용되어 허용되지 않은 것이 섞이므로, cipher_suites 와 curve_preferences 는 명시해야 한다.
+// TLS 1.3 의 cipher suite 는 설정할 수 없으므로, Go 의 FIPS 140 mode 가 아니면 max_version 을 TLS12 로 제한해야 한다.
+func checkRestrictedTLS(configPath string) error {
+	if configPath == "" {
+		return errors.New("no web config file, the address is served without TLS")
+	}
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read web config file: %w", err)
+	}
+	// exporter-toolkit 과 같은 기본값으로 읽는다.
+	config := web.Config{TLSConfig: web.TLSConfig{MinVersion: tls.VersionTLS12, MaxVersion: tls.VersionTLS13}}
+	if err := yaml.UnmarshalStrict(content, &config); err != nil {
+		return fmt.Errorf("failed to parse web config file %v: %w", configPath, err)
+	}
+
+	c := config.TLSConfig
+	switch {
+	case c.TLSCertPath == "" && c.TLSCert == "":
+		return fmt.Errorf("%v does not enable TLS", configPath)
+	case c.MinVersion < tls.VersionTLS12:
+		return fmt.Errorf("%v allows TLS versions before TLS 1.2", configPath)
+	case c.MaxVersion >= tls.VersionTLS13 && !fips140.Enabled():
+		return fmt.Errorf("%v allows TLS 1.3, whose cipher suites are only restricted in FIPS 140 mode (GODEBUG=fips140=on); set max_version to TLS12", configPath)
+	case len(c.CipherSuites) == 0:
+		return fmt.Errorf("%v does not list its cipher_suites", configPath)
+	case len(c.CurvePreferences) == 0:
+		return fmt.Errorf("%v does not list its curve_preferences", configPath)
+	}
+	for _, suite := range c.CipherSuites {
+		if !restrictedCipherSuites[uint16(suite)] {
+			return fmt.Errorf("%v allows the cipher suite %v", configPath, tls.CipherSuiteName(uint16(suite)))
+		}
+	}
+	for _, curve := range c.CurvePreferences {
+		if !restrictedCurves[tls.CurveID(curve)] {
+			return fmt.Errorf("%v allows the curve %v", configPath, tls.CurveID(curve))
+		}
+	}
+	return nil
+}
+
+// checkListenersRestrictedTLS : unix domain socket 을 제외한 listen 주소마다 web config file 을 검사한다. systemd socket
+// activation 의 listener 는 주소를 알 수 없으므로 web.config.file 을 검사한다.
+func checkListenersRestrictedTLS(flags *web.FlagConfig, listenConfigs map[string]string) error {
+	if flags.WebSystemdSocket != nil && *flags.WebSystemdSocket {
+		return checkRestrictedTLS(*flags.WebConfigFile)
+	}
+	for _, address := range *flags.WebListenAddresses {
+		if strings.HasPrefix(address, unixListenPrefix) {
+			continue
+		}
+		if err := checkRestrictedTLS(listenConfig(flags, listenConfigs, address)); err != nil {
+			return fmt.Errorf("listen address %v: %w", address, err)
+		}
+	}
+	return nil
+}
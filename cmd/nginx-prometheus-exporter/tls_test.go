@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/fips140"
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/exporter-toolkit/web"
+)
+
+func TestParseCipherSuites(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		names   []string
+		want    []uint16
+		wantErr bool
+	}{
+		{
+			name: "unset",
+		},
+		{
+			name:  "secure cipher suites",
+			names: []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384", "TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256"},
+			want:  []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384, tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256},
+		},
+		{
+			name:    "insecure cipher suite",
+			names:   []string{"TLS_RSA_WITH_RC4_128_SHA"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown cipher suite",
+			names:   []string{"TLS_NULL"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseCipherSuites(tt.names)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCipherSuites() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCipherSuites() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRestrictedTLS(t *testing.T) {
+	t.Parallel()
+
+	const restricted = `tls_server_config:
+  cert_file: server.crt
+  key_file: server.key
+  max_version: TLS12
+  cipher_suites: [TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384]
+  curve_preferences: [CurveP256, CurveP384]
+`
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{
+			name:   "restricted",
+			config: restricted,
+		},
+		{
+			name:    "plain HTTP",
+			config:  "basic_auth_users:\n  admin: $2a$04$vLFIaJRqemMmT/91.jop5utXFSw0vmw0ifO1dkm/q5vZOALjapKMy\n",
+			wantErr: true,
+		},
+		{
+			name:    "TLS 1.1",
+			config:  restricted + "  min_version: TLS11\n",
+			wantErr: true,
+		},
+		{
+			name:    "TLS 1.3 without FIPS 140 mode",
+			config:  strings.Replace(restricted, "TLS12", "TLS13", 1),
+			wantErr: !fips140.Enabled(),
+		},
+		{
+			name:    "ChaCha20",
+			config:  strings.Replace(restricted, "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256", 1),
+			wantErr: true,
+		},
+		{
+			name:    "default cipher suites",
+			config:  strings.Replace(restricted, "  cipher_suites: [TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384]\n", "", 1),
+			wantErr: true,
+		},
+		{
+			name:    "X25519",
+			config:  strings.Replace(restricted, "CurveP384", "X25519", 1),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "web.yml")
+			if err := os.WriteFile(path, []byte(tt.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := checkRestrictedTLS(path); (err != nil) != tt.wantErr {
+				t.Errorf("checkRestrictedTLS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Unix domain sockets are not checked.
+	addresses := []string{"unix:/run/exporter.sock"}
+	if err := checkListenersRestrictedTLS(&web.FlagConfig{WebListenAddresses: &addresses, WebConfigFile: new(string)}, nil); err != nil {
+		t.Errorf("checkListenersRestrictedTLS() returned error for a unix domain socket: %v", err)
+	}
+}
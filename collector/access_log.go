@@ -0,0 +1,434 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// accessLogPollInterval is the interval at which new lines of the access log are read.
+const accessLogPollInterval = time.Second
+
+// DefaultVhostLimit is the default number of distinct values of the vhost label.
+const DefaultVhostLimit = 100
+
+// otherVhost replaces the virtual hosts beyond the limit of the vhost label.
+const otherVhost = "other"
+
+// cacheStatuses are the values of $upstream_cache_status.
+var cacheStatuses = map[string]bool{
+	"hit":         true,
+	"miss":        true,
+	"bypass":      true,
+	"expired":     true,
+	"stale":       true,
+	"updating":    true,
+	"revalidated": true,
+}
+
+// AccessLogConfig configures an AccessLogCollector.
+type AccessLogConfig struct {
+	// Path is the path of the NGINX access log.
+	Path string
+	// Format is the log_format the access log is written with. DefaultAccessLogFormat is
+	// used when empty.
+	Format string
+	// GeoIPDatabase is the path of a MaxMind GeoLite2 or GeoIP2 Country or City database.
+	// When set, a country label of the client address is added to the request counters.
+	GeoIPDatabase string
+	// GeoIPCountryLimit is the number of distinct values of the country label. Later
+	// countries are counted as "other". DefaultGeoIPCountryLimit is used when zero.
+	GeoIPCountryLimit int
+	// VhostLimit is the number of distinct values of the vhost label. Later virtual hosts
+	// are counted as "other". DefaultVhostLimit is used when zero.
+	VhostLimit int
+	// UserAgentClasses classify $http_user_agent, the first matching class wins.
+	// DefaultUserAgentClasses are used when empty.
+	UserAgentClasses []UserAgentClass
+	// TopPaths is the number of most requested and slowest paths exported for every
+	// window of TopPathsWindow. Disabled when zero.
+	TopPaths int
+	// TopPathsWindow is the window of the top paths. DefaultTopPathsWindow is used when
+	// zero.
+	TopPathsWindow time.Duration
+	// QueueSize is the maximum number of lines read but not yet processed.
+	// DefaultLogQueueSize is used when zero.
+	QueueSize int
+	// MemoryLimit is the maximum number of bytes of the lines read but not yet processed,
+	// and of a single line. Lines beyond it are dropped and counted.
+	// DefaultLogMemoryLimit is used when zero.
+	MemoryLimit int64
+}
+
+// AccessLogCollector follows the NGINX access log in the background and counts its
+// requests. The virtual host of a request is taken from $server_name, $host or
+// $http_host, whichever the log format contains first, and its country from
+// $remote_addr. It implements the prometheus.Collector interface.
+type AccessLogCollector struct {
+	logger          *slog.Logger
+	format          *logFormat
+	tailer          *logTailer
+	queue           *lineQueue
+	countries       *geoIPCountries
+	parseErrors     prometheus.Counter
+	droppedLines    prometheus.Counter
+	requests        *prometheus.CounterVec
+	cacheResults    *prometheus.CounterVec
+	sentBytes       *prometheus.CounterVec
+	receivedBytes   *prometheus.CounterVec
+	tlsRequests     *prometheus.CounterVec
+	userAgents      *prometheus.CounterVec
+	topPaths        *topPaths
+	topRequestsDesc *prometheus.Desc
+	topLatencyDesc  *prometheus.Desc
+	uaClasses       []userAgentClass
+	topRequests     []topPath
+	topLatency      []topPath
+	vhosts          map[string]bool
+	vhostField      string
+	sentField       string
+	topAt           time.Time
+	topWindow       time.Duration
+	vhostLimit      int
+	topMutex        sync.RWMutex
+	timestamps      bool
+}
+
+// NewAccessLogCollector creates an AccessLogCollector. Call Start to follow the access
+// log.
+func NewAccessLogCollector(config AccessLogConfig, opts ...Option) (*AccessLogCollector, error) {
+	o := newOptions(DefaultNamespace, opts)
+
+	formatString := config.Format
+	if formatString == "" {
+		formatString = DefaultAccessLogFormat
+	}
+	format, err := parseLogFormat(formatString)
+	if err != nil {
+		return nil, err
+	}
+
+	uaClasses, err := compileUserAgentClasses(config.UserAgentClasses)
+	if err != nil {
+		return nil, err
+	}
+	var countries *geoIPCountries
+	if config.GeoIPDatabase != "" {
+		if countries, err = openGeoIPCountries(config.GeoIPDatabase, config.GeoIPCountryLimit); err != nil {
+			return nil, err
+		}
+	}
+	c := newAccessLogCollector(config.Path, format, countries, o)
+	c.uaClasses = uaClasses
+	c.setQueueLimits(config.QueueSize, config.MemoryLimit)
+	if config.VhostLimit > 0 {
+		c.vhostLimit = config.VhostLimit
+	}
+	if config.TopPaths > 0 {
+		c.topPaths = newTopPaths(config.TopPaths)
+		c.topWindow = config.TopPathsWindow
+		if c.topWindow <= 0 {
+			c.topWindow = DefaultTopPathsWindow
+		}
+	}
+	if missing := c.missingFields(); len(missing) > 0 {
+		c.logger.Warn("access log format lacks the variables of some metrics, they are empty or not exported",
+			"format", formatString, "missing", strings.Join(missing, ", "))
+	}
+	return c, nil
+}
+
+// missingFields : log format 에 없어 값이 비거나 노출되지 않는 metric 과 그에 필요한 변수를 반환한다.
+func (c *AccessLogCollector) missingFields() []string {
+	type requirement struct {
+		metric string
+		fields []string
+	}
+	required := []requirement{
+		{"vhost label", []string{"server_name", "host", "http_host"}},
+		{"status_class label", []string{"status"}},
+		{"http_cache_requests_total", []string{"upstream_cache_status"}},
+		{"http_response_bytes_total", []string{"bytes_sent", "body_bytes_sent"}},
+		{"http_request_bytes_total", []string{"request_length"}},
+		{"http_ssl_requests_total", []string{"ssl_protocol"}},
+		{"access_log_user_agent_requests_total", []string{"http_user_agent"}},
+	}
+	if c.topPaths != nil {
+		required = append(required,
+			requirement{"access_log_top_path_requests", []string{"uri", "request_uri", "request"}},
+			requirement{"access_log_top_path_latency_p99_seconds", []string{"request_time"}})
+	}
+
+	var missing []string
+	for _, r := range required {
+		if firstLogField(c.format, r.fields...) == "" {
+			missing = append(missing, r.metric+" ($"+strings.Join(r.fields, " or $")+")")
+		}
+	}
+	return missing
+}
+
+func newAccessLogCollector(path string, format *logFormat, countries *geoIPCountries, o options) *AccessLogCollector {
+	// GeoIP 데이터베이스가 있으면 요청 수 counter 에 country label 을 추가한다.
+	requestLabels := func(labels ...string) []string {
+		if countries != nil {
+			labels = append(labels, "country")
+		}
+		return labels
+	}
+
+	c := &AccessLogCollector{
+		logger:    o.logger,
+		format:    format,
+		tailer:    newLogTailer(path),
+		countries: countries,
+		parseErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			Name:        "access_log_parse_errors_total",
+			Help:        "Lines of the access log that did not match the log format",
+			ConstLabels: o.constLabels,
+		}),
+		droppedLines: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			Subsystem:   "exporter",
+			Name:        "log_lines_dropped_total",
+			Help:        "Lines of the access log that were dropped because the lines waiting to be processed exceeded the queue size or memory limit",
+			ConstLabels: o.constLabels,
+		}),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			Name:        "access_log_requests_total",
+			Help:        "Requests of the access log",
+			ConstLabels: o.constLabels,
+		}, requestLabels("vhost", "status_class")),
+		cacheResults: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			Name:        "http_cache_requests_total",
+			Help:        "Requests by $upstream_cache_status of the access log",
+			ConstLabels: o.constLabels,
+		}, requestLabels("vhost", "cache_status")),
+		sentBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			Name:        "http_response_bytes_total",
+			Help:        "Bytes sent to clients by $bytes_sent, or $body_bytes_sent, of the access log",
+			ConstLabels: o.constLabels,
+		}, []string{"vhost", "status_class"}),
+		receivedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			Name:        "http_request_bytes_total",
+			Help:        "Bytes received from clients by $request_length of the access log",
+			ConstLabels: o.constLabels,
+		}, []string{"vhost"}),
+		tlsRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			Name:        "http_ssl_requests_total",
+			Help:        "TLS requests by $ssl_protocol and $ssl_cipher of the access log",
+			ConstLabels: o.constLabels,
+		}, requestLabels("protocol", "cipher")),
+		userAgents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			Name:        "access_log_user_agent_requests_total",
+			Help:        "Requests of the access log by class of $http_user_agent",
+			ConstLabels: o.constLabels,
+		}, []string{"class"}),
+		topRequestsDesc: prometheus.NewDesc(prometheus.BuildFQName(o.namespace, "access_log", "top_path_requests"),
+			"Requests of one of the most requested paths of the access log in the last window",
+			[]string{"path"}, o.constLabels),
+		topLatencyDesc: prometheus.NewDesc(prometheus.BuildFQName(o.namespace, "access_log", "top_path_latency_p99_seconds"),
+			"99th percentile of $request_time of one of the slowest of the tracked paths of the access log in the last window",
+			[]string{"path"}, o.constLabels),
+	}
+	c.timestamps = o.sampleTimestamps
+	c.vhostField = firstLogField(format, "server_name", "host", "http_host")
+	c.vhosts = make(map[string]bool)
+	c.vhostLimit = DefaultVhostLimit
+	c.sentField = firstLogField(format, "bytes_sent", "body_bytes_sent")
+	c.setQueueLimits(0, 0)
+	return c
+}
+
+// setQueueLimits : 읽었지만 아직 처리하지 않은 줄의 수와 크기를 제한한다. 한 줄도 memoryLimit 을 넘을 수 없다.
+func (c *AccessLogCollector) setQueueLimits(size int, memoryLimit int64) {
+	c.queue = newLineQueue(size, memoryLimit, c.droppedLines)
+	c.tailer.maxLineSize = int(c.queue.maxBytes)
+	c.tailer.dropped = c.droppedLines.Inc
+}
+
+// Start follows the access log in the background until ctx is canceled.
+func (c *AccessLogCollector) Start(ctx context.Context) {
+	go c.run(ctx)
+	go c.processQueue(ctx)
+}
+
+// run : access log 를 읽어 큐에 넣는다. 처리가 따라가지 못하면 큐가 한도에 이르러 줄을 버린다.
+func (c *AccessLogCollector) run(ctx context.Context) {
+	ticker := time.NewTicker(accessLogPollInterval)
+	defer ticker.Stop()
+	defer c.tailer.close()
+
+	for {
+		if err := c.tailer.poll(c.queue.push); err != nil {
+			c.logger.Warn("error reading access log", "file", c.tailer.path, "error", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processQueue : 큐의 줄을 처리하고, window 가 끝나면 상위 path 를 교체한다.
+func (c *AccessLogCollector) processQueue(ctx context.Context) {
+	ticker := time.NewTicker(accessLogPollInterval)
+	defer ticker.Stop()
+	if c.countries != nil {
+		defer c.countries.close()
+	}
+
+	windowStart := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case line := <-c.queue.lines:
+			c.process(line)
+			c.queue.done(line)
+		case <-ticker.C:
+			if c.topPaths != nil && time.Since(windowStart) >= c.topWindow {
+				c.rotateTopPaths()
+				windowStart = time.Now()
+			}
+		}
+	}
+}
+
+// process : access log 의 한 줄을 파싱하여 counter 에 반영한다.
+func (c *AccessLogCollector) process(line string) {
+	entry, ok := c.format.parse(line)
+	if !ok {
+		c.parseErrors.Inc()
+		return
+	}
+	vhost := c.vhost(entry.get(c.vhostField))
+	class := statusClass(entry.get("status"))
+	var country string
+	if c.countries != nil {
+		country = c.countries.country(entry.get("remote_addr"))
+	}
+	requestLabels := func(labels ...string) []string {
+		if c.countries != nil {
+			labels = append(labels, country)
+		}
+		return labels
+	}
+
+	c.requests.WithLabelValues(requestLabels(vhost, class)...).Inc()
+	if status := strings.ToLower(entry.get("upstream_cache_status")); cacheStatuses[status] {
+		c.cacheResults.WithLabelValues(requestLabels(vhost, status)...).Inc()
+	}
+	if class != "" {
+		if sent, err := strconv.ParseFloat(entry.get(c.sentField), 64); err == nil {
+			c.sentBytes.WithLabelValues(vhost, class).Add(sent)
+		}
+	}
+	if received, err := strconv.ParseFloat(entry.get("request_length"), 64); err == nil {
+		c.receivedBytes.WithLabelValues(vhost).Add(received)
+	}
+	// 평문 요청의 $ssl_protocol 은 "-" 이다.
+	if protocol := entry.get("ssl_protocol"); protocol != "" {
+		c.tlsRequests.WithLabelValues(requestLabels(protocol, entry.get("ssl_cipher"))...).Inc()
+	}
+	if c.format.has("http_user_agent") {
+		c.userAgents.WithLabelValues(classifyUserAgent(c.uaClasses, entry.get("http_user_agent"))).Inc()
+	}
+	if c.topPaths != nil {
+		if path := requestPath(entry); path != "" {
+			latency, err := strconv.ParseFloat(entry.get("request_time"), 64)
+			if err != nil {
+				latency = -1
+			}
+			c.topPaths.observe(path, latency)
+		}
+	}
+}
+
+// vhost : vhost label 의 값을 반환한다. 처음 vhostLimit 개의 값만 유지하고, 이후 값은 otherVhost 로 센다.
+// $host 는 요청 헤더에서 오므로, 임의의 Host 헤더로 label 의 cardinality 가 커지지 않도록 제한한다.
+func (c *AccessLogCollector) vhost(name string) string {
+	if name == "" || c.vhosts[name] {
+		return name
+	}
+	if len(c.vhosts) >= c.vhostLimit {
+		return otherVhost
+	}
+	c.vhosts[name] = true
+	return name
+}
+
+// rotateTopPaths : window 가 끝나면 상위 path 를 교체한다. 이전 window 의 path series 는 사라진다.
+func (c *AccessLogCollector) rotateTopPaths() {
+	byRequests, byLatency := c.topPaths.rotate()
+	c.topMutex.Lock()
+	c.topRequests, c.topLatency, c.topAt = byRequests, byLatency, time.Now()
+	c.topMutex.Unlock()
+}
+
+// firstLogField returns the first of fields the format contains, or "".
+func firstLogField(format *logFormat, fields ...string) string {
+	for _, field := range fields {
+		if format.has(field) {
+			return field
+		}
+	}
+	return ""
+}
+
+// statusClass returns the class of an HTTP status code, e.g. 2xx for 204, or "" for an
+// invalid status.
+func statusClass(status string) string {
+	if len(status) != 3 || status[0] < '1' || status[0] > '5' {
+		return ""
+	}
+	return status[:1] + "xx"
+}
+
+// Describe sends the descriptors of the access log metrics to the provided channel.
+func (c *AccessLogCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.parseErrors.Describe(ch)
+	c.droppedLines.Describe(ch)
+	c.requests.Describe(ch)
+	c.cacheResults.Describe(ch)
+	c.sentBytes.Describe(ch)
+	c.receivedBytes.Describe(ch)
+	c.tlsRequests.Describe(ch)
+	c.userAgents.Describe(ch)
+	ch <- c.topRequestsDesc
+	ch <- c.topLatencyDesc
+}
+
+// Collect sends the access log metrics to the provided channel.
+func (c *AccessLogCollector) Collect(ch chan<- prometheus.Metric) {
+	c.parseErrors.Collect(ch)
+	c.droppedLines.Collect(ch)
+	c.requests.Collect(ch)
+	c.cacheResults.Collect(ch)
+	c.sentBytes.Collect(ch)
+	c.receivedBytes.Collect(ch)
+	c.tlsRequests.Collect(ch)
+	c.userAgents.Collect(ch)
+
+	c.topMutex.RLock()
+	defer c.topMutex.RUnlock()
+	for _, p := range c.topRequests {
+		ch <- timestamped(c.timestamps, c.topAt, prometheus.MustNewConstMetric(c.topRequestsDesc, prometheus.GaugeValue, p.value, p.path))
+	}
+	for _, p := range c.topLatency {
+		ch <- timestamped(c.timestamps, c.topAt, prometheus.MustNewConstMetric(c.topLatencyDesc, prometheus.GaugeValue, p.value, p.path))
+	}
+}
@@ -0,0 +1,308 @@
+package collector
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestAccessLogCollectorCacheStatus(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewAccessLogCollector(AccessLogConfig{
+		Path:   "/nonexistent",
+		Format: `$host "$request" $status $upstream_cache_status`,
+	}, WithLogger(slog.New(slog.DiscardHandler)))
+	if err != nil {
+		t.Fatalf("NewAccessLogCollector() returned error: %v", err)
+	}
+	for _, line := range []string{
+		`www.example.com "GET / HTTP/1.1" 200 HIT`,
+		`www.example.com "GET / HTTP/1.1" 200 HIT`,
+		`www.example.com "GET /new HTTP/1.1" 200 MISS`,
+		`api.example.com "POST /v1 HTTP/1.1" 200 BYPASS`,
+		`api.example.com "GET /v1 HTTP/1.1" 200 -`,
+		`garbage`,
+	} {
+		c.process(line)
+	}
+
+	expected := `
+# HELP nginx_access_log_parse_errors_total Lines of the access log that did not match the log format
+# TYPE nginx_access_log_parse_errors_total counter
+nginx_access_log_parse_errors_total 1
+# HELP nginx_http_cache_requests_total Requests by $upstream_cache_status of the access log
+# TYPE nginx_http_cache_requests_total counter
+nginx_http_cache_requests_total{cache_status="bypass",vhost="api.example.com"} 1
+nginx_http_cache_requests_total{cache_status="hit",vhost="www.example.com"} 2
+nginx_http_cache_requests_total{cache_status="miss",vhost="www.example.com"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "nginx_access_log_parse_errors_total", "nginx_http_cache_requests_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAccessLogCollectorBytes(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewAccessLogCollector(AccessLogConfig{
+		Path:   "/nonexistent",
+		Format: `$server_name $status $bytes_sent $body_bytes_sent $request_length`,
+	}, WithLogger(slog.New(slog.DiscardHandler)))
+	if err != nil {
+		t.Fatalf("NewAccessLogCollector() returned error: %v", err)
+	}
+	for _, line := range []string{
+		`www.example.com 200 1500 1000 300`,
+		`www.example.com 204 200 0 250`,
+		`www.example.com 404 500 300 100`,
+		`api.example.com 502 400 150 2000`,
+		`api.example.com 000 0 0 -`,
+	} {
+		c.process(line)
+	}
+
+	expected := `
+# HELP nginx_http_request_bytes_total Bytes received from clients by $request_length of the access log
+# TYPE nginx_http_request_bytes_total counter
+nginx_http_request_bytes_total{vhost="api.example.com"} 2000
+nginx_http_request_bytes_total{vhost="www.example.com"} 650
+# HELP nginx_http_response_bytes_total Bytes sent to clients by $bytes_sent, or $body_bytes_sent, of the access log
+# TYPE nginx_http_response_bytes_total counter
+nginx_http_response_bytes_total{status_class="2xx",vhost="www.example.com"} 1700
+nginx_http_response_bytes_total{status_class="4xx",vhost="www.example.com"} 500
+nginx_http_response_bytes_total{status_class="5xx",vhost="api.example.com"} 400
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "nginx_http_request_bytes_total", "nginx_http_response_bytes_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAccessLogCollectorTLS(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewAccessLogCollector(AccessLogConfig{
+		Path:   "/nonexistent",
+		Format: `$host $status $ssl_protocol/$ssl_cipher`,
+	}, WithLogger(slog.New(slog.DiscardHandler)))
+	if err != nil {
+		t.Fatalf("NewAccessLogCollector() returned error: %v", err)
+	}
+	for _, line := range []string{
+		`www.example.com 200 TLSv1.3/TLS_AES_128_GCM_SHA256`,
+		`www.example.com 200 TLSv1.3/TLS_AES_128_GCM_SHA256`,
+		`old.example.com 200 TLSv1/ECDHE-RSA-AES128-SHA`,
+		`www.example.com 301 -/-`,
+	} {
+		c.process(line)
+	}
+
+	expected := `
+# HELP nginx_http_ssl_requests_total TLS requests by $ssl_protocol and $ssl_cipher of the access log
+# TYPE nginx_http_ssl_requests_total counter
+nginx_http_ssl_requests_total{cipher="ECDHE-RSA-AES128-SHA",protocol="TLSv1"} 1
+nginx_http_ssl_requests_total{cipher="TLS_AES_128_GCM_SHA256",protocol="TLSv1.3"} 2
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "nginx_http_ssl_requests_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAccessLogCollectorCountries(t *testing.T) {
+	t.Parallel()
+
+	format, err := parseLogFormat(`$remote_addr $host $status $upstream_cache_status`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	countries := newGeoIPCountries(func(ip net.IP) (string, error) {
+		switch ip.String() {
+		case "1.1.1.1":
+			return "AU", nil
+		case "2.2.2.2":
+			return "FR", nil
+		case "3.3.3.3":
+			return "DE", nil
+		}
+		return "", errors.New("not found")
+	}, nil, 2)
+	c := newAccessLogCollector("/nonexistent", format, countries, newOptions(DefaultNamespace, []Option{WithLogger(slog.New(slog.DiscardHandler))}))
+	for _, line := range []string{
+		`1.1.1.1 www.example.com 200 HIT`,
+		`2.2.2.2 www.example.com 200 MISS`,
+		`3.3.3.3 www.example.com 200 -`,
+		`1.1.1.1 www.example.com 404 -`,
+		`10.0.0.1 www.example.com 200 -`,
+	} {
+		c.process(line)
+	}
+
+	expected := `
+# HELP nginx_access_log_requests_total Requests of the access log
+# TYPE nginx_access_log_requests_total counter
+nginx_access_log_requests_total{country="",status_class="2xx",vhost="www.example.com"} 1
+nginx_access_log_requests_total{country="AU",status_class="2xx",vhost="www.example.com"} 1
+nginx_access_log_requests_total{country="AU",status_class="4xx",vhost="www.example.com"} 1
+nginx_access_log_requests_total{country="FR",status_class="2xx",vhost="www.example.com"} 1
+nginx_access_log_requests_total{country="other",status_class="2xx",vhost="www.example.com"} 1
+# HELP nginx_http_cache_requests_total Requests by $upstream_cache_status of the access log
+# TYPE nginx_http_cache_requests_total counter
+nginx_http_cache_requests_total{cache_status="hit",country="AU",vhost="www.example.com"} 1
+nginx_http_cache_requests_total{cache_status="miss",country="FR",vhost="www.example.com"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "nginx_access_log_requests_total", "nginx_http_cache_requests_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAccessLogCollectorUserAgents(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		classes  []UserAgentClass
+		expected string
+	}{
+		{
+			name: "default classes",
+			expected: `
+nginx_access_log_user_agent_requests_total{class="bot"} 2
+nginx_access_log_user_agent_requests_total{class="browser"} 1
+nginx_access_log_user_agent_requests_total{class="mobile"} 1
+nginx_access_log_user_agent_requests_total{class="monitor"} 1
+nginx_access_log_user_agent_requests_total{class="other"} 2
+`,
+		},
+		{
+			name:    "configured classes",
+			classes: []UserAgentClass{{Name: "search", Regex: `(?i)googlebot|bingbot`}},
+			expected: `
+nginx_access_log_user_agent_requests_total{class="other"} 6
+nginx_access_log_user_agent_requests_total{class="search"} 1
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := NewAccessLogCollector(AccessLogConfig{
+				Path:             "/nonexistent",
+				Format:           `$status "$http_user_agent"`,
+				UserAgentClasses: test.classes,
+			}, WithLogger(slog.New(slog.DiscardHandler)))
+			if err != nil {
+				t.Fatalf("NewAccessLogCollector() returned error: %v", err)
+			}
+			for _, line := range []string{
+				`200 "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"`,
+				`200 "curl/8.5.0"`,
+				`200 "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Safari/537.36"`,
+				`200 "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) Mobile/15E148"`,
+				`200 "Pingdom.com_bot_version_1.4"`,
+				`200 "-"`,
+				`200 "SomethingElse/1.0"`,
+			} {
+				c.process(line)
+			}
+
+			expected := `
+# HELP nginx_access_log_user_agent_requests_total Requests of the access log by class of $http_user_agent
+# TYPE nginx_access_log_user_agent_requests_total counter
+` + test.expected
+			if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "nginx_access_log_user_agent_requests_total"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestAccessLogCollectorTopPaths(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewAccessLogCollector(AccessLogConfig{
+		Path:     "/nonexistent",
+		Format:   `"$request" $status $request_time`,
+		TopPaths: 1,
+	}, WithLogger(slog.New(slog.DiscardHandler)))
+	if err != nil {
+		t.Fatalf("NewAccessLogCollector() returned error: %v", err)
+	}
+	for _, line := range []string{
+		`"GET /api?id=1 HTTP/1.1" 200 0.002`,
+		`"GET /api?id=2 HTTP/1.1" 200 0.003`,
+		`"GET /report HTTP/1.1" 200 1.500`,
+	} {
+		c.process(line)
+	}
+	c.rotateTopPaths()
+
+	expected := `
+# HELP nginx_access_log_top_path_latency_p99_seconds 99th percentile of $request_time of one of the slowest of the tracked paths of the access log in the last window
+# TYPE nginx_access_log_top_path_latency_p99_seconds gauge
+nginx_access_log_top_path_latency_p99_seconds{path="/report"} 2.048
+# HELP nginx_access_log_top_path_requests Requests of one of the most requested paths of the access log in the last window
+# TYPE nginx_access_log_top_path_requests gauge
+nginx_access_log_top_path_requests{path="/api"} 2
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"nginx_access_log_top_path_latency_p99_seconds", "nginx_access_log_top_path_requests"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAccessLogCollectorVhosts(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewAccessLogCollector(AccessLogConfig{
+		Path:       "/nonexistent",
+		Format:     `$host $server_name $status`,
+		VhostLimit: 2,
+	}, WithLogger(slog.New(slog.DiscardHandler)))
+	if err != nil {
+		t.Fatalf("NewAccessLogCollector() returned error: %v", err)
+	}
+	for _, line := range []string{
+		`a.example.com www.example.com 200`,
+		`b.example.com api.example.com 200`,
+		`c.example.com evil.example.com 200`,
+		`d.example.com www.example.com 404`,
+	} {
+		c.process(line)
+	}
+
+	expected := `
+# HELP nginx_access_log_requests_total Requests of the access log
+# TYPE nginx_access_log_requests_total counter
+nginx_access_log_requests_total{status_class="2xx",vhost="api.example.com"} 1
+nginx_access_log_requests_total{status_class="2xx",vhost="other"} 1
+nginx_access_log_requests_total{status_class="2xx",vhost="www.example.com"} 1
+nginx_access_log_requests_total{status_class="4xx",vhost="www.example.com"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "nginx_access_log_requests_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAccessLogCollectorMissingFields(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewAccessLogCollector(AccessLogConfig{Path: "/nonexistent"}, WithLogger(slog.New(slog.DiscardHandler)))
+	if err != nil {
+		t.Fatalf("NewAccessLogCollector() returned error: %v", err)
+	}
+	want := []string{
+		"vhost label ($server_name or $host or $http_host)",
+		"http_cache_requests_total ($upstream_cache_status)",
+		"http_request_bytes_total ($request_length)",
+		"http_ssl_requests_total ($ssl_protocol)",
+	}
+	if got := c.missingFields(); !slices.Equal(got, want) {
+		t.Errorf("missingFields() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,372 @@
+package collector
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultCgroupRoot is the default mount point of the cgroup filesystem.
+const DefaultCgroupRoot = "/sys/fs/cgroup"
+
+// cgroupV1UnlimitedMemory is the smallest memory.limit_in_bytes of cgroup v1 that means
+// no limit. The kernel reports the maximum int64 rounded down to the page size.
+const cgroupV1UnlimitedMemory = 1 << 62
+
+// CgroupCollector collects the CPU and memory usage and limits of the cgroup of the
+// NGINX master process. It supports cgroup v1 and v2 and implements the
+// prometheus.Collector interface.
+type CgroupCollector struct {
+	logger                  *slog.Logger
+	cpuUsageDesc            *prometheus.Desc
+	cpuPeriodsDesc          *prometheus.Desc
+	cpuThrottledPeriodsDesc *prometheus.Desc
+	cpuThrottledDesc        *prometheus.Desc
+	cpuLimitDesc            *prometheus.Desc
+	memoryUsageDesc         *prometheus.Desc
+	memoryLimitDesc         *prometheus.Desc
+	oomKillsDesc            *prometheus.Desc
+	pidFile                 string
+	cgroupRoot              string
+	procRoot                string
+}
+
+// cgroupStats holds the stats of a cgroup. Limits are 0 when the cgroup is unlimited.
+type cgroupStats struct {
+	cpuUsageSeconds     float64
+	cpuPeriods          float64
+	cpuThrottledPeriods float64
+	cpuThrottledSeconds float64
+	cpuLimitCores       float64
+	memoryUsageBytes    float64
+	memoryLimitBytes    float64
+	oomKills            float64
+}
+
+// cgroupPaths holds the directories of the cgroup of a process: the unified
+// hierarchy of cgroup v2 or the per-controller hierarchies of cgroup v1.
+type cgroupPaths struct {
+	unified string
+	cpu     string
+	cpuacct string
+	memory  string
+}
+
+// NewCgroupCollector creates a CgroupCollector for the NGINX master process whose PID
+// is written to pidFile, configured by opts.
+func NewCgroupCollector(pidFile string, opts ...Option) *CgroupCollector {
+	o := newOptions(DefaultNamespace, opts)
+	namespace, constLabels := o.namespace, o.constLabels
+
+	newDesc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "cgroup", name), help, nil, constLabels)
+	}
+	return &CgroupCollector{
+		logger:                  o.logger,
+		cpuUsageDesc:            newDesc("cpu_usage_seconds_total", "CPU time consumed by the cgroup of NGINX"),
+		cpuPeriodsDesc:          newDesc("cpu_periods_total", "Enforcement periods of the CPU limit of the cgroup of NGINX"),
+		cpuThrottledPeriodsDesc: newDesc("cpu_throttled_periods_total", "Enforcement periods in which the cgroup of NGINX was throttled"),
+		cpuThrottledDesc:        newDesc("cpu_throttled_seconds_total", "Time the cgroup of NGINX was throttled for"),
+		cpuLimitDesc:            newDesc("cpu_limit_cores", "CPU limit of the cgroup of NGINX in cores, absent when unlimited"),
+		memoryUsageDesc:         newDesc("memory_usage_bytes", "Memory usage of the cgroup of NGINX, including the page cache"),
+		memoryLimitDesc:         newDesc("memory_limit_bytes", "Memory limit of the cgroup of NGINX, absent when unlimited"),
+		oomKillsDesc:            newDesc("memory_oom_kills_total", "Processes of the cgroup of NGINX killed by the OOM killer"),
+		pidFile:                 pidFile,
+		cgroupRoot:              o.cgroupRoot,
+		procRoot:                "/proc",
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of the cgroup metrics to
+// the provided channel.
+func (c *CgroupCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.cpuUsageDesc
+	ch <- c.cpuPeriodsDesc
+	ch <- c.cpuThrottledPeriodsDesc
+	ch <- c.cpuThrottledDesc
+	ch <- c.cpuLimitDesc
+	ch <- c.memoryUsageDesc
+	ch <- c.memoryLimitDesc
+	ch <- c.oomKillsDesc
+}
+
+// Collect fetches the cgroup stats of NGINX and delivers them as Prometheus metrics.
+// Nothing is collected when NGINX is not running or its cgroup cannot be read.
+func (c *CgroupCollector) Collect(ch chan<- prometheus.Metric) {
+	paths, err := c.cgroupPaths()
+	if err != nil {
+		c.logger.Warn("error finding the cgroup of NGINX", "error", err.Error())
+		return
+	}
+	stats, err := readCgroupStats(paths)
+	if err != nil {
+		c.logger.Warn("error reading the cgroup stats of NGINX", "error", err.Error())
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.cpuUsageDesc, prometheus.CounterValue, stats.cpuUsageSeconds)
+	ch <- prometheus.MustNewConstMetric(c.cpuPeriodsDesc, prometheus.CounterValue, stats.cpuPeriods)
+	ch <- prometheus.MustNewConstMetric(c.cpuThrottledPeriodsDesc, prometheus.CounterValue, stats.cpuThrottledPeriods)
+	ch <- prometheus.MustNewConstMetric(c.cpuThrottledDesc, prometheus.CounterValue, stats.cpuThrottledSeconds)
+	ch <- prometheus.MustNewConstMetric(c.memoryUsageDesc, prometheus.GaugeValue, stats.memoryUsageBytes)
+	ch <- prometheus.MustNewConstMetric(c.oomKillsDesc, prometheus.CounterValue, stats.oomKills)
+	if stats.cpuLimitCores > 0 {
+		ch <- prometheus.MustNewConstMetric(c.cpuLimitDesc, prometheus.GaugeValue, stats.cpuLimitCores)
+	}
+	if stats.memoryLimitBytes > 0 {
+		ch <- prometheus.MustNewConstMetric(c.memoryLimitDesc, prometheus.GaugeValue, stats.memoryLimitBytes)
+	}
+}
+
+// cgroupPaths : PID 파일의 NGINX master 프로세스가 속한 cgroup 디렉터리를 /proc/<pid>/cgroup 에서 찾는다.
+func (c *CgroupCollector) cgroupPaths() (cgroupPaths, error) {
+	content, err := os.ReadFile(c.pidFile)
+	if err != nil {
+		return cgroupPaths{}, fmt.Errorf("failed to read PID file: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return cgroupPaths{}, fmt.Errorf("invalid PID file %v: %w", c.pidFile, err)
+	}
+
+	f, err := os.Open(filepath.Join(c.procRoot, strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return cgroupPaths{}, fmt.Errorf("failed to read the cgroup of PID %d: %w", pid, err)
+	}
+	defer f.Close()
+	return parseProcCgroup(f, c.cgroupRoot)
+}
+
+// parseProcCgroup : /proc/<pid>/cgroup 의 "hierarchy-ID:controllers:path" 줄을 cgroupRoot 아래의 디렉터리로 변환한다.
+// cgroup v1 의 controller 는 cgroupRoot/<controllers> 에, v2 의 unified hierarchy 는 cgroupRoot 에 mount 되어 있다고 가정한다.
+func parseProcCgroup(r io.Reader, cgroupRoot string) (cgroupPaths, error) {
+	var paths cgroupPaths
+	var missing []error
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		controllers, path := fields[1], fields[2]
+		mount := filepath.Join(cgroupRoot, controllers)
+		dir, err := resolveCgroupDir(mount, path)
+		if err != nil {
+			missing = append(missing, err)
+			continue
+		}
+		if controllers == "" {
+			paths.unified = dir
+			continue
+		}
+		for _, controller := range strings.Split(controllers, ",") {
+			switch controller {
+			case "cpu":
+				paths.cpu = dir
+			case "cpuacct":
+				paths.cpuacct = dir
+			case "memory":
+				paths.memory = dir
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return cgroupPaths{}, fmt.Errorf("failed to read cgroup membership: %w", err)
+	}
+	if paths.unified == "" && (paths.cpuacct == "" || paths.memory == "") {
+		if len(missing) > 0 {
+			return cgroupPaths{}, errors.Join(missing...)
+		}
+		return cgroupPaths{}, errors.New("no cgroup v2 hierarchy or cgroup v1 cpuacct and memory controllers found")
+	}
+	return paths, nil
+}
+
+// resolveCgroupDir : mount 지점 아래에서 path 에 해당하는 cgroup 디렉터리를 찾는다. 디렉터리가 없으면 exporter 가 NGINX 의
+// cgroup 을 볼 수 없는 것이므로, mount 지점의 다른 cgroup 을 NGINX 의 것으로 보고하지 않도록 에러를 반환한다.
+func resolveCgroupDir(mount string, path string) (string, error) {
+	dir := filepath.Join(mount, path)
+	if _, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("cgroup %v of NGINX not found: %w", path, err)
+	}
+	return dir, nil
+}
+
+// readCgroupStats reads the stats of cgroup v1 when its cpuacct and memory controllers
+// are mounted and of cgroup v2 otherwise.
+func readCgroupStats(paths cgroupPaths) (cgroupStats, error) {
+	if paths.cpuacct != "" && paths.memory != "" {
+		return readCgroupV1Stats(paths)
+	}
+	return readCgroupV2Stats(paths.unified)
+}
+
+func readCgroupV2Stats(dir string) (cgroupStats, error) {
+	var stats cgroupStats
+
+	cpuStat, err := readCgroupKeyValues(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return cgroupStats{}, err
+	}
+	stats.cpuUsageSeconds = float64(cpuStat["usage_usec"]) / 1e6
+	stats.cpuPeriods = float64(cpuStat["nr_periods"])
+	stats.cpuThrottledPeriods = float64(cpuStat["nr_throttled"])
+	stats.cpuThrottledSeconds = float64(cpuStat["throttled_usec"]) / 1e6
+
+	// cpu.max 는 "<quota> <period>" 형식이며, 제한이 없으면 quota 가 max 이다. cpu controller 가 없으면 파일도 없다.
+	if cpuMax, err := readSysFile(filepath.Join(dir, "cpu.max")); err == nil {
+		if quota, period, ok := strings.Cut(cpuMax, " "); ok && quota != "max" {
+			stats.cpuLimitCores, err = cgroupRatio(quota, period)
+			if err != nil {
+				return cgroupStats{}, fmt.Errorf("invalid cpu.max %q: %w", cpuMax, err)
+			}
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return cgroupStats{}, err
+	}
+
+	memoryCurrent, err := readSysUint(filepath.Join(dir, "memory.current"))
+	if err != nil {
+		return cgroupStats{}, err
+	}
+	stats.memoryUsageBytes = float64(memoryCurrent)
+
+	if memoryMax, err := readSysFile(filepath.Join(dir, "memory.max")); err == nil {
+		if memoryMax != "max" {
+			limit, err := strconv.ParseUint(memoryMax, 10, 64)
+			if err != nil {
+				return cgroupStats{}, fmt.Errorf("invalid memory.max %q: %w", memoryMax, err)
+			}
+			stats.memoryLimitBytes = float64(limit)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return cgroupStats{}, err
+	}
+
+	if events, err := readCgroupKeyValues(filepath.Join(dir, "memory.events")); err == nil {
+		stats.oomKills = float64(events["oom_kill"])
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return cgroupStats{}, err
+	}
+	return stats, nil
+}
+
+func readCgroupV1Stats(paths cgroupPaths) (cgroupStats, error) {
+	var stats cgroupStats
+
+	usage, err := readSysUint(filepath.Join(paths.cpuacct, "cpuacct.usage"))
+	if err != nil {
+		return cgroupStats{}, err
+	}
+	stats.cpuUsageSeconds = float64(usage) / 1e9
+
+	if paths.cpu != "" {
+		cpuStat, err := readCgroupKeyValues(filepath.Join(paths.cpu, "cpu.stat"))
+		if err != nil {
+			return cgroupStats{}, err
+		}
+		stats.cpuPeriods = float64(cpuStat["nr_periods"])
+		stats.cpuThrottledPeriods = float64(cpuStat["nr_throttled"])
+		stats.cpuThrottledSeconds = float64(cpuStat["throttled_time"]) / 1e9
+
+		// cpu.cfs_quota_us 는 제한이 없으면 -1 이다.
+		quota, err := readSysFile(filepath.Join(paths.cpu, "cpu.cfs_quota_us"))
+		if err != nil {
+			return cgroupStats{}, err
+		}
+		if quota != "-1" {
+			period, err := readSysFile(filepath.Join(paths.cpu, "cpu.cfs_period_us"))
+			if err != nil {
+				return cgroupStats{}, err
+			}
+			stats.cpuLimitCores, err = cgroupRatio(quota, period)
+			if err != nil {
+				return cgroupStats{}, fmt.Errorf("invalid CFS quota %q and period %q: %w", quota, period, err)
+			}
+		}
+	}
+
+	memoryUsage, err := readSysUint(filepath.Join(paths.memory, "memory.usage_in_bytes"))
+	if err != nil {
+		return cgroupStats{}, err
+	}
+	stats.memoryUsageBytes = float64(memoryUsage)
+
+	memoryLimit, err := readSysUint(filepath.Join(paths.memory, "memory.limit_in_bytes"))
+	if err != nil {
+		return cgroupStats{}, err
+	}
+	if memoryLimit < cgroupV1UnlimitedMemory {
+		stats.memoryLimitBytes = float64(memoryLimit)
+	}
+
+	// oom_kill 은 Linux 4.13 부터 memory.oom_control 에 보고된다.
+	oomControl, err := readCgroupKeyValues(filepath.Join(paths.memory, "memory.oom_control"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return cgroupStats{}, err
+	}
+	stats.oomKills = float64(oomControl["oom_kill"])
+	return stats, nil
+}
+
+// readSysFile reads a single value file of /proc or /sys.
+func readSysFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func readSysUint(path string) (uint64, error) {
+	content, err := readSysFile(path)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseUint(content, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value of %v: %w", path, err)
+	}
+	return value, nil
+}
+
+// readCgroupKeyValues reads a flat keyed file such as cpu.stat with a "<key> <value>"
+// line per value. Lines whose value is not an unsigned integer are skipped.
+func readCgroupKeyValues(path string) (map[string]uint64, error) {
+	content, err := readSysFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]uint64)
+	for _, line := range strings.Split(content, "\n") {
+		key, value, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		if v, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64); err == nil {
+			values[key] = v
+		}
+	}
+	return values, nil
+}
+
+func cgroupRatio(quota string, period string) (float64, error) {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quota: %w", err)
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 {
+		return 0, fmt.Errorf("invalid period %q", period)
+	}
+	return q / p, nil
+}
@@ -0,0 +1,148 @@
+package collector
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func writeCgroupFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCgroupCollector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		files map[string]string
+		name  string
+		want  string
+	}{
+		{
+			name: "cgroup v2",
+			files: map[string]string{
+				"proc/42/cgroup": "0::/system.slice/nginx.service\n",
+				"cgroup/system.slice/nginx.service/cpu.stat": "usage_usec 2500000\nuser_usec 2000000\nsystem_usec 500000\n" +
+					"nr_periods 100\nnr_throttled 7\nthrottled_usec 350000\n",
+				"cgroup/system.slice/nginx.service/cpu.max":        "150000 100000\n",
+				"cgroup/system.slice/nginx.service/memory.current": "104857600\n",
+				"cgroup/system.slice/nginx.service/memory.max":     "max\n",
+				"cgroup/system.slice/nginx.service/memory.events":  "low 0\nhigh 0\nmax 3\noom 1\noom_kill 1\n",
+			},
+			want: `
+# HELP nginx_cgroup_cpu_limit_cores CPU limit of the cgroup of NGINX in cores, absent when unlimited
+# TYPE nginx_cgroup_cpu_limit_cores gauge
+nginx_cgroup_cpu_limit_cores 1.5
+# HELP nginx_cgroup_cpu_throttled_periods_total Enforcement periods in which the cgroup of NGINX was throttled
+# TYPE nginx_cgroup_cpu_throttled_periods_total counter
+nginx_cgroup_cpu_throttled_periods_total 7
+# HELP nginx_cgroup_cpu_throttled_seconds_total Time the cgroup of NGINX was throttled for
+# TYPE nginx_cgroup_cpu_throttled_seconds_total counter
+nginx_cgroup_cpu_throttled_seconds_total 0.35
+# HELP nginx_cgroup_cpu_usage_seconds_total CPU time consumed by the cgroup of NGINX
+# TYPE nginx_cgroup_cpu_usage_seconds_total counter
+nginx_cgroup_cpu_usage_seconds_total 2.5
+# HELP nginx_cgroup_memory_limit_bytes Memory limit of the cgroup of NGINX, absent when unlimited
+# TYPE nginx_cgroup_memory_limit_bytes gauge
+# HELP nginx_cgroup_memory_oom_kills_total Processes of the cgroup of NGINX killed by the OOM killer
+# TYPE nginx_cgroup_memory_oom_kills_total counter
+nginx_cgroup_memory_oom_kills_total 1
+# HELP nginx_cgroup_memory_usage_bytes Memory usage of the cgroup of NGINX, including the page cache
+# TYPE nginx_cgroup_memory_usage_bytes gauge
+nginx_cgroup_memory_usage_bytes 1.048576e+08
+`,
+		},
+		{
+			name: "cgroup v1",
+			files: map[string]string{
+				"proc/42/cgroup": "12:memory:/docker/abc\n4:cpu,cpuacct:/docker/abc\n0::/\n",
+				"cgroup/cpu,cpuacct/docker/abc/cpuacct.usage":     "3000000000\n",
+				"cgroup/cpu,cpuacct/docker/abc/cpu.stat":          "nr_periods 10\nnr_throttled 2\nthrottled_time 1000000000\n",
+				"cgroup/cpu,cpuacct/docker/abc/cpu.cfs_quota_us":  "-1\n",
+				"cgroup/cpu,cpuacct/docker/abc/cpu.cfs_period_us": "100000\n",
+				"cgroup/memory/docker/abc/memory.usage_in_bytes":  "2048\n",
+				"cgroup/memory/docker/abc/memory.limit_in_bytes":  "4096\n",
+				"cgroup/memory/docker/abc/memory.oom_control":     "oom_kill_disable 0\nunder_oom 0\noom_kill 0\n",
+			},
+			want: `
+# HELP nginx_cgroup_cpu_limit_cores CPU limit of the cgroup of NGINX in cores, absent when unlimited
+# TYPE nginx_cgroup_cpu_limit_cores gauge
+# HELP nginx_cgroup_cpu_throttled_periods_total Enforcement periods in which the cgroup of NGINX was throttled
+# TYPE nginx_cgroup_cpu_throttled_periods_total counter
+nginx_cgroup_cpu_throttled_periods_total 2
+# HELP nginx_cgroup_cpu_throttled_seconds_total Time the cgroup of NGINX was throttled for
+# TYPE nginx_cgroup_cpu_throttled_seconds_total counter
+nginx_cgroup_cpu_throttled_seconds_total 1
+# HELP nginx_cgroup_cpu_usage_seconds_total CPU time consumed by the cgroup of NGINX
+# TYPE nginx_cgroup_cpu_usage_seconds_total counter
+nginx_cgroup_cpu_usage_seconds_total 3
+# HELP nginx_cgroup_memory_limit_bytes Memory limit of the cgroup of NGINX, absent when unlimited
+# TYPE nginx_cgroup_memory_limit_bytes gauge
+nginx_cgroup_memory_limit_bytes 4096
+# HELP nginx_cgroup_memory_oom_kills_total Processes of the cgroup of NGINX killed by the OOM killer
+# TYPE nginx_cgroup_memory_oom_kills_total counter
+nginx_cgroup_memory_oom_kills_total 0
+# HELP nginx_cgroup_memory_usage_bytes Memory usage of the cgroup of NGINX, including the page cache
+# TYPE nginx_cgroup_memory_usage_bytes gauge
+nginx_cgroup_memory_usage_bytes 2048
+`,
+		},
+		{
+			// The cgroup root of another cgroup namespace is not the cgroup of NGINX.
+			name: "cgroup path not found",
+			files: map[string]string{
+				"proc/42/cgroup":        "0::/system.slice/nginx.service\n",
+				"cgroup/cpu.stat":       "usage_usec 2500000\n",
+				"cgroup/memory.current": "104857600\n",
+			},
+			want: `
+# HELP nginx_cgroup_cpu_usage_seconds_total CPU time consumed by the cgroup of NGINX
+# TYPE nginx_cgroup_cpu_usage_seconds_total counter
+`,
+		},
+		{
+			name: "missing cgroup",
+			files: map[string]string{
+				"proc/42/cgroup": "0::/\n",
+			},
+			want: `
+# HELP nginx_cgroup_cpu_usage_seconds_total CPU time consumed by the cgroup of NGINX
+# TYPE nginx_cgroup_cpu_usage_seconds_total counter
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			tt.files["nginx.pid"] = "42\n"
+			writeCgroupFiles(t, dir, tt.files)
+
+			c := NewCgroupCollector(filepath.Join(dir, "nginx.pid"),
+				WithCgroupRoot(filepath.Join(dir, "cgroup")),
+				WithLogger(slog.New(slog.DiscardHandler)),
+			)
+			c.procRoot = filepath.Join(dir, "proc")
+			if err := testutil.CollectAndCompare(c, strings.NewReader(tt.want), "nginx_cgroup_cpu_usage_seconds_total",
+				"nginx_cgroup_cpu_throttled_periods_total", "nginx_cgroup_cpu_throttled_seconds_total", "nginx_cgroup_cpu_limit_cores",
+				"nginx_cgroup_memory_usage_bytes", "nginx_cgroup_memory_limit_bytes", "nginx_cgroup_memory_oom_kills_total",
+			); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
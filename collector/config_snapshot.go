@@ -0,0 +1,191 @@
+package collector
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// configSnapshot holds what the NginxCollector derives from the NGINX config files: the
+// config metrics and the proxy targets of every health-checked file.
+type configSnapshot struct {
+	// cfg is the parsed config, local or of the remote config source. It is nil when the
+	// config cannot be parsed.
+	cfg     *nginxconf.Config
+	metrics []prometheus.Metric
+	files   []configFileTargets
+}
+
+// configFileTargets are the proxy targets of a health-checked config file.
+type configFileTargets struct {
+	tlsTargets map[string]bool
+	file       string
+	targets    []string
+}
+
+// currentConfigSnapshot : configRefreshInterval 이 없으면 설정을 매번 새로 읽고, 있으면 background 에서 마지막으로 읽은
+// snapshot 을 반환한다. 처음 호출 시 설정을 읽은 뒤 background 갱신을 시작한다.
+func (c *NginxCollector) currentConfigSnapshot() (*configSnapshot, bool) {
+	if c.configRefreshInterval <= 0 {
+		snapshot, err := c.loadConfigSnapshot()
+		if err != nil {
+			c.logger.Warn("error loading nginx config source", "source", c.configSource, "error", err.Error())
+			return nil, false
+		}
+		return snapshot, true
+	}
+
+	c.configRefresh.Do(func() {
+		c.refreshConfigSnapshot()
+		go c.runConfigRefresh()
+	})
+	c.snapshotMutex.Lock()
+	defer c.snapshotMutex.Unlock()
+	return c.snapshot, c.snapshot != nil
+}
+
+func (c *NginxCollector) runConfigRefresh() {
+	ticker := time.NewTicker(c.configRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.refreshConfigSnapshot()
+		case <-c.stopped:
+			return
+		}
+	}
+}
+
+// refreshConfigSnapshot : 설정을 다시 읽어 snapshot 을 교체한다. 원격 설정 소스를 읽지 못하면 이전 snapshot 을 유지한다.
+func (c *NginxCollector) refreshConfigSnapshot() {
+	snapshot, err := c.loadConfigSnapshot()
+	if err != nil {
+		c.logger.Warn("error loading nginx config source", "source", c.configSource, "error", err.Error())
+		return
+	}
+	c.snapshotMutex.Lock()
+	c.snapshot = snapshot
+	c.snapshotMutex.Unlock()
+}
+
+// loadConfigSnapshot : 설정 파일(원격 설정 소스이면 그 파일들)을 읽고 해석하여 config 메트릭과 health check 대상을 만든다.
+// 원격 설정 소스를 읽지 못한 경우에만 에러를 반환한다.
+func (c *NginxCollector) loadConfigSnapshot() (*configSnapshot, error) {
+	if c.configSource != "" {
+		return c.loadRemoteConfigSnapshot()
+	}
+
+	snapshot := &configSnapshot{}
+	snapshot.metrics = gatherMetrics(func(ch chan<- prometheus.Metric) {
+		snapshot.cfg = c.collectConfigMetrics(ch, nginxconf.Parser{}, c.nginxConfigPath)
+		if snapshot.cfg != nil {
+			snapshot.files = healthCheckTargets(snapshot.cfg, c.nginxConfigPath)
+		}
+
+		files := []string{c.nginxConfigPath}                                 // []string{"/home1/irteam/apps/nginx/nginx.conf"}
+		confdDir := filepath.Join(filepath.Dir(c.nginxConfigPath), "conf.d") // "/home1/irteam/apps/nginx/conf.d"
+		// 순회 하면서 files slice에 추가.
+		_ = filepath.WalkDir(confdDir, func(path string, dir fs.DirEntry, err error) error {
+			if err == nil && !dir.IsDir() {
+				files = append(files, path)
+			}
+			return nil
+		})
+
+		seenLabels := make(map[string]bool)
+		for _, f := range files {
+			info, err := os.Stat(f)
+			if err != nil || !strings.HasSuffix(info.Name(), ".conf") {
+				c.logger.Warn("skip config file", "file", f, "err", err)
+				continue
+			}
+
+			// symlink 로 연결된 동일 파일은 한 번만 전송한다.
+			label := c.fileLabel(f)
+			if seenLabels[label] {
+				continue
+			}
+			seenLabels[label] = true
+
+			// 파일의 마지막 수정 시각을 Unix timestamp로 치환하여 메트릭으로 전송
+			ch <- prometheus.MustNewConstMetric(
+				c.configModDesc,
+				prometheus.GaugeValue,
+				float64(info.ModTime().Unix()),
+				label,
+			)
+		}
+	})
+	return snapshot, nil
+}
+
+// loadRemoteConfigSnapshot : 원격 설정 소스에서 읽은 설정으로 config 메트릭과 health check 대상을 만든다.
+func (c *NginxCollector) loadRemoteConfigSnapshot() (*configSnapshot, error) {
+	ctx := context.Background()
+	if c.configSourceTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.configSourceTimeout)
+		defer cancel()
+	}
+	files, mainPath, err := loadConfigSource(ctx, c.configSourceClient, c.configSource, c.nginxConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make(map[string][]byte, len(files))
+	for name, f := range files {
+		contents[name] = f.content
+	}
+
+	// 로컬과 동일하게 메인 설정 파일과 conf.d 아래의 파일들을 대상으로 한다.
+	var names []string
+	for name := range files {
+		if isHealthCheckedFile(mainPath, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	snapshot := &configSnapshot{}
+	snapshot.metrics = gatherMetrics(func(ch chan<- prometheus.Metric) {
+		snapshot.cfg = c.collectConfigMetrics(ch, nginxconf.Parser{Files: contents}, mainPath)
+		if snapshot.cfg != nil {
+			snapshot.files = healthCheckTargets(snapshot.cfg, mainPath)
+		}
+
+		for _, name := range names {
+			f := files[name]
+
+			// nginx -T 출력에는 수정 시각이 없으므로 tarball 인 경우에만 전송한다.
+			if !f.modTime.IsZero() {
+				ch <- prometheus.MustNewConstMetric(c.configModDesc, prometheus.GaugeValue, float64(f.modTime.Unix()), c.fileLabel(name))
+			}
+		}
+	})
+	return snapshot, nil
+}
+
+// gatherMetrics : collect 가 전송한 메트릭을 모아 반환한다.
+func gatherMetrics(collect func(ch chan<- prometheus.Metric)) []prometheus.Metric {
+	ch := make(chan prometheus.Metric)
+	done := make(chan []prometheus.Metric)
+	go func() {
+		var metrics []prometheus.Metric
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+		done <- metrics
+	}()
+	collect(ch)
+	close(ch)
+	return <-done
+}
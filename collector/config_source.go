@@ -0,0 +1,141 @@
+package collector
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// dumpFileHeader prefixes the name of every file in the nginx -T output.
+const dumpFileHeader = "# configuration file "
+
+// configFile is a configuration file loaded from a remote config source.
+type configFile struct {
+	modTime time.Time
+	content []byte
+}
+
+// loadConfigSource : 원격 설정 소스로부터 설정 파일들을 읽어 메인 설정 파일 경로와 함께 반환한다.
+// http(s) URL 은 nginx -T 출력으로, 그 외에는 설정 디렉토리의 tarball 경로로 간주한다.
+func loadConfigSource(ctx context.Context, httpClient *http.Client, source string, configPath string) (map[string]configFile, string, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to create request to config source: %w", err)
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get config source %v: %w", source, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, "", fmt.Errorf("expected %v response from config source %v, got %v", http.StatusOK, source, resp.StatusCode)
+		}
+		return parseConfigDump(resp.Body)
+	}
+
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open config source: %w", err)
+	}
+	defer f.Close()
+
+	files, err := readConfigTarball(f, filepath.Dir(configPath))
+	if err != nil {
+		return nil, "", fmt.Errorf("%v: %w", source, err)
+	}
+	if _, ok := files[filepath.Clean(configPath)]; !ok {
+		return nil, "", fmt.Errorf("%v: %v not found in the tarball", source, filepath.Base(configPath))
+	}
+	return files, filepath.Clean(configPath), nil
+}
+
+// parseConfigDump : nginx -T 출력을 파일별로 나눈다. 첫 번째 파일이 메인 설정 파일이다.
+func parseConfigDump(r io.Reader) (map[string]configFile, string, error) {
+	files := make(map[string]configFile)
+	var main, current string
+	var content bytes.Buffer
+
+	flush := func() {
+		if current != "" {
+			files[current] = configFile{content: bytes.Clone(content.Bytes())}
+		}
+		content.Reset()
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, dumpFileHeader) && strings.HasSuffix(line, ":") {
+			flush()
+			current = filepath.Clean(strings.TrimSuffix(strings.TrimPrefix(line, dumpFileHeader), ":"))
+			if main == "" {
+				main = current
+			}
+			continue
+		}
+		// 첫 번째 파일 이전의 "syntax is ok" 같은 출력은 무시한다.
+		if current != "" {
+			content.WriteString(line)
+			content.WriteByte('\n')
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to read nginx -T output: %w", err)
+	}
+	flush()
+
+	if main == "" {
+		return nil, "", errors.New("no configuration files found in nginx -T output")
+	}
+	return files, main, nil
+}
+
+// readConfigTarball : (gzip 압축된) tarball 의 파일들을 root 디렉토리 아래에 있는 것으로 간주하여 읽는다.
+func readConfigTarball(r io.Reader, root string) (map[string]configFile, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip header: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	files := make(map[string]configFile)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tarball: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %v from tarball: %w", hdr.Name, err)
+		}
+		// ".." 로 root 밖을 가리키는 항목도 root 아래로 제한된다.
+		name := filepath.Join(root, filepath.Clean("/"+hdr.Name))
+		files[name] = configFile{content: content, modTime: hdr.ModTime}
+	}
+	return files, nil
+}
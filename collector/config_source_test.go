@@ -0,0 +1,118 @@
+package collector
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nginx/nginx-prometheus-exporter/client"
+)
+
+func TestParseConfigDump(t *testing.T) {
+	t.Parallel()
+
+	dump := `nginx: the configuration file /etc/nginx/nginx.conf syntax is ok
+# configuration file /etc/nginx/nginx.conf:
+http {
+    include conf.d/*.conf;
+}
+
+# configuration file /etc/nginx/conf.d/app.conf:
+server { listen 80; }
+
+`
+	files, main, err := parseConfigDump(strings.NewReader(dump))
+	if err != nil {
+		t.Fatalf("parseConfigDump() returned error: %v", err)
+	}
+	if main != "/etc/nginx/nginx.conf" {
+		t.Errorf("parseConfigDump() main = %v, want /etc/nginx/nginx.conf", main)
+	}
+	want := map[string]string{
+		"/etc/nginx/nginx.conf":      "http {\n    include conf.d/*.conf;\n}\n\n",
+		"/etc/nginx/conf.d/app.conf": "server { listen 80; }\n\n",
+	}
+	got := make(map[string]string)
+	for name, f := range files {
+		got[name] = string(f.content)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseConfigDump() files = %q, want %q", got, want)
+	}
+
+	if _, _, err := parseConfigDump(strings.NewReader("nginx: [emerg] unknown directive\n")); err == nil {
+		t.Error("parseConfigDump() did not return an error for output without configuration files")
+	}
+}
+
+func TestReadConfigTarball(t *testing.T) {
+	t.Parallel()
+
+	modTime := time.Unix(1700000000, 0)
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, hdr := range []*tar.Header{
+		{Name: "./", Typeflag: tar.TypeDir, Mode: 0o755},
+		{Name: "./nginx.conf", Typeflag: tar.TypeReg, Mode: 0o644, Size: 8, ModTime: modTime},
+		{Name: "../../escape.conf", Typeflag: tar.TypeReg, Mode: 0o644, Size: 8, ModTime: modTime},
+	} {
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte("events{}")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	root := filepath.FromSlash("/etc/nginx")
+	files, err := readConfigTarball(&buf, root)
+	if err != nil {
+		t.Fatalf("readConfigTarball() returned error: %v", err)
+	}
+
+	var names []string
+	for name, f := range files {
+		names = append(names, name)
+		if string(f.content) != "events{}" || !f.modTime.Equal(modTime) {
+			t.Errorf("readConfigTarball() %v = %q modified at %v, want %q modified at %v", name, f.content, f.modTime, "events{}", modTime)
+		}
+	}
+	if len(names) != 2 || files[filepath.Join(root, "nginx.conf")].content == nil || files[filepath.Join(root, "escape.conf")].content == nil {
+		t.Errorf("readConfigTarball() files = %v, want nginx.conf and escape.conf under %v", names, root)
+	}
+}
+
+func TestLoadRemoteConfigSnapshotDefaultClient(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("# configuration file /etc/nginx/nginx.conf:\nevents {}\n"))
+	}))
+	defer server.Close()
+
+	c := NewNginxCollector(fakeStubStatsClient{stats: &client.StubStats{}},
+		WithLogger(slog.New(slog.DiscardHandler)),
+		WithConfigPath("/etc/nginx/nginx.conf"),
+		WithConfigSource(server.URL, nil, time.Second))
+
+	if _, err := c.loadRemoteConfigSnapshot(); err != nil {
+		t.Fatalf("loadRemoteConfigSnapshot() returned error: %v", err)
+	}
+}
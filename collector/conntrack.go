@@ -0,0 +1,88 @@
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collectConntrack : netfilter conntrack table 의 entry 수와 최대값, proxy target 으로 향하는 entry 수를 전송한다.
+// conntrack 이 없는 host 에서는 로그만 남긴다.
+func (c *NginxCollector) collectConntrack(ch chan<- prometheus.Metric, targets []resolvedTarget) {
+	netfilterDir := filepath.Join(c.procRoot, "sys", "net", "netfilter")
+	count, err := readSysUint(filepath.Join(netfilterDir, "nf_conntrack_count"))
+	if err != nil {
+		c.logger.Warn("error reading conntrack table size", "error", err.Error())
+		return
+	}
+	limit, err := readSysUint(filepath.Join(netfilterDir, "nf_conntrack_max"))
+	if err != nil {
+		c.logger.Warn("error reading conntrack table size", "error", err.Error())
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.conntrackEntriesDesc, prometheus.GaugeValue, float64(count))
+	ch <- prometheus.MustNewConstMetric(c.conntrackLimitDesc, prometheus.GaugeValue, float64(limit))
+
+	if len(targets) == 0 {
+		return
+	}
+	f, err := os.Open(filepath.Join(c.procRoot, "net", "nf_conntrack"))
+	if err != nil {
+		c.logger.Warn("error reading conntrack entries", "error", err.Error())
+		return
+	}
+	defer f.Close()
+	entries, err := parseConntrackEntries(f)
+	if err != nil {
+		c.logger.Warn("error reading conntrack entries", "error", err.Error())
+		return
+	}
+	for _, t := range targets {
+		var count float64
+		for _, remote := range t.remotes {
+			count += entries[remote]
+		}
+		ch <- prometheus.MustNewConstMetric(c.upstreamConntrackEntriesDesc, prometheus.GaugeValue, count, t.target)
+	}
+}
+
+// parseConntrackEntries counts the entries of /proc/net/nf_conntrack by the destination
+// of their original direction, the first dst= and dport= of a line.
+func parseConntrackEntries(r io.Reader) (map[netip.AddrPort]float64, error) {
+	entries := make(map[netip.AddrPort]float64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var dst, dport string
+		for _, field := range strings.Fields(scanner.Text()) {
+			if dst == "" && strings.HasPrefix(field, "dst=") {
+				dst = strings.TrimPrefix(field, "dst=")
+			} else if dport == "" && strings.HasPrefix(field, "dport=") {
+				dport = strings.TrimPrefix(field, "dport=")
+			}
+			if dst != "" && dport != "" {
+				break
+			}
+		}
+		// ICMP 처럼 port 가 없는 entry 는 proxy target 과 관계가 없다.
+		addr, err := netip.ParseAddr(dst)
+		if err != nil {
+			continue
+		}
+		port, err := strconv.ParseUint(dport, 10, 16)
+		if err != nil {
+			continue
+		}
+		entries[netip.AddrPortFrom(addr.Unmap(), uint16(port))]++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read conntrack entries: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nginx/nginx-prometheus-exporter/client"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNginxCollectorConntrack(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"nginx.conf":    "http { include conf.d/*.conf; }\n",
+		"conf.d/a.conf": "server { location / { proxy_pass http://10.0.0.1:8080; } }\n",
+		"proc/sys/net/netfilter/nf_conntrack_count": "5\n",
+		"proc/sys/net/netfilter/nf_conntrack_max":   "262144\n",
+		"proc/net/nf_conntrack": "ipv4     2 tcp      6 431999 ESTABLISHED src=10.0.0.100 dst=10.0.0.1 sport=43210 dport=8080 " +
+			"src=10.0.0.1 dst=10.0.0.100 sport=8080 dport=43210 [ASSURED] mark=0 zone=0 use=2\n" +
+			"ipv4     2 tcp      6 117 TIME_WAIT src=10.0.0.100 dst=10.0.0.1 sport=43211 dport=8080 " +
+			"src=10.0.0.1 dst=10.0.0.100 sport=8080 dport=43211 [ASSURED] mark=0 zone=0 use=2\n" +
+			"ipv4     2 tcp      6 117 TIME_WAIT src=10.0.0.1 dst=10.0.0.100 sport=8080 dport=43212 " +
+			"src=10.0.0.100 dst=10.0.0.1 sport=43212 dport=8080 [ASSURED] mark=0 zone=0 use=2\n" +
+			"ipv4     2 icmp     1 29 src=10.0.0.100 dst=10.0.0.1 type=8 code=0 id=1 src=10.0.0.1 dst=10.0.0.100 type=0 code=0 id=1 mark=0 use=2\n" +
+			"ipv6     10 tcp      6 431999 ESTABLISHED src=2001:db8::100 dst=2001:db8::1 sport=43210 dport=443 " +
+			"src=2001:db8::1 dst=2001:db8::100 sport=443 dport=43210 [ASSURED] mark=0 zone=0 use=2\n",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := NewNginxCollector(fakeStubStatsClient{stats: &client.StubStats{}},
+		WithLogger(slog.New(slog.DiscardHandler)),
+		WithConfigPath(filepath.Join(dir, "nginx.conf")),
+		WithConntrack(true),
+		WithHealthChecks(HealthCheckConfig{Dial: func(context.Context, string, string) (net.Conn, error) {
+			return nil, errors.New("connection refused")
+		}}),
+	)
+	c.procRoot = filepath.Join(dir, "proc")
+
+	expected := `
+# HELP nginx_conntrack_entries Entries of the netfilter connection tracking table of the host
+# TYPE nginx_conntrack_entries gauge
+nginx_conntrack_entries 5
+# HELP nginx_conntrack_entries_limit Maximum number of entries of the netfilter connection tracking table of the host
+# TYPE nginx_conntrack_entries_limit gauge
+nginx_conntrack_entries_limit 262144
+# HELP nginx_upstream_conntrack_entries Netfilter connection tracking entries toward proxy targets
+# TYPE nginx_upstream_conntrack_entries gauge
+nginx_upstream_conntrack_entries{target="10.0.0.1:8080"} 2
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"nginx_conntrack_entries", "nginx_conntrack_entries_limit", "nginx_upstream_conntrack_entries",
+	); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,214 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// resolvConfPath is the resolver configuration the DNS cache reads its nameservers from.
+const resolvConfPath = "/etc/resolv.conf"
+
+// dnsQueryTimeout bounds a single DNS query of the DNS cache.
+const dnsQueryTimeout = 2 * time.Second
+
+type dnsCacheEntry struct {
+	expires time.Time
+	addrs   []string
+}
+
+// dnsCache resolves host names for health checks and caches the addresses for the
+// TTL of the DNS records, capped by maxTTL.
+type dnsCache struct {
+	entries map[string]dnsCacheEntry
+	lookup  func(ctx context.Context, host string) ([]string, time.Duration, error)
+	now     func() time.Time
+	maxTTL  time.Duration
+	mutex   sync.Mutex
+}
+
+// NewCachingDialer returns a dialer that resolves host names through a DNS cache
+// honoring record TTLs, capped by maxTTL, and dials the resolved addresses with dial.
+// Host names with IPv4 and IPv6 addresses are dialed over both families in the Happy
+// Eyeballs fashion of net.Dialer. A nil dial uses net.Dialer.
+func NewCachingDialer(dial DialContextFunc, maxTTL time.Duration) DialContextFunc {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	cache := &dnsCache{
+		entries: make(map[string]dnsCacheEntry),
+		now:     time.Now,
+		maxTTL:  maxTTL,
+	}
+	cache.lookup = cache.lookupTTL
+
+	return func(ctx context.Context, network string, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, address)
+		}
+
+		addrs, err := cache.resolve(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		if len(addrs) == 0 {
+			return nil, fmt.Errorf("no addresses found for %v", host)
+		}
+		return dialHappyEyeballs(ctx, dial, network, addrs, port)
+	}
+}
+
+// resolve : 캐시된 주소가 만료되지 않았으면 그대로, 아니면 새로 조회하여 반환한다.
+func (c *dnsCache) resolve(ctx context.Context, host string) ([]string, error) {
+	c.mutex.Lock()
+	entry, ok := c.entries[host]
+	c.mutex.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	addrs, ttl, err := c.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if ttl > c.maxTTL {
+		ttl = c.maxTTL
+	}
+
+	c.mutex.Lock()
+	c.entries[host] = dnsCacheEntry{addrs: addrs, expires: c.now().Add(ttl)}
+	c.mutex.Unlock()
+
+	return addrs, nil
+}
+
+// lookupTTL : resolv.conf 의 nameserver 에 직접 A, AAAA 레코드를 조회하여 TTL 과 함께 반환한다.
+// 직접 조회할 수 없으면 시스템 resolver 로 조회하고, TTL 은 maxTTL 을 사용한다.
+func (c *dnsCache) lookupTTL(ctx context.Context, host string) ([]string, time.Duration, error) {
+	if servers := nameservers(resolvConfPath); len(servers) > 0 && strings.Contains(host, ".") {
+		for _, server := range servers {
+			addrs, ttl, err := queryAddrs(ctx, server, host)
+			if err == nil && len(addrs) > 0 {
+				return addrs, ttl, nil
+			}
+		}
+	}
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to resolve %v: %w", host, err)
+	}
+	return addrs, c.maxTTL, nil
+}
+
+// nameservers : resolv.conf 의 nameserver 주소 목록을 반환한다.
+func nameservers(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var servers []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			servers = append(servers, net.JoinHostPort(fields[1], "53"))
+		}
+	}
+	return servers
+}
+
+// queryAddrs : server 에 host 의 A, AAAA 레코드를 조회하여 주소와 가장 짧은 TTL 을 반환한다.
+func queryAddrs(ctx context.Context, server string, host string) ([]string, time.Duration, error) {
+	name, err := dnsmessage.NewName(strings.TrimSuffix(host, ".") + ".")
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid host name %v: %w", host, err)
+	}
+
+	var addrs []string
+	var ttl time.Duration
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		answers, err := query(ctx, server, name, qtype)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, rr := range answers {
+			switch body := rr.Body.(type) {
+			case *dnsmessage.AResource:
+				addrs = append(addrs, net.IP(body.A[:]).String())
+			case *dnsmessage.AAAAResource:
+				addrs = append(addrs, net.IP(body.AAAA[:]).String())
+			default:
+				continue
+			}
+			if recordTTL := time.Duration(rr.Header.TTL) * time.Second; ttl == 0 || recordTTL < ttl {
+				ttl = recordTTL
+			}
+		}
+	}
+	return addrs, ttl, nil
+}
+
+func query(ctx context.Context, server string, name dnsmessage.Name, qtype dnsmessage.Type) ([]dnsmessage.Resource, error) {
+	var idBytes [2]byte
+	if _, err := rand.Read(idBytes[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate DNS query ID: %w", err)
+	}
+	id := binary.BigEndian.Uint16(idBytes[:])
+
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := msg.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack DNS query: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dnsQueryTimeout)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nameserver %v: %w", server, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(packed); err != nil {
+		return nil, fmt.Errorf("failed to send DNS query to %v: %w", server, err)
+	}
+	buf := make([]byte, 1232)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read DNS response from %v: %w", server, err)
+		}
+		var resp dnsmessage.Message
+		if err := resp.Unpack(buf[:n]); err != nil || resp.ID != id || !resp.Response {
+			// 다른 질의에 대한 응답이나 잘못된 패킷은 무시한다.
+			continue
+		}
+		if resp.RCode != dnsmessage.RCodeSuccess {
+			return nil, fmt.Errorf("nameserver %v returned %v for %v", server, resp.RCode, name)
+		}
+		if resp.Truncated {
+			return nil, errors.New("truncated DNS response")
+		}
+		return resp.Answers, nil
+	}
+}
@@ -0,0 +1,94 @@
+package collector
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestDNSCacheResolve(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1700000000, 0)
+	lookups := 0
+	cache := &dnsCache{
+		entries: make(map[string]dnsCacheEntry),
+		now:     func() time.Time { return now },
+		maxTTL:  time.Minute,
+		lookup: func(_ context.Context, _ string) ([]string, time.Duration, error) {
+			lookups++
+			return []string{"10.0.0.1"}, time.Hour, nil
+		},
+	}
+
+	for _, step := range []struct {
+		advance     time.Duration
+		wantLookups int
+	}{
+		{advance: 0, wantLookups: 1},
+		{advance: 30 * time.Second, wantLookups: 1},
+		// The record TTL of an hour is capped by the max TTL of a minute.
+		{advance: 31 * time.Second, wantLookups: 2},
+	} {
+		now = now.Add(step.advance)
+		addrs, err := cache.resolve(t.Context(), "backend.example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(addrs, []string{"10.0.0.1"}) || lookups != step.wantLookups {
+			t.Errorf("resolve() after %v = %v with %v lookups, want [10.0.0.1] with %v lookups", step.advance, addrs, lookups, step.wantLookups)
+		}
+	}
+}
+
+func TestQueryAddrs(t *testing.T) {
+	t.Parallel()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var req dnsmessage.Message
+			if err := req.Unpack(buf[:n]); err != nil {
+				continue
+			}
+			resp := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: req.ID, Response: true},
+				Questions: req.Questions,
+			}
+			q := req.Questions[0]
+			if q.Type == dnsmessage.TypeA {
+				resp.Answers = []dnsmessage.Resource{
+					{Header: dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: q.Class, TTL: 300}, Body: &dnsmessage.AResource{A: [4]byte{10, 0, 0, 1}}},
+					{Header: dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: q.Class, TTL: 60}, Body: &dnsmessage.AResource{A: [4]byte{10, 0, 0, 2}}},
+				}
+			}
+			packed, err := resp.Pack()
+			if err != nil {
+				continue
+			}
+			_, _ = conn.WriteTo(packed, addr)
+		}
+	}()
+
+	addrs, ttl, err := queryAddrs(t.Context(), conn.LocalAddr().String(), "backend.example.com")
+	if err != nil {
+		t.Fatalf("queryAddrs() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(addrs, []string{"10.0.0.1", "10.0.0.2"}) || ttl != time.Minute {
+		t.Errorf("queryAddrs() = %v, %v, want [10.0.0.1 10.0.0.2], 1m0s", addrs, ttl)
+	}
+}
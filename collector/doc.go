@@ -0,0 +1,17 @@
+// Package collector provides Prometheus collectors for NGINX and NGINX Plus.
+//
+// NginxCollector exports the stub_status metrics of NGINX together with metrics derived
+// from the NGINX configuration, and NginxPlusCollector exports the metrics of the
+// NGINX Plus API. Both implement prometheus.Collector, so they can be registered in
+// any prometheus.Registerer:
+//
+//	nginxClient := client.NewNginxClient(http.DefaultClient, "http://127.0.0.1:8080/stub_status")
+//	registry := prometheus.NewRegistry()
+//	registry.MustRegister(collector.NewNginxCollector(nginxClient,
+//		collector.WithConfigPath("/etc/nginx/nginx.conf"),
+//		collector.WithHealthChecks(collector.HealthCheckConfig{Interval: 30 * time.Second}),
+//	))
+//
+// The collectors are configured with functional options such as WithNamespace,
+// WithLogger and WithConfigPath.
+package collector
@@ -0,0 +1,125 @@
+package collector
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// happyEyeballsDelay is how long a dual-stack dial waits for the preferred address
+// family before it also dials the other one, the same as net.Dialer.
+const happyEyeballsDelay = 300 * time.Millisecond
+
+// Address families of the dual-stack health checks.
+const (
+	familyIPv4 = "ipv4"
+	familyIPv6 = "ipv6"
+)
+
+// lookupNetIPFunc resolves a host name, like net.Resolver.LookupNetIP.
+type lookupNetIPFunc func(ctx context.Context, network string, host string) ([]netip.Addr, error)
+
+// familyResult is the result of the health check of a proxy target over one address family.
+type familyResult struct {
+	family string
+	result float64
+}
+
+// splitFamilies : 주소를 IPv4 와 IPv6 주소로 나눈다.
+func splitFamilies(addrs []netip.Addr) (ipv4 []string, ipv6 []string) {
+	for _, addr := range addrs {
+		if addr = addr.Unmap(); addr.Is4() {
+			ipv4 = append(ipv4, addr.String())
+		} else {
+			ipv6 = append(ipv6, addr.String())
+		}
+	}
+	return ipv4, ipv6
+}
+
+// dialSerial : addrs 에 차례로 연결하여 처음 연결된 connection 을, 모두 실패하면 마지막 에러를 반환한다.
+func dialSerial(ctx context.Context, dial DialContextFunc, network string, addrs []string, port string) (net.Conn, error) {
+	var err error
+	for _, addr := range addrs {
+		var conn net.Conn
+		if conn, err = dial(ctx, network, net.JoinHostPort(addr, port)); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// dialHappyEyeballs : 첫 주소의 family 의 주소들에 먼저 연결하고, happyEyeballsDelay 안에 연결되지 않거나 모두 실패하면
+// 다른 family 의 주소들에도 동시에 연결하여(RFC 8305) 먼저 연결된 connection 을 반환한다. 한 family 가 응답하지 않아도
+// 다른 family 로 바로 연결된다.
+func dialHappyEyeballs(ctx context.Context, dial DialContextFunc, network string, addrs []string, port string) (net.Conn, error) {
+	var primaries, fallbacks []string
+	for _, addr := range addrs {
+		if isIPv4(addr) == isIPv4(addrs[0]) {
+			primaries = append(primaries, addr)
+		} else {
+			fallbacks = append(fallbacks, addr)
+		}
+	}
+	if len(fallbacks) == 0 {
+		return dialSerial(ctx, dial, network, primaries, port)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type dialResult struct {
+		conn net.Conn
+		err  error
+	}
+	results := make(chan dialResult, 2)
+	racing, fallbackStarted := 0, false
+	race := func(addrs []string) {
+		racing++
+		go func() {
+			conn, err := dialSerial(ctx, dial, network, addrs, port)
+			results <- dialResult{conn: conn, err: err}
+		}()
+	}
+	race(primaries)
+	fallback := time.NewTimer(happyEyeballsDelay)
+	defer fallback.Stop()
+
+	var firstErr error
+	for {
+		select {
+		case <-fallback.C:
+			race(fallbacks)
+			fallbackStarted = true
+		case res := <-results:
+			racing--
+			if res.err == nil {
+				if racing > 0 {
+					// 늦게 연결된 connection 은 닫는다.
+					go func() {
+						if late := <-results; late.conn != nil {
+							_ = late.conn.Close()
+						}
+					}()
+				}
+				return res.conn, nil
+			}
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			if !fallbackStarted {
+				fallback.Stop()
+				race(fallbacks)
+				fallbackStarted = true
+			} else if racing == 0 {
+				return nil, firstErr
+			}
+		}
+	}
+}
+
+func isIPv4(addr string) bool {
+	ip, err := netip.ParseAddr(addr)
+	return err == nil && ip.Unmap().Is4()
+}
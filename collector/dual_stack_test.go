@@ -0,0 +1,185 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nginx/nginx-prometheus-exporter/client"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakeLookup resolves dual.test to an IPv4 and an IPv6 address and v4.test to an IPv4 address.
+func fakeLookup(_ context.Context, _ string, host string) ([]netip.Addr, error) {
+	switch host {
+	case "dual.test":
+		return []netip.Addr{netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::1")}, nil
+	case "v4.test":
+		return []netip.Addr{netip.MustParseAddr("192.0.2.1")}, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+// fakeDial connects only to the addresses in up.
+func fakeDial(up ...string) DialContextFunc {
+	return func(_ context.Context, _ string, address string) (net.Conn, error) {
+		for _, addr := range up {
+			if address == addr {
+				client, server := net.Pipe()
+				_ = server.Close()
+				return client, nil
+			}
+		}
+		return nil, errors.New("connection refused")
+	}
+}
+
+func TestTCPHealthCheckDualStack(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		target    string
+		up        []string
+		want      []familyResult
+		result    float64
+		dualStack bool
+	}{
+		{
+			name:      "IPv6 down",
+			target:    "dual.test:8080",
+			up:        []string{"192.0.2.1:8080"},
+			dualStack: true,
+			result:    1,
+			want:      []familyResult{{family: familyIPv4, result: 1}, {family: familyIPv6, result: 0}},
+		},
+		{
+			name:      "IPv4 down",
+			target:    "dual.test",
+			up:        []string{"[2001:db8::1]:80"},
+			dualStack: true,
+			result:    1,
+			want:      []familyResult{{family: familyIPv4, result: 0}, {family: familyIPv6, result: 1}},
+		},
+		{
+			name:      "both down",
+			target:    "dual.test:8080",
+			dualStack: true,
+			want:      []familyResult{{family: familyIPv4, result: 0}, {family: familyIPv6, result: 0}},
+		},
+		{
+			name:      "single family",
+			target:    "v4.test:8080",
+			up:        []string{"v4.test:8080"},
+			dualStack: true,
+			result:    1,
+		},
+		{
+			name:      "IP address",
+			target:    "192.0.2.1:8080",
+			up:        []string{"192.0.2.1:8080"},
+			dualStack: true,
+			result:    1,
+		},
+		{
+			name:   "disabled",
+			target: "dual.test:8080",
+			up:     []string{"dual.test:8080"},
+			result: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			check := tcpHealthCheck{dial: fakeDial(tt.up...), lookup: fakeLookup, dualStack: tt.dualStack}
+			got := check.run(tt.target, false)
+			if got.result != tt.result || !reflect.DeepEqual(got.families, tt.want) || got.at.IsZero() {
+				t.Errorf("run(%v) = %+v, want result %v and families %+v", tt.target, got, tt.result, tt.want)
+			}
+		})
+	}
+}
+
+func TestDialHappyEyeballs(t *testing.T) {
+	t.Parallel()
+
+	// hang blocks the dials to its addresses until the dial is canceled.
+	dial := func(hang string, up string) DialContextFunc {
+		return func(ctx context.Context, network string, address string) (net.Conn, error) {
+			if address == hang {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return fakeDial(up)(ctx, network, address)
+		}
+	}
+	addrs := []string{"2001:db8::1", "2001:db8::2", "192.0.2.1"}
+
+	tests := []struct {
+		dial    DialContextFunc
+		name    string
+		wantErr bool
+	}{
+		{name: "preferred family", dial: dial("", "[2001:db8::2]:80")},
+		{name: "fallback after failures", dial: dial("", "192.0.2.1:80")},
+		{name: "fallback after delay", dial: dial("[2001:db8::1]:80", "192.0.2.1:80")},
+		{name: "all down", dial: dial("", ""), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+			conn, err := dialHappyEyeballs(ctx, tt.dial, "tcp", addrs, "80")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("dialHappyEyeballs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if conn != nil {
+				_ = conn.Close()
+			}
+			if ctx.Err() != nil {
+				t.Error("dialHappyEyeballs() waited for the hanging family until the timeout")
+			}
+		})
+	}
+}
+
+func TestNginxCollectorHealthCheckDualStack(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "nginx.conf")
+	if err := os.WriteFile(configPath, []byte("http { server { location / { proxy_pass http://dual.test:8080; } } }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := NewNginxCollector(fakeStubStatsClient{stats: &client.StubStats{}},
+		WithLogger(slog.New(slog.DiscardHandler)),
+		WithConfigPath(configPath),
+		WithConfigLabelMode(ConfigLabelModeBasename),
+		WithHealthChecks(HealthCheckConfig{Dial: fakeDial("192.0.2.1:8080"), DualStack: true}),
+	)
+	c.healthCheck.lookup = fakeLookup
+
+	expected := `
+# HELP nginx_upstream_health_check_family_status Whether a dual-stack proxy target accepted a TCP connection over the address family (1: success, 0: failure)
+# TYPE nginx_upstream_health_check_family_status gauge
+nginx_upstream_health_check_family_status{family="ipv4",file="nginx.conf",target="dual.test:8080"} 1
+nginx_upstream_health_check_family_status{family="ipv6",file="nginx.conf",target="dual.test:8080"} 0
+# HELP nginx_upstream_health_check_status Proxy Target의 TCP 연결 상태(1: 성공, 0: 실패)
+# TYPE nginx_upstream_health_check_status gauge
+nginx_upstream_health_check_status{file="nginx.conf",target="dual.test:8080"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"nginx_upstream_health_check_status", "nginx_upstream_health_check_family_status"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,144 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+var labelNameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Endpoint is an external Prometheus endpoint, e.g. of lua-resty-prometheus or njs,
+// whose metrics are merged into the exported metrics.
+type Endpoint struct {
+	// Labels are added to every series of the endpoint. Labels the endpoint already
+	// exports are overwritten.
+	Labels map[string]string
+	// Name identifies the endpoint in the endpoint label of the endpoint metrics.
+	Name string
+	// URL is the URL of the endpoint. It must serve the Prometheus text format.
+	URL string
+	// Prefix is prepended to the names of the metrics of the endpoint.
+	Prefix string
+}
+
+// EndpointGatherer fetches the metrics of an Endpoint on every Gather.
+type EndpointGatherer struct {
+	httpClient *http.Client
+	registry   *prometheus.Registry
+	up         prometheus.Gauge
+	endpoint   Endpoint
+}
+
+// NewEndpointGatherer creates an EndpointGatherer fetching endpoint with httpClient.
+func NewEndpointGatherer(endpoint Endpoint, httpClient *http.Client, opts ...Option) (*EndpointGatherer, error) {
+	o := newOptions(DefaultNamespace, opts)
+
+	if endpoint.Name == "" {
+		return nil, errors.New("endpoint without a name")
+	}
+	if !strings.HasPrefix(endpoint.URL, "http://") && !strings.HasPrefix(endpoint.URL, "https://") {
+		return nil, fmt.Errorf("endpoint %q has no http(s) URL", endpoint.Name)
+	}
+	if endpoint.Prefix != "" && !labelNameRe.MatchString(endpoint.Prefix) {
+		return nil, fmt.Errorf("invalid prefix %q of endpoint %q", endpoint.Prefix, endpoint.Name)
+	}
+	for name := range endpoint.Labels {
+		if !labelNameRe.MatchString(name) {
+			return nil, fmt.Errorf("invalid label name %q of endpoint %q", name, endpoint.Name)
+		}
+	}
+
+	g := &EndpointGatherer{
+		httpClient: httpClient,
+		registry:   prometheus.NewRegistry(),
+		endpoint:   endpoint,
+		up: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			Name:        "exporter_endpoint_up",
+			Help:        "Whether the last fetch of the external endpoint succeeded",
+			ConstLabels: MergeLabels(o.constLabels, map[string]string{"endpoint": endpoint.Name}),
+		}),
+	}
+	g.registry.MustRegister(g.up)
+
+	return g, nil
+}
+
+// Gather implements prometheus.Gatherer. When the endpoint cannot be fetched, only
+// the up metric is returned, along with the error.
+func (g *EndpointGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, fetchErr := g.fetch()
+	if fetchErr != nil {
+		g.up.Set(nginxDown)
+	} else {
+		g.up.Set(nginxUp)
+	}
+
+	gatherers := prometheus.Gatherers{g.registry, prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return families, nil
+	})}
+	result, err := gatherers.Gather()
+	if err = errors.Join(fetchErr, err); err != nil {
+		return result, fmt.Errorf("failed to gather endpoint %q: %w", g.endpoint.Name, err)
+	}
+	return result, nil
+}
+
+func (g *EndpointGatherer) fetch() ([]*dto.MetricFamily, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, g.endpoint.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a get request: %w", err)
+	}
+	req.Header.Set("Accept", "text/plain;version=0.0.4")
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %v: %w", g.endpoint.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("expected %v response, got %v", http.StatusOK, resp.StatusCode)
+	}
+
+	families, err := parseTextFormat(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	for _, family := range families {
+		relabelFamily(family, g.endpoint.Prefix, g.endpoint.Labels)
+	}
+	return families, nil
+}
+
+// relabelFamily : metric 이름 앞에 prefix 를 붙이고, 모든 series 에 labels 를 추가한다.
+func relabelFamily(family *dto.MetricFamily, prefix string, labels map[string]string) {
+	if prefix != "" {
+		family.Name = proto.String(prefix + "_" + family.GetName())
+	}
+	if len(labels) == 0 {
+		return
+	}
+	for _, metric := range family.GetMetric() {
+		merged := make(map[string]string, len(metric.GetLabel())+len(labels))
+		for _, pair := range metric.GetLabel() {
+			merged[pair.GetName()] = pair.GetValue()
+		}
+		for name, value := range labels {
+			merged[name] = value
+		}
+		metric.Label = metric.Label[:0]
+		for _, name := range slices.Sorted(maps.Keys(merged)) {
+			metric.Label = append(metric.Label, &dto.LabelPair{Name: proto.String(name), Value: proto.String(merged[name])})
+		}
+	}
+}
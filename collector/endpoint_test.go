@@ -0,0 +1,53 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestEndpointGatherer(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`# HELP requests_total Requests handled by Lua
+# TYPE requests_total counter
+requests_total{host="a",zone="lua"} 7
+`))
+	}))
+	t.Cleanup(srv.Close)
+
+	g, err := NewEndpointGatherer(Endpoint{
+		Name:   "lua",
+		URL:    srv.URL,
+		Prefix: "nginx_lua",
+		Labels: map[string]string{"zone": "edge", "source": "lua"},
+	}, srv.Client())
+	if err != nil {
+		t.Fatalf("NewEndpointGatherer() returned error: %v", err)
+	}
+
+	expected := `
+# HELP nginx_exporter_endpoint_up Whether the last fetch of the external endpoint succeeded
+# TYPE nginx_exporter_endpoint_up gauge
+nginx_exporter_endpoint_up{endpoint="lua"} 1
+# HELP nginx_lua_requests_total Requests handled by Lua
+# TYPE nginx_lua_requests_total counter
+nginx_lua_requests_total{host="a",source="lua",zone="edge"} 7
+`
+	if err := testutil.GatherAndCompare(g, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	srv.Close()
+	families, err := g.Gather()
+	if err == nil {
+		t.Error("Gather() returned no error for an unreachable endpoint")
+	}
+	if len(families) != 1 || families[0].GetName() != "nginx_exporter_endpoint_up" || families[0].GetMetric()[0].GetGauge().GetValue() != 0 {
+		t.Errorf("Gather() = %v, want only nginx_exporter_endpoint_up 0", families)
+	}
+}
@@ -0,0 +1,213 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultExecPluginInterval is the interval at which exec plugins are run by default.
+const DefaultExecPluginInterval = time.Minute
+
+// ExecPlugin is an external command whose standard output, in the Prometheus text
+// format, is merged into the exported metrics.
+type ExecPlugin struct {
+	// Name identifies the plugin in the plugin label of the plugin metrics.
+	Name string
+	// Command is the path of the executable followed by its arguments.
+	Command []string
+	// Interval is the interval at which the command is run. DefaultExecPluginInterval
+	// is used when zero.
+	Interval time.Duration
+	// Timeout bounds a single run of the command. Interval is used when zero.
+	Timeout time.Duration
+}
+
+// ExecPluginGatherer runs exec plugins in the background and gathers the metrics of
+// their last successful run, along with whether the last run succeeded and how long
+// it took.
+type ExecPluginGatherer struct {
+	logger     *slog.Logger
+	results    map[string][]*dto.MetricFamily
+	registry   *prometheus.Registry
+	up         *prometheus.GaugeVec
+	duration   *prometheus.GaugeVec
+	plugins    []ExecPlugin
+	mutex      sync.RWMutex
+	timestamps bool
+}
+
+// NewExecPluginGatherer creates an ExecPluginGatherer. The plugins are not run until
+// Start is called.
+func NewExecPluginGatherer(plugins []ExecPlugin, opts ...Option) (*ExecPluginGatherer, error) {
+	o := newOptions(DefaultNamespace, opts)
+
+	names := make(map[string]bool, len(plugins))
+	for _, plugin := range plugins {
+		if plugin.Name == "" {
+			return nil, errors.New("exec plugin without a name")
+		}
+		if names[plugin.Name] {
+			return nil, fmt.Errorf("duplicate exec plugin %q", plugin.Name)
+		}
+		if len(plugin.Command) == 0 {
+			return nil, fmt.Errorf("exec plugin %q has no command", plugin.Name)
+		}
+		names[plugin.Name] = true
+	}
+
+	g := &ExecPluginGatherer{
+		logger:     o.logger,
+		results:    make(map[string][]*dto.MetricFamily),
+		registry:   prometheus.NewRegistry(),
+		plugins:    plugins,
+		timestamps: o.sampleTimestamps,
+		up: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			Name:        "exporter_plugin_up",
+			Help:        "Whether the last run of the exec plugin succeeded",
+			ConstLabels: o.constLabels,
+		}, []string{"plugin"}),
+		duration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			Name:        "exporter_plugin_duration_seconds",
+			Help:        "Duration of the last run of the exec plugin",
+			ConstLabels: o.constLabels,
+		}, []string{"plugin"}),
+	}
+	g.registry.MustRegister(g.up, g.duration)
+
+	return g, nil
+}
+
+// Start runs every plugin immediately and then at its interval until ctx is done.
+func (g *ExecPluginGatherer) Start(ctx context.Context) {
+	for _, plugin := range g.plugins {
+		go g.run(ctx, plugin)
+	}
+}
+
+// Gather implements prometheus.Gatherer. Metrics of the plugins that are inconsistent,
+// e.g. the same series printed by two plugins, are dropped and logged instead of
+// failing the scrape, as the output of the plugins is not under the exporter's control.
+func (g *ExecPluginGatherer) Gather() ([]*dto.MetricFamily, error) {
+	g.mutex.RLock()
+	gatherers := prometheus.Gatherers{g.registry}
+	for _, plugin := range g.plugins {
+		families := g.results[plugin.Name]
+		gatherers = append(gatherers, prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+			return families, nil
+		}))
+	}
+	g.mutex.RUnlock()
+
+	families, err := gatherers.Gather()
+	if err != nil {
+		g.logger.Warn("dropped inconsistent exec plugin metrics", "error", err.Error())
+	}
+	return families, nil
+}
+
+func (g *ExecPluginGatherer) run(ctx context.Context, plugin ExecPlugin) {
+	interval := plugin.Interval
+	if interval <= 0 {
+		interval = DefaultExecPluginInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		g.runOnce(ctx, plugin, interval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce : plugin 을 한 번 실행하여 결과를 저장한다. 실패하면 이전 결과를 버리고 up 을 0 으로 설정한다.
+func (g *ExecPluginGatherer) runOnce(ctx context.Context, plugin ExecPlugin, interval time.Duration) {
+	timeout := plugin.Timeout
+	if timeout <= 0 {
+		timeout = interval
+	}
+
+	start := time.Now()
+	families, err := execPlugin(ctx, plugin, timeout)
+	g.duration.WithLabelValues(plugin.Name).Set(time.Since(start).Seconds())
+	if err == nil && g.timestamps {
+		setTimestamps(families, start)
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if err != nil {
+		g.logger.Error("exec plugin failed", "plugin", plugin.Name, "error", err.Error())
+		delete(g.results, plugin.Name)
+		g.up.WithLabelValues(plugin.Name).Set(0)
+		return
+	}
+	g.results[plugin.Name] = families
+	g.up.WithLabelValues(plugin.Name).Set(1)
+}
+
+// setTimestamps : plugin 이 timestamp 를 붙이지 않은 sample 에 실행 시각을 붙인다.
+func setTimestamps(families []*dto.MetricFamily, at time.Time) {
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			if m.TimestampMs == nil {
+				m.TimestampMs = proto.Int64(at.UnixMilli())
+			}
+		}
+	}
+}
+
+// execPlugin : plugin 의 command 를 실행하고, 표준 출력을 Prometheus text format 으로 파싱한다.
+func execPlugin(ctx context.Context, plugin ExecPlugin, timeout time.Duration) ([]*dto.MetricFamily, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	// #nosec G204
+	cmd := exec.CommandContext(ctx, plugin.Command[0], plugin.Command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return nil, fmt.Errorf("failed to run %v: %w: %s", plugin.Command[0], err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return nil, fmt.Errorf("failed to run %v: %w", plugin.Command[0], err)
+	}
+
+	families, err := parseTextFormat(&stdout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the output of %v: %w", plugin.Command[0], err)
+	}
+	return families, nil
+}
+
+// parseTextFormat : Prometheus text format 을 metric family 목록으로 파싱한다.
+func parseTextFormat(r io.Reader) ([]*dto.MetricFamily, error) {
+	var parser expfmt.TextParser
+	parsed, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid text format: %w", err)
+	}
+	families := make([]*dto.MetricFamily, 0, len(parsed))
+	for _, family := range parsed {
+		families = append(families, family)
+	}
+	return families, nil
+}
@@ -0,0 +1,111 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestExecPluginGatherer(t *testing.T) {
+	t.Parallel()
+
+	g, err := NewExecPluginGatherer([]ExecPlugin{
+		{
+			Name:    "certs",
+			Command: []string{"sh", "-c", `printf '# HELP site_cert_expiry_seconds Seconds until the certificate expires\n# TYPE site_cert_expiry_seconds gauge\nsite_cert_expiry_seconds{cert="a"} 42\n'`},
+		},
+		{
+			Name:    "broken",
+			Command: []string{"sh", "-c", "exit 1"},
+		},
+	}, WithLogger(slog.New(slog.DiscardHandler)))
+	if err != nil {
+		t.Fatalf("NewExecPluginGatherer() returned error: %v", err)
+	}
+	for _, plugin := range g.plugins {
+		g.runOnce(context.Background(), plugin, time.Minute)
+	}
+
+	expected := `
+# HELP nginx_exporter_plugin_up Whether the last run of the exec plugin succeeded
+# TYPE nginx_exporter_plugin_up gauge
+nginx_exporter_plugin_up{plugin="broken"} 0
+nginx_exporter_plugin_up{plugin="certs"} 1
+# HELP site_cert_expiry_seconds Seconds until the certificate expires
+# TYPE site_cert_expiry_seconds gauge
+site_cert_expiry_seconds{cert="a"} 42
+`
+	if err := testutil.GatherAndCompare(g, strings.NewReader(expected), "nginx_exporter_plugin_up", "site_cert_expiry_seconds"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewExecPluginGathererInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		plugins []ExecPlugin
+	}{
+		{
+			name:    "no name",
+			plugins: []ExecPlugin{{Command: []string{"true"}}},
+		},
+		{
+			name:    "no command",
+			plugins: []ExecPlugin{{Name: "a"}},
+		},
+		{
+			name:    "duplicate name",
+			plugins: []ExecPlugin{{Name: "a", Command: []string{"true"}}, {Name: "a", Command: []string{"true"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := NewExecPluginGatherer(tt.plugins); err == nil {
+				t.Error("NewExecPluginGatherer() returned no error")
+			}
+		})
+	}
+}
+
+func TestExecPluginGathererInconsistentMetrics(t *testing.T) {
+	t.Parallel()
+
+	g, err := NewExecPluginGatherer([]ExecPlugin{
+		{
+			Name:    "a",
+			Command: []string{"sh", "-c", `printf '# TYPE site_checks gauge\nsite_checks{check="x"} 1\n'`},
+		},
+		{
+			Name:    "b",
+			Command: []string{"sh", "-c", `printf '# TYPE site_checks gauge\nsite_checks{check="x"} 2\nsite_checks{check="y"} 3\n'`},
+		},
+	}, WithLogger(slog.New(slog.DiscardHandler)))
+	if err != nil {
+		t.Fatalf("NewExecPluginGatherer() returned error: %v", err)
+	}
+	for _, plugin := range g.plugins {
+		g.runOnce(context.Background(), plugin, time.Minute)
+	}
+
+	// The series printed by both plugins is dropped, the others are still gathered.
+	families, err := g.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+	var series int
+	for _, family := range families {
+		if family.GetName() == "site_checks" {
+			series = len(family.GetMetric())
+		}
+	}
+	if series != 2 {
+		t.Errorf("Gather() returned %d site_checks series, want 2", series)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows || plan9
+
+package collector
+
+import "io/fs"
+
+// fileOwner is not supported on this platform.
+func fileOwner(_ fs.FileInfo) (uint32, bool) {
+	return 0, false
+}
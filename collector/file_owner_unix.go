@@ -0,0 +1,17 @@
+//go:build !windows && !plan9
+
+package collector
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileOwner returns the uid of the file owner.
+func fileOwner(info fs.FileInfo) (uint32, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return stat.Uid, true
+}
@@ -0,0 +1,103 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// NameFilter selects NGINX Plus objects (zones, upstreams) by name.
+// Both regular expressions are fully anchored; an empty filter selects every name.
+type NameFilter struct {
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// NewNameFilter creates a NameFilter from include and exclude regular expressions.
+// An empty expression is ignored.
+func NewNameFilter(include string, exclude string) (NameFilter, error) {
+	var f NameFilter
+	var err error
+
+	if include != "" {
+		if f.include, err = regexp.Compile("^(?:" + include + ")$"); err != nil {
+			return NameFilter{}, fmt.Errorf("invalid include regexp %q: %w", include, err)
+		}
+	}
+	if exclude != "" {
+		if f.exclude, err = regexp.Compile("^(?:" + exclude + ")$"); err != nil {
+			return NameFilter{}, fmt.Errorf("invalid exclude regexp %q: %w", exclude, err)
+		}
+	}
+
+	return f, nil
+}
+
+// Match reports whether the name is selected by the filter.
+func (f NameFilter) Match(name string) bool {
+	if f.include != nil && !f.include.MatchString(name) {
+		return false
+	}
+	if f.exclude != nil && f.exclude.MatchString(name) {
+		return false
+	}
+	return true
+}
+
+// nameMatcher selects names.
+type nameMatcher interface {
+	Match(name string) bool
+}
+
+// filterByName removes the entries of m whose name is not selected by the filter.
+func filterByName[V any](m map[string]V, f nameMatcher) {
+	for name := range m {
+		if !f.Match(name) {
+			delete(m, name)
+		}
+	}
+}
+
+// nameListFile is a list of names read from a file, one per line, reloaded when the file changes.
+// Empty lines and lines starting with # are ignored.
+type nameListFile struct {
+	modTime time.Time
+	names   map[string]bool
+	path    string
+}
+
+// reload reads the file again if its modification time has changed.
+// The previous list is kept if the file cannot be read.
+func (l *nameListFile) reload() error {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", l.path, err)
+	}
+	if l.names != nil && info.ModTime().Equal(l.modTime) {
+		return nil
+	}
+
+	content, err := os.ReadFile(l.path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", l.path, err)
+	}
+	names := make(map[string]bool)
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names[line] = true
+	}
+
+	l.names = names
+	l.modTime = info.ModTime()
+	return nil
+}
+
+// Match reports whether the name is in the list. Every name matches until the file has been read.
+func (l *nameListFile) Match(name string) bool {
+	return l.names == nil || l.names[name]
+}
@@ -0,0 +1,110 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNameFilter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		include string
+		exclude string
+		match   []string
+		noMatch []string
+	}{
+		{
+			name:  "empty filter",
+			match: []string{"backend", ""},
+		},
+		{
+			name:    "include only",
+			include: "tenant-a-.*",
+			match:   []string{"tenant-a-web", "tenant-a-api"},
+			noMatch: []string{"tenant-b-web", "x-tenant-a-web"},
+		},
+		{
+			name:    "include and exclude",
+			include: "tenant-.*",
+			exclude: ".*-internal",
+			match:   []string{"tenant-a-web"},
+			noMatch: []string{"tenant-a-internal", "backend"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			f, err := NewNameFilter(tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("NewNameFilter() returned error: %v", err)
+			}
+			for _, name := range tt.match {
+				if !f.Match(name) {
+					t.Errorf("Match(%q) = false, want true", name)
+				}
+			}
+			for _, name := range tt.noMatch {
+				if f.Match(name) {
+					t.Errorf("Match(%q) = true, want false", name)
+				}
+			}
+		})
+	}
+
+	if _, err := NewNameFilter("(", ""); err == nil {
+		t.Error("NewNameFilter() did not return an error for an invalid regexp")
+	}
+}
+
+func TestNameListFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "upstreams")
+	writeList := func(content string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l := &nameListFile{path: path}
+	if !l.Match("backend") {
+		t.Error("Match() = false before the file was read, want true")
+	}
+
+	now := time.Now()
+	writeList("# payments\nbackend\n\n  api  \n", now)
+	if err := l.reload(); err != nil {
+		t.Fatalf("reload() returned error: %v", err)
+	}
+	for name, want := range map[string]bool{"backend": true, "api": true, "other": false, "# payments": false} {
+		if got := l.Match(name); got != want {
+			t.Errorf("Match(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	writeList("other\n", now.Add(time.Second))
+	if err := l.reload(); err != nil {
+		t.Fatalf("reload() returned error: %v", err)
+	}
+	if l.Match("backend") || !l.Match("other") {
+		t.Error("reload() did not pick up the changed file")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.reload(); err == nil {
+		t.Error("reload() did not return an error for a missing file")
+	}
+	if !l.Match("other") {
+		t.Error("reload() did not keep the previous list after an error")
+	}
+}
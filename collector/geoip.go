@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// DefaultGeoIPCountryLimit is the default number of distinct countries of the country
+// label.
+const DefaultGeoIPCountryLimit = 50
+
+// otherCountry replaces the countries beyond the limit of the country label.
+const otherCountry = "other"
+
+// geoIPCountries maps client addresses to ISO country codes. Only the first limit
+// distinct codes are kept; later ones are replaced with otherCountry so that the
+// cardinality of the country label is bounded.
+type geoIPCountries struct {
+	lookup func(ip net.IP) (string, error)
+	close  func() error
+	seen   map[string]bool
+	limit  int
+}
+
+// openGeoIPCountries : MaxMind GeoLite2/GeoIP2 Country 또는 City 데이터베이스를 연다.
+func openGeoIPCountries(path string, limit int) (*geoIPCountries, error) {
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	lookup := func(ip net.IP) (string, error) {
+		var record struct {
+			Country struct {
+				ISOCode string `maxminddb:"iso_code"`
+			} `maxminddb:"country"`
+		}
+		if err := db.Lookup(ip, &record); err != nil {
+			return "", fmt.Errorf("GeoIP lookup failed: %w", err)
+		}
+		return record.Country.ISOCode, nil
+	}
+	return newGeoIPCountries(lookup, db.Close, limit), nil
+}
+
+func newGeoIPCountries(lookup func(net.IP) (string, error), closeFunc func() error, limit int) *geoIPCountries {
+	if limit <= 0 {
+		limit = DefaultGeoIPCountryLimit
+	}
+	return &geoIPCountries{lookup: lookup, close: closeFunc, seen: make(map[string]bool), limit: limit}
+}
+
+// country : addr 의 국가 코드를 반환한다. 사설 주소처럼 데이터베이스에 없는 주소는 "" 이다.
+func (g *geoIPCountries) country(addr string) string {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return ""
+	}
+	code, err := g.lookup(ip)
+	if err != nil || code == "" {
+		return ""
+	}
+	if !g.seen[code] {
+		if len(g.seen) >= g.limit {
+			return otherCountry
+		}
+		g.seen[code] = true
+	}
+	return code
+}
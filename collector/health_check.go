@@ -0,0 +1,294 @@
+package collector
+
+import (
+	"context"
+	"crypto/tls"
+	"hash/fnv"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// healthCheckConcurrency is the maximum number of health checks run at the same time
+// by checkAll.
+const healthCheckConcurrency = 32
+
+// HealthCheckConfig configures the upstream health checks of the NginxCollector.
+type HealthCheckConfig struct {
+	// Dial dials the health check targets. net.Dialer is used when nil.
+	Dial DialContextFunc
+	// Interval runs the health checks in the background at this interval instead of
+	// during every scrape. Zero runs them during the scrape.
+	Interval time.Duration
+	// Jitter adds a random delay of up to Jitter to every background health check.
+	Jitter time.Duration
+	// DualStack checks proxy targets whose host name has both IPv4 and IPv6 addresses
+	// over each address family and exports the result of every family, so that an
+	// outage of one family is detected while the other still works.
+	DualStack bool
+	// TLS verifies the certificates of the targets of https proxy_pass directives with
+	// a TLS handshake after connecting. Its ServerName is sent and verified instead of
+	// the host of the target when set. Targets are only checked with TCP when nil.
+	TLS *tls.Config
+	// MaxTargets caps the number of distinct proxy targets that are checked. Targets
+	// beyond the first MaxTargets, in the order of the proxy_pass directives in the
+	// config with includes expanded, are skipped and counted as overflow. Unlimited when zero.
+	MaxTargets int
+}
+
+type healthCheckKey struct {
+	file   string
+	target string
+}
+
+// healthCheckResult is the result of a background health check run at at.
+type healthCheckResult struct {
+	at time.Time
+	// families holds the per-family results of a dual-stack check.
+	families []familyResult
+	result   float64
+}
+
+// tcpHealthCheck runs the TCP health checks of proxy targets.
+type tcpHealthCheck struct {
+	dial   DialContextFunc
+	lookup lookupNetIPFunc
+	// tls verifies the certificates of targets checked with TLS.
+	tls *tls.Config
+	// dualStack checks host names with IPv4 and IPv6 addresses over each family.
+	dualStack bool
+}
+
+func newTCPHealthCheck(config HealthCheckConfig) tcpHealthCheck {
+	return tcpHealthCheck{
+		dial:      config.Dial,
+		lookup:    net.DefaultResolver.LookupNetIP,
+		tls:       config.TLS,
+		dualStack: config.DualStack,
+	}
+}
+
+// run : proxyTarget 에 TCP 연결을 테스트한다. useTLS 이고 tls 가 설정되어 있으면, 연결한 뒤 TLS handshake 로 인증서까지
+// 검증하며 포트가 없는 target 은 443 번 포트로 연결한다. dualStack 이면, IPv4 와 IPv6 주소를 모두 가진 host 는 두 family 로
+// 동시에 연결하여 family 별 결과를 함께 반환하고, 둘 중 하나라도 연결되면 성공이다.
+func (t tcpHealthCheck) run(proxyTarget string, useTLS bool) healthCheckResult {
+	at := time.Now()
+	verify := useTLS && t.tls != nil
+	if !verify && !t.dualStack {
+		result, _ := tcpTest(t.dial, proxyTarget)
+		return healthCheckResult{at: at, result: result}
+	}
+
+	if !strings.Contains(proxyTarget, ":") {
+		port := "80"
+		if verify {
+			port = "443"
+		}
+		proxyTarget = net.JoinHostPort(proxyTarget, port)
+	}
+	host, port, err := net.SplitHostPort(proxyTarget)
+	if err != nil {
+		return healthCheckResult{at: at}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tcpTestTimeout)
+	defer cancel()
+	dial := t.dial
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	check := func(addrs []string) float64 {
+		conn, err := dialSerial(ctx, dial, "tcp", addrs, port)
+		if err != nil {
+			return 0
+		}
+		defer conn.Close()
+		if verify && !t.handshake(ctx, conn, host) {
+			return 0
+		}
+		return 1
+	}
+
+	if !t.dualStack || net.ParseIP(host) != nil {
+		return healthCheckResult{at: at, result: check([]string{host})}
+	}
+	addrs, err := t.lookup(ctx, "ip", host)
+	if err != nil {
+		return healthCheckResult{at: at}
+	}
+	ipv4, ipv6 := splitFamilies(addrs)
+	if len(ipv4) == 0 || len(ipv6) == 0 {
+		return healthCheckResult{at: at, result: check([]string{host})}
+	}
+
+	families := []familyResult{{family: familyIPv4}, {family: familyIPv6}}
+	done := make(chan struct{})
+	go func() {
+		families[1].result = check(ipv6)
+		close(done)
+	}()
+	families[0].result = check(ipv4)
+	<-done
+
+	return healthCheckResult{at: at, result: max(families[0].result, families[1].result), families: families}
+}
+
+// handshake : conn 에서 TLS handshake 를 하여 target 의 인증서를 검증한다. tls 의 ServerName 이 없으면 host 를 검증한다.
+func (t tcpHealthCheck) handshake(ctx context.Context, conn net.Conn, host string) bool {
+	config := t.tls.Clone()
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+	return tls.Client(conn, config).HandshakeContext(ctx) == nil
+}
+
+// healthChecker runs health checks in the background on a shared interval. Every
+// target is checked at a fixed phase of the interval derived from its name, plus a
+// random jitter, so that the checks are spread over the interval instead of all
+// firing at the same instant.
+type healthChecker struct {
+	results map[healthCheckKey]healthCheckResult
+	// targets 와 pending 은 검사 대상을, TLS 로 검사할지 여부에 대응시킨다.
+	targets  map[healthCheckKey]bool
+	pending  map[healthCheckKey]bool
+	stopped  chan struct{}
+	tcpCheck tcpHealthCheck
+	start    sync.Once
+	stop     sync.Once
+	interval time.Duration
+	jitter   time.Duration
+	mutex    sync.Mutex
+}
+
+func newHealthChecker(config HealthCheckConfig) *healthChecker {
+	return &healthChecker{
+		results:  make(map[healthCheckKey]healthCheckResult),
+		targets:  make(map[healthCheckKey]bool),
+		pending:  make(map[healthCheckKey]bool),
+		stopped:  make(chan struct{}),
+		tcpCheck: newTCPHealthCheck(config),
+		interval: config.Interval,
+		jitter:   config.Jitter,
+	}
+}
+
+// track : 이번 scrape 에서 발견한 target 을 기록하고, 마지막 검사 결과를 반환한다.
+// 아직 검사하지 않은 target 이면 ok 는 false 이다.
+func (h *healthChecker) track(file string, target string, useTLS bool) (result healthCheckResult, ok bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	key := healthCheckKey{file: file, target: target}
+	h.pending[key] = useTLS
+	result, ok = h.results[key]
+	return result, ok
+}
+
+// commit : 이번 scrape 에서 기록한 target 들로 검사 대상을 교체하고, 처음 호출 시 background 검사를 시작한다.
+func (h *healthChecker) commit() {
+	h.mutex.Lock()
+	h.targets, h.pending = h.pending, make(map[healthCheckKey]bool)
+	for key := range h.results {
+		if _, ok := h.targets[key]; !ok {
+			delete(h.results, key)
+		}
+	}
+	h.mutex.Unlock()
+
+	h.start.Do(func() {
+		go h.run()
+	})
+}
+
+func (h *healthChecker) run() {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		h.mutex.Lock()
+		for key := range h.targets {
+			time.AfterFunc(h.delay(key), func() {
+				h.check(key)
+			})
+		}
+		h.mutex.Unlock()
+
+		select {
+		case <-ticker.C:
+		case <-h.stopped:
+			return
+		}
+	}
+}
+
+// close : background 검사를 멈추고, 이미 예약된 검사의 결과는 버린다.
+func (h *healthChecker) close() {
+	h.stop.Do(func() {
+		close(h.stopped)
+	})
+	h.mutex.Lock()
+	h.targets = make(map[healthCheckKey]bool)
+	h.mutex.Unlock()
+}
+
+// delay : interval 내에서 target 별로 고정된 위치(phase)에 jitter 를 더한 검사 지연 시간을 반환한다.
+func (h *healthChecker) delay(key healthCheckKey) time.Duration {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key.file + "\x00" + key.target))
+	delay := time.Duration(float64(h.interval) * float64(hash.Sum32()) / (1 << 32))
+	if h.jitter > 0 {
+		delay += rand.N(h.jitter)
+	}
+	return delay
+}
+
+func (h *healthChecker) check(key healthCheckKey) {
+	h.mutex.Lock()
+	useTLS, ok := h.targets[key]
+	h.mutex.Unlock()
+	if !ok {
+		return
+	}
+	result := h.tcpCheck.run(key.target, useTLS)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	// 검사하는 동안 대상에서 제외된 target 의 결과는 저장하지 않는다.
+	if _, ok := h.targets[key]; ok {
+		h.results[key] = result
+	}
+}
+
+// checkAll : background 검사 주기를 기다리지 않고 모든 검사 대상을 즉시 검사하여, 그 결과를 반환한다.
+func (h *healthChecker) checkAll() map[healthCheckKey]healthCheckResult {
+	h.mutex.Lock()
+	keys := make([]healthCheckKey, 0, len(h.targets))
+	for key := range h.targets {
+		keys = append(keys, key)
+	}
+	h.mutex.Unlock()
+
+	var g errgroup.Group
+	g.SetLimit(healthCheckConcurrency)
+	for _, key := range keys {
+		g.Go(func() error {
+			h.check(key)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	results := make(map[healthCheckKey]healthCheckResult, len(keys))
+	for _, key := range keys {
+		if result, ok := h.results[key]; ok {
+			results[key] = result
+		}
+	}
+	return results
+}
@@ -0,0 +1,166 @@
+package collector
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHealthCheckerDelay(t *testing.T) {
+	t.Parallel()
+
+	h := newHealthChecker(HealthCheckConfig{Interval: time.Minute, Jitter: time.Second})
+	phases := make(map[time.Duration]bool)
+	for _, target := range []string{"10.0.0.1:80", "10.0.0.2:80", "10.0.0.3:80", "10.0.0.4:80"} {
+		key := healthCheckKey{file: "nginx.conf", target: target}
+		delay := h.delay(key)
+		if delay < 0 || delay >= time.Minute+time.Second {
+			t.Errorf("delay(%v) = %v, want within the interval plus jitter", target, delay)
+		}
+		phases[delay.Truncate(time.Second)] = true
+	}
+	if len(phases) < 2 {
+		t.Errorf("delay() put all targets at the same phase: %v", phases)
+	}
+}
+
+func TestHealthChecker(t *testing.T) {
+	t.Parallel()
+
+	h := newHealthChecker(HealthCheckConfig{
+		Interval: time.Hour,
+		Dial: func(_ context.Context, _ string, address string) (net.Conn, error) {
+			if address == "up:80" {
+				client, server := net.Pipe()
+				_ = server.Close()
+				return client, nil
+			}
+			return nil, errors.New("connection refused")
+		},
+	})
+
+	up := healthCheckKey{file: "nginx.conf", target: "up"}
+	down := healthCheckKey{file: "nginx.conf", target: "down"}
+	for _, key := range []healthCheckKey{up, down} {
+		if _, ok := h.track(key.file, key.target, false); ok {
+			t.Errorf("track(%v) returned a result before the first check", key.target)
+		}
+	}
+	h.commit()
+	h.check(up)
+	h.check(down)
+
+	for key, want := range map[healthCheckKey]float64{up: 1, down: 0} {
+		if got, ok := h.track(key.file, key.target, false); !ok || got.result != want || got.at.IsZero() {
+			t.Errorf("track(%v) = %+v, %v, want %v, true", key.target, got, ok, want)
+		}
+	}
+
+	// Targets no longer found in the config are dropped with their results.
+	h.commit()
+	h.track(up.file, up.target, false)
+	h.commit()
+	h.check(down)
+	if _, ok := h.track(down.file, down.target, false); ok {
+		t.Error("track() returned a result for a target removed from the config")
+	}
+}
+
+func TestHealthCheckerCheckAll(t *testing.T) {
+	t.Parallel()
+
+	h := newHealthChecker(HealthCheckConfig{
+		Interval: time.Hour,
+		Dial: func(_ context.Context, _ string, address string) (net.Conn, error) {
+			if address == "up:80" {
+				client, server := net.Pipe()
+				_ = server.Close()
+				return client, nil
+			}
+			return nil, errors.New("connection refused")
+		},
+	})
+	if results := h.checkAll(); len(results) != 0 {
+		t.Errorf("checkAll() = %v before the first scrape, want no results", results)
+	}
+
+	up := healthCheckKey{file: "nginx.conf", target: "up"}
+	down := healthCheckKey{file: "nginx.conf", target: "down"}
+	h.track(up.file, up.target, false)
+	h.track(down.file, down.target, false)
+	h.commit()
+
+	results := h.checkAll()
+	if len(results) != 2 || results[up].result != 1 || results[down].result != 0 || results[up].at.IsZero() {
+		t.Errorf("checkAll() = %+v, want up healthy and down unhealthy", results)
+	}
+	if got, ok := h.track(down.file, down.target, false); !ok || got.result != 0 {
+		t.Errorf("track(%v) = %+v, %v after checkAll(), want the stored result", down.target, got, ok)
+	}
+}
+
+func TestHealthCheckerClose(t *testing.T) {
+	t.Parallel()
+
+	h := newHealthChecker(HealthCheckConfig{
+		Interval: time.Hour,
+		Dial: func(_ context.Context, _ string, _ string) (net.Conn, error) {
+			return nil, errors.New("connection refused")
+		},
+	})
+	key := healthCheckKey{file: "nginx.conf", target: "down"}
+	h.track(key.file, key.target, false)
+	h.commit()
+	h.close()
+	h.close()
+
+	// A check scheduled before close does not store its result.
+	h.check(key)
+	if results := h.checkAll(); len(results) != 0 {
+		t.Errorf("checkAll() = %v after close(), want no results", results)
+	}
+}
+
+func TestTCPHealthCheckTLS(t *testing.T) {
+	t.Parallel()
+
+	backend := httptest.NewUnstartedServer(http.NotFoundHandler())
+	backend.Config.ErrorLog = log.New(io.Discard, "", 0)
+	backend.StartTLS()
+	t.Cleanup(backend.Close)
+	target := strings.TrimPrefix(backend.URL, "https://")
+	trusted := x509.NewCertPool()
+	trusted.AddCert(backend.Certificate())
+
+	tests := []struct {
+		tls    *tls.Config
+		name   string
+		want   float64
+		useTLS bool
+	}{
+		{name: "trusted CA", tls: &tls.Config{RootCAs: trusted, MinVersion: tls.VersionTLS12}, useTLS: true, want: 1},
+		{name: "expected SAN", tls: &tls.Config{RootCAs: trusted, ServerName: "example.com", MinVersion: tls.VersionTLS12}, useTLS: true, want: 1},
+		{name: "unexpected SAN", tls: &tls.Config{RootCAs: trusted, ServerName: "backend.internal", MinVersion: tls.VersionTLS12}, useTLS: true, want: 0},
+		{name: "untrusted CA", tls: &tls.Config{RootCAs: x509.NewCertPool(), MinVersion: tls.VersionTLS12}, useTLS: true, want: 0},
+		{name: "plain target", tls: &tls.Config{RootCAs: x509.NewCertPool(), MinVersion: tls.VersionTLS12}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			check := newTCPHealthCheck(HealthCheckConfig{TLS: tt.tls})
+			if got := check.run(target, tt.useTLS); got.result != tt.want {
+				t.Errorf("run(%v, %v) = %v, want %v", target, tt.useTLS, got.result, tt.want)
+			}
+		})
+	}
+}
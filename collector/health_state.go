@@ -0,0 +1,147 @@
+package collector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+)
+
+// healthStateEntry is the last health check result of a target and the number of
+// times its result changed.
+type healthStateEntry struct {
+	File        string  `json:"file"`
+	Target      string  `json:"target"`
+	Result      float64 `json:"result"`
+	Transitions uint64  `json:"transitions"`
+}
+
+// healthStateFile is the content of the health state file.
+type healthStateFile struct {
+	Targets []*healthStateEntry `json:"targets"`
+}
+
+// healthState tracks the last result and the transitions of every health-checked
+// target. With a path, it is restored from and saved to a state file, so that an
+// exporter restart neither resets the transition counters nor blanks out the results
+// until the first background checks. It is only used by Collect, which is serialized.
+type healthState struct {
+	entries map[healthCheckKey]*healthStateEntry
+	path    string
+	// transitions are the recent changes of the results, oldest first.
+	transitions []HealthTransition
+	dirty       bool
+}
+
+// newHealthState : path 의 상태 파일을 읽어 이전 결과를 복원한다. 파일이 없거나 읽을 수 없으면 빈 상태로 시작한다.
+func newHealthState(path string, logger *slog.Logger) *healthState {
+	s := &healthState{entries: make(map[healthCheckKey]*healthStateEntry), path: path}
+	if path == "" {
+		return s
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			logger.Warn("error reading health state file", "file", path, "error", err.Error())
+		}
+		return s
+	}
+	var file healthStateFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		logger.Warn("error parsing health state file", "file", path, "error", err.Error())
+		return s
+	}
+	for _, entry := range file.Targets {
+		s.entries[healthCheckKey{file: entry.File, target: entry.Target}] = entry
+	}
+	return s
+}
+
+// get returns the last result of a target, which may have been restored from the state
+// file.
+func (s *healthState) get(key healthCheckKey) (*healthStateEntry, bool) {
+	entry, ok := s.entries[key]
+	return entry, ok
+}
+
+// record : target 의 검사 결과를 기록하고, 이전 결과와 다르면 transition 을 센다.
+func (s *healthState) record(key healthCheckKey, result float64) *healthStateEntry {
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &healthStateEntry{File: key.file, Target: key.target, Result: result}
+		s.entries[key] = entry
+		s.dirty = true
+		return entry
+	}
+	if entry.Result != result {
+		entry.Result = result
+		entry.Transitions++
+		s.dirty = true
+		s.transitions = append(s.transitions, HealthTransition{At: time.Now(), File: key.file, Target: key.target, Healthy: result == 1})
+		if len(s.transitions) > maxHealthTransitions {
+			s.transitions = slices.Delete(s.transitions, 0, len(s.transitions)-maxHealthTransitions)
+		}
+	}
+	return entry
+}
+
+// commit : 이번 scrape 에서 보지 못한 target 을 버리고, 변경이 있으면 상태 파일에 저장한다. 버린 target 의 수를 반환한다.
+func (s *healthState) commit(seen map[healthCheckKey]bool) (int, error) {
+	removed := 0
+	for key := range s.entries {
+		if !seen[key] {
+			delete(s.entries, key)
+			removed++
+			s.dirty = true
+		}
+	}
+	if s.path == "" || !s.dirty {
+		return removed, nil
+	}
+	if err := s.save(); err != nil {
+		return removed, err
+	}
+	s.dirty = false
+	return removed, nil
+}
+
+// save : 임시 파일에 쓴 뒤 rename 하여, 쓰는 도중 종료되어도 상태 파일이 깨지지 않도록 한다.
+func (s *healthState) save() error {
+	file := healthStateFile{Targets: make([]*healthStateEntry, 0, len(s.entries))}
+	for _, entry := range s.entries {
+		file.Targets = append(file.Targets, entry)
+	}
+	sort.Slice(file.Targets, func(i, j int) bool {
+		if file.Targets[i].File != file.Targets[j].File {
+			return file.Targets[i].File < file.Targets[j].File
+		}
+		return file.Targets[i].Target < file.Targets[j].Target
+	})
+	content, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to encode health state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write health state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write health state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write health state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write health state file: %w", err)
+	}
+	return nil
+}
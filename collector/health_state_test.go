@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHealthState(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "health.json")
+	logger := slog.New(slog.DiscardHandler)
+	up := healthCheckKey{file: "/etc/nginx/nginx.conf", target: "10.0.0.1:80"}
+	removed := healthCheckKey{file: "/etc/nginx/nginx.conf", target: "10.0.0.2:80"}
+
+	s := newHealthState(path, logger)
+	for _, result := range []float64{1, 0, 0, 1} {
+		s.record(up, result)
+	}
+	s.record(removed, 1)
+	if len(s.transitions) != 2 || s.transitions[0].Healthy || !s.transitions[1].Healthy || s.transitions[1].Target != up.target {
+		t.Errorf("transitions = %+v, expected a transition to unhealthy and back of %v", s.transitions, up.target)
+	}
+	if n, err := s.commit(map[healthCheckKey]bool{up: true, removed: true}); err != nil || n != 0 {
+		t.Fatalf("commit() = %v, %v, expected no removed targets", n, err)
+	}
+	if n, err := s.commit(map[healthCheckKey]bool{up: true}); err != nil || n != 1 {
+		t.Fatalf("commit() = %v, %v, expected 1 removed target", n, err)
+	}
+
+	restored := newHealthState(path, logger)
+	entry, ok := restored.get(up)
+	if !ok || entry.Result != 1 || entry.Transitions != 2 {
+		t.Errorf("get(%v) = %+v, %v, expected result 1 with 2 transitions", up, entry, ok)
+	}
+	if _, ok := restored.get(removed); ok {
+		t.Errorf("get(%v) returned a target removed before the restart", removed)
+	}
+	if entry := restored.record(up, 0); entry.Transitions != 3 {
+		t.Errorf("record() after restart counted %d transitions, expected 3", entry.Transitions)
+	}
+}
+
+func TestHealthStateTransitionsLimit(t *testing.T) {
+	t.Parallel()
+
+	s := newHealthState("", slog.New(slog.DiscardHandler))
+	key := healthCheckKey{file: "/etc/nginx/nginx.conf", target: "10.0.0.1:80"}
+	for i := range maxHealthTransitions + 11 {
+		s.record(key, float64(i%2))
+	}
+	if len(s.transitions) != maxHealthTransitions {
+		t.Fatalf("kept %d transitions, expected %d", len(s.transitions), maxHealthTransitions)
+	}
+	if last := s.transitions[len(s.transitions)-1]; last.Healthy {
+		t.Errorf("last transition = %+v, expected the most recent transition to unhealthy", last)
+	}
+}
+
+func TestHealthStateInvalidFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "health.json")
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := newHealthState(path, slog.New(slog.DiscardHandler))
+	if len(s.entries) != 0 {
+		t.Errorf("newHealthState() restored %d targets from an invalid file", len(s.entries))
+	}
+}
@@ -1,11 +1,9 @@
 package collector
 
 import (
-	"fmt"
+	"context"
 	"github.com/prometheus/client_golang/prometheus"
 	"net"
-	"os"
-	"regexp"
 	"strings"
 	"time"
 )
@@ -42,74 +40,25 @@ func MergeLabels(a map[string]string, b map[string]string) map[string]string {
 	return c
 }
 
-// getProxyPassTarget : nginx.conf를 읽어 proxy_pass target을 가져오는 함수.
-func extractProxyTarget(filePath string) ([]string, error) {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, err
-	}
-	contentStr := string(content)
-
-	re := regexp.MustCompile(`proxy_pass\s+(.*?);`)
-	matches := re.FindAllStringSubmatch(contentStr, -1)
-
-	var targets []string
-	for _, match := range matches {
-		if len(match) > 1 {
-			// match[1]은 proxy_pass 뒤의 URL 또는 upstream 이름. 해당 이름에 대해 전처리 수행.
-			target := strings.TrimSpace(match[1])
-			target = strings.TrimPrefix(target, "http://")
-			target = strings.TrimPrefix(target, "https://")
-
-			// 전처리된 이름이 IP or 도메인 형식이 아닐 아닐 경우, upstream 으로 간주.
-			ipFormat := regexp.MustCompile(`^\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}(:\d+)?$`)
-			domainFormat := regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*(:\d+)?$`)
-
-			if !ipFormat.MatchString(target) && !domainFormat.MatchString(target) {
-				upstreamServers, err := findUpstreamServers(contentStr, target)
-				if err == nil {
-					targets = append(targets, upstreamServers...)
-				}
-			} else {
-				targets = append(targets, target)
-			}
-		}
-	}
-
-	return targets, nil
-}
-
-// findUpstreamServers : upstream 블록에서 서버 주소를 찾습니다.
-func findUpstreamServers(content, upstreamName string) ([]string, error) {
-	// upstream 블록을 찾는 정규식
-	reUpstreamBlock := regexp.MustCompile(fmt.Sprintf(`upstream\s+%s\s*\{([\s\S]*?)\}`, regexp.QuoteMeta(upstreamName)))
-	blockMatch := reUpstreamBlock.FindStringSubmatch(content)
-	if len(blockMatch) < 2 {
-		return nil, fmt.Errorf("upstream block '%s' not found", upstreamName)
-	}
-	upstreamContent := blockMatch[1]
-
-	// upstream 블록 내에서 server 주소를 찾는 정규식
-	reServer := regexp.MustCompile(`server\s+([^; ]+);`)
-	serverMatches := reServer.FindAllStringSubmatch(upstreamContent, -1)
+// DialContextFunc dials a network connection, like net.Dialer.DialContext.
+type DialContextFunc func(ctx context.Context, network string, address string) (net.Conn, error)
 
-	var servers []string
-	for _, serverMatch := range serverMatches {
-		if len(serverMatch) > 1 {
-			servers = append(servers, serverMatch[1])
-		}
-	}
-
-	return servers, nil
-}
+// tcpTestTimeout bounds a single TCP health check.
+const tcpTestTimeout = 3 * time.Second
 
 // tcpTest : proxyTarget 인자를 받아 TCP 연결을 테스트하는 함수.
-func tcpTest(proxyTarget string) (result float64, err error) {
+// dial 이 nil 이 아니면 (예: SOCKS5 프록시) 해당 dialer 로 연결한다.
+func tcpTest(dial DialContextFunc, proxyTarget string) (result float64, err error) {
 	if !strings.Contains(proxyTarget, ":") {
 		proxyTarget = proxyTarget + ":80"
 	}
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
 
-	conn, err := net.DialTimeout("tcp", proxyTarget, 3*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), tcpTestTimeout)
+	defer cancel()
+	conn, err := dial(ctx, "tcp", proxyTarget)
 	if err != nil {
 		return 0.0, nil
 	} else if conn != nil {
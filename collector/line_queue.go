@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Default bounds of the lines of a log read but not yet processed.
+const (
+	DefaultLogQueueSize   = 10000
+	DefaultLogMemoryLimit = 16 << 20
+)
+
+// lineQueue is a bounded queue of the lines read from a log. It holds at most a number of
+// lines and of bytes, and lines that do not fit are dropped and counted, so that a traffic
+// spike does not grow the memory of the exporter without bound.
+type lineQueue struct {
+	dropped  prometheus.Counter
+	lines    chan string
+	bytes    atomic.Int64
+	maxBytes int64
+}
+
+func newLineQueue(size int, maxBytes int64, dropped prometheus.Counter) *lineQueue {
+	if size <= 0 {
+		size = DefaultLogQueueSize
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultLogMemoryLimit
+	}
+	return &lineQueue{
+		dropped:  dropped,
+		lines:    make(chan string, size),
+		maxBytes: maxBytes,
+	}
+}
+
+// push : line 을 큐에 넣는다. 큐가 가득 찼거나 메모리 한도를 넘으면 기다리지 않고 line 을 버린다.
+func (q *lineQueue) push(line string) {
+	n := int64(len(line))
+	if q.bytes.Add(n) > q.maxBytes {
+		q.bytes.Add(-n)
+		q.dropped.Inc()
+		return
+	}
+	select {
+	case q.lines <- line:
+	default:
+		q.bytes.Add(-n)
+		q.dropped.Inc()
+	}
+}
+
+// done : 큐에서 꺼낸 line 의 처리가 끝나 메모리 한도에서 제외한다.
+func (q *lineQueue) done(line string) {
+	q.bytes.Add(-int64(len(line)))
+}
@@ -0,0 +1,31 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestLineQueue(t *testing.T) {
+	t.Parallel()
+
+	dropped := prometheus.NewCounter(prometheus.CounterOpts{Name: "dropped"})
+	q := newLineQueue(2, 10, dropped)
+
+	// The second line exceeds the memory limit and the fourth the queue size.
+	for _, line := range []string{"aaaa", "bbbbbbb", "cc", "d"} {
+		q.push(line)
+	}
+	if got := testutil.ToFloat64(dropped); got != 2 {
+		t.Errorf("dropped %v lines, expected 2", got)
+	}
+	if got := q.bytes.Load(); got != 6 {
+		t.Errorf("queued %d bytes, expected 6", got)
+	}
+	q.done(<-q.lines)
+	q.push("d")
+	if got := testutil.ToFloat64(dropped); got != 2 {
+		t.Errorf("dropped %v lines after a line was processed, expected 2", got)
+	}
+}
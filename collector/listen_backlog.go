@@ -0,0 +1,158 @@
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// listenSocket is a listening TCP socket with the current and maximum length of its
+// accept queue.
+type listenSocket struct {
+	local netip.AddrPort
+	queue uint32
+	limit uint32
+}
+
+// listenAddress is the address of a listen directive. A host name matches any address
+// of the port, since the exporter does not know what NGINX resolved it to.
+type listenAddress struct {
+	addr    netip.Addr
+	port    uint16
+	anyAddr bool
+}
+
+// collectListenBacklog : listen 지시어별로 해당 listen socket 의 accept queue 길이와 최대값을 합산하여 전송한다.
+// reuseport 로 하나의 listen 지시어에 socket 이 여러 개일 수 있으므로 합산한다. overflow 는 socket 별로 알 수 없어
+// /proc/net/netstat 의 host 전체 카운터를 전송한다.
+func (c *NginxCollector) collectListenBacklog(ch chan<- prometheus.Metric, cfg *nginxconf.Config) {
+	sockets, err := listenSockets()
+	if err != nil {
+		c.logger.Warn("error reading listen sockets", "error", err.Error())
+		return
+	}
+
+	for _, listen := range configListens(cfg) {
+		address, ok := parseListenAddress(listen)
+		if !ok {
+			continue
+		}
+		var queue, limit float64
+		for _, s := range sockets {
+			if address.matches(s.local) {
+				queue += float64(s.queue)
+				limit += float64(s.limit)
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(c.listenQueueDesc, prometheus.GaugeValue, queue, listen)
+		ch <- prometheus.MustNewConstMetric(c.listenQueueLimitDesc, prometheus.GaugeValue, limit, listen)
+	}
+
+	f, err := os.Open(filepath.Join(c.procRoot, "net", "netstat"))
+	if err != nil {
+		c.logger.Warn("error reading listen overflows", "error", err.Error())
+		return
+	}
+	defer f.Close()
+	stats, err := parseNetstat(f)
+	if err != nil {
+		c.logger.Warn("error reading listen overflows", "error", err.Error())
+		return
+	}
+	if v, ok := stats["TcpExt"]["ListenOverflows"]; ok {
+		ch <- prometheus.MustNewConstMetric(c.listenOverflowsDesc, prometheus.CounterValue, v)
+	}
+	if v, ok := stats["TcpExt"]["ListenDrops"]; ok {
+		ch <- prometheus.MustNewConstMetric(c.listenDropsDesc, prometheus.CounterValue, v)
+	}
+}
+
+// configListens returns the distinct addresses of the listen directives of the config.
+func configListens(cfg *nginxconf.Config) []string {
+	seen := make(map[string]bool)
+	var listens []string
+	cfg.Walk(func(d *nginxconf.Directive, _ []*nginxconf.Directive) {
+		if d.Name != "listen" || len(d.Args) == 0 || seen[d.Args[0]] {
+			return
+		}
+		seen[d.Args[0]] = true
+		listens = append(listens, d.Args[0])
+	})
+	sort.Strings(listens)
+	return listens
+}
+
+// parseListenAddress : listen 지시어의 주소를 해석한다. NGINX 와 같이 port 만 있거나 * 이면 0.0.0.0, 주소만 있으면
+// 80 port 이며, unix domain socket 은 제외한다.
+func parseListenAddress(listen string) (listenAddress, bool) {
+	if strings.HasPrefix(listen, "unix:") {
+		return listenAddress{}, false
+	}
+	if port, err := strconv.ParseUint(listen, 10, 16); err == nil {
+		return listenAddress{addr: netip.IPv4Unspecified(), port: uint16(port)}, true
+	}
+
+	host, portStr := listen, "80"
+	if h, p, err := net.SplitHostPort(listen); err == nil {
+		host, portStr = h, p
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return listenAddress{}, false
+	}
+	address := listenAddress{port: uint16(port)}
+	if host == "*" {
+		address.addr = netip.IPv4Unspecified()
+	} else if addr, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		address.addr = addr.Unmap()
+	} else {
+		address.anyAddr = true
+	}
+	return address, true
+}
+
+// matches reports whether a listen socket was created for the address.
+func (a listenAddress) matches(local netip.AddrPort) bool {
+	if local.Port() != a.port {
+		return false
+	}
+	return a.anyAddr || local.Addr() == a.addr
+}
+
+// parseNetstat parses /proc/net/netstat, where every group such as TcpExt has a line of
+// names followed by a line of values.
+func parseNetstat(r io.Reader) (map[string]map[string]float64, error) {
+	stats := make(map[string]map[string]float64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		names := strings.Fields(scanner.Text())
+		if !scanner.Scan() {
+			break
+		}
+		values := strings.Fields(scanner.Text())
+		if len(names) == 0 || len(names) != len(values) || names[0] != values[0] {
+			return nil, fmt.Errorf("invalid netstat line %q", scanner.Text())
+		}
+		group := strings.TrimSuffix(names[0], ":")
+		stats[group] = make(map[string]float64, len(names)-1)
+		for i := 1; i < len(names); i++ {
+			if v, err := strconv.ParseFloat(values[i], 64); err == nil {
+				stats[group][names[i]] = v
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read netstat: %w", err)
+	}
+	return stats, nil
+}
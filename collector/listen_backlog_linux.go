@@ -0,0 +1,111 @@
+//go:build linux
+
+package collector
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"syscall"
+)
+
+// Constants of linux/sock_diag.h and linux/inet_diag.h.
+const (
+	sockDiagByFamily = 20
+	tcpListenState   = 10
+	inetDiagReqLen   = 56
+	inetDiagMsgLen   = 72
+)
+
+// listenSockets : NETLINK_INET_DIAG 로 IPv4, IPv6 의 LISTEN 상태 TCP socket 을 조회한다. LISTEN socket 의
+// idiag_rqueue 는 accept queue 의 현재 길이, idiag_wqueue 는 backlog 최대값이다.
+func listenSockets() ([]listenSocket, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_INET_DIAG)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open netlink socket: %w", err)
+	}
+	defer syscall.Close(fd)
+
+	var sockets []listenSocket
+	for seq, family := range []uint8{syscall.AF_INET, syscall.AF_INET6} {
+		s, err := dumpListenSockets(fd, family, uint32(seq+1))
+		if err != nil {
+			return nil, err
+		}
+		sockets = append(sockets, s...)
+	}
+	return sockets, nil
+}
+
+func dumpListenSockets(fd int, family uint8, seq uint32) ([]listenSocket, error) {
+	req := make([]byte, syscall.NLMSG_HDRLEN+inetDiagReqLen)
+	binary.NativeEndian.PutUint32(req[0:4], uint32(len(req)))
+	binary.NativeEndian.PutUint16(req[4:6], sockDiagByFamily)
+	binary.NativeEndian.PutUint16(req[6:8], syscall.NLM_F_REQUEST|syscall.NLM_F_DUMP)
+	binary.NativeEndian.PutUint32(req[8:12], seq)
+	body := req[syscall.NLMSG_HDRLEN:]
+	body[0] = family
+	body[1] = syscall.IPPROTO_TCP
+	binary.NativeEndian.PutUint32(body[4:8], 1<<tcpListenState)
+
+	if err := syscall.Sendto(fd, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("failed to send inet_diag request: %w", err)
+	}
+
+	var sockets []listenSocket
+	buf := make([]byte, os.Getpagesize()*8)
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to receive inet_diag response: %w", err)
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return nil, fmt.Errorf("invalid inet_diag response: %w", err)
+		}
+		for _, msg := range msgs {
+			if msg.Header.Seq != seq {
+				continue
+			}
+			switch msg.Header.Type {
+			case syscall.NLMSG_DONE:
+				return sockets, nil
+			case syscall.NLMSG_ERROR:
+				if len(msg.Data) >= 4 {
+					if errno := -int32(binary.NativeEndian.Uint32(msg.Data[0:4])); errno != 0 {
+						return nil, fmt.Errorf("inet_diag request failed: %w", syscall.Errno(errno))
+					}
+				}
+				return nil, errors.New("inet_diag request failed")
+			}
+			if s, ok := parseInetDiagMsg(msg.Data); ok {
+				sockets = append(sockets, s)
+			}
+		}
+	}
+}
+
+// parseInetDiagMsg parses a struct inet_diag_msg. The ports and addresses of its
+// struct inet_diag_sockid are in network byte order.
+func parseInetDiagMsg(data []byte) (listenSocket, bool) {
+	if len(data) < inetDiagMsgLen {
+		return listenSocket{}, false
+	}
+	port := binary.BigEndian.Uint16(data[4:6])
+	var addr netip.Addr
+	switch data[0] {
+	case syscall.AF_INET:
+		addr = netip.AddrFrom4([4]byte(data[8:12]))
+	case syscall.AF_INET6:
+		addr = netip.AddrFrom16([16]byte(data[8:24])).Unmap()
+	default:
+		return listenSocket{}, false
+	}
+	return listenSocket{
+		local: netip.AddrPortFrom(addr, port),
+		queue: binary.NativeEndian.Uint32(data[56:60]),
+		limit: binary.NativeEndian.Uint32(data[60:64]),
+	}, true
+}
@@ -0,0 +1,35 @@
+//go:build linux
+
+package collector
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestListenSockets(t *testing.T) {
+	t.Parallel()
+
+	listener, err := (&net.ListenConfig{}).Listen(context.Background(), "tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	local := netip.MustParseAddrPort(listener.Addr().String())
+
+	sockets, err := listenSockets()
+	if err != nil {
+		t.Fatalf("listenSockets() returned error: %v", err)
+	}
+	for _, s := range sockets {
+		if s.local == local {
+			if s.limit == 0 {
+				t.Errorf("listen socket %v has no backlog", local)
+			}
+			return
+		}
+	}
+	t.Errorf("listenSockets() = %v, want a socket on %v", sockets, local)
+}
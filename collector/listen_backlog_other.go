@@ -0,0 +1,10 @@
+//go:build !linux
+
+package collector
+
+import "errors"
+
+// listenSockets is not supported on this platform.
+func listenSockets() ([]listenSocket, error) {
+	return nil, errors.New("listen sockets can only be read on Linux")
+}
@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestParseListenAddress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		listen  string
+		matches []string
+		misses  []string
+		ok      bool
+	}{
+		{listen: "80", ok: true, matches: []string{"0.0.0.0:80"}, misses: []string{"127.0.0.1:80", "[::]:80", "0.0.0.0:8080"}},
+		{listen: "*:8080", ok: true, matches: []string{"0.0.0.0:8080"}, misses: []string{"10.0.0.1:8080"}},
+		{listen: "127.0.0.1", ok: true, matches: []string{"127.0.0.1:80"}, misses: []string{"0.0.0.0:80"}},
+		{listen: "10.0.0.1:443", ok: true, matches: []string{"10.0.0.1:443"}, misses: []string{"10.0.0.2:443"}},
+		{listen: "[::]:443", ok: true, matches: []string{"[::]:443"}, misses: []string{"0.0.0.0:443"}},
+		{listen: "[::1]", ok: true, matches: []string{"[::1]:80"}},
+		{listen: "localhost:8080", ok: true, matches: []string{"127.0.0.1:8080", "[::1]:8080"}, misses: []string{"127.0.0.1:80"}},
+		{listen: "unix:/run/nginx.sock"},
+		{listen: "10.0.0.1:http"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.listen, func(t *testing.T) {
+			t.Parallel()
+
+			address, ok := parseListenAddress(tt.listen)
+			if ok != tt.ok {
+				t.Fatalf("parseListenAddress(%q) ok = %v, want %v", tt.listen, ok, tt.ok)
+			}
+			for _, local := range tt.matches {
+				if !address.matches(netip.MustParseAddrPort(local)) {
+					t.Errorf("listen %q does not match socket %v", tt.listen, local)
+				}
+			}
+			for _, local := range tt.misses {
+				if address.matches(netip.MustParseAddrPort(local)) {
+					t.Errorf("listen %q matches socket %v", tt.listen, local)
+				}
+			}
+		})
+	}
+}
+
+func TestParseNetstat(t *testing.T) {
+	t.Parallel()
+
+	content := "TcpExt: SyncookiesSent ListenOverflows ListenDrops\n" +
+		"TcpExt: 0 12 15\n" +
+		"IpExt: InNoRoutes\n" +
+		"IpExt: 3\n"
+	stats, err := parseNetstat(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("parseNetstat() returned error: %v", err)
+	}
+	if got := stats["TcpExt"]["ListenOverflows"]; got != 12 {
+		t.Errorf("ListenOverflows = %v, want 12", got)
+	}
+	if got := stats["IpExt"]["InNoRoutes"]; got != 3 {
+		t.Errorf("InNoRoutes = %v, want 3", got)
+	}
+
+	if _, err := parseNetstat(strings.NewReader("TcpExt: ListenOverflows\nIpExt: 3\n")); err == nil {
+		t.Error("parseNetstat() returned no error for mismatched lines")
+	}
+}
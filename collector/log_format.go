@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultAccessLogFormat is the predefined combined format of the NGINX access log.
+const DefaultAccessLogFormat = `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"`
+
+var logFormatVariableRe = regexp.MustCompile(`\$(?:\{(\w+)\}|(\w+))`)
+
+// logFormat matches the lines of an access log written with an NGINX log_format.
+type logFormat struct {
+	re *regexp.Regexp
+	// index maps a variable name to its submatch index.
+	index map[string]int
+}
+
+// logEntry is a line of an access log split into the values of its variables.
+type logEntry struct {
+	format *logFormat
+	values []string
+}
+
+// parseLogFormat : log_format 문자열을 줄 전체와 일치하는 정규식으로 변환한다. 변수는 capture group 이 되고, 변수 사이의
+// 문자열은 그대로 일치해야 한다. 같은 변수가 여러 번 나오면 처음 값을 사용한다.
+func parseLogFormat(format string) (*logFormat, error) {
+	matches := logFormatVariableRe.FindAllStringSubmatchIndex(format, -1)
+	if len(matches) == 0 {
+		return nil, errors.New("invalid log format: no variables")
+	}
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	index := make(map[string]int, len(matches))
+	last := 0
+	for _, m := range matches {
+		pattern.WriteString(regexp.QuoteMeta(format[last:m[0]]))
+		var name string
+		if m[2] >= 0 {
+			name = format[m[2]:m[3]]
+		} else {
+			name = format[m[4]:m[5]]
+		}
+		if _, ok := index[name]; ok {
+			pattern.WriteString("(?:.*?)")
+		} else {
+			index[name] = len(index) + 1
+			pattern.WriteString("(.*?)")
+		}
+		last = m[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(format[last:]))
+	pattern.WriteString("$")
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid log format: %w", err)
+	}
+	return &logFormat{re: re, index: index}, nil
+}
+
+// parse splits line into the values of the variables of the format.
+func (f *logFormat) parse(line string) (logEntry, bool) {
+	values := f.re.FindStringSubmatch(line)
+	if values == nil {
+		return logEntry{}, false
+	}
+	return logEntry{format: f, values: values}, true
+}
+
+// has reports whether the format contains the variable.
+func (f *logFormat) has(name string) bool {
+	_, ok := f.index[name]
+	return ok
+}
+
+// get returns the value of a variable. Variables missing from the format and empty
+// values, which NGINX writes as "-", are returned as "".
+func (e logEntry) get(name string) string {
+	i, ok := e.format.index[name]
+	if !ok || e.values[i] == "-" {
+		return ""
+	}
+	return e.values[i]
+}
@@ -0,0 +1,47 @@
+package collector
+
+import (
+	"testing"
+)
+
+func TestParseLogFormat(t *testing.T) {
+	t.Parallel()
+
+	format, err := parseLogFormat(`$remote_addr [$time_local] "$request" $status ${upstream_cache_status}_x $host $status`)
+	if err != nil {
+		t.Fatalf("parseLogFormat() returned error: %v", err)
+	}
+
+	entry, ok := format.parse(`10.0.0.1 [16/Oct/2026:10:00:00 +0000] "GET /a b HTTP/1.1" 200 HIT_x www.example.com 200`)
+	if !ok {
+		t.Fatal("parse() did not match the line")
+	}
+	expected := map[string]string{
+		"remote_addr":           "10.0.0.1",
+		"time_local":            "16/Oct/2026:10:00:00 +0000",
+		"request":               "GET /a b HTTP/1.1",
+		"status":                "200",
+		"upstream_cache_status": "HIT",
+		"host":                  "www.example.com",
+		"missing":               "",
+	}
+	for name, value := range expected {
+		if got := entry.get(name); got != value {
+			t.Errorf("get(%q) = %q, expected %q", name, got, value)
+		}
+	}
+
+	if entry, ok = format.parse(`10.0.0.1 [-] "-" 499 -_x - 499`); !ok {
+		t.Fatal("parse() did not match the line with empty values")
+	}
+	if got := entry.get("upstream_cache_status"); got != "" {
+		t.Errorf("get(upstream_cache_status) = %q, expected empty value", got)
+	}
+
+	if _, ok := format.parse("not an access log line"); ok {
+		t.Error("parse() matched an invalid line")
+	}
+	if _, err := parseLogFormat("no variables"); err == nil {
+		t.Error("parseLogFormat() returned no error for a format without variables")
+	}
+}
@@ -0,0 +1,136 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// logTailer follows a log file like tail -F. Lines written before the first poll are
+// skipped, and a rotated or truncated file is read again from the start. Lines longer
+// than maxLineSize, when set, are skipped and reported to dropped.
+type logTailer struct {
+	file        *os.File
+	reader      *bufio.Reader
+	dropped     func()
+	path        string
+	partial     []byte
+	offset      int64
+	maxLineSize int
+	fromStart   bool
+	skipping    bool
+}
+
+func newLogTailer(path string) *logTailer {
+	return &logTailer{path: path}
+}
+
+// poll : 마지막 poll 이후 추가된 완결된 줄마다 fn 을 호출한다. 파일이 교체되었으면 이전 파일의 나머지를 읽은 뒤 새 파일을
+// 처음부터 읽고, 파일이 잘렸으면 처음부터 다시 읽는다.
+func (t *logTailer) poll(fn func(line string)) error {
+	if t.file == nil {
+		if err := t.open(); err != nil {
+			return err
+		}
+	}
+	if err := t.read(fn); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(t.path)
+	if err != nil {
+		// logrotate 가 파일을 옮긴 뒤 아직 새 파일이 만들어지지 않았다.
+		return nil
+	}
+	current, err := t.file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat access log: %w", err)
+	}
+	switch {
+	case !os.SameFile(info, current):
+		t.close()
+		t.fromStart = true
+		if err := t.open(); err != nil {
+			return err
+		}
+		return t.read(fn)
+	case info.Size() < t.offset:
+		if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to seek access log: %w", err)
+		}
+		t.reader.Reset(t.file)
+		t.offset, t.partial, t.skipping = 0, nil, false
+		return t.read(fn)
+	}
+	return nil
+}
+
+func (t *logTailer) open() error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return fmt.Errorf("failed to open access log: %w", err)
+	}
+	var offset int64
+	if !t.fromStart {
+		if offset, err = f.Seek(0, io.SeekEnd); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("failed to seek access log: %w", err)
+		}
+	}
+	t.file, t.offset, t.partial, t.skipping = f, offset, nil, false
+	if t.reader == nil {
+		t.reader = bufio.NewReader(f)
+	} else {
+		t.reader.Reset(f)
+	}
+	return nil
+}
+
+// read : EOF 까지 읽는다. 아직 줄바꿈이 쓰이지 않은 마지막 줄은 다음 read 까지 보관한다.
+func (t *logTailer) read(fn func(line string)) error {
+	for {
+		// ReadBytes 와 달리 ReadSlice 는 buffer 크기만큼씩 반환하므로, 줄바꿈 없이 계속 쓰이는 줄도 maxLineSize 까지만 보관한다.
+		chunk, err := t.reader.ReadSlice('\n')
+		t.offset += int64(len(chunk))
+		t.keep(chunk)
+		switch {
+		case err == nil:
+			line := t.partial
+			t.partial = t.partial[:0]
+			if t.skipping {
+				t.skipping = false
+				continue
+			}
+			fn(string(bytes.TrimRight(line, "\r\n")))
+		case errors.Is(err, bufio.ErrBufferFull):
+		case errors.Is(err, io.EOF):
+			return nil
+		default:
+			return fmt.Errorf("failed to read access log: %w", err)
+		}
+	}
+}
+
+// keep : 읽은 줄의 일부를 보관한다. maxLineSize 를 넘는 줄은 버리고 줄바꿈까지 건너뛴다.
+func (t *logTailer) keep(chunk []byte) {
+	if t.skipping {
+		return
+	}
+	t.partial = append(t.partial, chunk...)
+	if t.maxLineSize > 0 && len(t.partial) > t.maxLineSize {
+		t.partial, t.skipping = nil, true
+		if t.dropped != nil {
+			t.dropped()
+		}
+	}
+}
+
+func (t *logTailer) close() {
+	if t.file != nil {
+		_ = t.file.Close()
+		t.file = nil
+	}
+}
@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLogTailer(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "access.log")
+	write := func(flag int, content string) {
+		t.Helper()
+		f, err := os.OpenFile(path, flag|os.O_WRONLY, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tailer := newLogTailer(path)
+	defer tailer.close()
+	var lines []string
+	poll := func() {
+		t.Helper()
+		if err := tailer.poll(func(line string) { lines = append(lines, line) }); err != nil {
+			t.Fatalf("poll() returned error: %v", err)
+		}
+	}
+
+	write(os.O_CREATE, "old\n")
+	poll()
+	write(os.O_APPEND, "a\nb")
+	poll()
+	write(os.O_APPEND, "c\n")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	write(os.O_CREATE, "d\r\n")
+	poll()
+	write(os.O_TRUNC, "e\n")
+	poll()
+
+	expected := []string{"a", "bc", "d", "e"}
+	if !slices.Equal(lines, expected) {
+		t.Errorf("poll() read %q, expected %q", lines, expected)
+	}
+}
+
+func TestLogTailerMaxLineSize(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "access.log")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dropped := 0
+	tailer := newLogTailer(path)
+	tailer.maxLineSize = 10
+	tailer.dropped = func() { dropped++ }
+	defer tailer.close()
+
+	var lines []string
+	poll := func() {
+		t.Helper()
+		if err := tailer.poll(func(line string) { lines = append(lines, line) }); err != nil {
+			t.Fatalf("poll() returned error: %v", err)
+		}
+	}
+	poll()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// The long line is larger than the buffer of the reader and is written in two parts.
+	if _, err := f.WriteString("a\n" + strings.Repeat("x", 5000)); err != nil {
+		t.Fatal(err)
+	}
+	poll()
+	if _, err := f.WriteString(strings.Repeat("x", 5000) + "\nb\n"); err != nil {
+		t.Fatal(err)
+	}
+	poll()
+
+	expected := []string{"a", "b"}
+	if !slices.Equal(lines, expected) || dropped != 1 {
+		t.Errorf("poll() read %q and dropped %d lines, expected %q and 1", lines, dropped, expected)
+	}
+}
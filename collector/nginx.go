@@ -1,36 +1,98 @@
 package collector
 
 import (
-	"io/fs"
 	"log/slog"
-	"os"
-	"path/filepath"
-	"strings"
+	"net/http"
 	"sync"
+	"time"
 
 	"github.com/nginx/nginx-prometheus-exporter/client"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// StubStatsClient fetches the stub_status stats of NGINX. It is implemented by
+// client.NginxClient and can be implemented by custom clients, e.g. mocks or caching clients.
+type StubStatsClient interface {
+	GetStubStats() (*client.StubStats, error)
+}
+
 // NginxCollector collects NGINX metrics. It implements prometheus.Collector interface.
 type NginxCollector struct {
 	upMetric    prometheus.Gauge
 	logger      *slog.Logger
-	nginxClient *client.NginxClient
+	nginxClient StubStatsClient
 	metrics     map[string]*prometheus.Desc
 	mutex       sync.Mutex
 
 	// Custom For Nginx Proxy //
-	nginxConfigPath         string
-	configModDesc           *prometheus.Desc
-	upstreamHealthCheckDesc *prometheus.Desc
+	nginxConfigPath               string
+	nginxBinaryPath               string
+	buildInfo                     nginxBuildInfoCache
+	configLabelMode               string
+	configStrict                  bool
+	upstreamTCPStates             bool
+	conntrack                     bool
+	listenBacklog                 bool
+	sampleTimestamps              bool
+	securityAudit                 bool
+	configSource                  string
+	configSourceClient            *http.Client
+	configSourceTimeout           time.Duration
+	healthCheck                   tcpHealthCheck
+	healthChecker                 *healthChecker
+	healthState                   *healthState
+	configModDesc                 *prometheus.Desc
+	upstreamHealthCheckDesc       *prometheus.Desc
+	upstreamHealthTransitionsDesc *prometheus.Desc
+	upstreamHealthRemovedDesc     *prometheus.Desc
+	upstreamHealthFamilyDesc      *prometheus.Desc
+	upstreamHealthOverflowDesc    *prometheus.Desc
+	buildInfoDesc                 *prometheus.Desc
+	buildModuleDesc               *prometheus.Desc
+	missingIncludesDesc           *prometheus.Desc
+	settingInfoDesc               *prometheus.Desc
+	upstreamServerInfoDesc        *prometheus.Desc
+	insecurePermissionsDesc       *prometheus.Desc
+	configWarningsDesc            *prometheus.Desc
+	configHashDesc                *prometheus.Desc
+	// security audit 메트릭은 securityAudit 이 true 일 때만 수집한다.
+	securityFindingsDesc *prometheus.Desc
+	// upstream TCP 상태 메트릭은 upstreamTCPStates 가 true 일 때만 수집한다.
+	upstreamTCPConnectionsDesc *prometheus.Desc
+	// conntrack 메트릭은 conntrack 이 true 일 때만 수집한다.
+	conntrackEntriesDesc         *prometheus.Desc
+	conntrackLimitDesc           *prometheus.Desc
+	upstreamConntrackEntriesDesc *prometheus.Desc
+	// listen backlog 메트릭은 listenBacklog 가 true 일 때만 수집한다.
+	listenQueueDesc      *prometheus.Desc
+	listenQueueLimitDesc *prometheus.Desc
+	listenOverflowsDesc  *prometheus.Desc
+	listenDropsDesc      *prometheus.Desc
+	procRoot             string
+	// snapshot 은 configRefreshInterval 마다 background 에서 읽은 설정으로, Collect 는 이를 그대로 사용한다.
+	snapshot              *configSnapshot
+	stopped               chan struct{}
+	configRefreshInterval time.Duration
+	configRefresh         sync.Once
+	stop                  sync.Once
+	snapshotMutex         sync.Mutex
+	// pendingHealth 는 Collect 중에 모은 health check 결과로, commitHealthState 에서 status 로 옮긴다.
+	pendingHealth []UpstreamHealth
+	// overflowHealth 는 maxHealthTargets 를 넘어 검사하지 않은 target 으로, commitHealthState 에서 비운다.
+	overflowHealth       map[healthCheckKey]bool
+	status               collectorStatus
+	removedHealthTargets uint64
+	maxHealthTargets     int
 }
 
-// NewNginxCollector creates an NginxCollector.
-func NewNginxCollector(nginxClient *client.NginxClient, namespace string, constLabels map[string]string, logger *slog.Logger, nginxConfigPath string) *NginxCollector {
-	return &NginxCollector{
+// NewNginxCollector creates an NginxCollector configured by opts.
+func NewNginxCollector(nginxClient StubStatsClient, opts ...Option) *NginxCollector {
+	o := newOptions(DefaultNamespace, opts)
+	namespace, constLabels := o.namespace, o.constLabels
+
+	c := &NginxCollector{
 		nginxClient: nginxClient,
-		logger:      logger,
+		logger:      o.logger,
 		metrics: map[string]*prometheus.Desc{
 			"connections_active":   newGlobalMetric(namespace, "connections_active", "Active client connections", constLabels),
 			"connections_accepted": newGlobalMetric(namespace, "connections_accepted", "Accepted client connections", constLabels),
@@ -51,8 +113,146 @@ func NewNginxCollector(nginxClient *client.NginxClient, namespace string, constL
 			"Proxy Target의 TCP 연결 상태(1: 성공, 0: 실패)",
 			[]string{"file", "target"}, constLabels,
 		),
-		nginxConfigPath: nginxConfigPath,
+		upstreamHealthTransitionsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "upstream", "health_check_transitions_total"),
+			"Number of times the health check status of a proxy target changed",
+			[]string{"file", "target"}, constLabels,
+		),
+		upstreamHealthRemovedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "upstream", "health_check_targets_removed_total"),
+			"Number of health-checked proxy targets that disappeared from the config and are no longer exported",
+			nil, constLabels,
+		),
+		upstreamHealthFamilyDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "upstream", "health_check_family_status"),
+			"Whether a dual-stack proxy target accepted a TCP connection over the address family (1: success, 0: failure)",
+			[]string{"file", "target", "family"}, constLabels,
+		),
+		upstreamHealthOverflowDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "upstream", "health_check_targets_overflow"),
+			"Number of proxy targets that were not health-checked because the config has more targets than the maximum",
+			nil, constLabels,
+		),
+		buildInfoDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "build", "info"),
+			"NGINX build information reported by nginx -V",
+			[]string{"version", "compiler", "openssl", "configure_args"}, constLabels,
+		),
+		buildModuleDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "build", "module"),
+			"Modules compiled into the NGINX binary",
+			[]string{"module"}, constLabels,
+		),
+		missingIncludesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "config", "missing_includes_total"),
+			"Include directives whose pattern matches no files",
+			[]string{"file", "pattern"}, constLabels,
+		),
+		settingInfoDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "config", "setting_info"),
+			"Configured value of timeout and request size directives per context",
+			[]string{"directive", "context", "file", "value"}, constLabels,
+		),
+		upstreamServerInfoDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "config", "upstream_server_info"),
+			"Servers of the upstream blocks with their load balancing weight and failover parameters, NGINX defaults when not set",
+			[]string{"upstream", "server", "weight", "backup", "max_fails", "fail_timeout"}, constLabels,
+		),
+		insecurePermissionsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "config", "insecure_permissions"),
+			"Config and TLS private key files with insecure permissions or ownership",
+			[]string{"file", "issue"}, constLabels,
+		),
+		configHashDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "config", "hash"),
+			"SHA-256 hash of the fully resolved NGINX config, ignoring comments and formatting",
+			[]string{"hash"}, constLabels,
+		),
+		securityFindingsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "config", "security_findings"),
+			"Risky settings of the NGINX config by audit rule, such as server_tokens on, legacy ssl_protocols, TLS servers without HSTS or autoindex on",
+			[]string{"rule", "file"}, constLabels,
+		),
+		configWarningsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "exporter", "config_warnings_total"),
+			"Config constructs the exporter cannot model, such as unparsable files or proxy targets it cannot health check",
+			[]string{"file", "kind"}, constLabels,
+		),
+		upstreamTCPConnectionsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "upstream", "tcp_connections"),
+			"TCP sockets of the host toward proxy targets by state",
+			[]string{"target", "state"}, constLabels,
+		),
+		conntrackEntriesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "conntrack", "entries"),
+			"Entries of the netfilter connection tracking table of the host",
+			nil, constLabels,
+		),
+		conntrackLimitDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "conntrack", "entries_limit"),
+			"Maximum number of entries of the netfilter connection tracking table of the host",
+			nil, constLabels,
+		),
+		upstreamConntrackEntriesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "upstream", "conntrack_entries"),
+			"Netfilter connection tracking entries toward proxy targets",
+			[]string{"target"}, constLabels,
+		),
+		listenQueueDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "listen", "queue_length"),
+			"Connections waiting in the accept queues of the sockets of a listen directive",
+			[]string{"listen"}, constLabels,
+		),
+		listenQueueLimitDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "listen", "queue_limit"),
+			"Maximum length of the accept queues (backlog) of the sockets of a listen directive",
+			[]string{"listen"}, constLabels,
+		),
+		listenOverflowsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "listen", "overflows_total"),
+			"Connections dropped because an accept queue of the host was full",
+			nil, constLabels,
+		),
+		listenDropsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "listen", "drops_total"),
+			"Connection requests to listen sockets of the host that were dropped for any reason",
+			nil, constLabels,
+		),
+		nginxConfigPath:       o.configPath,
+		nginxBinaryPath:       o.binaryPath,
+		configLabelMode:       o.configLabelMode,
+		configStrict:          o.configStrict,
+		configSource:          o.configSource,
+		configSourceClient:    o.configSourceClient,
+		configSourceTimeout:   o.configSourceTimeout,
+		healthCheck:           newTCPHealthCheck(o.healthCheck),
+		stopped:               make(chan struct{}),
+		configRefreshInterval: o.configRefreshInterval,
+		maxHealthTargets:      o.healthCheck.MaxTargets,
+		healthState:           newHealthState(o.healthStateFile, o.logger),
+		procRoot:              "/proc",
+		upstreamTCPStates:     o.upstreamTCPStates,
+		conntrack:             o.conntrack,
+		listenBacklog:         o.listenBacklog,
+		sampleTimestamps:      o.sampleTimestamps,
+		securityAudit:         o.securityAudit,
+	}
+	if o.healthCheck.Interval > 0 {
+		c.healthChecker = newHealthChecker(o.healthCheck)
 	}
+	return c
+}
+
+// Close stops the background health checks and config refreshes of the collector. It
+// is called when the collector is no longer scraped, e.g. because its target was removed.
+func (c *NginxCollector) Close() error {
+	if c.healthChecker != nil {
+		c.healthChecker.close()
+	}
+	c.stop.Do(func() {
+		close(c.stopped)
+	})
+	return nil
 }
 
 // Describe sends the super-set of all possible descriptors of NGINX metrics
@@ -66,6 +266,37 @@ func (c *NginxCollector) Describe(ch chan<- *prometheus.Desc) {
 
 	ch <- c.configModDesc
 	ch <- c.upstreamHealthCheckDesc
+	ch <- c.upstreamHealthTransitionsDesc
+	ch <- c.upstreamHealthRemovedDesc
+	ch <- c.upstreamHealthFamilyDesc
+	if c.maxHealthTargets > 0 {
+		ch <- c.upstreamHealthOverflowDesc
+	}
+	ch <- c.buildInfoDesc
+	ch <- c.buildModuleDesc
+	ch <- c.missingIncludesDesc
+	ch <- c.settingInfoDesc
+	ch <- c.upstreamServerInfoDesc
+	ch <- c.insecurePermissionsDesc
+	ch <- c.configWarningsDesc
+	ch <- c.configHashDesc
+	if c.securityAudit {
+		ch <- c.securityFindingsDesc
+	}
+	if c.upstreamTCPStates {
+		ch <- c.upstreamTCPConnectionsDesc
+	}
+	if c.listenBacklog {
+		ch <- c.listenQueueDesc
+		ch <- c.listenQueueLimitDesc
+		ch <- c.listenOverflowsDesc
+		ch <- c.listenDropsDesc
+	}
+	if c.conntrack {
+		ch <- c.conntrackEntriesDesc
+		ch <- c.conntrackLimitDesc
+		ch <- c.upstreamConntrackEntriesDesc
+	}
 }
 
 // Collect fetches metrics from NGINX and sends them to the provided channel.
@@ -74,6 +305,7 @@ func (c *NginxCollector) Collect(ch chan<- prometheus.Metric) {
 	defer c.mutex.Unlock()
 
 	stats, err := c.nginxClient.GetStubStats()
+	c.status.recordScrape(err)
 	if err != nil {
 		c.upMetric.Set(nginxDown)
 		ch <- c.upMetric
@@ -100,49 +332,129 @@ func (c *NginxCollector) Collect(ch chan<- prometheus.Metric) {
 		prometheus.CounterValue, float64(stats.Requests))
 
 	////// CUSTOM FOR NGINX PROXY //////
-	files := []string{c.nginxConfigPath}                                 // []string{"/home1/irteam/apps/nginx/nginx.conf"}
-	confdDir := filepath.Join(filepath.Dir(c.nginxConfigPath), "conf.d") // "/home1/irteam/apps/nginx/conf.d"
-	// 순회 하면서 files slice에 추가.
-	_ = filepath.WalkDir(confdDir, func(path string, dir fs.DirEntry, err error) error {
-		if err == nil && !dir.IsDir() {
-			files = append(files, path)
+	if c.nginxBinaryPath != "" {
+		c.collectBuildInfo(ch)
+	}
+	if c.healthChecker != nil {
+		defer c.healthChecker.commit()
+	}
+	snapshot, ok := c.currentConfigSnapshot()
+	if !ok {
+		return
+	}
+	for _, m := range snapshot.metrics {
+		ch <- m
+	}
+	if c.listenBacklog && c.configSource == "" && snapshot.cfg != nil {
+		c.collectListenBacklog(ch, snapshot.cfg)
+	}
+
+	seenHealth := make(map[healthCheckKey]bool)
+	var targets []string
+	for _, f := range snapshot.files {
+		c.collectProxyTargetHealth(ch, f.file, f.targets, f.tlsTargets, seenHealth)
+		targets = append(targets, f.targets...)
+	}
+	c.commitHealthState(ch, seenHealth)
+
+	// /proc 에서 읽는 연결 메트릭은 proxy target 을 한 번만 해석하여 함께 사용한다.
+	if c.configSource == "" && (c.upstreamTCPStates || c.conntrack) {
+		resolved := c.resolveTCPTargets(targets)
+		if c.upstreamTCPStates {
+			c.collectUpstreamTCPStates(ch, resolved)
 		}
-		return nil
-	})
+		if c.conntrack {
+			c.collectConntrack(ch, resolved)
+		}
+	}
+}
 
-	for _, f := range files {
-		info, err := os.Stat(f)
-		if err != nil || !strings.HasSuffix(info.Name(), ".conf") {
-			c.logger.Warn("skip config file", "file", f, "err", err)
+// collectProxyTargetHealth : proxy target 별로 tcp 연결 테스트를 수행하여 health check 메트릭을 전송한다. tlsTargets 의
+// target 은 TLS 인증서까지 검증한다. file 레이블은 configLabelMode 에 따라 렌더링하며, 같은 레이블 값이 되는 series 는 seen
+// 으로 걸러 한 번만 전송한다. seen 에는 background 검사 결과가 아직 없어 전송하지 않은 target 도 포함된다.
+func (c *NginxCollector) collectProxyTargetHealth(ch chan<- prometheus.Metric, file string, proxyTargets []string, tlsTargets map[string]bool, seen map[healthCheckKey]bool) {
+	label := c.fileLabel(file)
+	for _, target := range proxyTargets {
+		key := healthCheckKey{file: label, target: target}
+		if seen[key] {
 			continue
 		}
-
-		proxyTargets, err := extractProxyTarget(f)
-		if err != nil {
-			c.logger.Warn("error extracting proxy targets", "file", f, "error", err.Error())
+		// 생성된 설정처럼 target 이 지나치게 많으면, 앞의 maxHealthTargets 개만 검사하여 backend 와 scrape 시간을 보호한다.
+		if c.maxHealthTargets > 0 && len(seen) >= c.maxHealthTargets {
+			if c.overflowHealth == nil {
+				c.overflowHealth = make(map[healthCheckKey]bool)
+			}
+			c.overflowHealth[key] = true
 			continue
 		}
-
-		// prox target 추출 후, tcp 연결 테스트 수행
-		for _, target := range proxyTargets {
-			netResult, err := tcpTest(target)
-			if err != nil {
-				c.logger.Warn("error testing proxy target", "file", f, "target", target, "error", err.Error())
+		seen[key] = true
+		var state *healthStateEntry
+		var checked healthCheckResult
+		if c.healthChecker != nil {
+			// background 검사 결과가 아직 없는 target 은 상태 파일에서 복원한 결과를 전송하고, 그것도 없으면 전송하지 않는다.
+			var ok bool
+			if checked, ok = c.healthChecker.track(file, target, tlsTargets[target]); ok {
+				state = c.healthState.record(key, checked.result)
+			} else if state, ok = c.healthState.get(key); !ok {
+				continue
 			}
-			ch <- prometheus.MustNewConstMetric(
-				c.upstreamHealthCheckDesc,
-				prometheus.GaugeValue,
-				netResult,
-				f, target,
-			)
+		} else {
+			checked = c.healthCheck.run(target, tlsTargets[target])
+			state = c.healthState.record(key, checked.result)
 		}
-
-		// 파일의 마지막 수정 시각을 Unix timestamp로 치환하여 메트릭으로 전송
-		ch <- prometheus.MustNewConstMetric(
-			c.configModDesc,
+		checkedAt := checked.at
+		c.pendingHealth = append(c.pendingHealth, UpstreamHealth{
+			CheckedAt:   checkedAt,
+			File:        label,
+			Target:      target,
+			Transitions: state.Transitions,
+			Healthy:     state.Result == 1,
+		})
+		ch <- timestamped(c.sampleTimestamps, checkedAt, prometheus.MustNewConstMetric(
+			c.upstreamHealthCheckDesc,
 			prometheus.GaugeValue,
-			float64(info.ModTime().Unix()),
-			f,
-		)
+			state.Result,
+			label, target,
+		))
+		ch <- timestamped(c.sampleTimestamps, checkedAt, prometheus.MustNewConstMetric(c.upstreamHealthTransitionsDesc, prometheus.CounterValue, float64(state.Transitions), label, target))
+		for _, family := range checked.families {
+			ch <- timestamped(c.sampleTimestamps, checkedAt, prometheus.MustNewConstMetric(c.upstreamHealthFamilyDesc, prometheus.GaugeValue, family.result, label, target, family.family))
+		}
+	}
+}
+
+// commitHealthState : 이번 scrape 의 health check 결과로 상태를 갱신하고 상태 파일에 저장한다. 설정에서 사라진 target 의
+// series 는 더 이상 전송하지 않으므로 Prometheus 에서 stale 처리되며, 그 수를 누적하여 전송한다. maxHealthTargets 를 넘어
+// 검사하지 않은 target 은 설정에 남아 있으므로 상태를 유지하고 사라진 target 으로 세지 않는다.
+func (c *NginxCollector) commitHealthState(ch chan<- prometheus.Metric, seen map[healthCheckKey]bool) {
+	for key := range c.overflowHealth {
+		seen[key] = true
+	}
+	removed, err := c.healthState.commit(seen)
+	if err != nil {
+		c.logger.Warn("error saving health state", "file", c.healthState.path, "error", err.Error())
+	}
+	c.removedHealthTargets += uint64(removed)
+	c.status.recordHealth(c.pendingHealth, c.healthState.transitions)
+	c.pendingHealth = nil
+	ch <- prometheus.MustNewConstMetric(c.upstreamHealthRemovedDesc, prometheus.CounterValue, float64(c.removedHealthTargets))
+	if c.maxHealthTargets > 0 {
+		ch <- prometheus.MustNewConstMetric(c.upstreamHealthOverflowDesc, prometheus.GaugeValue, float64(len(c.overflowHealth)))
+		c.overflowHealth = nil
+	}
+}
+
+// collectBuildInfo : nginx -V 결과를 build info 메트릭으로 전송한다. 결과는 바이너리가 바뀔 때까지 buildInfo 에 저장된다.
+func (c *NginxCollector) collectBuildInfo(ch chan<- prometheus.Metric) {
+	info, err := c.buildInfo.get(c.nginxBinaryPath)
+	if err != nil {
+		c.logger.Warn("error getting nginx build info", "binary", c.nginxBinaryPath, "error", err.Error())
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.buildInfoDesc, prometheus.GaugeValue, 1,
+		info.Version, info.Compiler, info.OpenSSL, info.ConfigureArgs)
+	for _, module := range info.Modules {
+		ch <- prometheus.MustNewConstMetric(c.buildModuleDesc, prometheus.GaugeValue, 1, module)
 	}
 }
@@ -0,0 +1,141 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const nginxBinaryTimeout = 5 * time.Second
+
+// nginxBuildInfo holds the build information reported by `nginx -V`.
+type nginxBuildInfo struct {
+	Version       string
+	Compiler      string
+	OpenSSL       string
+	ConfigureArgs string
+	Modules       []string
+}
+
+// getNginxBuildInfo : nginx 바이너리를 -V 옵션으로 실행하여 빌드 정보를 가져오는 함수.
+func getNginxBuildInfo(binaryPath string) (*nginxBuildInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), nginxBinaryTimeout)
+	defer cancel()
+
+	// nginx -V 는 결과를 stderr 로 출력한다.
+	out, err := exec.CommandContext(ctx, binaryPath, "-V").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %v -V: %w", binaryPath, err)
+	}
+
+	return parseNginxBuildInfo(strings.NewReader(string(out)))
+}
+
+// nginxBuildInfoCache keeps the build information of the NGINX binary until the binary changes.
+type nginxBuildInfoCache struct {
+	modTime time.Time
+	info    *nginxBuildInfo
+	// run 은 nginx -V 를 실행하는 함수로, 테스트에서 바꿀 수 있다.
+	run  func(binaryPath string) (*nginxBuildInfo, error)
+	path string
+}
+
+// get : 바이너리의 경로 또는 수정 시각이 바뀌었을 때만 nginx -V 를 다시 실행하고, 그 외에는 저장된 빌드 정보를 반환한다.
+func (c *nginxBuildInfoCache) get(binaryPath string) (*nginxBuildInfo, error) {
+	// nginx.binary-path 에 PATH 에서 찾는 이름이 주어질 수 있으므로, 실제 파일의 경로로 바꾼다.
+	path, err := exec.LookPath(binaryPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %v: %w", binaryPath, err)
+	}
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %v: %w", path, err)
+	}
+	if c.info != nil && c.path == path && c.modTime.Equal(fileInfo.ModTime()) {
+		return c.info, nil
+	}
+
+	run := c.run
+	if run == nil {
+		run = getNginxBuildInfo
+	}
+	info, err := run(path)
+	if err != nil {
+		return nil, err
+	}
+	c.info, c.path, c.modTime = info, path, fileInfo.ModTime()
+	return info, nil
+}
+
+func parseNginxBuildInfo(r io.Reader) (*nginxBuildInfo, error) {
+	var info nginxBuildInfo
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "nginx version:"):
+			version := strings.TrimSpace(strings.TrimPrefix(line, "nginx version:"))
+			if _, v, ok := strings.Cut(version, "/"); ok {
+				version = v
+			}
+			info.Version = version
+		case strings.HasPrefix(line, "built by "):
+			info.Compiler = strings.TrimPrefix(line, "built by ")
+		case strings.HasPrefix(line, "built with OpenSSL "):
+			info.OpenSSL = strings.TrimPrefix(line, "built with OpenSSL ")
+		case strings.HasPrefix(line, "configure arguments:"):
+			info.ConfigureArgs = strings.TrimSpace(strings.TrimPrefix(line, "configure arguments:"))
+			info.Modules = parseNginxModules(info.ConfigureArgs)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read nginx -V output: %w", err)
+	}
+
+	if info.Version == "" {
+		return nil, errors.New("nginx version not found in nginx -V output")
+	}
+
+	return &info, nil
+}
+
+// parseNginxModules extracts the compiled in modules from the configure arguments,
+// e.g. --with-http_v2_module, --with-stream=dynamic or --add-module=/path/to/module.
+func parseNginxModules(configureArgs string) []string {
+	var modules []string
+	seen := make(map[string]bool)
+
+	for _, arg := range strings.Fields(configureArgs) {
+		var module string
+		switch {
+		case strings.HasPrefix(arg, "--add-module="), strings.HasPrefix(arg, "--add-dynamic-module="):
+			_, path, _ := strings.Cut(arg, "=")
+			module = filepath.Base(strings.Trim(path, `"'`))
+		case strings.HasPrefix(arg, "--with-"):
+			name, _, _ := strings.Cut(strings.TrimPrefix(arg, "--with-"), "=")
+			if !strings.HasSuffix(name, "_module") && name != "stream" && name != "mail" {
+				// --with-threads, --with-cc-opt 등 모듈이 아닌 옵션은 제외한다.
+				continue
+			}
+			module = name
+		default:
+			continue
+		}
+
+		if module != "" && !seen[module] {
+			seen[module] = true
+			modules = append(modules, module)
+		}
+	}
+
+	return modules
+}
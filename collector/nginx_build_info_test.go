@@ -0,0 +1,95 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const validNginxV = `nginx version: nginx/1.25.3
+built by gcc 12.2.0 (Debian 12.2.0-14)
+built with OpenSSL 3.0.11 19 Sep 2023
+TLS SNI support enabled
+configure arguments: --prefix=/etc/nginx --with-threads --with-http_ssl_module --with-http_v2_module --with-stream=dynamic --add-dynamic-module=/build/ngx_brotli --with-cc-opt='-O2'
+`
+
+func TestParseNginxBuildInfo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		want    *nginxBuildInfo
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{
+			name:  "valid nginx -V output",
+			input: validNginxV,
+			want: &nginxBuildInfo{
+				Version:       "1.25.3",
+				Compiler:      "gcc 12.2.0 (Debian 12.2.0-14)",
+				OpenSSL:       "3.0.11 19 Sep 2023",
+				ConfigureArgs: "--prefix=/etc/nginx --with-threads --with-http_ssl_module --with-http_v2_module --with-stream=dynamic --add-dynamic-module=/build/ngx_brotli --with-cc-opt='-O2'",
+				Modules:       []string{"http_ssl_module", "http_v2_module", "stream", "ngx_brotli"},
+			},
+		},
+		{
+			name:    "invalid output",
+			input:   "nginx: command not found",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseNginxBuildInfo(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseNginxBuildInfo() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNginxBuildInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNginxBuildInfoCache(t *testing.T) {
+	t.Parallel()
+
+	binaryPath := filepath.Join(t.TempDir(), "nginx")
+	if err := os.WriteFile(binaryPath, nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	runs := 0
+	cache := nginxBuildInfoCache{
+		run: func(string) (*nginxBuildInfo, error) {
+			runs++
+			return parseNginxBuildInfo(strings.NewReader(validNginxV))
+		},
+	}
+
+	for range 3 {
+		if _, err := cache.get(binaryPath); err != nil {
+			t.Fatalf("get() returned error: %v", err)
+		}
+	}
+	if runs != 1 {
+		t.Errorf("nginx -V ran %v times for an unchanged binary, want 1", runs)
+	}
+
+	modTime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(binaryPath, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.get(binaryPath); err != nil {
+		t.Fatalf("get() returned error: %v", err)
+	}
+	if runs != 2 {
+		t.Errorf("nginx -V ran %v times after the binary changed, want 2", runs)
+	}
+}
@@ -0,0 +1,264 @@
+package collector
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Modes for rendering the file label of config metrics.
+const (
+	ConfigLabelModeAbsolute = "absolute"
+	ConfigLabelModeRelative = "relative"
+	ConfigLabelModeBasename = "basename"
+)
+
+// settingDirectives are the directives exported as config setting info metrics.
+var settingDirectives = map[string]bool{
+	"keepalive_timeout":     true,
+	"proxy_read_timeout":    true,
+	"proxy_connect_timeout": true,
+	"client_max_body_size":  true,
+}
+
+type settingKey struct {
+	directive string
+	context   string
+	file      string
+	value     string
+}
+
+// upstreamServerKey is a server of an upstream block with its load balancing parameters.
+// Parameters that are not set hold the NGINX defaults.
+type upstreamServerKey struct {
+	upstream    string
+	server      string
+	weight      string
+	backup      string
+	maxFails    string
+	failTimeout string
+}
+
+type missingIncludeKey struct {
+	file    string
+	pattern string
+}
+
+// Kinds of config warnings reported in strict mode.
+const (
+	configWarningUnparsableFile   = "unparsable_file"
+	configWarningUnknownDirective = "unknown_directive"
+	configWarningUnresolvedTarget = "unresolved_target"
+	configWarningUnsupportedPass  = "unsupported_pass"
+)
+
+// upstreamDirectives are the directives the exporter understands inside upstream blocks.
+var upstreamDirectives = map[string]bool{
+	"server":             true,
+	"zone":               true,
+	"state":              true,
+	"hash":               true,
+	"ip_hash":            true,
+	"keepalive":          true,
+	"keepalive_requests": true,
+	"keepalive_time":     true,
+	"keepalive_timeout":  true,
+	"least_conn":         true,
+	"least_time":         true,
+	"ntlm":               true,
+	"queue":              true,
+	"random":             true,
+	"resolver":           true,
+	"resolver_timeout":   true,
+	"sticky":             true,
+}
+
+// unsupportedPassDirectives are proxying directives whose targets are not health checked.
+var unsupportedPassDirectives = map[string]bool{
+	"fastcgi_pass":   true,
+	"grpc_pass":      true,
+	"memcached_pass": true,
+	"scgi_pass":      true,
+	"uwsgi_pass":     true,
+}
+
+type configWarningKey struct {
+	file string
+	kind string
+}
+
+// collectConfigMetrics : include 를 모두 해석한 NGINX 설정으로부터 메트릭을 생성하고, 해석한 설정을 반환한다.
+// parser 가 원격 설정 소스의 파일들을 사용하는 경우, 로컬 파일 권한 검사는 수행하지 않는다.
+func (c *NginxCollector) collectConfigMetrics(ch chan<- prometheus.Metric, parser nginxconf.Parser, configPath string) *nginxconf.Config {
+	parser.Tolerant = c.configStrict
+	cfg, err := parser.Parse(configPath)
+	if err != nil {
+		c.logger.Warn("error parsing nginx config", "file", configPath, "error", err.Error())
+		if c.configStrict {
+			ch <- prometheus.MustNewConstMetric(c.configWarningsDesc, prometheus.GaugeValue, 1, c.fileLabel(configPath), configWarningUnparsableFile)
+		}
+		return nil
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.configHashDesc, prometheus.GaugeValue, 1, cfg.Hash())
+
+	missing := make(map[missingIncludeKey]float64)
+	for _, m := range cfg.MissingIncludes {
+		missing[missingIncludeKey{file: c.fileLabel(m.File), pattern: m.Pattern}]++
+	}
+	for k, count := range missing {
+		ch <- prometheus.MustNewConstMetric(c.missingIncludesDesc, prometheus.GaugeValue, count, k.file, k.pattern)
+	}
+
+	settings := make(map[settingKey]bool)
+	cfg.Walk(func(d *nginxconf.Directive, parents []*nginxconf.Directive) {
+		if !settingDirectives[d.Name] {
+			return
+		}
+		settings[settingKey{
+			directive: d.Name,
+			context:   directiveContext(parents),
+			file:      c.fileLabel(d.File),
+			value:     strings.Join(d.Args, " "),
+		}] = true
+	})
+	for k := range settings {
+		ch <- prometheus.MustNewConstMetric(c.settingInfoDesc, prometheus.GaugeValue, 1, k.directive, k.context, k.file, k.value)
+	}
+	for k := range upstreamServers(cfg) {
+		ch <- prometheus.MustNewConstMetric(c.upstreamServerInfoDesc, prometheus.GaugeValue, 1, k.upstream, k.server, k.weight, k.backup, k.maxFails, k.failTimeout)
+	}
+
+	if c.securityAudit {
+		for k, count := range c.securityFindings(cfg) {
+			ch <- prometheus.MustNewConstMetric(c.securityFindingsDesc, prometheus.GaugeValue, count, k.rule, k.file)
+		}
+	}
+
+	if c.configStrict {
+		for k, count := range c.configWarnings(cfg) {
+			ch <- prometheus.MustNewConstMetric(c.configWarningsDesc, prometheus.GaugeValue, count, k.file, k.kind)
+		}
+	}
+
+	if parser.Files != nil {
+		return cfg
+	}
+	for k := range c.auditPermissions(cfg) {
+		ch <- prometheus.MustNewConstMetric(c.insecurePermissionsDesc, prometheus.GaugeValue, 1, k.file, k.issue)
+	}
+	return cfg
+}
+
+// configWarnings : health check 대상으로 해석할 수 없는 설정을 파일, 종류별로 센다.
+func (c *NginxCollector) configWarnings(cfg *nginxconf.Config) map[configWarningKey]float64 {
+	warnings := make(map[configWarningKey]float64)
+	for _, f := range cfg.UnparsableFiles {
+		c.logger.Warn("skipped unparsable nginx config file", "file", f.File, "error", f.Err.Error())
+		warnings[configWarningKey{file: c.fileLabel(f.File), kind: configWarningUnparsableFile}]++
+	}
+
+	cfg.Walk(func(d *nginxconf.Directive, parents []*nginxconf.Directive) {
+		var kind string
+		switch {
+		case len(parents) > 0 && parents[len(parents)-1].Name == "upstream" && !upstreamDirectives[d.Name]:
+			kind = configWarningUnknownDirective
+		case d.Name == "proxy_pass" && len(d.Args) > 0 && (strings.Contains(d.Args[0], "$") || strings.HasPrefix(d.Args[0], "unix:") || strings.Contains(d.Args[0], "://unix:")):
+			kind = configWarningUnresolvedTarget
+		case unsupportedPassDirectives[d.Name]:
+			kind = configWarningUnsupportedPass
+		default:
+			return
+		}
+		warnings[configWarningKey{file: c.fileLabel(d.File), kind: kind}]++
+	})
+
+	return warnings
+}
+
+// upstreamServers : upstream 블록의 server 를 weight, backup, max_fails, fail_timeout 과 함께 반환한다. 설정하지 않은 값은
+// NGINX 의 기본값으로 채워, 기본값을 명시하는 변경은 series 를 바꾸지 않는다.
+func upstreamServers(cfg *nginxconf.Config) map[upstreamServerKey]bool {
+	servers := make(map[upstreamServerKey]bool)
+	for _, s := range configUpstreamServers(cfg) {
+		k := upstreamServerKey{
+			upstream:    s.upstream,
+			server:      s.address,
+			weight:      "1",
+			backup:      "false",
+			maxFails:    "1",
+			failTimeout: "10s",
+		}
+		for _, arg := range s.params {
+			name, value, _ := strings.Cut(arg, "=")
+			switch name {
+			case "weight":
+				k.weight = value
+			case "backup":
+				k.backup = "true"
+			case "max_fails":
+				k.maxFails = value
+			case "fail_timeout":
+				k.failTimeout = value
+			}
+		}
+		servers[k] = true
+	}
+	return servers
+}
+
+// directiveContext : 디렉티브를 감싸는 가장 가까운 블록을 "server:example.com", "location:/api" 형태로 반환한다.
+func directiveContext(parents []*nginxconf.Directive) string {
+	if len(parents) == 0 {
+		return "main"
+	}
+
+	parent := parents[len(parents)-1]
+	switch parent.Name {
+	case "server":
+		for _, d := range parent.Block {
+			if d.Name == "server_name" && len(d.Args) > 0 {
+				return "server:" + d.Args[0]
+			}
+		}
+		for _, d := range parent.Block {
+			if d.Name == "listen" && len(d.Args) > 0 {
+				return "server:" + d.Args[0]
+			}
+		}
+		return "server"
+	case "location", "upstream", "if", "limit_except":
+		if len(parent.Args) > 0 {
+			return parent.Name + ":" + strings.Join(parent.Args, " ")
+		}
+	}
+	return parent.Name
+}
+
+// fileLabel : symlink 를 해석한 뒤, configLabelMode 에 따라 file 레이블 값을 반환한다.
+func (c *NginxCollector) fileLabel(path string) string {
+	resolved := resolvePath(path)
+
+	switch c.configLabelMode {
+	case ConfigLabelModeBasename:
+		return filepath.Base(resolved)
+	case ConfigLabelModeRelative:
+		root := resolvePath(filepath.Dir(c.nginxConfigPath))
+		if rel, err := filepath.Rel(root, resolved); err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+			return rel
+		}
+	}
+	return resolved
+}
+
+func resolvePath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return path
+}
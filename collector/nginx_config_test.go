@@ -0,0 +1,198 @@
+package collector
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+)
+
+func TestDirectiveContext(t *testing.T) {
+	t.Parallel()
+
+	server := &nginxconf.Directive{
+		Name: "server",
+		Block: []*nginxconf.Directive{
+			{Name: "listen", Args: []string{"443", "ssl"}},
+			{Name: "server_name", Args: []string{"example.com", "www.example.com"}},
+		},
+	}
+	anonymousServer := &nginxconf.Directive{
+		Name:  "server",
+		Block: []*nginxconf.Directive{{Name: "listen", Args: []string{"8080"}}},
+	}
+	http := &nginxconf.Directive{Name: "http", Block: []*nginxconf.Directive{}}
+	location := &nginxconf.Directive{Name: "location", Args: []string{"~", "^/api"}, Block: []*nginxconf.Directive{}}
+
+	tests := []struct {
+		name    string
+		want    string
+		parents []*nginxconf.Directive
+	}{
+		{
+			name: "main context",
+			want: "main",
+		},
+		{
+			name:    "http context",
+			parents: []*nginxconf.Directive{http},
+			want:    "http",
+		},
+		{
+			name:    "server with server_name",
+			parents: []*nginxconf.Directive{http, server},
+			want:    "server:example.com",
+		},
+		{
+			name:    "server without server_name",
+			parents: []*nginxconf.Directive{http, anonymousServer},
+			want:    "server:8080",
+		},
+		{
+			name:    "location context",
+			parents: []*nginxconf.Directive{http, server, location},
+			want:    "location:~ ^/api",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := directiveContext(tt.parents); got != tt.want {
+				t.Errorf("directiveContext() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileLabel(t *testing.T) {
+	t.Parallel()
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	site := filepath.Join(dir, "sites-available", "app.conf")
+	link := filepath.Join(dir, "sites-enabled", "app.conf")
+	for _, d := range []string{filepath.Dir(site), filepath.Dir(link)} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(site, []byte("server {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(site, link); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		mode string
+		path string
+		want string
+	}{
+		{mode: ConfigLabelModeAbsolute, path: link, want: site},
+		{mode: ConfigLabelModeRelative, path: link, want: filepath.Join("sites-available", "app.conf")},
+		{mode: ConfigLabelModeRelative, path: "/outside/root.conf", want: "/outside/root.conf"},
+		{mode: ConfigLabelModeBasename, path: link, want: "app.conf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode+" "+tt.path, func(t *testing.T) {
+			t.Parallel()
+			c := &NginxCollector{nginxConfigPath: filepath.Join(dir, "nginx.conf"), configLabelMode: tt.mode}
+			if got := c.fileLabel(tt.path); got != tt.want {
+				t.Errorf("fileLabel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigWarnings(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"nginx.conf": `http {
+    upstream backend {
+        server 10.0.0.1:8080;
+        custom_module_directive on;
+    }
+    include conf.d/*.conf;
+    server {
+        location / { proxy_pass http://backend; }
+        location /dyn { proxy_pass http://$host; }
+        location /php { fastcgi_pass 127.0.0.1:9000; }
+    }
+}
+`,
+		"conf.d/broken.conf": "server {\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := &NginxCollector{nginxConfigPath: filepath.Join(dir, "nginx.conf"), configLabelMode: ConfigLabelModeBasename, logger: slog.New(slog.DiscardHandler)}
+	cfg, err := nginxconf.ParseTolerant(c.nginxConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[configWarningKey]float64{
+		{file: "nginx.conf", kind: configWarningUnknownDirective}: 1,
+		{file: "nginx.conf", kind: configWarningUnresolvedTarget}: 1,
+		{file: "nginx.conf", kind: configWarningUnsupportedPass}:  1,
+		{file: "broken.conf", kind: configWarningUnparsableFile}:  1,
+	}
+	if got := c.configWarnings(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("configWarnings() = %v, want %v", got, want)
+	}
+}
+
+func TestUpstreamServers(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "nginx.conf")
+	content := `http {
+    upstream backend {
+        zone backend 64k;
+        server 10.0.0.1:8080 weight=5 max_fails=3 fail_timeout=30s;
+        server 10.0.0.2:8080;
+        server 10.0.0.3:8080 backup max_conns=100;
+    }
+    server {
+        listen 80;
+        location / { proxy_pass http://backend; }
+    }
+}
+stream {
+    upstream dns {
+        server 10.0.0.53:53 weight=2;
+    }
+}
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := nginxconf.Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[upstreamServerKey]bool{
+		{upstream: "backend", server: "10.0.0.1:8080", weight: "5", backup: "false", maxFails: "3", failTimeout: "30s"}: true,
+		{upstream: "backend", server: "10.0.0.2:8080", weight: "1", backup: "false", maxFails: "1", failTimeout: "10s"}: true,
+		{upstream: "backend", server: "10.0.0.3:8080", weight: "1", backup: "true", maxFails: "1", failTimeout: "10s"}:  true,
+		{upstream: "dns", server: "10.0.0.53:53", weight: "2", backup: "false", maxFails: "1", failTimeout: "10s"}:      true,
+	}
+	if got := upstreamServers(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("upstreamServers() = %v, want %v", got, want)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"strconv"
 	"sync"
+	"time"
 
 	plusclient "github.com/nginx/nginx-plus-go-client/v2/client"
 	"github.com/prometheus/client_golang/prometheus"
@@ -32,9 +33,18 @@ type LabelUpdater interface {
 // NginxPlusCollector collects NGINX Plus metrics. It implements prometheus.Collector interface.
 type NginxPlusCollector struct {
 	upMetric                       prometheus.Gauge
+	apiVersionMetric               *prometheus.Desc
+	apiEndpointUpMetric            *prometheus.Desc
+	apiRequestDurationMetric       *prometheus.Desc
+	instanceInfoMetric             *prometheus.Desc
 	logger                         *slog.Logger
 	cacheZoneMetrics               map[string]*prometheus.Desc
 	workerMetrics                  map[string]*prometheus.Desc
+	licenseMetrics                 map[string]*prometheus.Desc
+	nginxMetrics                   map[string]*prometheus.Desc
+	slabMetrics                    map[string]*prometheus.Desc
+	keyvalZoneMetrics              map[string]*prometheus.Desc
+	streamKeyvalZoneMetrics        map[string]*prometheus.Desc
 	nginxClient                    *plusclient.NginxClient
 	streamServerZoneMetrics        map[string]*prometheus.Desc
 	streamZoneSyncMetrics          map[string]*prometheus.Desc
@@ -57,6 +67,9 @@ type NginxPlusCollector struct {
 	cacheZoneLabels                map[string][]string
 	totalMetrics                   map[string]*prometheus.Desc
 	variableLabelNames             VariableLabelNames
+	config                         NginxPlusCollectorConfig
+	upstreamsFile                  *nameListFile
+	status                         collectorStatus
 	variableLabelsMutex            sync.RWMutex
 	mutex                          sync.Mutex
 }
@@ -255,15 +268,28 @@ func NewVariableLabelNames(upstreamServerVariableLabelNames []string, serverZone
 	}
 }
 
-// NewNginxPlusCollector creates an NginxPlusCollector.
-func NewNginxPlusCollector(nginxClient *plusclient.NginxClient, namespace string, variableLabelNames VariableLabelNames, constLabels map[string]string, logger *slog.Logger) *NginxPlusCollector {
+// NewNginxPlusCollector creates an NginxPlusCollector configured by opts.
+func NewNginxPlusCollector(nginxClient *plusclient.NginxClient, opts ...Option) *NginxPlusCollector {
+	o := newOptions(DefaultPlusNamespace, opts)
+	namespace, variableLabelNames, constLabels, logger, config := o.namespace, o.variableLabelNames, o.constLabels, o.logger, o.plusConfig
+
 	upstreamServerVariableLabelNames := variableLabelNames.UpstreamServerVariableLabelNames
 	streamUpstreamServerVariableLabelNames := variableLabelNames.StreamUpstreamServerVariableLabelNames
 
 	upstreamServerVariableLabelNames = append(upstreamServerVariableLabelNames, variableLabelNames.UpstreamServerPeerVariableLabelNames...)
 	streamUpstreamServerVariableLabelNames = append(streamUpstreamServerVariableLabelNames, variableLabelNames.StreamUpstreamServerPeerVariableLabelNames...)
-	return &NginxPlusCollector{
+	if config.PeerServiceLabels {
+		upstreamServerVariableLabelNames = append(upstreamServerVariableLabelNames, peerServiceLabelNames...)
+		streamUpstreamServerVariableLabelNames = append(streamUpstreamServerVariableLabelNames, peerServiceLabelNames...)
+	}
+	var upstreamsFile *nameListFile
+	if config.UpstreamsFile != "" {
+		upstreamsFile = &nameListFile{path: config.UpstreamsFile}
+	}
+	c := &NginxPlusCollector{
 		variableLabelNames:             variableLabelNames,
+		config:                         config,
+		upstreamsFile:                  upstreamsFile,
 		upstreamServerLabels:           make(map[string][]string),
 		serverZoneLabels:               make(map[string][]string),
 		streamServerZoneLabels:         make(map[string][]string),
@@ -274,15 +300,25 @@ func NewNginxPlusCollector(nginxClient *plusclient.NginxClient, namespace string
 		nginxClient:                    nginxClient,
 		logger:                         logger,
 		totalMetrics: map[string]*prometheus.Desc{
-			"connections_accepted":  newGlobalMetric(namespace, "connections_accepted", "Accepted client connections", constLabels),
-			"connections_dropped":   newGlobalMetric(namespace, "connections_dropped", "Dropped client connections", constLabels),
-			"connections_active":    newGlobalMetric(namespace, "connections_active", "Active client connections", constLabels),
-			"connections_idle":      newGlobalMetric(namespace, "connections_idle", "Idle client connections", constLabels),
-			"http_requests_total":   newGlobalMetric(namespace, "http_requests_total", "Total http requests", constLabels),
-			"http_requests_current": newGlobalMetric(namespace, "http_requests_current", "Current http requests", constLabels),
-			"ssl_handshakes":        newGlobalMetric(namespace, "ssl_handshakes", "Successful SSL handshakes", constLabels),
-			"ssl_handshakes_failed": newGlobalMetric(namespace, "ssl_handshakes_failed", "Failed SSL handshakes", constLabels),
-			"ssl_session_reuses":    newGlobalMetric(namespace, "ssl_session_reuses", "Session reuses during SSL handshake", constLabels),
+			"connections_accepted":                  newGlobalMetric(namespace, "connections_accepted", "Accepted client connections", constLabels),
+			"connections_dropped":                   newGlobalMetric(namespace, "connections_dropped", "Dropped client connections", constLabels),
+			"connections_active":                    newGlobalMetric(namespace, "connections_active", "Active client connections", constLabels),
+			"connections_idle":                      newGlobalMetric(namespace, "connections_idle", "Idle client connections", constLabels),
+			"http_requests_total":                   newGlobalMetric(namespace, "http_requests_total", "Total http requests", constLabels),
+			"http_requests_current":                 newGlobalMetric(namespace, "http_requests_current", "Current http requests", constLabels),
+			"ssl_handshakes":                        newGlobalMetric(namespace, "ssl_handshakes", "Successful SSL handshakes", constLabels),
+			"ssl_handshakes_failed":                 newGlobalMetric(namespace, "ssl_handshakes_failed", "Failed SSL handshakes", constLabels),
+			"ssl_session_reuses":                    newGlobalMetric(namespace, "ssl_session_reuses", "Session reuses during SSL handshake", constLabels),
+			"processes_respawned":                   newGlobalMetric(namespace, "processes_respawned", "Total number of abnormally terminated and respawned child processes", constLabels),
+			"ssl_no_common_protocol":                newGlobalMetric(namespace, "ssl_no_common_protocol", "SSL handshakes failed because of no common protocol", constLabels),
+			"ssl_no_common_cipher":                  newGlobalMetric(namespace, "ssl_no_common_cipher", "SSL handshakes failed because of no shared cipher", constLabels),
+			"ssl_handshake_timeout":                 newGlobalMetric(namespace, "ssl_handshake_timeout", "SSL handshakes failed because of a timeout", constLabels),
+			"ssl_peer_rejected_cert":                newGlobalMetric(namespace, "ssl_peer_rejected_cert", "Failed SSL handshakes when NGINX presented the certificate to the client but it was rejected with a corresponding alert message", constLabels),
+			"ssl_verify_failures_no_cert":           newGlobalMetric(namespace, "ssl_verify_failures", "SSL certificate verification errors", MergeLabels(constLabels, prometheus.Labels{"type": "no_cert"})),
+			"ssl_verify_failures_expired_cert":      newGlobalMetric(namespace, "ssl_verify_failures", "SSL certificate verification errors", MergeLabels(constLabels, prometheus.Labels{"type": "expired_cert"})),
+			"ssl_verify_failures_revoked_cert":      newGlobalMetric(namespace, "ssl_verify_failures", "SSL certificate verification errors", MergeLabels(constLabels, prometheus.Labels{"type": "revoked_cert"})),
+			"ssl_verify_failures_hostname_mismatch": newGlobalMetric(namespace, "ssl_verify_failures", "SSL certificate verification errors", MergeLabels(constLabels, prometheus.Labels{"type": "hostname_mismatch"})),
+			"ssl_verify_failures_other":             newGlobalMetric(namespace, "ssl_verify_failures", "SSL certificate verification errors", MergeLabels(constLabels, prometheus.Labels{"type": "other"})),
 		},
 		serverZoneMetrics: map[string]*prometheus.Desc{
 			"processing":            newServerZoneMetric(namespace, "processing", "Client requests that are currently being processed", variableLabelNames.ServerZoneVariableLabelNames, constLabels),
@@ -353,93 +389,101 @@ func NewNginxPlusCollector(nginxClient *plusclient.NginxClient, namespace string
 			"ssl_session_reuses":    newStreamServerZoneMetric(namespace, "ssl_session_reuses", "Session reuses during SSL handshake", variableLabelNames.StreamServerZoneVariableLabelNames, constLabels),
 		},
 		upstreamMetrics: map[string]*prometheus.Desc{
-			"keepalive": newUpstreamMetric(namespace, "keepalive", "Idle keepalive connections", constLabels),
-			"zombies":   newUpstreamMetric(namespace, "zombies", "Servers removed from the group but still processing active client requests", constLabels),
+			"keepalive":       newUpstreamMetric(namespace, "keepalive", "Idle keepalive connections", constLabels),
+			"draining_peers":  newUpstreamMetric(namespace, "draining_peers", "Servers in the draining state", constLabels),
+			"draining_active": newUpstreamMetric(namespace, "draining_active", "Active connections remaining on servers in the draining state", constLabels),
+			"queue_size":      newUpstreamMetric(namespace, "queue_size", "Current number of requests in the queue", constLabels),
+			"queue_max_size":  newUpstreamMetric(namespace, "queue_max_size", "Maximum number of requests that can be in the queue at the same time", constLabels),
+			"queue_overflows": newUpstreamMetric(namespace, "queue_overflows", "Total number of requests rejected due to the queue overflow", constLabels),
+			"zombies":         newUpstreamMetric(namespace, "zombies", "Servers removed from the group but still processing active client requests", constLabels),
 		},
 		streamUpstreamMetrics: map[string]*prometheus.Desc{
 			"zombies": newStreamUpstreamMetric(namespace, "zombies", "Servers removed from the group but still processing active client connections", constLabels),
 		},
 		upstreamServerMetrics: map[string]*prometheus.Desc{
-			"state":                   newUpstreamServerMetric(namespace, "state", "Current state", upstreamServerVariableLabelNames, constLabels),
-			"active":                  newUpstreamServerMetric(namespace, "active", "Active connections", upstreamServerVariableLabelNames, constLabels),
-			"limit":                   newUpstreamServerMetric(namespace, "limit", "Limit for connections which corresponds to the max_conns parameter of the upstream server. Zero value means there is no limit", upstreamServerVariableLabelNames, constLabels),
-			"requests":                newUpstreamServerMetric(namespace, "requests", "Total client requests", upstreamServerVariableLabelNames, constLabels),
-			"responses_1xx":           newUpstreamServerMetric(namespace, "responses", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "1xx"})),
-			"responses_2xx":           newUpstreamServerMetric(namespace, "responses", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "2xx"})),
-			"responses_3xx":           newUpstreamServerMetric(namespace, "responses", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "3xx"})),
-			"responses_4xx":           newUpstreamServerMetric(namespace, "responses", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "4xx"})),
-			"responses_5xx":           newUpstreamServerMetric(namespace, "responses", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "5xx"})),
-			"sent":                    newUpstreamServerMetric(namespace, "sent", "Bytes sent to this server", upstreamServerVariableLabelNames, constLabels),
-			"received":                newUpstreamServerMetric(namespace, "received", "Bytes received to this server", upstreamServerVariableLabelNames, constLabels),
-			"fails":                   newUpstreamServerMetric(namespace, "fails", "Number of unsuccessful attempts to communicate with the server", upstreamServerVariableLabelNames, constLabels),
-			"unavail":                 newUpstreamServerMetric(namespace, "unavail", "How many times the server became unavailable for client requests (state 'unavail') due to the number of unsuccessful attempts reaching the max_fails threshold", upstreamServerVariableLabelNames, constLabels),
-			"header_time":             newUpstreamServerMetric(namespace, "header_time", "Average time to get the response header from the server", upstreamServerVariableLabelNames, constLabels),
-			"response_time":           newUpstreamServerMetric(namespace, "response_time", "Average time to get the full response from the server", upstreamServerVariableLabelNames, constLabels),
-			"health_checks_checks":    newUpstreamServerMetric(namespace, "health_checks_checks", "Total health check requests", upstreamServerVariableLabelNames, constLabels),
-			"health_checks_fails":     newUpstreamServerMetric(namespace, "health_checks_fails", "Failed health checks", upstreamServerVariableLabelNames, constLabels),
-			"health_checks_unhealthy": newUpstreamServerMetric(namespace, "health_checks_unhealthy", "How many times the server became unhealthy (state 'unhealthy')", upstreamServerVariableLabelNames, constLabels),
-			"codes_100":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "100"})),
-			"codes_101":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "101"})),
-			"codes_102":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "102"})),
-			"codes_200":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "200"})),
-			"codes_201":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "201"})),
-			"codes_202":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "202"})),
-			"codes_204":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "204"})),
-			"codes_206":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "206"})),
-			"codes_300":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "300"})),
-			"codes_301":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "301"})),
-			"codes_302":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "302"})),
-			"codes_303":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "303"})),
-			"codes_304":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "304"})),
-			"codes_307":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "307"})),
-			"codes_400":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "400"})),
-			"codes_401":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "401"})),
-			"codes_403":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "403"})),
-			"codes_404":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "404"})),
-			"codes_405":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "405"})),
-			"codes_408":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "408"})),
-			"codes_409":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "409"})),
-			"codes_411":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "411"})),
-			"codes_412":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "412"})),
-			"codes_413":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "413"})),
-			"codes_414":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "414"})),
-			"codes_415":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "415"})),
-			"codes_416":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "416"})),
-			"codes_429":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "429"})),
-			"codes_444":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "444"})),
-			"codes_494":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "494"})),
-			"codes_495":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "495"})),
-			"codes_496":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "496"})),
-			"codes_497":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "497"})),
-			"codes_499":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "499"})),
-			"codes_500":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "500"})),
-			"codes_501":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "501"})),
-			"codes_502":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "502"})),
-			"codes_503":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "503"})),
-			"codes_504":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "504"})),
-			"codes_507":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "507"})),
-			"ssl_handshakes":          newUpstreamServerMetric(namespace, "ssl_handshakes", "Successful SSL handshakes", upstreamServerVariableLabelNames, constLabels),
-			"ssl_handshakes_failed":   newUpstreamServerMetric(namespace, "ssl_handshakes_failed", "Failed SSL handshakes", upstreamServerVariableLabelNames, constLabels),
-			"ssl_session_reuses":      newUpstreamServerMetric(namespace, "ssl_session_reuses", "Session reuses during SSL handshake", upstreamServerVariableLabelNames, constLabels),
+			"state":                     newUpstreamServerMetric(namespace, "state", "Current state", upstreamServerVariableLabelNames, constLabels),
+			"draining":                  newUpstreamServerMetric(namespace, "draining", "Whether the server is draining (1) or not (0)", upstreamServerVariableLabelNames, constLabels),
+			"active":                    newUpstreamServerMetric(namespace, "active", "Active connections", upstreamServerVariableLabelNames, constLabels),
+			"limit":                     newUpstreamServerMetric(namespace, "limit", "Limit for connections which corresponds to the max_conns parameter of the upstream server. Zero value means there is no limit", upstreamServerVariableLabelNames, constLabels),
+			"requests":                  newUpstreamServerMetric(namespace, "requests", "Total client requests", upstreamServerVariableLabelNames, constLabels),
+			"responses_1xx":             newUpstreamServerMetric(namespace, "responses", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "1xx"})),
+			"responses_2xx":             newUpstreamServerMetric(namespace, "responses", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "2xx"})),
+			"responses_3xx":             newUpstreamServerMetric(namespace, "responses", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "3xx"})),
+			"responses_4xx":             newUpstreamServerMetric(namespace, "responses", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "4xx"})),
+			"responses_5xx":             newUpstreamServerMetric(namespace, "responses", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "5xx"})),
+			"sent":                      newUpstreamServerMetric(namespace, "sent", "Bytes sent to this server", upstreamServerVariableLabelNames, constLabels),
+			"received":                  newUpstreamServerMetric(namespace, "received", "Bytes received to this server", upstreamServerVariableLabelNames, constLabels),
+			"fails":                     newUpstreamServerMetric(namespace, "fails", "Number of unsuccessful attempts to communicate with the server", upstreamServerVariableLabelNames, constLabels),
+			"unavail":                   newUpstreamServerMetric(namespace, "unavail", "How many times the server became unavailable for client requests (state 'unavail') due to the number of unsuccessful attempts reaching the max_fails threshold", upstreamServerVariableLabelNames, constLabels),
+			"header_time":               newUpstreamServerMetric(namespace, "header_time", "Average time to get the response header from the server", upstreamServerVariableLabelNames, constLabels),
+			"response_time":             newUpstreamServerMetric(namespace, "response_time", "Average time to get the full response from the server", upstreamServerVariableLabelNames, constLabels),
+			"health_checks_checks":      newUpstreamServerMetric(namespace, "health_checks_checks", "Total health check requests", upstreamServerVariableLabelNames, constLabels),
+			"health_checks_fails":       newUpstreamServerMetric(namespace, "health_checks_fails", "Failed health checks", upstreamServerVariableLabelNames, constLabels),
+			"health_checks_unhealthy":   newUpstreamServerMetric(namespace, "health_checks_unhealthy", "How many times the server became unhealthy (state 'unhealthy')", upstreamServerVariableLabelNames, constLabels),
+			"health_checks_last_passed": newUpstreamServerMetric(namespace, "health_checks_last_passed", "Whether the last health check passed (1) or failed (0)", upstreamServerVariableLabelNames, constLabels),
+			"codes_100":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "100"})),
+			"codes_101":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "101"})),
+			"codes_102":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "102"})),
+			"codes_200":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "200"})),
+			"codes_201":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "201"})),
+			"codes_202":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "202"})),
+			"codes_204":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "204"})),
+			"codes_206":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "206"})),
+			"codes_300":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "300"})),
+			"codes_301":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "301"})),
+			"codes_302":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "302"})),
+			"codes_303":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "303"})),
+			"codes_304":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "304"})),
+			"codes_307":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "307"})),
+			"codes_400":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "400"})),
+			"codes_401":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "401"})),
+			"codes_403":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "403"})),
+			"codes_404":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "404"})),
+			"codes_405":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "405"})),
+			"codes_408":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "408"})),
+			"codes_409":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "409"})),
+			"codes_411":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "411"})),
+			"codes_412":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "412"})),
+			"codes_413":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "413"})),
+			"codes_414":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "414"})),
+			"codes_415":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "415"})),
+			"codes_416":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "416"})),
+			"codes_429":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "429"})),
+			"codes_444":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "444"})),
+			"codes_494":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "494"})),
+			"codes_495":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "495"})),
+			"codes_496":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "496"})),
+			"codes_497":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "497"})),
+			"codes_499":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "499"})),
+			"codes_500":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "500"})),
+			"codes_501":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "501"})),
+			"codes_502":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "502"})),
+			"codes_503":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "503"})),
+			"codes_504":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "504"})),
+			"codes_507":                 newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "507"})),
+			"ssl_handshakes":            newUpstreamServerMetric(namespace, "ssl_handshakes", "Successful SSL handshakes", upstreamServerVariableLabelNames, constLabels),
+			"ssl_handshakes_failed":     newUpstreamServerMetric(namespace, "ssl_handshakes_failed", "Failed SSL handshakes", upstreamServerVariableLabelNames, constLabels),
+			"ssl_session_reuses":        newUpstreamServerMetric(namespace, "ssl_session_reuses", "Session reuses during SSL handshake", upstreamServerVariableLabelNames, constLabels),
 		},
 		streamUpstreamServerMetrics: map[string]*prometheus.Desc{
-			"state":                   newStreamUpstreamServerMetric(namespace, "state", "Current state", streamUpstreamServerVariableLabelNames, constLabels),
-			"active":                  newStreamUpstreamServerMetric(namespace, "active", "Active connections", streamUpstreamServerVariableLabelNames, constLabels),
-			"limit":                   newStreamUpstreamServerMetric(namespace, "limit", "Limit for connections which corresponds to the max_conns parameter of the upstream server. Zero value means there is no limit", streamUpstreamServerVariableLabelNames, constLabels),
-			"sent":                    newStreamUpstreamServerMetric(namespace, "sent", "Bytes sent to this server", streamUpstreamServerVariableLabelNames, constLabels),
-			"received":                newStreamUpstreamServerMetric(namespace, "received", "Bytes received from this server", streamUpstreamServerVariableLabelNames, constLabels),
-			"fails":                   newStreamUpstreamServerMetric(namespace, "fails", "Number of unsuccessful attempts to communicate with the server", streamUpstreamServerVariableLabelNames, constLabels),
-			"unavail":                 newStreamUpstreamServerMetric(namespace, "unavail", "How many times the server became unavailable for client connections (state 'unavail') due to the number of unsuccessful attempts reaching the max_fails threshold", streamUpstreamServerVariableLabelNames, constLabels),
-			"connections":             newStreamUpstreamServerMetric(namespace, "connections", "Total number of client connections forwarded to this server", streamUpstreamServerVariableLabelNames, constLabels),
-			"connect_time":            newStreamUpstreamServerMetric(namespace, "connect_time", "Average time to connect to the upstream server", streamUpstreamServerVariableLabelNames, constLabels),
-			"first_byte_time":         newStreamUpstreamServerMetric(namespace, "first_byte_time", "Average time to receive the first byte of data", streamUpstreamServerVariableLabelNames, constLabels),
-			"response_time":           newStreamUpstreamServerMetric(namespace, "response_time", "Average time to receive the last byte of data", streamUpstreamServerVariableLabelNames, constLabels),
-			"health_checks_checks":    newStreamUpstreamServerMetric(namespace, "health_checks_checks", "Total health check requests", streamUpstreamServerVariableLabelNames, constLabels),
-			"health_checks_fails":     newStreamUpstreamServerMetric(namespace, "health_checks_fails", "Failed health checks", streamUpstreamServerVariableLabelNames, constLabels),
-			"health_checks_unhealthy": newStreamUpstreamServerMetric(namespace, "health_checks_unhealthy", "How many times the server became unhealthy (state 'unhealthy')", streamUpstreamServerVariableLabelNames, constLabels),
-			"ssl_handshakes":          newStreamUpstreamServerMetric(namespace, "ssl_handshakes", "Successful SSL handshakes", streamUpstreamServerVariableLabelNames, constLabels),
-			"ssl_handshakes_failed":   newStreamUpstreamServerMetric(namespace, "ssl_handshakes_failed", "Failed SSL handshakes", streamUpstreamServerVariableLabelNames, constLabels),
-			"ssl_session_reuses":      newStreamUpstreamServerMetric(namespace, "ssl_session_reuses", "Session reuses during SSL handshake", streamUpstreamServerVariableLabelNames, constLabels),
+			"state":                     newStreamUpstreamServerMetric(namespace, "state", "Current state", streamUpstreamServerVariableLabelNames, constLabels),
+			"active":                    newStreamUpstreamServerMetric(namespace, "active", "Active connections", streamUpstreamServerVariableLabelNames, constLabels),
+			"limit":                     newStreamUpstreamServerMetric(namespace, "limit", "Limit for connections which corresponds to the max_conns parameter of the upstream server. Zero value means there is no limit", streamUpstreamServerVariableLabelNames, constLabels),
+			"sent":                      newStreamUpstreamServerMetric(namespace, "sent", "Bytes sent to this server", streamUpstreamServerVariableLabelNames, constLabels),
+			"received":                  newStreamUpstreamServerMetric(namespace, "received", "Bytes received from this server", streamUpstreamServerVariableLabelNames, constLabels),
+			"fails":                     newStreamUpstreamServerMetric(namespace, "fails", "Number of unsuccessful attempts to communicate with the server", streamUpstreamServerVariableLabelNames, constLabels),
+			"unavail":                   newStreamUpstreamServerMetric(namespace, "unavail", "How many times the server became unavailable for client connections (state 'unavail') due to the number of unsuccessful attempts reaching the max_fails threshold", streamUpstreamServerVariableLabelNames, constLabels),
+			"connections":               newStreamUpstreamServerMetric(namespace, "connections", "Total number of client connections forwarded to this server", streamUpstreamServerVariableLabelNames, constLabels),
+			"connect_time":              newStreamUpstreamServerMetric(namespace, "connect_time", "Average time to connect to the upstream server", streamUpstreamServerVariableLabelNames, constLabels),
+			"first_byte_time":           newStreamUpstreamServerMetric(namespace, "first_byte_time", "Average time to receive the first byte of data", streamUpstreamServerVariableLabelNames, constLabels),
+			"response_time":             newStreamUpstreamServerMetric(namespace, "response_time", "Average time to receive the last byte of data", streamUpstreamServerVariableLabelNames, constLabels),
+			"health_checks_checks":      newStreamUpstreamServerMetric(namespace, "health_checks_checks", "Total health check requests", streamUpstreamServerVariableLabelNames, constLabels),
+			"health_checks_fails":       newStreamUpstreamServerMetric(namespace, "health_checks_fails", "Failed health checks", streamUpstreamServerVariableLabelNames, constLabels),
+			"health_checks_unhealthy":   newStreamUpstreamServerMetric(namespace, "health_checks_unhealthy", "How many times the server became unhealthy (state 'unhealthy')", streamUpstreamServerVariableLabelNames, constLabels),
+			"health_checks_last_passed": newStreamUpstreamServerMetric(namespace, "health_checks_last_passed", "Whether the last health check passed (1) or failed (0)", streamUpstreamServerVariableLabelNames, constLabels),
+			"ssl_handshakes":            newStreamUpstreamServerMetric(namespace, "ssl_handshakes", "Successful SSL handshakes", streamUpstreamServerVariableLabelNames, constLabels),
+			"ssl_handshakes_failed":     newStreamUpstreamServerMetric(namespace, "ssl_handshakes_failed", "Failed SSL handshakes", streamUpstreamServerVariableLabelNames, constLabels),
+			"ssl_session_reuses":        newStreamUpstreamServerMetric(namespace, "ssl_session_reuses", "Session reuses during SSL handshake", streamUpstreamServerVariableLabelNames, constLabels),
 		},
 		streamZoneSyncMetrics: map[string]*prometheus.Desc{
 			"bytes_in":        newStreamZoneSyncMetric(namespace, "bytes_in", "Bytes received by this node", constLabels),
@@ -532,6 +576,14 @@ func NewNginxPlusCollector(nginxClient *plusclient.NginxClient, namespace string
 			"rejected_dry_run": newStreamLimitConnectionMetric(namespace, "rejected_dry_run", "Total number of connections accounted as rejected in the dry run mode", constLabels),
 		},
 		upMetric: newUpMetric(namespace, constLabels),
+		apiVersionMetric: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "api_version"),
+			"NGINX Plus API version used by the exporter", nil, constLabels),
+		apiEndpointUpMetric: prometheus.NewDesc(prometheus.BuildFQName(namespace, "api_endpoint", "up"),
+			"Whether the NGINX Plus API endpoint was fetched successfully", []string{"endpoint"}, constLabels),
+		apiRequestDurationMetric: prometheus.NewDesc(prometheus.BuildFQName(namespace, "api_request", "duration_seconds"),
+			"Duration of the last request to the NGINX Plus API endpoint", []string{"endpoint"}, constLabels),
+		instanceInfoMetric: prometheus.NewDesc(prometheus.BuildFQName(namespace, "instance", "info"),
+			"NGINX Plus instance information reported by the /nginx endpoint", []string{"version", "build", "address", "ppid"}, constLabels),
 		cacheZoneMetrics: map[string]*prometheus.Desc{
 			"size":                      newCacheZoneMetric(namespace, "size", "Total size of the cache", variableLabelNames.CacheZoneVariableLabelNames, constLabels),
 			"max_size":                  newCacheZoneMetric(namespace, "max_size", "Maximum size of the cache", variableLabelNames.CacheZoneVariableLabelNames, constLabels),
@@ -563,13 +615,60 @@ func NewNginxPlusCollector(nginxClient *plusclient.NginxClient, namespace string
 			"http_requests_total":   newWorkerMetric(namespace, "http_requests_total", "The total number of client requests received by the worker process", constLabels),
 			"http_requests_current": newWorkerMetric(namespace, "http_requests_current", "The current number of client requests that are currently being processed by the worker process", constLabels),
 		},
+		slabMetrics: map[string]*prometheus.Desc{
+			"pages_used": newSlabMetric(namespace, "pages_used", "Number of used memory pages of the shared memory zone", []string{"zone"}, constLabels),
+			"pages_free": newSlabMetric(namespace, "pages_free", "Number of free memory pages of the shared memory zone", []string{"zone"}, constLabels),
+			"fails":      newSlabMetric(namespace, "fails", "Number of unsuccessful attempts to allocate memory in the shared memory zone", []string{"zone"}, constLabels),
+			"slot_used":  newSlabMetric(namespace, "slot_used", "Number of used memory slots of the slot size", []string{"zone", "slot"}, constLabels),
+			"slot_free":  newSlabMetric(namespace, "slot_free", "Number of free memory slots of the slot size", []string{"zone", "slot"}, constLabels),
+			"slot_reqs":  newSlabMetric(namespace, "slot_reqs", "Total number of attempts to allocate memory of the slot size", []string{"zone", "slot"}, constLabels),
+			"slot_fails": newSlabMetric(namespace, "slot_fails", "Number of unsuccessful attempts to allocate memory of the slot size", []string{"zone", "slot"}, constLabels),
+		},
+		keyvalZoneMetrics: map[string]*prometheus.Desc{
+			"entries":    newKeyvalZoneMetric(namespace, "keyval_zone", "entries", "Number of key-value pairs stored in the keyval zone", constLabels),
+			"pages_used": newKeyvalZoneMetric(namespace, "keyval_zone", "pages_used", "Number of used memory pages of the keyval zone", constLabels),
+			"pages_free": newKeyvalZoneMetric(namespace, "keyval_zone", "pages_free", "Number of free memory pages of the keyval zone", constLabels),
+		},
+		streamKeyvalZoneMetrics: map[string]*prometheus.Desc{
+			"entries":    newKeyvalZoneMetric(namespace, "stream_keyval_zone", "entries", "Number of key-value pairs stored in the keyval zone", constLabels),
+			"pages_used": newKeyvalZoneMetric(namespace, "stream_keyval_zone", "pages_used", "Number of used memory pages of the keyval zone", constLabels),
+			"pages_free": newKeyvalZoneMetric(namespace, "stream_keyval_zone", "pages_free", "Number of free memory pages of the keyval zone", constLabels),
+		},
+		nginxMetrics: map[string]*prometheus.Desc{
+			"generation":     newNginxMetric(namespace, "generation", "Total number of configuration reloads", constLabels),
+			"load_timestamp": newNginxMetric(namespace, "load_timestamp", "Time of the last reload of configuration (expressed as Unix Epoch Time)", constLabels),
+		},
+		licenseMetrics: map[string]*prometheus.Desc{
+			"active_till":       newLicenseMetric(namespace, "active_till", "License expiration date (expressed as Unix Epoch Time)", constLabels),
+			"eval":              newLicenseMetric(namespace, "eval", "Indicates whether an evaluation license is being used", constLabels),
+			"reporting_healthy": newLicenseMetric(namespace, "reporting_healthy", "Indicates whether the usage reporting state is still considered healthy despite recent failed attempts", constLabels),
+			"reporting_fails":   newLicenseMetric(namespace, "reporting_fails", "Number of failed usage reporting attempts, reset each time the usage report is successfully sent", constLabels),
+			"reporting_grace":   newLicenseMetric(namespace, "reporting_grace", "Number of seconds before traffic processing is stopped after unsuccessful usage reporting attempts", constLabels),
+		},
 	}
+	addSSLFailureMetrics(c.serverZoneMetrics, func(metricName string, docString string, labels prometheus.Labels) *prometheus.Desc {
+		return newServerZoneMetric(namespace, metricName, docString, variableLabelNames.ServerZoneVariableLabelNames, labels)
+	}, constLabels)
+	addSSLFailureMetrics(c.streamServerZoneMetrics, func(metricName string, docString string, labels prometheus.Labels) *prometheus.Desc {
+		return newStreamServerZoneMetric(namespace, metricName, docString, variableLabelNames.StreamServerZoneVariableLabelNames, labels)
+	}, constLabels)
+	addSSLFailureMetrics(c.upstreamServerMetrics, func(metricName string, docString string, labels prometheus.Labels) *prometheus.Desc {
+		return newUpstreamServerMetric(namespace, metricName, docString, upstreamServerVariableLabelNames, labels)
+	}, constLabels)
+	addSSLFailureMetrics(c.streamUpstreamServerMetrics, func(metricName string, docString string, labels prometheus.Labels) *prometheus.Desc {
+		return newStreamUpstreamServerMetric(namespace, metricName, docString, streamUpstreamServerVariableLabelNames, labels)
+	}, constLabels)
+	return c
 }
 
 // Describe sends the super-set of all possible descriptors of NGINX Plus metrics
 // to the provided channel.
 func (c *NginxPlusCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.upMetric.Desc()
+	ch <- c.apiVersionMetric
+	ch <- c.apiEndpointUpMetric
+	ch <- c.apiRequestDurationMetric
+	ch <- c.instanceInfoMetric
 
 	for _, m := range c.totalMetrics {
 		ch <- m
@@ -616,6 +715,21 @@ func (c *NginxPlusCollector) Describe(ch chan<- *prometheus.Desc) {
 	for _, m := range c.workerMetrics {
 		ch <- m
 	}
+	for _, m := range c.nginxMetrics {
+		ch <- m
+	}
+	for _, m := range c.licenseMetrics {
+		ch <- m
+	}
+	for _, m := range c.slabMetrics {
+		ch <- m
+	}
+	for _, m := range c.keyvalZoneMetrics {
+		ch <- m
+	}
+	for _, m := range c.streamKeyvalZoneMetrics {
+		ch <- m
+	}
 }
 
 // Collect fetches metrics from NGINX Plus and sends them to the provided channel.
@@ -623,8 +737,14 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 	c.mutex.Lock() // To protect metrics from concurrent collects
 	defer c.mutex.Unlock()
 
-	// FIXME: https://github.com/nginx/nginx-prometheus-exporter/issues/858
-	stats, err := c.nginxClient.GetStats(context.TODO())
+	ctx := context.Background()
+	if c.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.Timeout)
+		defer cancel()
+	}
+	stats, err := c.getStatsWithFallback(ctx)
+	c.status.recordScrape(err)
 	if err != nil {
 		c.upMetric.Set(nginxDown)
 		ch <- c.upMetric
@@ -634,25 +754,58 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 
 	c.upMetric.Set(nginxUp)
 	ch <- c.upMetric
+	ch <- prometheus.MustNewConstMetric(c.apiVersionMetric, prometheus.GaugeValue, float64(c.nginxClient.Version()))
 
-	ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_accepted"],
-		prometheus.CounterValue, float64(stats.Connections.Accepted))
-	ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_dropped"],
-		prometheus.CounterValue, float64(stats.Connections.Dropped))
-	ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_active"],
-		prometheus.GaugeValue, float64(stats.Connections.Active))
-	ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_idle"],
-		prometheus.GaugeValue, float64(stats.Connections.Idle))
-	ch <- prometheus.MustNewConstMetric(c.totalMetrics["http_requests_total"],
-		prometheus.CounterValue, float64(stats.HTTPRequests.Total))
-	ch <- prometheus.MustNewConstMetric(c.totalMetrics["http_requests_current"],
-		prometheus.GaugeValue, float64(stats.HTTPRequests.Current))
-	ch <- prometheus.MustNewConstMetric(c.totalMetrics["ssl_handshakes"],
-		prometheus.CounterValue, float64(stats.SSL.Handshakes))
-	ch <- prometheus.MustNewConstMetric(c.totalMetrics["ssl_handshakes_failed"],
-		prometheus.CounterValue, float64(stats.SSL.HandshakesFailed))
-	ch <- prometheus.MustNewConstMetric(c.totalMetrics["ssl_session_reuses"],
-		prometheus.CounterValue, float64(stats.SSL.SessionReuses))
+	for _, endpoint := range stats.endpoints {
+		ch <- prometheus.MustNewConstMetric(c.apiEndpointUpMetric, prometheus.GaugeValue, booleanToFloat64[stats.fetched(endpoint)], endpoint)
+		ch <- prometheus.MustNewConstMetric(c.apiRequestDurationMetric, prometheus.GaugeValue, stats.durations[endpoint].Seconds(), endpoint)
+	}
+
+	if stats.fetched("connections") {
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_accepted"],
+			prometheus.CounterValue, float64(stats.Connections.Accepted))
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_dropped"],
+			prometheus.CounterValue, float64(stats.Connections.Dropped))
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_active"],
+			prometheus.GaugeValue, float64(stats.Connections.Active))
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_idle"],
+			prometheus.GaugeValue, float64(stats.Connections.Idle))
+	}
+	if stats.fetched("http/requests") {
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["http_requests_total"],
+			prometheus.CounterValue, float64(stats.HTTPRequests.Total))
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["http_requests_current"],
+			prometheus.GaugeValue, float64(stats.HTTPRequests.Current))
+	}
+	if stats.fetched("ssl") {
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["ssl_handshakes"],
+			prometheus.CounterValue, float64(stats.SSL.Handshakes))
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["ssl_handshakes_failed"],
+			prometheus.CounterValue, float64(stats.SSL.HandshakesFailed))
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["ssl_session_reuses"],
+			prometheus.CounterValue, float64(stats.SSL.SessionReuses))
+		collectSSLFailures(ch, c.totalMetrics, stats.SSL)
+	}
+	if stats.fetched("nginx") {
+		// The load timestamp is not a label, as it changes on every reload and would start a new series each
+		// time; it is exported as the load_timestamp gauge instead. The ppid stays the same across reloads,
+		// which are handled by the same master process.
+		ch <- prometheus.MustNewConstMetric(c.instanceInfoMetric, prometheus.GaugeValue, 1,
+			stats.NginxInfo.Version, stats.NginxInfo.Build, stats.NginxInfo.Address,
+			strconv.FormatUint(stats.NginxInfo.ParentProcessID, 10))
+		ch <- prometheus.MustNewConstMetric(c.nginxMetrics["generation"],
+			prometheus.CounterValue, float64(stats.NginxInfo.Generation))
+		if loadTime, err := time.Parse(time.RFC3339, stats.NginxInfo.LoadTimestamp); err == nil {
+			ch <- prometheus.MustNewConstMetric(c.nginxMetrics["load_timestamp"],
+				prometheus.GaugeValue, float64(loadTime.UnixMilli())/1000)
+		} else {
+			c.logger.Warn("failed to parse the load timestamp", "load_timestamp", stats.NginxInfo.LoadTimestamp, "error", err.Error())
+		}
+	}
+	if stats.fetched("processes") {
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["processes_respawned"],
+			prometheus.CounterValue, float64(stats.Processes.Respawned))
+	}
 
 	for name, zone := range stats.ServerZones {
 		labelValues := []string{name}
@@ -687,92 +840,95 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 			prometheus.CounterValue, float64(zone.Received), labelValues...)
 		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["sent"],
 			prometheus.CounterValue, float64(zone.Sent), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_100"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPContinue), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_101"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSwitchingProtocols), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_102"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPProcessing), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_200"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPOk), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_201"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPCreated), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_202"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPAccepted), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_204"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNoContent), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_206"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPPartialContent), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_300"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSpecialResponse), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_301"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPMovedPermanently), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_302"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPMovedTemporarily), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_303"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSeeOther), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_304"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNotModified), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_307"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPTemporaryRedirect), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_400"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPBadRequest), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_401"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPUnauthorized), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_403"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPForbidden), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_404"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNotFound), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_405"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNotAllowed), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_408"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRequestTimeOut), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_409"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPConflict), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_411"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPLengthRequired), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_412"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPPreconditionFailed), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_413"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRequestEntityTooLarge), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_414"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRequestURITooLarge), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_415"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPUnsupportedMediaType), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_416"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRangeNotSatisfiable), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_429"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPTooManyRequests), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_444"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPClose), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_494"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRequestHeaderTooLarge), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_495"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSCertError), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_496"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSNoCert), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_497"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPToHTTPS), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_499"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPClientClosedRequest), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_500"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPInternalServerError), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_501"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNotImplemented), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_502"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPBadGateway), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_503"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPServiceUnavailable), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_504"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPGatewayTimeOut), labelValues...)
-		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_507"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPInsufficientStorage), labelValues...)
+		if !c.config.SkipResponseCodes {
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_100"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPContinue), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_101"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSwitchingProtocols), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_102"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPProcessing), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_200"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPOk), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_201"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPCreated), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_202"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPAccepted), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_204"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNoContent), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_206"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPPartialContent), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_300"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSpecialResponse), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_301"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPMovedPermanently), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_302"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPMovedTemporarily), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_303"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSeeOther), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_304"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNotModified), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_307"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPTemporaryRedirect), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_400"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPBadRequest), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_401"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPUnauthorized), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_403"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPForbidden), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_404"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNotFound), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_405"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNotAllowed), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_408"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRequestTimeOut), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_409"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPConflict), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_411"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPLengthRequired), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_412"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPPreconditionFailed), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_413"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRequestEntityTooLarge), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_414"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRequestURITooLarge), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_415"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPUnsupportedMediaType), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_416"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRangeNotSatisfiable), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_429"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPTooManyRequests), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_444"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPClose), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_494"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRequestHeaderTooLarge), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_495"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSCertError), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_496"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSNoCert), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_497"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPToHTTPS), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_499"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPClientClosedRequest), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_500"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPInternalServerError), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_501"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNotImplemented), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_502"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPBadGateway), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_503"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPServiceUnavailable), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_504"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPGatewayTimeOut), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["codes_507"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPInsufficientStorage), labelValues...)
+		}
 		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["ssl_handshakes"],
 			prometheus.CounterValue, float64(zone.SSL.Handshakes), labelValues...)
 		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["ssl_handshakes_failed"],
 			prometheus.CounterValue, float64(zone.SSL.HandshakesFailed), labelValues...)
 		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["ssl_session_reuses"],
 			prometheus.CounterValue, float64(zone.SSL.SessionReuses), labelValues...)
+		collectSSLFailures(ch, c.serverZoneMetrics, zone.SSL, labelValues...)
 	}
 
 	for name, zone := range stats.StreamServerZones {
@@ -809,9 +965,11 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 			prometheus.CounterValue, float64(zone.SSL.HandshakesFailed), labelValues...)
 		ch <- prometheus.MustNewConstMetric(c.streamServerZoneMetrics["ssl_session_reuses"],
 			prometheus.CounterValue, float64(zone.SSL.SessionReuses), labelValues...)
+		collectSSLFailures(ch, c.streamServerZoneMetrics, zone.SSL, labelValues...)
 	}
 
 	for name, upstream := range stats.Upstreams {
+		var drainingPeers, drainingActive uint64
 		for _, peer := range upstream.Peers {
 			labelValues := []string{name, peer.Server}
 			varLabelValues := c.getUpstreamServerLabelValues(name)
@@ -835,9 +993,18 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 			} else {
 				labelValues = append(labelValues, varPeerLabelValues...)
 			}
+			if c.config.PeerServiceLabels {
+				labelValues = append(labelValues, peer.Service, peer.Name)
+			}
 
 			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["state"],
 				prometheus.GaugeValue, upstreamServerStates[peer.State], labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["draining"],
+				prometheus.GaugeValue, booleanToFloat64[peer.State == "draining"], labelValues...)
+			if peer.State == "draining" {
+				drainingPeers++
+				drainingActive += peer.Active
+			}
 			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["active"],
 				prometheus.GaugeValue, float64(peer.Active), labelValues...)
 			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["limit"],
@@ -874,98 +1041,116 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 					prometheus.CounterValue, float64(peer.HealthChecks.Fails), labelValues...)
 				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["health_checks_unhealthy"],
 					prometheus.CounterValue, float64(peer.HealthChecks.Unhealthy), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["health_checks_last_passed"],
+					prometheus.GaugeValue, booleanToFloat64[peer.HealthChecks.LastPassed], labelValues...)
+			}
+			if !c.config.SkipResponseCodes {
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_100"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPContinue), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_101"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPSwitchingProtocols), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_102"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPProcessing), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_200"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPOk), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_201"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPCreated), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_202"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPAccepted), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_204"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPNoContent), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_206"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPPartialContent), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_300"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPSpecialResponse), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_301"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPMovedPermanently), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_302"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPMovedTemporarily), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_303"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPSeeOther), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_304"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPNotModified), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_307"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPTemporaryRedirect), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_400"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPBadRequest), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_401"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPUnauthorized), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_403"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPForbidden), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_404"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPNotFound), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_405"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPNotAllowed), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_408"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPRequestTimeOut), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_409"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPConflict), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_411"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPLengthRequired), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_412"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPPreconditionFailed), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_413"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPRequestEntityTooLarge), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_414"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPRequestURITooLarge), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_415"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPUnsupportedMediaType), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_416"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPRangeNotSatisfiable), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_429"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPTooManyRequests), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_444"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPClose), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_494"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPRequestHeaderTooLarge), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_495"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPSCertError), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_496"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPSNoCert), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_497"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPToHTTPS), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_499"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPClientClosedRequest), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_500"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPInternalServerError), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_501"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPNotImplemented), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_502"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPBadGateway), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_503"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPServiceUnavailable), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_504"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPGatewayTimeOut), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_507"],
+					prometheus.CounterValue, float64(peer.Responses.Codes.HTTPInsufficientStorage), labelValues...)
 			}
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_100"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPContinue), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_101"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPSwitchingProtocols), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_102"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPProcessing), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_200"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPOk), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_201"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPCreated), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_202"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPAccepted), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_204"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPNoContent), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_206"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPPartialContent), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_300"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPSpecialResponse), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_301"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPMovedPermanently), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_302"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPMovedTemporarily), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_303"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPSeeOther), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_304"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPNotModified), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_307"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPTemporaryRedirect), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_400"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPBadRequest), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_401"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPUnauthorized), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_403"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPForbidden), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_404"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPNotFound), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_405"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPNotAllowed), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_408"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPRequestTimeOut), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_409"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPConflict), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_411"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPLengthRequired), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_412"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPPreconditionFailed), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_413"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPRequestEntityTooLarge), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_414"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPRequestURITooLarge), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_415"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPUnsupportedMediaType), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_416"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPRangeNotSatisfiable), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_429"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPTooManyRequests), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_444"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPClose), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_494"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPRequestHeaderTooLarge), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_495"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPSCertError), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_496"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPSNoCert), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_497"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPToHTTPS), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_499"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPClientClosedRequest), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_500"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPInternalServerError), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_501"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPNotImplemented), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_502"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPBadGateway), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_503"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPServiceUnavailable), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_504"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPGatewayTimeOut), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_507"],
-				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPInsufficientStorage), labelValues...)
 			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["ssl_handshakes"],
 				prometheus.CounterValue, float64(peer.SSL.Handshakes), labelValues...)
 			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["ssl_handshakes_failed"],
 				prometheus.CounterValue, float64(peer.SSL.HandshakesFailed), labelValues...)
 			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["ssl_session_reuses"],
 				prometheus.CounterValue, float64(peer.SSL.SessionReuses), labelValues...)
+			collectSSLFailures(ch, c.upstreamServerMetrics, peer.SSL, labelValues...)
 		}
+		ch <- prometheus.MustNewConstMetric(c.upstreamMetrics["draining_peers"],
+			prometheus.GaugeValue, float64(drainingPeers), name)
+		ch <- prometheus.MustNewConstMetric(c.upstreamMetrics["draining_active"],
+			prometheus.GaugeValue, float64(drainingActive), name)
 		ch <- prometheus.MustNewConstMetric(c.upstreamMetrics["keepalive"],
 			prometheus.GaugeValue, float64(upstream.Keepalive), name)
 		ch <- prometheus.MustNewConstMetric(c.upstreamMetrics["zombies"],
 			prometheus.GaugeValue, float64(upstream.Zombies), name)
+		// The queue is only reported for upstreams with the queue directive.
+		if upstream.Queue.MaxSize > 0 {
+			ch <- prometheus.MustNewConstMetric(c.upstreamMetrics["queue_size"],
+				prometheus.GaugeValue, float64(upstream.Queue.Size), name)
+			ch <- prometheus.MustNewConstMetric(c.upstreamMetrics["queue_max_size"],
+				prometheus.GaugeValue, float64(upstream.Queue.MaxSize), name)
+			ch <- prometheus.MustNewConstMetric(c.upstreamMetrics["queue_overflows"],
+				prometheus.CounterValue, float64(upstream.Queue.Overflows), name)
+		}
 	}
 
 	for name, upstream := range stats.StreamUpstreams {
@@ -992,6 +1177,9 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 			} else {
 				labelValues = append(labelValues, varPeerLabelValues...)
 			}
+			if c.config.PeerServiceLabels {
+				labelValues = append(labelValues, peer.Service, peer.Name)
+			}
 
 			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["state"],
 				prometheus.GaugeValue, upstreamServerStates[peer.State], labelValues...)
@@ -1022,6 +1210,8 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 					prometheus.CounterValue, float64(peer.HealthChecks.Fails), labelValues...)
 				ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["health_checks_unhealthy"],
 					prometheus.CounterValue, float64(peer.HealthChecks.Unhealthy), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["health_checks_last_passed"],
+					prometheus.GaugeValue, booleanToFloat64[peer.HealthChecks.LastPassed], labelValues...)
 			}
 			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["ssl_handshakes"],
 				prometheus.CounterValue, float64(peer.SSL.Handshakes), labelValues...)
@@ -1029,6 +1219,7 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 				prometheus.CounterValue, float64(peer.SSL.HandshakesFailed), labelValues...)
 			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["ssl_session_reuses"],
 				prometheus.CounterValue, float64(peer.SSL.SessionReuses), labelValues...)
+			collectSSLFailures(ch, c.streamUpstreamServerMetrics, peer.SSL, labelValues...)
 		}
 		ch <- prometheus.MustNewConstMetric(c.streamUpstreamMetrics["zombies"],
 			prometheus.GaugeValue, float64(upstream.Zombies), name)
@@ -1073,86 +1264,88 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 			prometheus.CounterValue, float64(zone.Received), name)
 		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["sent"],
 			prometheus.CounterValue, float64(zone.Sent), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_100"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPContinue), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_101"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSwitchingProtocols), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_102"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPProcessing), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_200"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPOk), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_201"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPCreated), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_202"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPAccepted), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_204"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNoContent), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_206"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPPartialContent), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_300"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSpecialResponse), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_301"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPMovedPermanently), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_302"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPMovedTemporarily), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_303"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSeeOther), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_304"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNotModified), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_307"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPTemporaryRedirect), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_400"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPBadRequest), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_401"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPUnauthorized), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_403"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPForbidden), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_404"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNotFound), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_405"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNotAllowed), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_408"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRequestTimeOut), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_409"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPConflict), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_411"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPLengthRequired), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_412"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPPreconditionFailed), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_413"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRequestEntityTooLarge), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_414"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRequestURITooLarge), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_415"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPUnsupportedMediaType), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_416"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRangeNotSatisfiable), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_429"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPTooManyRequests), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_444"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPClose), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_494"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRequestHeaderTooLarge), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_495"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSCertError), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_496"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSNoCert), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_497"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPToHTTPS), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_499"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPClientClosedRequest), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_500"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPInternalServerError), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_501"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNotImplemented), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_502"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPBadGateway), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_503"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPServiceUnavailable), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_504"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPGatewayTimeOut), name)
-		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_507"],
-			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPInsufficientStorage), name)
+		if !c.config.SkipResponseCodes {
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_100"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPContinue), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_101"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSwitchingProtocols), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_102"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPProcessing), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_200"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPOk), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_201"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPCreated), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_202"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPAccepted), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_204"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNoContent), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_206"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPPartialContent), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_300"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSpecialResponse), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_301"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPMovedPermanently), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_302"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPMovedTemporarily), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_303"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSeeOther), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_304"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNotModified), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_307"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPTemporaryRedirect), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_400"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPBadRequest), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_401"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPUnauthorized), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_403"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPForbidden), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_404"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNotFound), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_405"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNotAllowed), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_408"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRequestTimeOut), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_409"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPConflict), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_411"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPLengthRequired), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_412"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPPreconditionFailed), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_413"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRequestEntityTooLarge), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_414"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRequestURITooLarge), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_415"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPUnsupportedMediaType), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_416"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRangeNotSatisfiable), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_429"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPTooManyRequests), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_444"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPClose), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_494"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPRequestHeaderTooLarge), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_495"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSCertError), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_496"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPSNoCert), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_497"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPToHTTPS), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_499"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPClientClosedRequest), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_500"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPInternalServerError), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_501"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPNotImplemented), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_502"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPBadGateway), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_503"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPServiceUnavailable), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_504"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPGatewayTimeOut), name)
+			ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_507"],
+				prometheus.CounterValue, float64(zone.Responses.Codes.HTTPInsufficientStorage), name)
+		}
 	}
 
 	for name, zone := range stats.Resolvers {
@@ -1236,8 +1429,8 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["bypass_bytes_written"], prometheus.CounterValue, float64(zone.Bypass.BytesWritten), labelValues...)
 	}
 
-	for id, worker := range stats.Workers {
-		workerID := strconv.FormatInt(int64(id), 10)
+	for _, worker := range stats.Workers {
+		workerID := strconv.Itoa(worker.ID)
 		workerPID := strconv.FormatUint(worker.ProcessID, 10)
 		ch <- prometheus.MustNewConstMetric(c.workerMetrics["connection_accepted"], prometheus.CounterValue, float64(worker.Connections.Accepted), workerID, workerPID)
 		ch <- prometheus.MustNewConstMetric(c.workerMetrics["connection_dropped"], prometheus.CounterValue, float64(worker.Connections.Dropped), workerID, workerPID)
@@ -1246,8 +1439,79 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 		ch <- prometheus.MustNewConstMetric(c.workerMetrics["http_requests_total"], prometheus.CounterValue, float64(worker.HTTP.HTTPRequests.Total), workerID, workerPID)
 		ch <- prometheus.MustNewConstMetric(c.workerMetrics["http_requests_current"], prometheus.GaugeValue, float64(worker.HTTP.HTTPRequests.Current), workerID, workerPID)
 	}
+
+	if c.config.CollectSlabs {
+		for name, slab := range stats.Slabs {
+			ch <- prometheus.MustNewConstMetric(c.slabMetrics["pages_used"], prometheus.GaugeValue, float64(slab.Pages.Used), name)
+			ch <- prometheus.MustNewConstMetric(c.slabMetrics["pages_free"], prometheus.GaugeValue, float64(slab.Pages.Free), name)
+
+			var fails uint64
+			for size, slot := range slab.Slots {
+				fails += slot.Fails
+				if !c.config.SlabSlotDetail {
+					continue
+				}
+				ch <- prometheus.MustNewConstMetric(c.slabMetrics["slot_used"], prometheus.GaugeValue, float64(slot.Used), name, size)
+				ch <- prometheus.MustNewConstMetric(c.slabMetrics["slot_free"], prometheus.GaugeValue, float64(slot.Free), name, size)
+				ch <- prometheus.MustNewConstMetric(c.slabMetrics["slot_reqs"], prometheus.CounterValue, float64(slot.Reqs), name, size)
+				ch <- prometheus.MustNewConstMetric(c.slabMetrics["slot_fails"], prometheus.CounterValue, float64(slot.Fails), name, size)
+			}
+			ch <- prometheus.MustNewConstMetric(c.slabMetrics["fails"], prometheus.CounterValue, float64(fails), name)
+		}
+	}
+
+	c.collectKeyvalZones(ch, c.keyvalZoneMetrics, stats.KeyVals, stats.Slabs)
+	c.collectKeyvalZones(ch, c.streamKeyvalZoneMetrics, stats.StreamKeyVals, stats.Slabs)
+
+	// The license endpoint is only available in NGINX Plus R33 and later; older releases report an empty license.
+	if stats.License != nil && stats.License.ActiveTill != 0 {
+		ch <- prometheus.MustNewConstMetric(c.licenseMetrics["active_till"], prometheus.GaugeValue, float64(stats.License.ActiveTill))
+		ch <- prometheus.MustNewConstMetric(c.licenseMetrics["eval"], prometheus.GaugeValue, booleanToFloat64[stats.License.Eval])
+		ch <- prometheus.MustNewConstMetric(c.licenseMetrics["reporting_healthy"], prometheus.GaugeValue, booleanToFloat64[stats.License.Reporting.Healthy])
+		ch <- prometheus.MustNewConstMetric(c.licenseMetrics["reporting_fails"], prometheus.GaugeValue, float64(stats.License.Reporting.Fails))
+		ch <- prometheus.MustNewConstMetric(c.licenseMetrics["reporting_grace"], prometheus.GaugeValue, float64(stats.License.Reporting.Grace))
+	}
 }
 
+// collectKeyvalZones sends the number of entries and the memory usage of keyval zones.
+func (c *NginxPlusCollector) collectKeyvalZones(ch chan<- prometheus.Metric, metrics map[string]*prometheus.Desc, keyVals plusclient.KeyValPairsByZone, slabs plusclient.Slabs) {
+	for name, pairs := range keyVals {
+		ch <- prometheus.MustNewConstMetric(metrics["entries"], prometheus.GaugeValue, float64(len(pairs)), name)
+		if slab, ok := slabs[name]; ok {
+			ch <- prometheus.MustNewConstMetric(metrics["pages_used"], prometheus.GaugeValue, float64(slab.Pages.Used), name)
+			ch <- prometheus.MustNewConstMetric(metrics["pages_free"], prometheus.GaugeValue, float64(slab.Pages.Free), name)
+		}
+	}
+}
+
+// addSSLFailureMetrics adds the metrics of the SSL handshake failure reasons and the certificate verification
+// failures, created by newMetric, to metrics.
+func addSSLFailureMetrics(metrics map[string]*prometheus.Desc, newMetric func(metricName string, docString string, constLabels prometheus.Labels) *prometheus.Desc, constLabels prometheus.Labels) {
+	metrics["ssl_no_common_protocol"] = newMetric("ssl_no_common_protocol", "SSL handshakes failed because of no common protocol", constLabels)
+	metrics["ssl_no_common_cipher"] = newMetric("ssl_no_common_cipher", "SSL handshakes failed because of no shared cipher", constLabels)
+	metrics["ssl_handshake_timeout"] = newMetric("ssl_handshake_timeout", "SSL handshakes failed because of a timeout", constLabels)
+	metrics["ssl_peer_rejected_cert"] = newMetric("ssl_peer_rejected_cert", "Failed SSL handshakes when the certificate presented by NGINX was rejected by the peer", constLabels)
+	for _, failure := range []string{"no_cert", "expired_cert", "revoked_cert", "hostname_mismatch", "other"} {
+		metrics["ssl_verify_failures_"+failure] = newMetric("ssl_verify_failures", "SSL certificate verification errors", MergeLabels(constLabels, prometheus.Labels{"type": failure}))
+	}
+}
+
+// collectSSLFailures sends the SSL handshake failure reasons and the certificate verification failures of ssl.
+func collectSSLFailures(ch chan<- prometheus.Metric, metrics map[string]*prometheus.Desc, ssl plusclient.SSL, labelValues ...string) {
+	ch <- prometheus.MustNewConstMetric(metrics["ssl_no_common_protocol"], prometheus.CounterValue, float64(ssl.NoCommonProtocol), labelValues...)
+	ch <- prometheus.MustNewConstMetric(metrics["ssl_no_common_cipher"], prometheus.CounterValue, float64(ssl.NoCommonCipher), labelValues...)
+	ch <- prometheus.MustNewConstMetric(metrics["ssl_handshake_timeout"], prometheus.CounterValue, float64(ssl.HandshakeTimeout), labelValues...)
+	ch <- prometheus.MustNewConstMetric(metrics["ssl_peer_rejected_cert"], prometheus.CounterValue, float64(ssl.PeerRejectedCert), labelValues...)
+	ch <- prometheus.MustNewConstMetric(metrics["ssl_verify_failures_no_cert"], prometheus.CounterValue, float64(ssl.VerifyFailures.NoCert), labelValues...)
+	ch <- prometheus.MustNewConstMetric(metrics["ssl_verify_failures_expired_cert"], prometheus.CounterValue, float64(ssl.VerifyFailures.ExpiredCert), labelValues...)
+	ch <- prometheus.MustNewConstMetric(metrics["ssl_verify_failures_revoked_cert"], prometheus.CounterValue, float64(ssl.VerifyFailures.RevokedCert), labelValues...)
+	ch <- prometheus.MustNewConstMetric(metrics["ssl_verify_failures_hostname_mismatch"], prometheus.CounterValue, float64(ssl.VerifyFailures.HostnameMismatch), labelValues...)
+	ch <- prometheus.MustNewConstMetric(metrics["ssl_verify_failures_other"], prometheus.CounterValue, float64(ssl.VerifyFailures.Other), labelValues...)
+}
+
+// peerServiceLabelNames are the labels added to upstream peer metrics when PeerServiceLabels is enabled.
+var peerServiceLabelNames = []string{"service", "server_name"}
+
 var upstreamServerStates = map[string]float64{
 	"up":        1.0,
 	"draining":  2.0,
@@ -1331,3 +1595,19 @@ func newCacheZoneMetric(namespace string, metricName string, docString string, v
 func newWorkerMetric(namespace string, metricName string, docString string, constLabels prometheus.Labels) *prometheus.Desc {
 	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "worker", metricName), docString, []string{"id", "pid"}, constLabels)
 }
+
+func newLicenseMetric(namespace string, metricName string, docString string, constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "license", metricName), docString, nil, constLabels)
+}
+
+func newSlabMetric(namespace string, metricName string, docString string, labels []string, constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "slab", metricName), docString, labels, constLabels)
+}
+
+func newKeyvalZoneMetric(namespace string, subsystem string, metricName string, docString string, constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, metricName), docString, []string{"zone"}, constLabels)
+}
+
+func newNginxMetric(namespace string, metricName string, docString string, constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "nginx", metricName), docString, nil, constLabels)
+}
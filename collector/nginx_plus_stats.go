@@ -0,0 +1,502 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	plusclient "github.com/nginx/nginx-plus-go-client/v2/client"
+	"golang.org/x/sync/errgroup"
+)
+
+// Groups of NGINX Plus API endpoints that can be skipped by the NginxPlusCollector.
+const (
+	PlusEndpointServerZones       = "server_zones"
+	PlusEndpointLocationZones     = "location_zones"
+	PlusEndpointUpstreams         = "upstreams"
+	PlusEndpointCaches            = "caches"
+	PlusEndpointResolvers         = "resolvers"
+	PlusEndpointLimitReqs         = "limit_reqs"
+	PlusEndpointLimitConns        = "limit_conns"
+	PlusEndpointWorkers           = "workers"
+	PlusEndpointStreamServerZones = "stream_server_zones"
+	PlusEndpointStreamUpstreams   = "stream_upstreams"
+	PlusEndpointStreamLimitConns  = "stream_limit_conns"
+	PlusEndpointStreamZoneSync    = "stream_zone_sync"
+	PlusEndpointLicense           = "license"
+
+	// PlusEndpointHTTP and PlusEndpointStream select all HTTP and all stream endpoints.
+	PlusEndpointHTTP   = "http"
+	PlusEndpointStream = "stream"
+)
+
+// PlusEndpoints lists all groups of NGINX Plus API endpoints that can be skipped.
+var PlusEndpoints = []string{
+	PlusEndpointHTTP,
+	PlusEndpointServerZones,
+	PlusEndpointLocationZones,
+	PlusEndpointUpstreams,
+	PlusEndpointCaches,
+	PlusEndpointResolvers,
+	PlusEndpointLimitReqs,
+	PlusEndpointLimitConns,
+	PlusEndpointWorkers,
+	PlusEndpointStream,
+	PlusEndpointStreamServerZones,
+	PlusEndpointStreamUpstreams,
+	PlusEndpointStreamLimitConns,
+	PlusEndpointStreamZoneSync,
+	PlusEndpointLicense,
+}
+
+// NginxPlusCollectorConfig holds the optional settings of the NginxPlusCollector.
+type NginxPlusCollectorConfig struct {
+	// SkipEndpoints lists the groups of API endpoints which are not collected.
+	SkipEndpoints []string
+	// ServerZoneFilter selects the HTTP and stream server zones which are collected.
+	ServerZoneFilter NameFilter
+	// UpstreamFilter selects the HTTP and stream upstreams which are collected.
+	UpstreamFilter NameFilter
+	// CacheZoneFilter selects the cache zones which are collected.
+	CacheZoneFilter NameFilter
+	// UpstreamsFile is the path to a file listing the names of the HTTP and stream upstreams which are
+	// collected, one per line. The file is read again when it changes.
+	UpstreamsFile string
+	// PeerServiceLabels adds the service name and the server name of upstream peers as labels, to identify
+	// peers resolved from DNS SRV records (the service= parameter).
+	PeerServiceLabels bool
+	// SkipResponseCodes disables the per-status-code response metrics of server zones, location zones and upstream peers.
+	SkipResponseCodes bool
+	// CollectSlabs enables the collection of the slab allocator usage of shared memory zones.
+	CollectSlabs bool
+	// SlabSlotDetail exports the usage of every slot size in addition to the per-zone totals.
+	SlabSlotDetail bool
+	// CollectKeyvals enables the collection of the number of entries and the memory usage of keyval zones.
+	CollectKeyvals bool
+	// CollectLocationZones enables the collection of the metrics of the location zones, the locations
+	// with a status_zone directive.
+	CollectLocationZones bool
+	// FetchConcurrency is the maximum number of API endpoints fetched at the same time.
+	FetchConcurrency int
+	// Timeout limits the duration of the API requests of a scrape, including the retries with older API
+	// versions. Disabled when 0.
+	Timeout time.Duration
+	// NewClient creates a client for the given API version. If set, the collector retries with
+	// older API versions when NGINX Plus does not support the version of the current client.
+	NewClient func(apiVersion int) (*plusclient.NginxClient, error)
+}
+
+// defaultPlusFetchConcurrency is the number of API endpoints fetched at the same time when FetchConcurrency is not set.
+const defaultPlusFetchConcurrency = 4
+
+// pathNotFoundCode is the error code of the NGINX Plus API for missing endpoints and zones.
+const pathNotFoundCode = "PathNotFound"
+
+// minPlusAPIVersion is the oldest NGINX Plus API version supported by the client.
+const minPlusAPIVersion = 4
+
+// plusStats holds the responses of the NGINX Plus API endpoints, including those not covered by plusclient.Stats.
+type plusStats struct {
+	License       *plusclient.NginxLicense
+	KeyVals       plusclient.KeyValPairsByZone
+	StreamKeyVals plusclient.KeyValPairsByZone
+	// errors holds the result of every fetched endpoint by path.
+	errors map[string]error
+	// durations holds the request duration of every fetched endpoint by path.
+	durations map[string]time.Duration
+	// endpoints lists the paths of the fetched endpoints in the order of plusEndpoints.
+	endpoints []string
+	plusclient.Stats
+}
+
+// fetched reports whether the endpoint was fetched successfully.
+func (s *plusStats) fetched(path string) bool {
+	err, ok := s.errors[path]
+	return ok && err == nil
+}
+
+// plusEndpoint is a single NGINX Plus API endpoint and the function storing its response in the stats.
+type plusEndpoint struct {
+	fetch func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error
+	// enabled reports whether an opt-in endpoint is collected. Endpoints without it are always collected.
+	enabled func(config NginxPlusCollectorConfig) bool
+	path    string
+	groups  []string
+}
+
+var plusEndpoints = []plusEndpoint{
+	{
+		path: "connections",
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			connections, err := client.GetConnections(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get connections: %w", err)
+			}
+			stats.Connections = *connections
+			return nil
+		},
+	},
+	{
+		path: "http/requests",
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			httpRequests, err := client.GetHTTPRequests(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get HTTP requests: %w", err)
+			}
+			stats.HTTPRequests = *httpRequests
+			return nil
+		},
+	},
+	{
+		path: "ssl",
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			ssl, err := client.GetSSL(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get SSL: %w", err)
+			}
+			stats.SSL = *ssl
+			return nil
+		},
+	},
+	{
+		path: "nginx",
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			nginxInfo, err := client.GetNginxInfo(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get nginx info: %w", err)
+			}
+			stats.NginxInfo = *nginxInfo
+			return nil
+		},
+	},
+	{
+		path: "processes",
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			processes, err := client.GetProcesses(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get processes: %w", err)
+			}
+			stats.Processes = *processes
+			return nil
+		},
+	},
+	{
+		path:   "http/server_zones",
+		groups: []string{PlusEndpointHTTP, PlusEndpointServerZones},
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			serverZones, err := client.GetServerZones(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get server zones: %w", err)
+			}
+			stats.ServerZones = *serverZones
+			return nil
+		},
+	},
+	{
+		path:    "http/location_zones",
+		groups:  []string{PlusEndpointHTTP, PlusEndpointLocationZones},
+		enabled: func(config NginxPlusCollectorConfig) bool { return config.CollectLocationZones },
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			locationZones, err := client.GetLocationZones(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get location zones: %w", err)
+			}
+			stats.LocationZones = *locationZones
+			return nil
+		},
+	},
+	{
+		path:   "http/upstreams",
+		groups: []string{PlusEndpointHTTP, PlusEndpointUpstreams},
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			upstreams, err := client.GetUpstreams(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get upstreams: %w", err)
+			}
+			stats.Upstreams = *upstreams
+			return nil
+		},
+	},
+	{
+		path:   "http/caches",
+		groups: []string{PlusEndpointHTTP, PlusEndpointCaches},
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			caches, err := client.GetCaches(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get caches: %w", err)
+			}
+			stats.Caches = *caches
+			return nil
+		},
+	},
+	{
+		path:   "resolvers",
+		groups: []string{PlusEndpointResolvers},
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			resolvers, err := client.GetResolvers(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get resolvers: %w", err)
+			}
+			stats.Resolvers = *resolvers
+			return nil
+		},
+	},
+	{
+		path:   "http/limit_reqs",
+		groups: []string{PlusEndpointHTTP, PlusEndpointLimitReqs},
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			limitReqs, err := client.GetHTTPLimitReqs(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get HTTP limit requests: %w", err)
+			}
+			stats.HTTPLimitRequests = *limitReqs
+			return nil
+		},
+	},
+	{
+		path:   "http/limit_conns",
+		groups: []string{PlusEndpointHTTP, PlusEndpointLimitConns},
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			limitConns, err := client.GetHTTPConnectionsLimit(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get HTTP limit connections: %w", err)
+			}
+			stats.HTTPLimitConnections = *limitConns
+			return nil
+		},
+	},
+	{
+		path:   "workers",
+		groups: []string{PlusEndpointWorkers},
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			workers, err := client.GetWorkers(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get workers: %w", err)
+			}
+			stats.Workers = workers
+			return nil
+		},
+	},
+	{
+		path:   "stream/server_zones",
+		groups: []string{PlusEndpointStream, PlusEndpointStreamServerZones},
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			streamServerZones, err := client.GetStreamServerZones(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get stream server zones: %w", err)
+			}
+			stats.StreamServerZones = *streamServerZones
+			return nil
+		},
+	},
+	{
+		path:   "stream/upstreams",
+		groups: []string{PlusEndpointStream, PlusEndpointStreamUpstreams},
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			streamUpstreams, err := client.GetStreamUpstreams(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get stream upstreams: %w", err)
+			}
+			stats.StreamUpstreams = *streamUpstreams
+			return nil
+		},
+	},
+	{
+		path:   "stream/limit_conns",
+		groups: []string{PlusEndpointStream, PlusEndpointStreamLimitConns},
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			streamLimitConns, err := client.GetStreamConnectionsLimit(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get stream limit connections: %w", err)
+			}
+			stats.StreamLimitConnections = *streamLimitConns
+			return nil
+		},
+	},
+	{
+		path:   "stream/zone_sync",
+		groups: []string{PlusEndpointStream, PlusEndpointStreamZoneSync},
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			zoneSync, err := client.GetStreamZoneSync(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get stream zone sync: %w", err)
+			}
+			stats.StreamZoneSync = zoneSync
+			return nil
+		},
+	},
+	{
+		path: "slabs",
+		// The memory usage of keyval zones is reported by the slabs endpoint.
+		enabled: func(config NginxPlusCollectorConfig) bool { return config.CollectSlabs || config.CollectKeyvals },
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			slabs, err := client.GetSlabs(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get slabs: %w", err)
+			}
+			stats.Slabs = *slabs
+			return nil
+		},
+	},
+	{
+		path:    "http/keyvals",
+		groups:  []string{PlusEndpointHTTP},
+		enabled: func(config NginxPlusCollectorConfig) bool { return config.CollectKeyvals },
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			keyVals, err := client.GetAllKeyValPairs(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get HTTP keyvals: %w", err)
+			}
+			stats.KeyVals = keyVals
+			return nil
+		},
+	},
+	{
+		path:    "stream/keyvals",
+		groups:  []string{PlusEndpointStream},
+		enabled: func(config NginxPlusCollectorConfig) bool { return config.CollectKeyvals },
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			keyVals, err := client.GetAllStreamKeyValPairs(ctx)
+			if err != nil {
+				// Like the other stream endpoints, a missing stream module is not an error.
+				if isPathNotFound(err) {
+					return nil
+				}
+				return fmt.Errorf("failed to get stream keyvals: %w", err)
+			}
+			stats.StreamKeyVals = keyVals
+			return nil
+		},
+	},
+	{
+		path:   "license",
+		groups: []string{PlusEndpointLicense},
+		fetch: func(ctx context.Context, client *plusclient.NginxClient, stats *plusStats) error {
+			license, err := client.GetNginxLicense(ctx)
+			if err != nil {
+				// NGINX Plus releases before R33 do not have the license endpoint.
+				if isPathNotFound(err) {
+					return nil
+				}
+				return fmt.Errorf("failed to get license: %w", err)
+			}
+			stats.License = license
+			return nil
+		},
+	},
+}
+
+// getStats fetches the NGINX Plus API endpoints which are not skipped, at most FetchConcurrency at a time.
+// Every endpoint stores its response in a separate field of the stats, so the fetches do not need locking.
+// A failed endpoint does not fail the others; an error is only returned when no endpoint could be fetched.
+func (c *NginxPlusCollector) getStats(ctx context.Context) (*plusStats, error) {
+	stats := &plusStats{
+		errors:    make(map[string]error),
+		durations: make(map[string]time.Duration),
+	}
+
+	concurrency := c.config.FetchConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultPlusFetchConcurrency
+	}
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+
+	var endpoints []plusEndpoint
+	for _, endpoint := range plusEndpoints {
+		if endpoint.enabled != nil && !endpoint.enabled(c.config) {
+			continue
+		}
+		if slices.ContainsFunc(endpoint.groups, func(group string) bool { return slices.Contains(c.config.SkipEndpoints, group) }) {
+			continue
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	errs := make([]error, len(endpoints))
+	durations := make([]time.Duration, len(endpoints))
+	for i, endpoint := range endpoints {
+		g.Go(func() error {
+			start := time.Now()
+			errs[i] = endpoint.fetch(ctx, c.nginxClient, stats)
+			durations[i] = time.Since(start)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	failed := 0
+	for i, endpoint := range endpoints {
+		stats.endpoints = append(stats.endpoints, endpoint.path)
+		stats.errors[endpoint.path] = errs[i]
+		stats.durations[endpoint.path] = durations[i]
+		if errs[i] != nil {
+			failed++
+		}
+	}
+	if failed == len(endpoints) {
+		return nil, fmt.Errorf("error returned from contacting Plus API: %w", errors.Join(errs...))
+	}
+	for i, endpoint := range endpoints {
+		if errs[i] != nil {
+			c.logger.Warn("error getting stats from endpoint", "endpoint", endpoint.path, "error", errs[i].Error())
+		}
+	}
+
+	if c.upstreamsFile != nil {
+		if err := c.upstreamsFile.reload(); err != nil {
+			c.logger.Warn("failed to load the upstreams file, the previous list is used", "error", err.Error())
+		}
+		filterByName(stats.Upstreams, c.upstreamsFile)
+		filterByName(stats.StreamUpstreams, c.upstreamsFile)
+	}
+	filterByName(stats.ServerZones, c.config.ServerZoneFilter)
+	filterByName(stats.StreamServerZones, c.config.ServerZoneFilter)
+	filterByName(stats.Upstreams, c.config.UpstreamFilter)
+	filterByName(stats.StreamUpstreams, c.config.UpstreamFilter)
+	filterByName(stats.Caches, c.config.CacheZoneFilter)
+
+	return stats, nil
+}
+
+// getStatsWithFallback fetches the stats, switching to an older API version while NGINX Plus rejects the current one.
+func (c *NginxPlusCollector) getStatsWithFallback(ctx context.Context) (*plusStats, error) {
+	for {
+		stats, err := c.getStats(ctx)
+		if err == nil || c.config.NewClient == nil || !isUnsupportedAPIVersion(err) {
+			return stats, err
+		}
+
+		version := c.nginxClient.Version()
+		if version <= minPlusAPIVersion {
+			return nil, err
+		}
+		nginxClient, clientErr := c.config.NewClient(version - 1)
+		if clientErr != nil {
+			return nil, fmt.Errorf("failed to create a client for API version %d: %w", version-1, clientErr)
+		}
+		c.logger.Warn("NGINX Plus API version is not supported, retrying with an older version", "version", version, "new_version", version-1, "error", err.Error())
+		c.nginxClient = nginxClient
+	}
+}
+
+// isPathNotFound reports whether the error is a response for a missing API endpoint or zone.
+func isPathNotFound(err error) bool {
+	var statusErr plusclient.StatusError
+	return errors.As(err, &statusErr) && statusErr.Code() == pathNotFoundCode
+}
+
+// isUnsupportedAPIVersion reports whether the error is a rejection of the API version rather than of a single endpoint.
+func isUnsupportedAPIVersion(err error) bool {
+	var statusErr plusclient.StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	if statusErr.Code() == pathNotFoundCode {
+		return false
+	}
+	return statusErr.Status() == http.StatusNotFound || statusErr.Status() == http.StatusBadRequest
+}
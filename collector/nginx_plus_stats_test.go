@@ -0,0 +1,885 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	plusclient "github.com/nginx/nginx-plus-go-client/v2/client"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestGetStatsWithFallback(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.HasPrefix(r.URL.Path, "/api/7/") {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"status":404,"text":"unknown version","code":"UnknownVersion"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	newClient := func(apiVersion int) (*plusclient.NginxClient, error) {
+		return plusclient.NewNginxClient(server.URL+"/api", plusclient.WithAPIVersion(apiVersion))
+	}
+	nginxClient, err := newClient(9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewNginxPlusCollector(nginxClient, WithLogger(slog.New(slog.DiscardHandler)), WithPlusConfig(NginxPlusCollectorConfig{
+		SkipEndpoints: PlusEndpoints,
+		NewClient:     newClient,
+	}))
+
+	if _, err := c.getStatsWithFallback(context.Background()); err != nil {
+		t.Fatalf("getStatsWithFallback() returned error: %v", err)
+	}
+	if got := c.nginxClient.Version(); got != 7 {
+		t.Errorf("negotiated API version = %v, want 7", got)
+	}
+}
+
+func TestNginxPlusCollectorTimeout(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/9/connections" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(10 * time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	nginxClient, err := plusclient.NewNginxClient(server.URL + "/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewNginxPlusCollector(nginxClient, WithLogger(slog.New(slog.DiscardHandler)), WithPlusConfig(NginxPlusCollectorConfig{
+		SkipEndpoints: []string{PlusEndpointWorkers, PlusEndpointLicense},
+		Timeout:       100 * time.Millisecond,
+	}))
+
+	start := time.Now()
+	up, ok := gatherValue(t, c, "nginxplus_api_endpoint_up", "endpoint", "connections")
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Collect() took %v, want it to stop after the timeout", elapsed)
+	}
+	if !ok || up != 0 {
+		t.Errorf("nginxplus_api_endpoint_up{endpoint=\"connections\"} = %v (collected %v), want 0", up, ok)
+	}
+}
+
+func TestIsUnsupportedAPIVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{
+			name: "unknown version",
+			body: `{"error":{"status":404,"text":"unknown version","code":"UnknownVersion"}}`,
+			want: true,
+		},
+		{
+			name: "missing endpoint",
+			body: `{"error":{"status":404,"text":"path not found","code":"PathNotFound"}}`,
+			want: false,
+		},
+		{
+			name: "server error",
+			body: `{"error":{"status":500,"text":"internal error","code":"InternalError"}}`,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			nginxClient, err := plusclient.NewNginxClient(server.URL + "/api")
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = nginxClient.GetConnections(context.Background())
+			if got := isUnsupportedAPIVersion(err); got != tt.want {
+				t.Errorf("isUnsupportedAPIVersion(%v) = %v, want %v", err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetStatsSkipEndpoints(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		skip     []string
+		wantSkip []string
+		wantGet  []string
+	}{
+		{
+			name:     "skip all HTTP endpoints",
+			skip:     []string{PlusEndpointHTTP},
+			wantSkip: []string{"/api/9/http/server_zones", "/api/9/http/upstreams", "/api/9/http/caches"},
+			wantGet:  []string{"/api/9/http/requests", "/api/9/stream/server_zones", "/api/9/stream/upstreams"},
+		},
+		{
+			name:     "skip stream upstreams only",
+			skip:     []string{PlusEndpointStreamUpstreams},
+			wantSkip: []string{"/api/9/stream/upstreams"},
+			wantGet:  []string{"/api/9/stream/server_zones", "/api/9/http/upstreams"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var mu sync.Mutex
+			var paths []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				paths = append(paths, r.URL.Path)
+				mu.Unlock()
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			nginxClient, err := plusclient.NewNginxClient(server.URL + "/api")
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := NewNginxPlusCollector(nginxClient, WithLogger(slog.New(slog.DiscardHandler)), WithPlusConfig(NginxPlusCollectorConfig{
+				SkipEndpoints: append([]string{PlusEndpointWorkers, PlusEndpointLicense}, tt.skip...),
+			}))
+			if _, err := c.getStats(context.Background()); err != nil {
+				t.Fatalf("getStats() returned error: %v", err)
+			}
+
+			for _, path := range tt.wantSkip {
+				if slices.Contains(paths, path) {
+					t.Errorf("getStats() requested skipped endpoint %v", path)
+				}
+			}
+			for _, path := range tt.wantGet {
+				if !slices.Contains(paths, path) {
+					t.Errorf("getStats() did not request endpoint %v", path)
+				}
+			}
+		})
+	}
+}
+
+func TestGetStatsPartialFailure(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/9/http/") {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"error":{"status":500,"text":"internal error","code":"InternalError"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	nginxClient, err := plusclient.NewNginxClient(server.URL + "/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewNginxPlusCollector(nginxClient, WithLogger(slog.New(slog.DiscardHandler)), WithPlusConfig(NginxPlusCollectorConfig{
+		SkipEndpoints: []string{PlusEndpointWorkers, PlusEndpointLicense},
+	}))
+
+	stats, err := c.getStats(context.Background())
+	if err != nil {
+		t.Fatalf("getStats() returned error: %v", err)
+	}
+	for path, want := range map[string]bool{
+		"connections":         true,
+		"stream/server_zones": true,
+		"http/requests":       false,
+		"http/upstreams":      false,
+		"workers":             false,
+	} {
+		if got := stats.fetched(path); got != want {
+			t.Errorf("fetched(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	for endpoint, want := range map[string]float64{
+		"connections":         1,
+		"stream/server_zones": 1,
+		"http/requests":       0,
+		"http/upstreams":      0,
+	} {
+		if got, ok := gatherValue(t, c, "nginxplus_api_endpoint_up", "endpoint", endpoint); !ok || got != want {
+			t.Errorf("nginxplus_api_endpoint_up{endpoint=%q} = %v (collected %v), want %v", endpoint, got, ok, want)
+		}
+	}
+	for _, endpoint := range stats.endpoints {
+		if _, ok := gatherValue(t, c, "nginxplus_api_request_duration_seconds", "endpoint", endpoint); !ok {
+			t.Errorf("nginxplus_api_request_duration_seconds{endpoint=%q} was not collected", endpoint)
+		}
+	}
+	if got := testutil.CollectAndCount(c, "nginxplus_api_request_duration_seconds"); got != len(stats.endpoints) {
+		t.Errorf("nginxplus_api_request_duration_seconds has %d series, want %d", got, len(stats.endpoints))
+	}
+}
+
+// pathNotFoundResponse is served with the status 404 by the fake NGINX Plus API of newPlusTestClient.
+const pathNotFoundResponse = `{"error":{"status":404,"text":"path not found","code":"PathNotFound"}}`
+
+// seriesValue is the value of the series of a metric with the label.
+type seriesValue struct {
+	name, label, value string
+	want               float64
+}
+
+// gatherValue returns the value of the series of the metric with the label, collected from the collector.
+func gatherValue(t *testing.T, c prometheus.Collector, name, label, value string) (float64, bool) {
+	t.Helper()
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, pair := range metric.GetLabel() {
+				if pair.GetName() == label && pair.GetValue() == value {
+					if metric.GetGauge() != nil {
+						return metric.GetGauge().GetValue(), true
+					}
+					return metric.GetCounter().GetValue(), true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+// newPlusTestClient creates a client of a fake NGINX Plus API, which serves the responses by path relative
+// to /api/9/. Other paths are served as an NGINX Plus R32 without worker processes, which has no license and
+// an empty object for every other endpoint.
+func newPlusTestClient(t *testing.T, responses map[string]string) *plusclient.NginxClient {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		path := strings.TrimPrefix(r.URL.Path, "/api/9/")
+		body, ok := responses[path]
+		switch {
+		case ok && body == pathNotFoundResponse:
+			w.WriteHeader(http.StatusNotFound)
+		case !ok && path == "nginx":
+			body = `{"version":"1.25.5","build":"nginx-plus-r32"}`
+		case !ok && path == "workers":
+			body = `[]`
+		case !ok:
+			body = `{}`
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	nginxClient, err := plusclient.NewNginxClient(server.URL + "/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nginxClient
+}
+
+func newPlusTestCollector(t *testing.T, responses map[string]string, config NginxPlusCollectorConfig) *NginxPlusCollector {
+	t.Helper()
+
+	return NewNginxPlusCollector(newPlusTestClient(t, responses), WithLogger(slog.New(slog.DiscardHandler)), WithPlusConfig(config))
+}
+
+func TestNginxPlusCollectorMetrics(t *testing.T) {
+	t.Parallel()
+
+	const locationZones = `{"api":{"requests":12,"responses":{"1xx":0,"2xx":9,"3xx":1,"4xx":1,"5xx":1,"total":12},"discarded":2,"received":300,"sent":4000}}`
+	locationZoneMetrics := []string{
+		"nginxplus_location_zone_requests", "nginxplus_location_zone_responses",
+		"nginxplus_location_zone_discarded", "nginxplus_location_zone_sent",
+	}
+	const slabs = `{"cache":{"pages":{"used":3,"free":61},"slots":{
+		"8":{"used":1,"free":100,"reqs":10,"fails":2},
+		"16":{"used":4,"free":50,"reqs":20,"fails":5}
+	}}}`
+	const slabZone = `
+# HELP nginxplus_slab_fails Number of unsuccessful attempts to allocate memory in the shared memory zone
+# TYPE nginxplus_slab_fails counter
+nginxplus_slab_fails{zone="cache"} 7
+# HELP nginxplus_slab_pages_free Number of free memory pages of the shared memory zone
+# TYPE nginxplus_slab_pages_free gauge
+nginxplus_slab_pages_free{zone="cache"} 61
+# HELP nginxplus_slab_pages_used Number of used memory pages of the shared memory zone
+# TYPE nginxplus_slab_pages_used gauge
+nginxplus_slab_pages_used{zone="cache"} 3
+`
+	const generation = `
+# HELP nginxplus_nginx_generation Total number of configuration reloads
+# TYPE nginxplus_nginx_generation counter
+nginxplus_nginx_generation 4
+`
+	const instanceInfo = `
+# HELP nginxplus_instance_info NGINX Plus instance information reported by the /nginx endpoint
+# TYPE nginxplus_instance_info gauge
+nginxplus_instance_info{address="10.0.0.1",build="nginx-plus-r32",ppid="100",version="1.25.5"} 1
+`
+
+	tests := []struct {
+		name      string
+		responses map[string]string
+		config    NginxPlusCollectorConfig
+		expected  string
+		metrics   []string
+		series    []seriesValue
+	}{
+		{
+			// The worker IDs reported by the API do not follow the order of the workers.
+			name: "workers",
+			responses: map[string]string{
+				"workers": `[{"id":1,"pid":1202,"connections":{"accepted":20,"dropped":0,"active":5,"idle":4},"http":{"requests":{"total":70,"current":1}}},` +
+					`{"id":0,"pid":1201,"connections":{"accepted":10,"dropped":1,"active":3,"idle":2},"http":{"requests":{"total":40,"current":2}}}]`,
+			},
+			expected: `
+# HELP nginxplus_worker_connection_accepted The total number of accepted client connections
+# TYPE nginxplus_worker_connection_accepted counter
+nginxplus_worker_connection_accepted{id="0",pid="1201"} 10
+nginxplus_worker_connection_accepted{id="1",pid="1202"} 20
+# HELP nginxplus_worker_http_requests_total The total number of client requests received by the worker process
+# TYPE nginxplus_worker_http_requests_total counter
+nginxplus_worker_http_requests_total{id="0",pid="1201"} 40
+nginxplus_worker_http_requests_total{id="1",pid="1202"} 70
+`,
+			metrics: []string{"nginxplus_worker_connection_accepted", "nginxplus_worker_http_requests_total"},
+		},
+		{
+			name: "limit zones",
+			responses: map[string]string{
+				"http/limit_reqs":    `{"one":{"passed":10,"delayed":3,"rejected":2,"delayed_dry_run":4,"rejected_dry_run":1}}`,
+				"http/limit_conns":   `{"addr":{"passed":7,"rejected":5,"rejected_dry_run":6}}`,
+				"stream/limit_conns": `{"tcp":{"passed":8,"rejected":9,"rejected_dry_run":0}}`,
+			},
+			expected: `
+# HELP nginxplus_limit_request_delayed_dry_run Total number of requests accounted as delayed in the dry run mode
+# TYPE nginxplus_limit_request_delayed_dry_run counter
+nginxplus_limit_request_delayed_dry_run{zone="one"} 4
+# HELP nginxplus_limit_request_passed Total number of requests that were neither limited nor accounted as limited
+# TYPE nginxplus_limit_request_passed counter
+nginxplus_limit_request_passed{zone="one"} 10
+# HELP nginxplus_limit_request_rejected Total number of requests that were rejected
+# TYPE nginxplus_limit_request_rejected counter
+nginxplus_limit_request_rejected{zone="one"} 2
+# HELP nginxplus_limit_request_rejected_dry_run Total number of requests accounted as rejected in the dry run mode
+# TYPE nginxplus_limit_request_rejected_dry_run counter
+nginxplus_limit_request_rejected_dry_run{zone="one"} 1
+# HELP nginxplus_limit_connection_rejected_dry_run Total number of connections accounted as rejected in the dry run mode
+# TYPE nginxplus_limit_connection_rejected_dry_run counter
+nginxplus_limit_connection_rejected_dry_run{zone="addr"} 6
+# HELP nginxplus_stream_limit_connection_rejected Total number of connections that were rejected
+# TYPE nginxplus_stream_limit_connection_rejected counter
+nginxplus_stream_limit_connection_rejected{zone="tcp"} 9
+`,
+			metrics: []string{
+				"nginxplus_limit_request_passed", "nginxplus_limit_request_rejected",
+				"nginxplus_limit_request_delayed_dry_run", "nginxplus_limit_request_rejected_dry_run",
+				"nginxplus_limit_connection_rejected_dry_run", "nginxplus_stream_limit_connection_rejected",
+			},
+		},
+		{
+			name: "license",
+			responses: map[string]string{
+				"nginx":   `{"version":"1.27.2","build":"nginx-plus-r33","address":"10.0.0.1","generation":1,"load_timestamp":"2026-10-16T08:00:00.000Z","pid":10,"ppid":1}`,
+				"license": `{"active_till":1798761600,"eval":true,"reporting":{"healthy":false,"fails":3,"grace":86400}}`,
+			},
+			expected: `
+# HELP nginxplus_license_active_till License expiration date (expressed as Unix Epoch Time)
+# TYPE nginxplus_license_active_till gauge
+nginxplus_license_active_till 1.7987616e+09
+# HELP nginxplus_license_eval Indicates whether an evaluation license is being used
+# TYPE nginxplus_license_eval gauge
+nginxplus_license_eval 1
+# HELP nginxplus_license_reporting_fails Number of failed usage reporting attempts, reset each time the usage report is successfully sent
+# TYPE nginxplus_license_reporting_fails gauge
+nginxplus_license_reporting_fails 3
+# HELP nginxplus_license_reporting_grace Number of seconds before traffic processing is stopped after unsuccessful usage reporting attempts
+# TYPE nginxplus_license_reporting_grace gauge
+nginxplus_license_reporting_grace 86400
+# HELP nginxplus_license_reporting_healthy Indicates whether the usage reporting state is still considered healthy despite recent failed attempts
+# TYPE nginxplus_license_reporting_healthy gauge
+nginxplus_license_reporting_healthy 0
+`,
+			metrics: []string{
+				"nginxplus_license_active_till", "nginxplus_license_eval", "nginxplus_license_reporting_healthy",
+				"nginxplus_license_reporting_fails", "nginxplus_license_reporting_grace",
+			},
+		},
+		{
+			// An empty license, as reported by releases before R33, is not exported.
+			name: "empty license",
+			responses: map[string]string{
+				"nginx":   `{"version":"1.27.2","build":"nginx-plus-r33","address":"10.0.0.1","generation":1,"load_timestamp":"2026-10-16T08:00:00.000Z","pid":10,"ppid":1}`,
+				"license": `{"active_till":0,"eval":false,"reporting":{"healthy":true,"fails":0,"grace":0}}`,
+			},
+			metrics: []string{
+				"nginxplus_license_active_till", "nginxplus_license_eval", "nginxplus_license_reporting_healthy",
+				"nginxplus_license_reporting_fails", "nginxplus_license_reporting_grace",
+			},
+		},
+		{
+			// A missing license endpoint is not a failure of the endpoint.
+			name: "missing license endpoint",
+			responses: map[string]string{
+				"nginx":   `{"version":"1.27.2","build":"nginx-plus-r33","address":"10.0.0.1","generation":1,"load_timestamp":"2026-10-16T08:00:00.000Z","pid":10,"ppid":1}`,
+				"license": pathNotFoundResponse,
+			},
+			metrics: []string{
+				"nginxplus_license_active_till", "nginxplus_license_eval", "nginxplus_license_reporting_healthy",
+				"nginxplus_license_reporting_fails", "nginxplus_license_reporting_grace",
+			},
+			series: []seriesValue{{name: "nginxplus_api_endpoint_up", label: "endpoint", value: "license", want: 1}},
+		},
+		{
+			name: "health checks",
+			responses: map[string]string{
+				"http/upstreams": `{"backend":{"zone":"backend","peers":[` +
+					`{"id":0,"server":"10.0.0.1:80","state":"up","health_checks":{"checks":10,"fails":0,"unhealthy":0,"last_passed":true}},` +
+					`{"id":1,"server":"10.0.0.2:80","state":"unhealthy","health_checks":{"checks":10,"fails":4,"unhealthy":1,"last_passed":false}}]}}`,
+			},
+			expected: `
+# HELP nginxplus_upstream_server_health_checks_fails Failed health checks
+# TYPE nginxplus_upstream_server_health_checks_fails counter
+nginxplus_upstream_server_health_checks_fails{server="10.0.0.1:80",upstream="backend"} 0
+nginxplus_upstream_server_health_checks_fails{server="10.0.0.2:80",upstream="backend"} 4
+# HELP nginxplus_upstream_server_health_checks_last_passed Whether the last health check passed (1) or failed (0)
+# TYPE nginxplus_upstream_server_health_checks_last_passed gauge
+nginxplus_upstream_server_health_checks_last_passed{server="10.0.0.1:80",upstream="backend"} 1
+nginxplus_upstream_server_health_checks_last_passed{server="10.0.0.2:80",upstream="backend"} 0
+`,
+			metrics: []string{"nginxplus_upstream_server_health_checks_fails", "nginxplus_upstream_server_health_checks_last_passed"},
+		},
+		{
+			name: "location zones",
+			responses: map[string]string{
+				"http/location_zones": locationZones,
+			},
+			config: NginxPlusCollectorConfig{CollectLocationZones: true},
+			expected: `
+# HELP nginxplus_location_zone_discarded Requests completed without sending a response
+# TYPE nginxplus_location_zone_discarded counter
+nginxplus_location_zone_discarded{location_zone="api"} 2
+# HELP nginxplus_location_zone_requests Total client requests
+# TYPE nginxplus_location_zone_requests counter
+nginxplus_location_zone_requests{location_zone="api"} 12
+# HELP nginxplus_location_zone_responses Total responses sent to clients
+# TYPE nginxplus_location_zone_responses counter
+nginxplus_location_zone_responses{code="1xx",location_zone="api"} 0
+nginxplus_location_zone_responses{code="2xx",location_zone="api"} 9
+nginxplus_location_zone_responses{code="3xx",location_zone="api"} 1
+nginxplus_location_zone_responses{code="4xx",location_zone="api"} 1
+nginxplus_location_zone_responses{code="5xx",location_zone="api"} 1
+# HELP nginxplus_location_zone_sent Bytes sent to clients
+# TYPE nginxplus_location_zone_sent counter
+nginxplus_location_zone_sent{location_zone="api"} 4000
+`,
+			metrics: locationZoneMetrics,
+		},
+		{
+			name: "location zones skipped",
+			responses: map[string]string{
+				"http/location_zones": locationZones,
+			},
+			config:  NginxPlusCollectorConfig{CollectLocationZones: true, SkipEndpoints: []string{PlusEndpointLocationZones}},
+			metrics: locationZoneMetrics,
+		},
+		{
+			name: "location zones not enabled",
+			responses: map[string]string{
+				"http/location_zones": locationZones,
+			},
+			metrics: locationZoneMetrics,
+		},
+		{
+			name: "ssl",
+			responses: map[string]string{
+				"ssl":               `{"handshakes":10,"no_common_protocol":1,"verify_failures":{"no_cert":2}}`,
+				"http/server_zones": `{"site":{"ssl":{"handshakes":5,"no_common_cipher":3,"handshake_timeout":4,"verify_failures":{"expired_cert":6}}}}`,
+				"http/upstreams":    `{"backend":{"zone":"backend","peers":[{"server":"10.0.0.1:80","state":"up","ssl":{"peer_rejected_cert":7,"verify_failures":{"hostname_mismatch":8}}}]}}`,
+			},
+			expected: `
+# HELP nginxplus_ssl_no_common_protocol SSL handshakes failed because of no common protocol
+# TYPE nginxplus_ssl_no_common_protocol counter
+nginxplus_ssl_no_common_protocol 1
+# HELP nginxplus_server_zone_ssl_no_common_cipher SSL handshakes failed because of no shared cipher
+# TYPE nginxplus_server_zone_ssl_no_common_cipher counter
+nginxplus_server_zone_ssl_no_common_cipher{server_zone="site"} 3
+# HELP nginxplus_server_zone_ssl_handshake_timeout SSL handshakes failed because of a timeout
+# TYPE nginxplus_server_zone_ssl_handshake_timeout counter
+nginxplus_server_zone_ssl_handshake_timeout{server_zone="site"} 4
+# HELP nginxplus_upstream_server_ssl_peer_rejected_cert Failed SSL handshakes when the certificate presented by NGINX was rejected by the peer
+# TYPE nginxplus_upstream_server_ssl_peer_rejected_cert counter
+nginxplus_upstream_server_ssl_peer_rejected_cert{server="10.0.0.1:80",upstream="backend"} 7
+`,
+			metrics: []string{
+				"nginxplus_ssl_no_common_protocol", "nginxplus_server_zone_ssl_no_common_cipher",
+				"nginxplus_server_zone_ssl_handshake_timeout", "nginxplus_upstream_server_ssl_peer_rejected_cert",
+			},
+			series: []seriesValue{
+				{name: "nginxplus_ssl_verify_failures", label: "type", value: "no_cert", want: 2},
+				{name: "nginxplus_server_zone_ssl_verify_failures", label: "type", value: "expired_cert", want: 6},
+				{name: "nginxplus_upstream_server_ssl_verify_failures", label: "type", value: "hostname_mismatch", want: 8},
+			},
+		},
+		{
+			// The queue metrics are only reported for upstreams with a queue.
+			name: "upstream queue",
+			responses: map[string]string{
+				"http/upstreams": `{
+					"queued":{"zone":"queued","peers":[],"queue":{"size":2,"max_size":10,"overflows":5}},
+					"plain":{"zone":"plain","peers":[]}
+				}`,
+			},
+			expected: `
+# HELP nginxplus_upstream_queue_max_size Maximum number of requests that can be in the queue at the same time
+# TYPE nginxplus_upstream_queue_max_size gauge
+nginxplus_upstream_queue_max_size{upstream="queued"} 10
+# HELP nginxplus_upstream_queue_overflows Total number of requests rejected due to the queue overflow
+# TYPE nginxplus_upstream_queue_overflows counter
+nginxplus_upstream_queue_overflows{upstream="queued"} 5
+# HELP nginxplus_upstream_queue_size Current number of requests in the queue
+# TYPE nginxplus_upstream_queue_size gauge
+nginxplus_upstream_queue_size{upstream="queued"} 2
+`,
+			metrics: []string{"nginxplus_upstream_queue_size", "nginxplus_upstream_queue_max_size", "nginxplus_upstream_queue_overflows"},
+		},
+		{
+			name: "slabs",
+			responses: map[string]string{
+				"slabs": slabs,
+			},
+			config:   NginxPlusCollectorConfig{CollectSlabs: true},
+			expected: slabZone,
+			metrics: []string{
+				"nginxplus_slab_pages_used", "nginxplus_slab_pages_free", "nginxplus_slab_fails",
+				"nginxplus_slab_slot_used", "nginxplus_slab_slot_free", "nginxplus_slab_slot_reqs", "nginxplus_slab_slot_fails",
+			},
+		},
+		{
+			name: "slabs with slot detail",
+			responses: map[string]string{
+				"slabs": slabs,
+			},
+			config: NginxPlusCollectorConfig{CollectSlabs: true, SlabSlotDetail: true},
+			expected: slabZone + `
+# HELP nginxplus_slab_slot_fails Number of unsuccessful attempts to allocate memory of the slot size
+# TYPE nginxplus_slab_slot_fails counter
+nginxplus_slab_slot_fails{slot="16",zone="cache"} 5
+nginxplus_slab_slot_fails{slot="8",zone="cache"} 2
+# HELP nginxplus_slab_slot_used Number of used memory slots of the slot size
+# TYPE nginxplus_slab_slot_used gauge
+nginxplus_slab_slot_used{slot="16",zone="cache"} 4
+nginxplus_slab_slot_used{slot="8",zone="cache"} 1
+`,
+			metrics: []string{
+				"nginxplus_slab_pages_used", "nginxplus_slab_pages_free", "nginxplus_slab_fails",
+				"nginxplus_slab_slot_used", "nginxplus_slab_slot_fails",
+			},
+		},
+		{
+			name: "processes",
+			responses: map[string]string{
+				"processes": `{"respawned":3}`,
+			},
+			expected: `
+# HELP nginxplus_processes_respawned Total number of abnormally terminated and respawned child processes
+# TYPE nginxplus_processes_respawned counter
+nginxplus_processes_respawned 3
+`,
+			metrics: []string{"nginxplus_processes_respawned"},
+		},
+		{
+			// NGINX Plus without stream keyval zones responds with PathNotFound, which is not a failure.
+			// The slabs are only collected for the keyval zones, not exported on their own.
+			name: "keyval zones",
+			responses: map[string]string{
+				"http/keyvals":   `{"sessions":{"a":"1","b":"2"},"blocklist":{}}`,
+				"stream/keyvals": pathNotFoundResponse,
+				"slabs":          `{"sessions":{"pages":{"used":2,"free":30},"slots":{}}}`,
+			},
+			config: NginxPlusCollectorConfig{CollectKeyvals: true},
+			expected: `
+# HELP nginxplus_keyval_zone_entries Number of key-value pairs stored in the keyval zone
+# TYPE nginxplus_keyval_zone_entries gauge
+nginxplus_keyval_zone_entries{zone="blocklist"} 0
+nginxplus_keyval_zone_entries{zone="sessions"} 2
+# HELP nginxplus_keyval_zone_pages_free Number of free memory pages of the keyval zone
+# TYPE nginxplus_keyval_zone_pages_free gauge
+nginxplus_keyval_zone_pages_free{zone="sessions"} 30
+# HELP nginxplus_keyval_zone_pages_used Number of used memory pages of the keyval zone
+# TYPE nginxplus_keyval_zone_pages_used gauge
+nginxplus_keyval_zone_pages_used{zone="sessions"} 2
+`,
+			metrics: []string{
+				"nginxplus_keyval_zone_entries", "nginxplus_keyval_zone_pages_used", "nginxplus_keyval_zone_pages_free",
+				"nginxplus_stream_keyval_zone_entries", "nginxplus_slab_pages_used",
+			},
+			series: []seriesValue{{name: "nginxplus_api_endpoint_up", label: "endpoint", value: "stream/keyvals", want: 1}},
+		},
+		{
+			name: "generation and load timestamp",
+			responses: map[string]string{
+				"nginx": `{"version":"1.25.5","build":"nginx-plus-r32","generation":4,"load_timestamp":"2024-05-02T10:20:30.500Z"}`,
+			},
+			expected: generation + `
+# HELP nginxplus_nginx_load_timestamp Time of the last reload of configuration (expressed as Unix Epoch Time)
+# TYPE nginxplus_nginx_load_timestamp gauge
+nginxplus_nginx_load_timestamp 1.7146452305e+09
+`,
+			metrics: []string{"nginxplus_nginx_generation", "nginxplus_nginx_load_timestamp"},
+		},
+		{
+			name: "unparsable load timestamp",
+			responses: map[string]string{
+				"nginx": `{"version":"1.25.5","build":"nginx-plus-r32","generation":4,"load_timestamp":"yesterday"}`,
+			},
+			expected: generation,
+			metrics:  []string{"nginxplus_nginx_generation", "nginxplus_nginx_load_timestamp"},
+		},
+		{
+			name: "draining",
+			responses: map[string]string{
+				"http/upstreams": `{"backend":{"zone":"backend","peers":[
+					{"server":"10.0.0.1:80","state":"up","active":4},
+					{"server":"10.0.0.2:80","state":"draining","active":3},
+					{"server":"10.0.0.3:80","state":"draining","active":2},
+					{"server":"10.0.0.4:80","state":"unhealthy","active":1}
+				]}}`,
+			},
+			expected: `
+# HELP nginxplus_upstream_draining_active Active connections remaining on servers in the draining state
+# TYPE nginxplus_upstream_draining_active gauge
+nginxplus_upstream_draining_active{upstream="backend"} 5
+# HELP nginxplus_upstream_draining_peers Servers in the draining state
+# TYPE nginxplus_upstream_draining_peers gauge
+nginxplus_upstream_draining_peers{upstream="backend"} 2
+# HELP nginxplus_upstream_server_draining Whether the server is draining (1) or not (0)
+# TYPE nginxplus_upstream_server_draining gauge
+nginxplus_upstream_server_draining{server="10.0.0.1:80",upstream="backend"} 0
+nginxplus_upstream_server_draining{server="10.0.0.2:80",upstream="backend"} 1
+nginxplus_upstream_server_draining{server="10.0.0.3:80",upstream="backend"} 1
+nginxplus_upstream_server_draining{server="10.0.0.4:80",upstream="backend"} 0
+`,
+			metrics: []string{"nginxplus_upstream_draining_peers", "nginxplus_upstream_draining_active", "nginxplus_upstream_server_draining"},
+		},
+		{
+			name: "instance info",
+			responses: map[string]string{
+				"nginx": `{"version":"1.25.5","build":"nginx-plus-r32","address":"10.0.0.1","ppid":100,"load_timestamp":"2024-05-02T10:20:30.500Z"}`,
+			},
+			expected: instanceInfo,
+			metrics:  []string{"nginxplus_instance_info"},
+		},
+		{
+			// The series stays the same across reloads, which change the load timestamp but not the ppid of the master process.
+			name: "instance info after a reload",
+			responses: map[string]string{
+				"nginx": `{"version":"1.25.5","build":"nginx-plus-r32","address":"10.0.0.1","ppid":100,"load_timestamp":"2024-05-03T08:00:00.000Z"}`,
+			},
+			expected: instanceInfo,
+			metrics:  []string{"nginxplus_instance_info"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newPlusTestCollector(t, test.responses, test.config)
+			if err := testutil.CollectAndCompare(c, strings.NewReader(test.expected), test.metrics...); err != nil {
+				t.Error(err)
+			}
+			for _, s := range test.series {
+				if got, ok := gatherValue(t, c, s.name, s.label, s.value); !ok || got != s.want {
+					t.Errorf("%s{%s=%q} = %v (collected %v), want %v", s.name, s.label, s.value, got, ok, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNginxPlusCollectorSkipResponseCodes(t *testing.T) {
+	t.Parallel()
+
+	responses := map[string]string{
+		"http/server_zones":   `{"site":{"responses":{"2xx":5,"codes":{"200":5}}}}`,
+		"http/location_zones": `{"root":{"responses":{"2xx":6,"codes":{"200":6}}}}`,
+		"http/upstreams":      `{"backend":{"zone":"backend","peers":[{"server":"10.0.0.1:80","state":"up","responses":{"2xx":7,"codes":{"200":7}}}]}}`,
+	}
+	tests := []struct {
+		name              string
+		skipResponseCodes bool
+	}{
+		{name: "with response codes", skipResponseCodes: false},
+		{name: "without response codes", skipResponseCodes: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newPlusTestCollector(t, responses, NginxPlusCollectorConfig{SkipResponseCodes: test.skipResponseCodes, CollectLocationZones: true})
+
+			for _, metric := range []struct {
+				name      string
+				wantTotal float64
+			}{
+				{name: "nginxplus_server_zone_responses", wantTotal: 5},
+				{name: "nginxplus_location_zone_responses", wantTotal: 6},
+				{name: "nginxplus_upstream_server_responses", wantTotal: 7},
+			} {
+				if got, _ := gatherValue(t, c, metric.name, "code", "2xx"); got != metric.wantTotal {
+					t.Errorf("%s{code=\"2xx\"} = %v, want %v", metric.name, got, metric.wantTotal)
+				}
+				codes, collected := gatherValue(t, c, metric.name+"_codes", "code", "200")
+				if collected == test.skipResponseCodes {
+					t.Errorf("%s_codes{code=\"200\"} collected = %v, want %v", metric.name, collected, !test.skipResponseCodes)
+				}
+				if collected && codes != metric.wantTotal {
+					t.Errorf("%s_codes{code=\"200\"} = %v, want %v", metric.name, codes, metric.wantTotal)
+				}
+			}
+		})
+	}
+}
+
+func TestNginxPlusCollectorNamespace(t *testing.T) {
+	t.Parallel()
+
+	nginxClient, err := plusclient.NewNginxClient("http://127.0.0.1/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewNginxPlusCollector(nginxClient, WithNamespace("custom"), WithLogger(slog.New(slog.DiscardHandler)))
+
+	ch := make(chan *prometheus.Desc)
+	go func() {
+		c.Describe(ch)
+		close(ch)
+	}()
+	descs := 0
+	for desc := range ch {
+		descs++
+		if !strings.Contains(desc.String(), `fqName: "custom_`) {
+			t.Errorf("%v does not use the configured namespace", desc)
+		}
+	}
+	if descs == 0 {
+		t.Error("Describe() did not send any descriptions")
+	}
+}
+
+func TestNginxPlusCollectorPeerServiceLabels(t *testing.T) {
+	t.Parallel()
+
+	responses := map[string]string{
+		"http/upstreams":   `{"backend":{"zone":"backend","peers":[{"server":"10.0.0.1:80","service":"_http._tcp","name":"backend.example.com","state":"up"}]}}`,
+		"stream/upstreams": `{"tcp":{"zone":"tcp","peers":[{"server":"10.0.0.2:53","service":"_dns._udp","name":"dns.example.com","state":"up"}]}}`,
+	}
+	tests := []struct {
+		name              string
+		variableLabels    []string
+		wantUpstream      map[string]string
+		wantStream        map[string]string
+		peerServiceLabels bool
+	}{
+		{
+			name:         "disabled",
+			wantUpstream: map[string]string{"upstream": "backend", "server": "10.0.0.1:80"},
+			wantStream:   map[string]string{"upstream": "tcp", "server": "10.0.0.2:53"},
+		},
+		{
+			name:              "enabled",
+			peerServiceLabels: true,
+			wantUpstream:      map[string]string{"upstream": "backend", "server": "10.0.0.1:80", "service": "_http._tcp", "server_name": "backend.example.com"},
+			wantStream:        map[string]string{"upstream": "tcp", "server": "10.0.0.2:53", "service": "_dns._udp", "server_name": "dns.example.com"},
+		},
+		{
+			// The variable labels without values are filled with empty values before the service labels.
+			name:              "enabled with variable labels",
+			peerServiceLabels: true,
+			variableLabels:    []string{"team"},
+			wantUpstream:      map[string]string{"upstream": "backend", "server": "10.0.0.1:80", "team": "", "service": "_http._tcp", "server_name": "backend.example.com"},
+			wantStream:        map[string]string{"upstream": "tcp", "server": "10.0.0.2:53", "team": "", "service": "_dns._udp", "server_name": "dns.example.com"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := NewNginxPlusCollector(newPlusTestClient(t, responses),
+				WithLogger(slog.New(slog.DiscardHandler)),
+				WithPlusConfig(NginxPlusCollectorConfig{PeerServiceLabels: test.peerServiceLabels}),
+				WithVariableLabelNames(VariableLabelNames{
+					UpstreamServerVariableLabelNames:       test.variableLabels,
+					StreamUpstreamServerVariableLabelNames: test.variableLabels,
+				}))
+
+			registry := prometheus.NewPedanticRegistry()
+			registry.MustRegister(c)
+			families, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]map[string]string{
+				"nginxplus_upstream_server_state":        test.wantUpstream,
+				"nginxplus_stream_upstream_server_state": test.wantStream,
+			}
+			for _, family := range families {
+				wantLabels, ok := want[family.GetName()]
+				if !ok {
+					continue
+				}
+				delete(want, family.GetName())
+				got := make(map[string]string)
+				for _, pair := range family.GetMetric()[0].GetLabel() {
+					got[pair.GetName()] = pair.GetValue()
+				}
+				if !maps.Equal(got, wantLabels) {
+					t.Errorf("%s labels = %v, want %v", family.GetName(), got, wantLabels)
+				}
+			}
+			for name := range want {
+				t.Errorf("%s was not collected", name)
+			}
+		})
+	}
+}
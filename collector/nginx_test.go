@@ -0,0 +1,299 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nginx/nginx-prometheus-exporter/client"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type fakeStubStatsClient struct {
+	stats *client.StubStats
+	err   error
+}
+
+func (c fakeStubStatsClient) GetStubStats() (*client.StubStats, error) {
+	return c.stats, c.err
+}
+
+func TestNginxCollectorStubStats(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		client fakeStubStatsClient
+		name   string
+		want   string
+	}{
+		{
+			name: "stats",
+			client: fakeStubStatsClient{stats: &client.StubStats{
+				Connections: client.StubConnections{Active: 3, Accepted: 10, Handled: 10, Reading: 1, Writing: 1, Waiting: 1},
+				Requests:    42,
+			}},
+			want: `
+# HELP nginx_up Status of the last metric scrape
+# TYPE nginx_up gauge
+nginx_up 1
+# HELP nginx_http_requests_total Total http requests
+# TYPE nginx_http_requests_total counter
+nginx_http_requests_total 42
+`,
+		},
+		{
+			name:   "error",
+			client: fakeStubStatsClient{err: errors.New("connection refused")},
+			want: `
+# HELP nginx_up Status of the last metric scrape
+# TYPE nginx_up gauge
+nginx_up 0
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := NewNginxCollector(tt.client, WithLogger(slog.New(slog.DiscardHandler)), WithConfigPath(filepath.Join(t.TempDir(), "nginx.conf")))
+			if err := testutil.CollectAndCompare(c, strings.NewReader(tt.want), "nginx_up", "nginx_http_requests_total"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestNginxCollectorHealthCheckFileLabel(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile := func(name string, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("nginx.conf", "http { include conf.d/*.conf; }\n")
+	writeFile("conf.d/a.conf", "server { location / { proxy_pass http://10.0.0.1:8080; } }\n")
+	if err := os.Symlink(filepath.Join(dir, "conf.d", "a.conf"), filepath.Join(dir, "conf.d", "b.conf")); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewNginxCollector(fakeStubStatsClient{stats: &client.StubStats{}},
+		WithLogger(slog.New(slog.DiscardHandler)),
+		WithConfigPath(filepath.Join(dir, "nginx.conf")),
+		WithConfigLabelMode(ConfigLabelModeRelative),
+		WithHealthChecks(HealthCheckConfig{Dial: func(context.Context, string, string) (net.Conn, error) {
+			return nil, errors.New("connection refused")
+		}}),
+	)
+
+	// The symlinked b.conf resolves to the same file label as a.conf and is exported once.
+	expected := `
+# HELP nginx_upstream_health_check_status Proxy Target의 TCP 연결 상태(1: 성공, 0: 실패)
+# TYPE nginx_upstream_health_check_status gauge
+nginx_upstream_health_check_status{file="conf.d/a.conf",target="10.0.0.1:8080"} 0
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "nginx_upstream_health_check_status"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNginxCollectorHealthCheckRemovedTargets(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "nginx.conf")
+	writeConfig := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("http { server { location /a { proxy_pass http://10.0.0.1:8080; } location /b { proxy_pass http://10.0.0.2:8080; } } }\n")
+
+	c := NewNginxCollector(fakeStubStatsClient{stats: &client.StubStats{}},
+		WithLogger(slog.New(slog.DiscardHandler)),
+		WithConfigPath(configPath),
+		WithConfigLabelMode(ConfigLabelModeBasename),
+		WithHealthChecks(HealthCheckConfig{Dial: func(context.Context, string, string) (net.Conn, error) {
+			return nil, errors.New("connection refused")
+		}}),
+	)
+	if n := testutil.CollectAndCount(c, "nginx_upstream_health_check_status"); n != 2 {
+		t.Fatalf("collected %d health check series, expected 2", n)
+	}
+
+	// The series of the removed target are no longer exported, so that Prometheus marks them stale.
+	writeConfig("http { server { location /a { proxy_pass http://10.0.0.1:8080; } } }\n")
+	expected := `
+# HELP nginx_upstream_health_check_status Proxy Target의 TCP 연결 상태(1: 성공, 0: 실패)
+# TYPE nginx_upstream_health_check_status gauge
+nginx_upstream_health_check_status{file="nginx.conf",target="10.0.0.1:8080"} 0
+# HELP nginx_upstream_health_check_targets_removed_total Number of health-checked proxy targets that disappeared from the config and are no longer exported
+# TYPE nginx_upstream_health_check_targets_removed_total counter
+nginx_upstream_health_check_targets_removed_total 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"nginx_upstream_health_check_status", "nginx_upstream_health_check_targets_removed_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNginxCollectorHealthCheckMaxTargets(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "conf.d"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"nginx.conf":    "http { include conf.d/*.conf; server { location / { proxy_pass http://10.0.0.1:8080; } } }\n",
+		"conf.d/a.conf": "server { location /a { proxy_pass http://10.0.0.2:8080; } location /b { proxy_pass http://10.0.0.1:8080; } }\n",
+		"conf.d/b.conf": "server { location / { proxy_pass http://10.0.0.3:8080; } location /c { proxy_pass http://10.0.0.4:8080; } }\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+	}{
+		{name: "during the scrape"},
+		{name: "background", interval: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var mutex sync.Mutex
+			dialed := make(map[string]bool)
+			c := NewNginxCollector(fakeStubStatsClient{stats: &client.StubStats{}},
+				WithLogger(slog.New(slog.DiscardHandler)),
+				WithConfigPath(filepath.Join(dir, "nginx.conf")),
+				WithConfigLabelMode(ConfigLabelModeBasename),
+				WithHealthChecks(HealthCheckConfig{MaxTargets: 3, Interval: tt.interval, Dial: func(_ context.Context, _ string, address string) (net.Conn, error) {
+					mutex.Lock()
+					defer mutex.Unlock()
+					dialed[address] = true
+					return nil, errors.New("connection refused")
+				}}),
+			)
+			t.Cleanup(func() { _ = c.Close() })
+
+			// The background checker only tracks targets during the first scrape and has results after checking them.
+			testutil.CollectAndCount(c, "nginx_upstream_health_check_status")
+			if c.healthChecker != nil {
+				c.healthChecker.checkAll()
+			}
+
+			// The first three targets in the order of the config with includes expanded are checked, the others overflow.
+			expected := `
+# HELP nginx_upstream_health_check_status Proxy Target의 TCP 연결 상태(1: 성공, 0: 실패)
+# TYPE nginx_upstream_health_check_status gauge
+nginx_upstream_health_check_status{file="a.conf",target="10.0.0.1:8080"} 0
+nginx_upstream_health_check_status{file="a.conf",target="10.0.0.2:8080"} 0
+nginx_upstream_health_check_status{file="b.conf",target="10.0.0.3:8080"} 0
+# HELP nginx_upstream_health_check_targets_overflow Number of proxy targets that were not health-checked because the config has more targets than the maximum
+# TYPE nginx_upstream_health_check_targets_overflow gauge
+nginx_upstream_health_check_targets_overflow 2
+`
+			if err := testutil.CollectAndCompare(c, strings.NewReader(expected),
+				"nginx_upstream_health_check_status", "nginx_upstream_health_check_targets_overflow"); err != nil {
+				t.Error(err)
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			if len(dialed) != 3 {
+				t.Errorf("dialed %v, want the three checked targets only", dialed)
+			}
+		})
+	}
+}
+
+func TestNginxCollectorHealthCheckMaxTargetsOverflowNotRemoved(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "nginx.conf")
+	writeConfig := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("http { server { location /a { proxy_pass http://10.0.0.1:8080; } } }\n")
+
+	c := NewNginxCollector(fakeStubStatsClient{stats: &client.StubStats{}},
+		WithLogger(slog.New(slog.DiscardHandler)),
+		WithConfigPath(configPath),
+		WithConfigLabelMode(ConfigLabelModeBasename),
+		WithHealthChecks(HealthCheckConfig{MaxTargets: 1, Dial: func(context.Context, string, string) (net.Conn, error) {
+			return nil, errors.New("connection refused")
+		}}),
+	)
+	if n := testutil.CollectAndCount(c, "nginx_upstream_health_check_status"); n != 1 {
+		t.Fatalf("collected %d health check series, expected 1", n)
+	}
+
+	// A target pushed beyond the maximum is still in the config, so it is not counted as removed.
+	writeConfig("http { server { location /b { proxy_pass http://10.0.0.2:8080; } location /a { proxy_pass http://10.0.0.1:8080; } } }\n")
+	expected := `
+# HELP nginx_upstream_health_check_status Proxy Target의 TCP 연결 상태(1: 성공, 0: 실패)
+# TYPE nginx_upstream_health_check_status gauge
+nginx_upstream_health_check_status{file="nginx.conf",target="10.0.0.2:8080"} 0
+# HELP nginx_upstream_health_check_targets_removed_total Number of health-checked proxy targets that disappeared from the config and are no longer exported
+# TYPE nginx_upstream_health_check_targets_removed_total counter
+nginx_upstream_health_check_targets_removed_total 0
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"nginx_upstream_health_check_status", "nginx_upstream_health_check_targets_removed_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNginxCollectorConfigRefreshInterval(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "nginx.conf")
+	writeConfig := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("http { server { location /a { proxy_pass http://10.0.0.1:8080; } } }\n")
+
+	c := NewNginxCollector(fakeStubStatsClient{stats: &client.StubStats{}},
+		WithLogger(slog.New(slog.DiscardHandler)),
+		WithConfigPath(configPath),
+		WithConfigRefreshInterval(time.Hour),
+		WithHealthChecks(HealthCheckConfig{Dial: func(context.Context, string, string) (net.Conn, error) {
+			return nil, errors.New("connection refused")
+		}}),
+	)
+	t.Cleanup(func() { _ = c.Close() })
+	if n := testutil.CollectAndCount(c, "nginx_upstream_health_check_status"); n != 1 {
+		t.Fatalf("collected %d health check series, expected 1", n)
+	}
+
+	// Scrapes serve the config of the last refresh until the next one.
+	writeConfig("http { server { location /a { proxy_pass http://10.0.0.1:8080; } location /b { proxy_pass http://10.0.0.2:8080; } } }\n")
+	if n := testutil.CollectAndCount(c, "nginx_upstream_health_check_status"); n != 1 {
+		t.Errorf("collected %d health check series before the refresh, expected 1", n)
+	}
+	c.refreshConfigSnapshot()
+	if n := testutil.CollectAndCount(c, "nginx_upstream_health_check_status"); n != 2 {
+		t.Errorf("collected %d health check series after the refresh, expected 2", n)
+	}
+}
@@ -0,0 +1,225 @@
+package collector
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Default namespaces (metric name prefixes) of the collectors.
+const (
+	DefaultNamespace     = "nginx"
+	DefaultPlusNamespace = "nginxplus"
+)
+
+// DefaultConfigPath is the default path of the NGINX configuration file.
+const DefaultConfigPath = "/etc/nginx/nginx.conf"
+
+type options struct {
+	logger                *slog.Logger
+	constLabels           map[string]string
+	configSourceClient    *http.Client
+	namespace             string
+	configPath            string
+	binaryPath            string
+	cgroupRoot            string
+	configLabelMode       string
+	configSource          string
+	healthStateFile       string
+	configRefreshInterval time.Duration
+	configSourceTimeout   time.Duration
+	probeMaxIdleConns     int
+	healthCheck           HealthCheckConfig
+	variableLabelNames    VariableLabelNames
+	plusConfig            NginxPlusCollectorConfig
+	configStrict          bool
+	upstreamTCPStates     bool
+	conntrack             bool
+	listenBacklog         bool
+	sampleTimestamps      bool
+	securityAudit         bool
+}
+
+// Option configures NewNginxCollector and NewNginxPlusCollector. Options that do not
+// apply to a collector are ignored by it.
+type Option func(*options)
+
+func newOptions(namespace string, opts []Option) options {
+	o := options{
+		logger:          slog.Default(),
+		namespace:       namespace,
+		configPath:      DefaultConfigPath,
+		configLabelMode: ConfigLabelModeAbsolute,
+		cgroupRoot:      DefaultCgroupRoot,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithNamespace sets the namespace (metric name prefix) of the metrics.
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithLogger sets the logger. slog.Default() is used by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithConstLabels sets labels added to every metric.
+func WithConstLabels(constLabels map[string]string) Option {
+	return func(o *options) {
+		o.constLabels = constLabels
+	}
+}
+
+// WithConfigPath sets the path of the NGINX configuration file the config metrics and
+// upstream health checks are derived from. DefaultConfigPath is used by default.
+func WithConfigPath(path string) Option {
+	return func(o *options) {
+		o.configPath = path
+	}
+}
+
+// WithConfigLabelMode sets how the file label of config and upstream health metrics is rendered. One of
+// ConfigLabelModeAbsolute (the default), ConfigLabelModeRelative or ConfigLabelModeBasename.
+func WithConfigLabelMode(mode string) Option {
+	return func(o *options) {
+		o.configLabelMode = mode
+	}
+}
+
+// WithConfigStrict reports config constructs the collector cannot model as config warnings.
+func WithConfigStrict(strict bool) Option {
+	return func(o *options) {
+		o.configStrict = strict
+	}
+}
+
+// WithConfigSource reads the NGINX configuration from a remote source: an http(s) URL
+// serving the output of nginx -T, fetched with httpClient within timeout, or the path of
+// a tarball of the directory of the configuration file. A timeout of 0 disables it.
+// http.DefaultClient is used when httpClient is nil.
+func WithConfigSource(source string, httpClient *http.Client, timeout time.Duration) Option {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return func(o *options) {
+		o.configSource = source
+		o.configSourceClient = httpClient
+		o.configSourceTimeout = timeout
+	}
+}
+
+// WithBinaryPath sets the path of the NGINX binary used to export build information.
+func WithBinaryPath(path string) Option {
+	return func(o *options) {
+		o.binaryPath = path
+	}
+}
+
+// WithCgroupRoot sets the mount point of the cgroup filesystem the cgroup metrics are
+// read from. DefaultCgroupRoot is used by default.
+func WithCgroupRoot(path string) Option {
+	return func(o *options) {
+		o.cgroupRoot = path
+	}
+}
+
+// WithHealthChecks configures the upstream health checks.
+func WithHealthChecks(config HealthCheckConfig) Option {
+	return func(o *options) {
+		o.healthCheck = config
+	}
+}
+
+// WithHealthStateFile persists the last upstream health check results and their
+// transition counters to a state file, and restores them on startup.
+func WithHealthStateFile(path string) Option {
+	return func(o *options) {
+		o.healthStateFile = path
+	}
+}
+
+// WithUpstreamTCPStates counts the TCP sockets of the host toward the proxy targets of
+// the local NGINX config by state. It reads /proc/net/tcp and /proc/net/tcp6 and is
+// only supported on Linux.
+func WithUpstreamTCPStates(enabled bool) Option {
+	return func(o *options) {
+		o.upstreamTCPStates = enabled
+	}
+}
+
+// WithConntrack exports the size and limit of the netfilter connection tracking table
+// of the host and its entries toward the proxy targets of the local NGINX config. It
+// reads /proc/sys/net/netfilter and /proc/net/nf_conntrack and is only supported on Linux.
+func WithConntrack(enabled bool) Option {
+	return func(o *options) {
+		o.conntrack = enabled
+	}
+}
+
+// WithListenBacklog exports the accept queue length and backlog of the sockets of every
+// listen directive of the local NGINX config, read with NETLINK_INET_DIAG, and the
+// listen overflows of the host. It is only supported on Linux.
+func WithListenBacklog(enabled bool) Option {
+	return func(o *options) {
+		o.listenBacklog = enabled
+	}
+}
+
+// WithSecurityAudit exports risky settings of the NGINX config, such as server_tokens
+// on, ssl_protocols allowing TLSv1 or TLSv1.1, TLS servers without HSTS and autoindex
+// on, as config security findings.
+func WithSecurityAudit(enabled bool) Option {
+	return func(o *options) {
+		o.securityAudit = enabled
+	}
+}
+
+// WithSampleTimestamps attaches the time a result was collected in the background, e.g.
+// of a probe or a background health check, to its samples. Without it, Prometheus
+// uses the scrape time and staleness handling applies as usual.
+func WithSampleTimestamps(enabled bool) Option {
+	return func(o *options) {
+		o.sampleTimestamps = enabled
+	}
+}
+
+// WithVariableLabelNames sets the names of the variable labels of the NGINX Plus metrics.
+func WithVariableLabelNames(names VariableLabelNames) Option {
+	return func(o *options) {
+		o.variableLabelNames = names
+	}
+}
+
+// WithPlusConfig sets the configuration of the NGINX Plus collector.
+func WithPlusConfig(config NginxPlusCollectorConfig) Option {
+	return func(o *options) {
+		o.plusConfig = config
+	}
+}
+
+// WithProbeKeepAlive keeps up to maxIdle idle connections per host open between the
+// background HTTP probes and reuses them, instead of a new TCP and TLS handshake for
+// every probe. Keep-alives are disabled when 0.
+func WithProbeKeepAlive(maxIdle int) Option {
+	return func(o *options) {
+		o.probeMaxIdleConns = maxIdle
+	}
+}
+
+// WithConfigRefreshInterval reads and parses the NGINX config files in the background at
+// this interval instead of during every scrape, and serves the config metrics and health
+// check targets of the last read. Zero reads them during every scrape.
+func WithConfigRefreshInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.configRefreshInterval = interval
+	}
+}
@@ -0,0 +1,105 @@
+package collector
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+)
+
+// Issues reported by the config and key file permission audit.
+const (
+	permissionIssueWorldReadable = "world_readable"
+	permissionIssueWorldWritable = "world_writable"
+	permissionIssueGroupWritable = "group_writable"
+	permissionIssueOwnerNotRoot  = "owner_not_root"
+)
+
+// keyDirectives are the directives referencing TLS private key files.
+var keyDirectives = map[string]bool{
+	"ssl_certificate_key":       true,
+	"proxy_ssl_certificate_key": true,
+	"grpc_ssl_certificate_key":  true,
+	"uwsgi_ssl_certificate_key": true,
+}
+
+// permissionIssues : 파일 권한을 검사하여 발견된 문제 목록을 반환한다.
+// 개인키 파일은 다른 사용자가 읽을 수 있는 경우도 문제로 간주한다.
+func permissionIssues(info fs.FileInfo, privateKey bool) []string {
+	var issues []string
+	perm := info.Mode().Perm()
+
+	if privateKey && perm&0o004 != 0 {
+		issues = append(issues, permissionIssueWorldReadable)
+	}
+	if perm&0o002 != 0 {
+		issues = append(issues, permissionIssueWorldWritable)
+	}
+	if perm&0o020 != 0 {
+		issues = append(issues, permissionIssueGroupWritable)
+	}
+	if uid, ok := fileOwner(info); ok && uid != 0 {
+		issues = append(issues, permissionIssueOwnerNotRoot)
+	}
+
+	return issues
+}
+
+// privateKeyFiles returns the TLS private key files referenced in the config.
+func privateKeyFiles(cfg *nginxconf.Config) []string {
+	var keys []string
+	seen := make(map[string]bool)
+
+	cfg.Walk(func(d *nginxconf.Directive, _ []*nginxconf.Directive) {
+		if !keyDirectives[d.Name] || len(d.Args) == 0 {
+			return
+		}
+		key := d.Args[0]
+		// 변수, engine:, data: 형태의 키는 파일이 아니므로 제외한다.
+		if strings.Contains(key, "$") || strings.HasPrefix(key, "engine:") || strings.HasPrefix(key, "data:") {
+			return
+		}
+		if !filepath.IsAbs(key) {
+			key = filepath.Join(cfg.Root(), key)
+		}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	})
+
+	return keys
+}
+
+type permissionKey struct {
+	file  string
+	issue string
+}
+
+// auditPermissions returns the permission issues of the config files and private keys,
+// keyed by the rendered file label.
+func (c *NginxCollector) auditPermissions(cfg *nginxconf.Config) map[permissionKey]bool {
+	findings := make(map[permissionKey]bool)
+
+	audit := func(path string, privateKey bool) {
+		info, err := os.Stat(path)
+		if err != nil {
+			c.logger.Debug("skip permission audit", "file", path, "error", err.Error())
+			return
+		}
+		for _, issue := range permissionIssues(info, privateKey) {
+			findings[permissionKey{file: c.fileLabel(path), issue: issue}] = true
+		}
+	}
+
+	for _, f := range cfg.Files {
+		audit(f, false)
+	}
+	for _, key := range privateKeyFiles(cfg) {
+		audit(key, true)
+	}
+
+	return findings
+}
@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"io/fs"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeFileInfo struct {
+	mode fs.FileMode
+}
+
+func (f fakeFileInfo) Name() string       { return "fake" }
+func (f fakeFileInfo) Size() int64        { return 0 }
+func (f fakeFileInfo) Mode() fs.FileMode  { return f.mode }
+func (f fakeFileInfo) ModTime() time.Time { return time.Time{} }
+func (f fakeFileInfo) IsDir() bool        { return false }
+func (f fakeFileInfo) Sys() any           { return nil }
+
+func TestPermissionIssues(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		want       []string
+		mode       fs.FileMode
+		privateKey bool
+	}{
+		{
+			name: "secure config file",
+			mode: 0o644,
+		},
+		{
+			name: "group writable config file",
+			mode: 0o664,
+			want: []string{permissionIssueGroupWritable},
+		},
+		{
+			name:       "secure private key",
+			mode:       0o600,
+			privateKey: true,
+		},
+		{
+			name:       "world readable private key",
+			mode:       0o644,
+			privateKey: true,
+			want:       []string{permissionIssueWorldReadable},
+		},
+		{
+			name:       "world writable private key",
+			mode:       0o666,
+			privateKey: true,
+			want:       []string{permissionIssueWorldReadable, permissionIssueWorldWritable, permissionIssueGroupWritable},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := permissionIssues(fakeFileInfo{mode: tt.mode}, tt.privateKey); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("permissionIssues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
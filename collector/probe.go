@@ -0,0 +1,234 @@
+package collector
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+)
+
+// Defaults of the synthetic probes sent through NGINX.
+const (
+	DefaultProbeInterval = time.Minute
+	DefaultProbeTimeout  = 5 * time.Second
+)
+
+// probeConcurrency is the maximum number of probes sent at the same time.
+const probeConcurrency = 8
+
+// maxProbeBodySize is the maximum number of bytes of a response body read by a probe,
+// so that the duration includes the transfer of small responses only.
+const maxProbeBodySize = 64 * 1024
+
+// httpProbe is an HTTP request sent through NGINX. host is sent as the Host header and
+// as the TLS server name, so that the request is routed to the intended server block.
+type httpProbe struct {
+	headers        map[string]string
+	method         string
+	url            string
+	host           string
+	expectedStatus int
+}
+
+// probeResult is the result of an httpProbe started at start. status is 0 when no
+// response was received.
+type probeResult struct {
+	start    time.Time
+	duration float64
+	status   int
+	success  bool
+}
+
+// probeClients holds the HTTP clients a prober sends its probes with, one per host
+// since the host is sent as TLS server name. With keep-alives, the client of a host
+// keeps up to maxIdle idle connections open between probes, so that a probe does not
+// need a new TCP and TLS handshake every interval.
+type probeClients struct {
+	transport *http.Transport
+	clients   map[string]*probeClient
+	maxIdle   int
+	mutex     sync.Mutex
+}
+
+type probeClient struct {
+	client *http.Client
+	used   bool
+}
+
+// newProbeClients creates the probe clients of transport or, when nil, of a clone of
+// http.DefaultTransport. Keep-alives are disabled when maxIdle is 0.
+func newProbeClients(transport *http.Transport, maxIdle int) (*probeClients, error) {
+	t, err := probeTransport(transport)
+	if err != nil {
+		return nil, err
+	}
+	return &probeClients{transport: t, clients: make(map[string]*probeClient), maxIdle: maxIdle}, nil
+}
+
+// client : host 의 client 를 반환한다. keep-alive 를 사용하지 않으면 매번 새 client 를 만든다.
+func (c *probeClients) client(host string) *http.Client {
+	if c.maxIdle <= 0 {
+		return c.newClient(host)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	pc, ok := c.clients[host]
+	if !ok {
+		pc = &probeClient{client: c.newClient(host)}
+		c.clients[host] = pc
+	}
+	pc.used = true
+	return pc.client
+}
+
+// newClient : server_name 별로 SNI 가 달라야 하므로 host 별로 transport 를 복제한다. redirect 는 따르지 않는다.
+func (c *probeClients) newClient(host string) *http.Client {
+	t := c.transport.Clone()
+	if c.maxIdle > 0 {
+		t.MaxIdleConnsPerHost = c.maxIdle
+	} else {
+		t.DisableKeepAlives = true
+	}
+	if host != "" {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		t.TLSClientConfig.ServerName = host
+	}
+	return &http.Client{
+		Transport: t,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// sweep : 지난 sweep 이후 사용하지 않은 host(설정에서 사라진 server_name 등)의 idle 연결을 닫고 client 를 버린다.
+func (c *probeClients) sweep() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for host, pc := range c.clients {
+		if !pc.used {
+			pc.client.CloseIdleConnections()
+			delete(c.clients, host)
+			continue
+		}
+		pc.used = false
+	}
+}
+
+// close : 모든 idle 연결을 닫는다.
+func (c *probeClients) close() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for host, pc := range c.clients {
+		pc.client.CloseIdleConnections()
+		delete(c.clients, host)
+	}
+}
+
+// runProbe : probe 요청을 보내고 응답 status 와 소요 시간을 반환한다. redirect 는 따르지 않는다.
+// expectedStatus 가 0 이면 500 미만의 응답을 성공으로 본다.
+func runProbe(ctx context.Context, clients *probeClients, p httpProbe, timeout time.Duration) (probeResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	method := p.method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.url, nil)
+	if err != nil {
+		return probeResult{}, fmt.Errorf("invalid probe request: %w", err)
+	}
+	for name, value := range p.headers {
+		req.Header.Set(name, value)
+	}
+
+	if p.host != "" {
+		req.Host = p.host
+	}
+
+	start := time.Now()
+	resp, err := clients.client(p.host).Do(req)
+	if err != nil {
+		return probeResult{start: start, duration: time.Since(start).Seconds()}, fmt.Errorf("probe request failed: %w", err)
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxProbeBodySize))
+	_ = resp.Body.Close()
+
+	result := probeResult{start: start, duration: time.Since(start).Seconds(), status: resp.StatusCode}
+	if p.expectedStatus != 0 {
+		result.success = resp.StatusCode == p.expectedStatus
+	} else {
+		result.success = resp.StatusCode < http.StatusInternalServerError
+	}
+	return result, nil
+}
+
+// parseProbeURL : NGINX listener 의 base URL 을 검사한다.
+func parseProbeURL(rawURL string) (*url.URL, error) {
+	base, err := url.Parse(rawURL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid probe URL %q: must be an http(s) URL of the NGINX listener", rawURL)
+	}
+	return base, nil
+}
+
+// probeTransport returns transport or, when nil, a clone of http.DefaultTransport.
+func probeTransport(transport *http.Transport) (*http.Transport, error) {
+	if transport != nil {
+		return transport, nil
+	}
+	t, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil, errors.New("http.DefaultTransport is not an *http.Transport")
+	}
+	return t.Clone(), nil
+}
+
+// runProbeLoop : ctx 가 취소될 때까지 interval 마다 probe 를 실행한다. 시작하자마자 한 번 실행한다.
+func runProbeLoop(ctx context.Context, interval time.Duration, probe func(context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		probe(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeHostNames returns the names of a server_name directive that can be sent as Host
+// header. Wildcard, regular expression and catch-all names such as "_" are skipped.
+func probeHostNames(d *nginxconf.Directive) []string {
+	var names []string
+	for _, name := range d.Args {
+		// ".example.com" 은 example.com 과 그 하위 도메인을 뜻한다.
+		name = strings.ToLower(strings.TrimPrefix(name, "."))
+		if name == "" || name == "_" || strings.ContainsAny(name, "*~$") {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func boolToFloat64(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
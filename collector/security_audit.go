@@ -0,0 +1,197 @@
+package collector
+
+import (
+	"strings"
+
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+)
+
+// Rules of the config security audit.
+const (
+	SecurityRuleServerTokens = "server_tokens"
+	SecurityRuleSSLProtocols = "ssl_protocols"
+	SecurityRuleMissingHSTS  = "missing_hsts"
+	SecurityRuleAutoindex    = "autoindex"
+)
+
+// Severities of config security findings, from lowest to highest.
+const (
+	SecuritySeverityLow    = "low"
+	SecuritySeverityMedium = "medium"
+	SecuritySeverityHigh   = "high"
+)
+
+// SecuritySeverities are the severities of config security findings, from lowest to
+// highest.
+var SecuritySeverities = []string{SecuritySeverityLow, SecuritySeverityMedium, SecuritySeverityHigh}
+
+// securityRuleSeverities are the severities of the findings of every rule.
+var securityRuleSeverities = map[string]string{
+	SecurityRuleServerTokens: SecuritySeverityLow,
+	SecurityRuleSSLProtocols: SecuritySeverityHigh,
+	SecurityRuleMissingHSTS:  SecuritySeverityMedium,
+	SecurityRuleAutoindex:    SecuritySeverityMedium,
+}
+
+// legacySSLProtocols are the TLS versions reported by the ssl_protocols rule.
+var legacySSLProtocols = map[string]bool{
+	"SSLv2":   true,
+	"SSLv3":   true,
+	"TLSv1":   true,
+	"TLSv1.1": true,
+}
+
+// SecurityFinding is a risky setting found in the NGINX config by the security audit.
+type SecurityFinding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	File     string `json:"file"`
+	Context  string `json:"context"`
+	Message  string `json:"message"`
+	Line     int    `json:"line"`
+}
+
+// SecuritySeverityRank returns the position of a severity in SecuritySeverities, or -1
+// for an unknown severity.
+func SecuritySeverityRank(severity string) int {
+	for i, s := range SecuritySeverities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// SecurityFindings audits the config for risky settings and returns the findings in
+// the order they appear: server_tokens on, ssl_protocols allowing TLSv1 or TLSv1.1,
+// TLS servers without a Strict-Transport-Security header and autoindex on.
+func SecurityFindings(cfg *nginxconf.Config) []SecurityFinding {
+	var findings []SecurityFinding
+	report := func(rule string, d *nginxconf.Directive, parents []*nginxconf.Directive, message string) {
+		findings = append(findings, SecurityFinding{
+			Rule:     rule,
+			Severity: securityRuleSeverities[rule],
+			File:     d.File,
+			Line:     d.Line,
+			Context:  directiveContext(parents),
+			Message:  message,
+		})
+	}
+
+	cfg.Walk(func(d *nginxconf.Directive, parents []*nginxconf.Directive) {
+		switch d.Name {
+		case "server_tokens":
+			if len(d.Args) == 1 && d.Args[0] == "on" {
+				report(SecurityRuleServerTokens, d, parents, "server_tokens on discloses the NGINX version")
+			}
+		case "autoindex":
+			if len(d.Args) == 1 && d.Args[0] == "on" {
+				report(SecurityRuleAutoindex, d, parents, "autoindex on lists the contents of directories")
+			}
+		case "ssl_protocols":
+			var legacy []string
+			for _, protocol := range d.Args {
+				if legacySSLProtocols[protocol] {
+					legacy = append(legacy, protocol)
+				}
+			}
+			if len(legacy) > 0 {
+				report(SecurityRuleSSLProtocols, d, parents, "ssl_protocols allows "+strings.Join(legacy, ", "))
+			}
+		case "server":
+			// stream 블록의 server 에는 HTTP 헤더가 없다.
+			if len(parents) == 0 || parents[len(parents)-1].Name != "http" || !d.IsBlock() {
+				return
+			}
+			http := parents[len(parents)-1]
+			server := append(parents[:len(parents):len(parents)], d)
+			if findDirective(d.Block, "server_tokens") == nil && findDirective(http.Block, "server_tokens") == nil {
+				report(SecurityRuleServerTokens, d, server, "server_tokens is not set and defaults to on, which discloses the NGINX version")
+			}
+			if isTLSServer(d) {
+				for _, gap := range hstsGaps(d, parents, hasHSTS(http.Block, false)) {
+					report(SecurityRuleMissingHSTS, gap[len(gap)-1], gap, "TLS responses do not set Strict-Transport-Security")
+				}
+			}
+		}
+	})
+
+	return findings
+}
+
+type securityFindingKey struct {
+	rule string
+	file string
+}
+
+// securityFindings : SecurityFindings 의 결과를 규칙, 파일별로 센다.
+func (c *NginxCollector) securityFindings(cfg *nginxconf.Config) map[securityFindingKey]float64 {
+	findings := make(map[securityFindingKey]float64)
+	for _, f := range SecurityFindings(cfg) {
+		findings[securityFindingKey{rule: f.Rule, file: c.fileLabel(f.File)}]++
+	}
+	return findings
+}
+
+// isTLSServer reports whether a server block listens with TLS.
+func isTLSServer(server *nginxconf.Directive) bool {
+	for _, d := range server.Block {
+		switch {
+		case d.Name == "listen" && len(d.Args) > 1:
+			for _, arg := range d.Args[1:] {
+				if arg == "ssl" || arg == "quic" {
+					return true
+				}
+			}
+		case d.Name == "ssl" && len(d.Args) == 1 && d.Args[0] == "on":
+			return true
+		}
+	}
+	return false
+}
+
+// hstsGaps : Strict-Transport-Security 헤더가 추가되지 않는 server 또는 location 블록을, 그 블록까지의 parents 로 반환한다.
+// add_header 는 현재 블록에 add_header 가 없을 때만 상위 블록에서 상속되므로, add_header 를 다시 정의하면서 HSTS 를 빠뜨린
+// location 도 누락으로 본다.
+func hstsGaps(d *nginxconf.Directive, parents []*nginxconf.Directive, inherited bool) [][]*nginxconf.Directive {
+	parents = append(parents[:len(parents):len(parents)], d)
+	if !hasHSTS(d.Block, inherited) {
+		return [][]*nginxconf.Directive{parents}
+	}
+	var gaps [][]*nginxconf.Directive
+	for _, l := range d.Block {
+		if l.Name == "location" && l.IsBlock() {
+			gaps = append(gaps, hstsGaps(l, parents, true)...)
+		}
+	}
+	return gaps
+}
+
+// hasHSTS returns whether the add_header directives of a block set the
+// Strict-Transport-Security header, or inherited when the block has none.
+func hasHSTS(block []*nginxconf.Directive, inherited bool) bool {
+	found := false
+	for _, d := range block {
+		if d.Name != "add_header" {
+			continue
+		}
+		if len(d.Args) > 0 && strings.EqualFold(d.Args[0], "Strict-Transport-Security") {
+			return true
+		}
+		found = true
+	}
+	if found {
+		return false
+	}
+	return inherited
+}
+
+// findDirective returns the first directive of a block with the given name, or nil.
+func findDirective(block []*nginxconf.Directive, name string) *nginxconf.Directive {
+	for _, d := range block {
+		if d.Name == name {
+			return d
+		}
+	}
+	return nil
+}
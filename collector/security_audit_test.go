@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+)
+
+func TestSecurityFindings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		config string
+		want   map[securityFindingKey]float64
+	}{
+		{
+			name: "hardened",
+			config: `http {
+    server_tokens off;
+    add_header Strict-Transport-Security "max-age=31536000" always;
+    server {
+        listen 443 ssl;
+        ssl_protocols TLSv1.2 TLSv1.3;
+        location / { autoindex off; }
+    }
+    server { listen 80; }
+}
+stream {
+    server { listen 5432 ssl; }
+}
+`,
+			want: map[securityFindingKey]float64{},
+		},
+		{
+			name: "risky settings",
+			config: `http {
+    ssl_protocols TLSv1 TLSv1.1 TLSv1.2;
+    server {
+        listen 443 ssl;
+        server_tokens on;
+        location /files { autoindex on; }
+    }
+    server {
+        listen 80;
+    }
+}
+`,
+			want: map[securityFindingKey]float64{
+				{rule: SecurityRuleSSLProtocols, file: "nginx.conf"}: 1,
+				{rule: SecurityRuleServerTokens, file: "nginx.conf"}: 2,
+				{rule: SecurityRuleMissingHSTS, file: "nginx.conf"}:  1,
+				{rule: SecurityRuleAutoindex, file: "nginx.conf"}:    1,
+			},
+		},
+		{
+			name: "location overrides inherited headers",
+			config: `http {
+    server_tokens off;
+    server {
+        listen 443 ssl;
+        add_header Strict-Transport-Security "max-age=31536000";
+        location / { }
+        location /api { add_header X-Frame-Options DENY; }
+    }
+    server {
+        listen 443 quic;
+        add_header X-Frame-Options DENY;
+    }
+}
+`,
+			want: map[securityFindingKey]float64{
+				{rule: SecurityRuleMissingHSTS, file: "nginx.conf"}: 2,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "nginx.conf")
+			if err := os.WriteFile(path, []byte(tt.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := nginxconf.Parse(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := &NginxCollector{nginxConfigPath: path, configLabelMode: ConfigLabelModeBasename, logger: slog.New(slog.DiscardHandler)}
+			if got := c.securityFindings(cfg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("securityFindings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package collector
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrNoBackgroundHealthChecks is returned by RunHealthChecks when the health checks
+// run during the scrape instead of in the background.
+var ErrNoBackgroundHealthChecks = errors.New("background health checks are disabled")
+
+// maxHealthTransitions is the number of recent health transitions kept for status
+// pages.
+const maxHealthTransitions = 50
+
+// ScrapeStatus is the result of the last scrape of an NGINX or NGINX Plus instance.
+type ScrapeStatus struct {
+	At    time.Time
+	Error string
+	Up    bool
+}
+
+// UpstreamHealth is the last health check result of a proxy target.
+type UpstreamHealth struct {
+	// CheckedAt is the time of the check. It is zero for a result restored from the
+	// health state file.
+	CheckedAt   time.Time
+	File        string
+	Target      string
+	Transitions uint64
+	Healthy     bool
+}
+
+// HealthTransition is a change of the health check result of a proxy target.
+type HealthTransition struct {
+	At      time.Time
+	File    string
+	Target  string
+	Healthy bool
+}
+
+// collectorStatus holds the results of the last scrape for status pages, which read
+// them concurrently with Collect.
+type collectorStatus struct {
+	scrape      ScrapeStatus
+	health      []UpstreamHealth
+	transitions []HealthTransition
+	mutex       sync.RWMutex
+}
+
+// recordScrape : scrape 결과를 기록한다.
+func (s *collectorStatus) recordScrape(err error) {
+	status := ScrapeStatus{At: time.Now(), Up: err == nil}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	s.mutex.Lock()
+	s.scrape = status
+	s.mutex.Unlock()
+}
+
+// recordHealth : 이번 scrape 의 health check 결과와 최근 transition 으로 교체한다.
+func (s *collectorStatus) recordHealth(health []UpstreamHealth, transitions []HealthTransition) {
+	s.mutex.Lock()
+	s.health = health
+	s.transitions = slices.Clone(transitions)
+	s.mutex.Unlock()
+}
+
+// ScrapeStatus returns the result of the last scrape of NGINX.
+func (c *NginxCollector) ScrapeStatus() ScrapeStatus {
+	c.status.mutex.RLock()
+	defer c.status.mutex.RUnlock()
+	return c.status.scrape
+}
+
+// UpstreamHealth returns the health check results of the proxy targets of the last
+// scrape.
+func (c *NginxCollector) UpstreamHealth() []UpstreamHealth {
+	c.status.mutex.RLock()
+	defer c.status.mutex.RUnlock()
+	return slices.Clone(c.status.health)
+}
+
+// HealthTransitions returns the recent changes of the health check results, oldest
+// first.
+func (c *NginxCollector) HealthTransitions() []HealthTransition {
+	c.status.mutex.RLock()
+	defer c.status.mutex.RUnlock()
+	return slices.Clone(c.status.transitions)
+}
+
+// RunHealthChecks runs the background health checks of all proxy targets of the last
+// scrape now, instead of at their next interval, and returns their results. The
+// results are exported by the next scrape, which also counts their transitions, so
+// Transitions is always zero.
+func (c *NginxCollector) RunHealthChecks() ([]UpstreamHealth, error) {
+	if c.healthChecker == nil {
+		return nil, ErrNoBackgroundHealthChecks
+	}
+	results := c.healthChecker.checkAll()
+	health := make([]UpstreamHealth, 0, len(results))
+	for key, result := range results {
+		health = append(health, UpstreamHealth{
+			CheckedAt: result.at,
+			File:      c.fileLabel(key.file),
+			Target:    key.target,
+			Healthy:   result.result == 1,
+		})
+	}
+	slices.SortFunc(health, func(a, b UpstreamHealth) int {
+		return cmp.Or(cmp.Compare(a.File, b.File), cmp.Compare(a.Target, b.Target))
+	})
+	return health, nil
+}
+
+// ScrapeStatus returns the result of the last scrape of NGINX Plus.
+func (c *NginxPlusCollector) ScrapeStatus() ScrapeStatus {
+	c.status.mutex.RLock()
+	defer c.status.mutex.RUnlock()
+	return c.status.scrape
+}
@@ -0,0 +1,161 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SyntheticProbe is a request sent periodically to the local NGINX to check a specific
+// location or virtual host.
+type SyntheticProbe struct {
+	// Headers are added to the request.
+	Headers map[string]string
+	// Name identifies the probe in the probe label of the probe metrics.
+	Name string
+	// URL is the base URL of the local NGINX listener, e.g. http://127.0.0.1:80.
+	URL string
+	// Path is the requested path, including the query string. "/" is used when empty.
+	Path string
+	// Method is the request method. GET is used when empty.
+	Method string
+	// Host is sent as Host header and TLS server name to select a virtual host.
+	Host string
+	// ExpectedStatus is the status code of a successful probe. Any status below 500
+	// succeeds when zero.
+	ExpectedStatus int
+	// Interval is the interval of the probe. DefaultProbeInterval is used when zero.
+	Interval time.Duration
+	// Timeout is the timeout of the probe. DefaultProbeTimeout is used when zero.
+	Timeout time.Duration
+}
+
+// SyntheticProber runs the synthetic probes of the exporter config file in the
+// background, each at its own interval. It implements the prometheus.Collector
+// interface.
+type SyntheticProber struct {
+	logger       *slog.Logger
+	clients      *probeClients
+	results      map[string]probeResult
+	successDesc  *prometheus.Desc
+	statusDesc   *prometheus.Desc
+	durationDesc *prometheus.Desc
+	probes       []SyntheticProbe
+	mutex        sync.RWMutex
+	timestamps   bool
+}
+
+// NewSyntheticProber creates a SyntheticProber that sends probes with transport, or a
+// clone of http.DefaultTransport when nil. Call Start to run the probes.
+func NewSyntheticProber(probes []SyntheticProbe, transport *http.Transport, opts ...Option) (*SyntheticProber, error) {
+	o := newOptions(DefaultNamespace, opts)
+	namespace, constLabels := o.namespace, o.constLabels
+
+	names := make(map[string]bool, len(probes))
+	validated := make([]SyntheticProbe, 0, len(probes))
+	for _, probe := range probes {
+		if probe.Name == "" {
+			return nil, errors.New("probe without a name")
+		}
+		if names[probe.Name] {
+			return nil, fmt.Errorf("duplicate probe %q", probe.Name)
+		}
+		names[probe.Name] = true
+		if _, err := parseProbeURL(probe.URL); err != nil {
+			return nil, fmt.Errorf("probe %q: %w", probe.Name, err)
+		}
+		if probe.Path == "" {
+			probe.Path = "/"
+		}
+		if !strings.HasPrefix(probe.Path, "/") {
+			return nil, fmt.Errorf("probe %q: invalid path %q: must start with /", probe.Name, probe.Path)
+		}
+		if probe.ExpectedStatus != 0 && (probe.ExpectedStatus < 100 || probe.ExpectedStatus > 599) {
+			return nil, fmt.Errorf("probe %q: invalid expected status %d", probe.Name, probe.ExpectedStatus)
+		}
+		if probe.Interval <= 0 {
+			probe.Interval = DefaultProbeInterval
+		}
+		if probe.Timeout <= 0 {
+			probe.Timeout = DefaultProbeTimeout
+		}
+		validated = append(validated, probe)
+	}
+
+	p := &SyntheticProber{
+		logger:  o.logger,
+		results: make(map[string]probeResult),
+		successDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "probe", "success"),
+			"Whether the last run of a synthetic probe got the expected response",
+			[]string{"probe"}, constLabels),
+		statusDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "probe", "status_code"),
+			"HTTP status code of the last run of a synthetic probe, absent without response",
+			[]string{"probe"}, constLabels),
+		durationDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "probe", "duration_seconds"),
+			"Duration of the last run of a synthetic probe",
+			[]string{"probe"}, constLabels),
+		probes:     validated,
+		timestamps: o.sampleTimestamps,
+	}
+	var err error
+	if p.clients, err = newProbeClients(transport, o.probeMaxIdleConns); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Start runs every probe in the background at its interval until ctx is canceled.
+func (p *SyntheticProber) Start(ctx context.Context) {
+	context.AfterFunc(ctx, p.clients.close)
+	for _, probe := range p.probes {
+		go runProbeLoop(ctx, probe.Interval, func(ctx context.Context) {
+			p.run(ctx, probe)
+		})
+	}
+}
+
+// run : probe 를 한 번 실행하고 결과를 저장한다.
+func (p *SyntheticProber) run(ctx context.Context, probe SyntheticProbe) {
+	result, err := runProbe(ctx, p.clients, httpProbe{
+		headers:        probe.Headers,
+		method:         probe.Method,
+		url:            strings.TrimSuffix(probe.URL, "/") + probe.Path,
+		host:           probe.Host,
+		expectedStatus: probe.ExpectedStatus,
+	}, probe.Timeout)
+	if err != nil {
+		p.logger.Debug("synthetic probe failed", "probe", probe.Name, "error", err.Error())
+	}
+
+	p.mutex.Lock()
+	p.results[probe.Name] = result
+	p.mutex.Unlock()
+}
+
+// Describe sends the descriptors of the synthetic probe metrics to the provided channel.
+func (p *SyntheticProber) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.successDesc
+	ch <- p.statusDesc
+	ch <- p.durationDesc
+}
+
+// Collect sends the results of the last run of every probe to the provided channel.
+func (p *SyntheticProber) Collect(ch chan<- prometheus.Metric) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	for name, result := range p.results {
+		ch <- timestamped(p.timestamps, result.start, prometheus.MustNewConstMetric(p.successDesc, prometheus.GaugeValue, boolToFloat64(result.success), name))
+		ch <- timestamped(p.timestamps, result.start, prometheus.MustNewConstMetric(p.durationDesc, prometheus.GaugeValue, result.duration, name))
+		if result.status != 0 {
+			ch <- timestamped(p.timestamps, result.start, prometheus.MustNewConstMetric(p.statusDesc, prometheus.GaugeValue, float64(result.status), name))
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestSyntheticProber(t *testing.T) {
+	t.Parallel()
+
+	nginx := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead && r.Host == "api.example.com" && r.URL.Path == "/v1/healthz" && r.Header.Get("X-Probe") == "1":
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/admin/":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer nginx.Close()
+
+	p, err := NewSyntheticProber([]SyntheticProbe{
+		{Name: "api", URL: nginx.URL + "/", Path: "/v1/healthz", Method: http.MethodHead, Host: "api.example.com", Headers: map[string]string{"X-Probe": "1"}, ExpectedStatus: http.StatusNoContent},
+		{Name: "admin_denied", URL: nginx.URL, Path: "/admin/", ExpectedStatus: http.StatusForbidden},
+		{Name: "admin_open", URL: nginx.URL, Path: "/admin/", ExpectedStatus: http.StatusOK},
+		{Name: "root", URL: nginx.URL},
+	}, nil, WithLogger(slog.New(slog.DiscardHandler)))
+	if err != nil {
+		t.Fatalf("NewSyntheticProber() returned error: %v", err)
+	}
+	for _, probe := range p.probes {
+		p.run(context.Background(), probe)
+	}
+
+	expected := `
+# HELP nginx_probe_status_code HTTP status code of the last run of a synthetic probe, absent without response
+# TYPE nginx_probe_status_code gauge
+nginx_probe_status_code{probe="admin_denied"} 403
+nginx_probe_status_code{probe="admin_open"} 403
+nginx_probe_status_code{probe="api"} 204
+nginx_probe_status_code{probe="root"} 404
+# HELP nginx_probe_success Whether the last run of a synthetic probe got the expected response
+# TYPE nginx_probe_success gauge
+nginx_probe_success{probe="admin_denied"} 1
+nginx_probe_success{probe="admin_open"} 0
+nginx_probe_success{probe="api"} 1
+nginx_probe_success{probe="root"} 1
+`
+	if err := testutil.CollectAndCompare(p, strings.NewReader(expected), "nginx_probe_status_code", "nginx_probe_success"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewSyntheticProberInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		probes []SyntheticProbe
+	}{
+		{name: "no name", probes: []SyntheticProbe{{URL: "http://127.0.0.1"}}},
+		{name: "duplicate name", probes: []SyntheticProbe{{Name: "a", URL: "http://127.0.0.1"}, {Name: "a", URL: "http://127.0.0.1"}}},
+		{name: "invalid URL", probes: []SyntheticProbe{{Name: "a", URL: "127.0.0.1:80"}}},
+		{name: "invalid path", probes: []SyntheticProbe{{Name: "a", URL: "http://127.0.0.1", Path: "healthz"}}},
+		{name: "invalid expected status", probes: []SyntheticProbe{{Name: "a", URL: "http://127.0.0.1", ExpectedStatus: 1000}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := NewSyntheticProber(test.probes, nil); err == nil {
+				t.Errorf("NewSyntheticProber() returned no error")
+			}
+		})
+	}
+}
+
+func TestSyntheticProberSampleTimestamps(t *testing.T) {
+	t.Parallel()
+
+	nginx := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer nginx.Close()
+
+	for _, enabled := range []bool{false, true} {
+		p, err := NewSyntheticProber([]SyntheticProbe{{Name: "root", URL: nginx.URL}}, nil,
+			WithLogger(slog.New(slog.DiscardHandler)), WithSampleTimestamps(enabled))
+		if err != nil {
+			t.Fatalf("NewSyntheticProber() returned error: %v", err)
+		}
+		p.run(context.Background(), p.probes[0])
+		want := p.results["root"].start.UnixMilli()
+
+		ch := make(chan prometheus.Metric, 3)
+		p.Collect(ch)
+		close(ch)
+		for m := range ch {
+			var metric dto.Metric
+			if err := m.Write(&metric); err != nil {
+				t.Fatal(err)
+			}
+			switch {
+			case !enabled && metric.TimestampMs != nil:
+				t.Errorf("%v has timestamp %v without WithSampleTimestamps", m.Desc(), metric.GetTimestampMs())
+			case enabled && metric.GetTimestampMs() != want:
+				t.Errorf("%v has timestamp %v, want the probe start %v", m.Desc(), metric.GetTimestampMs(), want)
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package collector
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+)
+
+// Kinds of config targets.
+const (
+	ConfigTargetUpstream  = "upstream"
+	ConfigTargetServer    = "server"
+	ConfigTargetProxyPass = "proxy_pass"
+)
+
+// ConfigTarget is an upstream, upstream server or proxy_pass target found in the NGINX config.
+type ConfigTarget struct {
+	Kind    string
+	File    string
+	Context string
+	Value   string
+	// Addresses holds the addresses a proxy_pass target resolves to. It is empty
+	// when the target cannot be resolved, e.g. when it contains variables.
+	Addresses []string
+	Line      int
+	// HealthChecked reports whether the file is scanned for upstream health checks.
+	HealthChecked bool
+}
+
+// configUpstreamServer is a server directive of an upstream block of the config.
+type configUpstreamServer struct {
+	upstream string
+	// block is the block the upstream is defined in, http or stream.
+	block   string
+	address string
+	// params are the parameters following the address, e.g. weight=2 or backup.
+	params []string
+}
+
+// configUpstreamServers : 설정의 모든 upstream 블록의 server 를 나온 순서대로 반환한다. health check, targets 명령, /status,
+// upstream probe 와 upstream 인벤토리 메트릭이 같은 해석을 사용하도록, upstream server 는 모두 여기서 찾는다.
+func configUpstreamServers(cfg *nginxconf.Config) []configUpstreamServer {
+	var servers []configUpstreamServer
+	cfg.Walk(func(d *nginxconf.Directive, parents []*nginxconf.Directive) {
+		if d.Name != "server" || len(d.Args) == 0 || len(parents) == 0 || parents[len(parents)-1].Name != "upstream" {
+			return
+		}
+		s := configUpstreamServer{
+			upstream: strings.Join(parents[len(parents)-1].Args, " "),
+			address:  d.Args[0],
+			params:   d.Args[1:],
+		}
+		if len(parents) >= 2 {
+			s.block = parents[len(parents)-2].Name
+		}
+		servers = append(servers, s)
+	})
+	return servers
+}
+
+// ConfigTargets returns the upstreams, upstream servers and proxy_pass targets of the
+// config in the order they appear. configPath is the path of the main config file.
+func ConfigTargets(cfg *nginxconf.Config, configPath string) []ConfigTarget {
+	upstreams := make(map[string][]string)
+	for _, s := range configUpstreamServers(cfg) {
+		upstreams[s.upstream] = append(upstreams[s.upstream], s.address)
+	}
+
+	var targets []ConfigTarget
+	cfg.Walk(func(d *nginxconf.Directive, parents []*nginxconf.Directive) {
+		target := ConfigTarget{
+			File:          d.File,
+			Line:          d.Line,
+			Context:       directiveContext(parents),
+			HealthChecked: isHealthCheckedFile(configPath, d.File),
+		}
+		switch {
+		case d.Name == "upstream" && d.IsBlock():
+			target.Kind = ConfigTargetUpstream
+			target.Value = strings.Join(d.Args, " ")
+		case d.Name == "server" && len(d.Args) > 0 && len(parents) > 0 && parents[len(parents)-1].Name == "upstream":
+			target.Kind = ConfigTargetServer
+			target.Value = d.Args[0]
+		case d.Name == "proxy_pass" && len(d.Args) > 0:
+			target.Kind = ConfigTargetProxyPass
+			target.Value = d.Args[0]
+			target.Addresses = resolveProxyPass(d.Args[0], upstreams)
+		default:
+			return
+		}
+		targets = append(targets, target)
+	})
+
+	return targets
+}
+
+// healthCheckTargets : health check 대상 파일별로 proxy_pass target 이 해석되는 주소를 반환한다. https 로 proxy 하는
+// target 은 tlsTargets 에 담는다.
+func healthCheckTargets(cfg *nginxconf.Config, configPath string) []configFileTargets {
+	var files []configFileTargets
+	index := make(map[string]int)
+	for _, t := range ConfigTargets(cfg, configPath) {
+		if t.Kind != ConfigTargetProxyPass || !t.HealthChecked || len(t.Addresses) == 0 {
+			continue
+		}
+		i, ok := index[t.File]
+		if !ok {
+			i = len(files)
+			index[t.File] = i
+			files = append(files, configFileTargets{file: t.File, tlsTargets: make(map[string]bool)})
+		}
+		files[i].targets = append(files[i].targets, t.Addresses...)
+		if strings.HasPrefix(t.Value, "https://") {
+			for _, addr := range t.Addresses {
+				files[i].tlsTargets[addr] = true
+			}
+		}
+	}
+	return files
+}
+
+// resolveProxyPass : proxy_pass 인자를 upstream 서버 주소 또는 호스트 주소로 해석한다.
+func resolveProxyPass(arg string, upstreams map[string][]string) []string {
+	if strings.Contains(arg, "$") || strings.HasPrefix(arg, "unix:") || strings.Contains(arg, "://unix:") {
+		return nil
+	}
+
+	host := arg
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.IndexByte(host, '/'); i >= 0 {
+		host = host[:i]
+	}
+	if servers, ok := upstreams[host]; ok {
+		return servers
+	}
+	return []string{host}
+}
+
+// isHealthCheckedFile : 메인 설정 파일과 conf.d 디렉토리 아래의 .conf 파일만 health check 대상이다.
+func isHealthCheckedFile(configPath string, file string) bool {
+	if filepath.Clean(file) == filepath.Clean(configPath) {
+		return true
+	}
+	confdDir := filepath.Join(filepath.Dir(configPath), "conf.d") + string(filepath.Separator)
+	return strings.HasPrefix(filepath.Clean(file), confdDir) && strings.HasSuffix(file, ".conf")
+}
@@ -0,0 +1,86 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+)
+
+func TestConfigTargets(t *testing.T) {
+	t.Parallel()
+
+	files := map[string][]byte{
+		"/etc/nginx/nginx.conf": []byte(`http {
+    upstream backend {
+        server 10.0.0.1:8080;
+    }
+    include conf.d/*.conf;
+    include sites/*.conf;
+}
+`),
+		"/etc/nginx/conf.d/app.conf": []byte(`server {
+    location /api { proxy_pass http://backend/api; }
+    location /dyn { proxy_pass http://$host; }
+}
+`),
+		"/etc/nginx/sites/other.conf": []byte(`server {
+    location / { proxy_pass http://10.0.0.2:8080; }
+}
+`),
+	}
+	cfg, err := nginxconf.Parser{Files: files}.Parse("/etc/nginx/nginx.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ConfigTarget{
+		{Kind: ConfigTargetUpstream, File: "/etc/nginx/nginx.conf", Line: 2, Context: "http", Value: "backend", HealthChecked: true},
+		{Kind: ConfigTargetServer, File: "/etc/nginx/nginx.conf", Line: 3, Context: "upstream:backend", Value: "10.0.0.1:8080", HealthChecked: true},
+		{Kind: ConfigTargetProxyPass, File: "/etc/nginx/conf.d/app.conf", Line: 2, Context: "location:/api", Value: "http://backend/api", Addresses: []string{"10.0.0.1:8080"}, HealthChecked: true},
+		{Kind: ConfigTargetProxyPass, File: "/etc/nginx/conf.d/app.conf", Line: 3, Context: "location:/dyn", Value: "http://$host", HealthChecked: true},
+		{Kind: ConfigTargetProxyPass, File: "/etc/nginx/sites/other.conf", Line: 2, Context: "location:/", Value: "http://10.0.0.2:8080", Addresses: []string{"10.0.0.2:8080"}},
+	}
+	if got := ConfigTargets(cfg, "/etc/nginx/nginx.conf"); !reflect.DeepEqual(got, want) {
+		t.Errorf("ConfigTargets() = %+v, want %+v", got, want)
+	}
+}
+
+func TestHealthCheckTargets(t *testing.T) {
+	t.Parallel()
+
+	files := map[string][]byte{
+		"/etc/nginx/nginx.conf": []byte(`http {
+    upstream secure_backend {
+        server 10.0.0.3:8443 weight=2 max_fails=3;
+        server 10.0.0.4:8443 backup;
+    }
+    include conf.d/*.conf;
+}
+`),
+		"/etc/nginx/conf.d/app.conf": []byte(`server {
+    location /a { proxy_pass http://10.0.0.1:8080; }
+    location /b { proxy_pass https://backend.internal; }
+    location /c { proxy_pass https://secure_backend; }
+    location /d { proxy_pass http://$host; }
+}
+`),
+	}
+	cfg, err := nginxconf.Parser{Files: files}.Parse("/etc/nginx/nginx.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []configFileTargets{{
+		file:    "/etc/nginx/conf.d/app.conf",
+		targets: []string{"10.0.0.1:8080", "backend.internal", "10.0.0.3:8443", "10.0.0.4:8443"},
+		tlsTargets: map[string]bool{
+			"backend.internal": true,
+			"10.0.0.3:8443":    true,
+			"10.0.0.4:8443":    true,
+		},
+	}}
+	if got := healthCheckTargets(cfg, "/etc/nginx/nginx.conf"); !reflect.DeepEqual(got, want) {
+		t.Errorf("healthCheckTargets() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,182 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const tcpStateResolveTimeout = 2 * time.Second
+
+// tcpStates are the TCP states counted toward upstreams by their code in /proc/net/tcp.
+var tcpStates = map[string]string{
+	"01": "established",
+	"02": "syn_sent",
+	"06": "time_wait",
+}
+
+type tcpConnKey struct {
+	state  string
+	remote netip.AddrPort
+}
+
+// resolvedTarget is a proxy target with the addresses it resolves to.
+type resolvedTarget struct {
+	target  string
+	remotes []netip.AddrPort
+}
+
+// resolveTCPTargets : 중복을 제외한 proxy target 을 주소로 해석한다. 해석할 수 없는 target 은 로그를 남기고 제외한다.
+func (c *NginxCollector) resolveTCPTargets(targets []string) []resolvedTarget {
+	resolved := make([]resolvedTarget, 0, len(targets))
+	seen := make(map[string]bool)
+	for _, target := range targets {
+		if seen[target] {
+			continue
+		}
+		seen[target] = true
+
+		remotes, err := resolveTCPTarget(target)
+		if err != nil {
+			c.logger.Warn("error resolving proxy target", "target", target, "error", err.Error())
+			continue
+		}
+		resolved = append(resolved, resolvedTarget{target: target, remotes: remotes})
+	}
+	return resolved
+}
+
+// collectUpstreamTCPStates : /proc/net/tcp{,6} 의 socket 중 proxy target 으로 향하는 socket 을 상태별로 세어 전송한다.
+// 호스트 이름인 target 은 해석한 모든 주소로 향하는 socket 을 합산한다.
+func (c *NginxCollector) collectUpstreamTCPStates(ch chan<- prometheus.Metric, targets []resolvedTarget) {
+	conns, err := readTCPConnections(filepath.Join(c.procRoot, "net"))
+	if err != nil {
+		c.logger.Warn("error reading TCP connections", "error", err.Error())
+		return
+	}
+
+	for _, t := range targets {
+		for _, state := range tcpStates {
+			var count float64
+			for _, remote := range t.remotes {
+				count += conns[tcpConnKey{remote: remote, state: state}]
+			}
+			ch <- prometheus.MustNewConstMetric(c.upstreamTCPConnectionsDesc, prometheus.GaugeValue, count, t.target, state)
+		}
+	}
+}
+
+// resolveTCPTarget : host:port 형식의 target 을 주소 목록으로 해석한다. port 가 없으면 tcpTest 와 같이 80 을 사용한다.
+func resolveTCPTarget(target string) ([]netip.AddrPort, error) {
+	if !strings.Contains(target, ":") {
+		target += ":80"
+	}
+	host, portStr, err := net.SplitHostPort(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target: %w", err)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q: %w", portStr, err)
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return []netip.AddrPort{netip.AddrPortFrom(addr.Unmap(), uint16(port))}, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tcpStateResolveTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %v: %w", host, err)
+	}
+	remotes := make([]netip.AddrPort, 0, len(addrs))
+	for _, addr := range addrs {
+		remotes = append(remotes, netip.AddrPortFrom(addr.Unmap(), uint16(port)))
+	}
+	return remotes, nil
+}
+
+// readTCPConnections counts the sockets of dir/tcp and dir/tcp6 by remote address and
+// state. dir/tcp6 is optional, since IPv6 may be disabled.
+func readTCPConnections(dir string) (map[tcpConnKey]float64, error) {
+	conns := make(map[tcpConnKey]float64)
+	for _, name := range []string{"tcp", "tcp6"} {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			if name == "tcp6" && errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to open %v: %w", name, err)
+		}
+		err = parseProcNetTCP(f, conns)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %v: %w", name, err)
+		}
+	}
+	return conns, nil
+}
+
+// parseProcNetTCP : /proc/net/tcp 형식의 각 줄에서 rem_address 와 st 를 읽어 세어둔다.
+func parseProcNetTCP(r io.Reader, conns map[tcpConnKey]float64) error {
+	scanner := bufio.NewScanner(r)
+	// 첫 줄은 header 이다.
+	scanner.Scan()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		state, ok := tcpStates[fields[3]]
+		if !ok {
+			continue
+		}
+		remote, err := parseProcNetAddr(fields[2])
+		if err != nil {
+			return err
+		}
+		conns[tcpConnKey{remote: remote, state: state}]++
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read TCP connections: %w", err)
+	}
+	return nil
+}
+
+// parseProcNetAddr parses an address of /proc/net/tcp{,6} such as 0100007F:1F90. The
+// address is hex encoded as 32-bit words in host byte order, the port in big endian.
+func parseProcNetAddr(s string) (netip.AddrPort, error) {
+	addrHex, portHex, ok := strings.Cut(s, ":")
+	if !ok {
+		return netip.AddrPort{}, fmt.Errorf("invalid address %q", s)
+	}
+	raw, err := hex.DecodeString(addrHex)
+	if err != nil || (len(raw) != 4 && len(raw) != 16) {
+		return netip.AddrPort{}, fmt.Errorf("invalid address %q", s)
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("invalid port of address %q: %w", s, err)
+	}
+
+	ip := make([]byte, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		binary.BigEndian.PutUint32(ip[i:], binary.NativeEndian.Uint32(raw[i:]))
+	}
+	addr, _ := netip.AddrFromSlice(ip)
+	return netip.AddrPortFrom(addr.Unmap(), uint16(port)), nil
+}
@@ -0,0 +1,124 @@
+package collector
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nginx/nginx-prometheus-exporter/client"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// procNetAddr encodes an address like /proc/net/tcp{,6} on the host running the test.
+func procNetAddr(addrPort netip.AddrPort, v6 bool) string {
+	addr := addrPort.Addr()
+	if v6 {
+		addr = netip.AddrFrom16(addr.As16())
+	}
+	ip := addr.AsSlice()
+	raw := make([]byte, len(ip))
+	for i := 0; i < len(ip); i += 4 {
+		binary.NativeEndian.PutUint32(raw[i:], binary.BigEndian.Uint32(ip[i:]))
+	}
+	return fmt.Sprintf("%s:%04X", strings.ToUpper(hex.EncodeToString(raw)), addrPort.Port())
+}
+
+func procNetTCPLine(remote string, state string) string {
+	return fmt.Sprintf("   0: %s %s %s 00000000:00000000 00:00000000 00000000     0        0 0 1 0000000000000000\n",
+		procNetAddr(netip.MustParseAddrPort("10.0.0.100:43210"), false), remote, state)
+}
+
+func TestParseProcNetAddr(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		addr  string
+		v6    bool
+		error bool
+	}{
+		{name: "ipv4", addr: "10.0.0.1:8080"},
+		{name: "ipv6", addr: "[2001:db8::1]:443", v6: true},
+		{name: "ipv4-mapped ipv6", addr: "10.0.0.1:8080", v6: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			want := netip.MustParseAddrPort(tt.addr)
+			got, err := parseProcNetAddr(procNetAddr(want, tt.v6))
+			if err != nil {
+				t.Fatalf("parseProcNetAddr() returned error: %v", err)
+			}
+			if got != want {
+				t.Errorf("parseProcNetAddr() = %v, want %v", got, want)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"", "0100007F", "XYZ:1F90", "0100007F:XYZ", "01007F:1F90"} {
+		if _, err := parseProcNetAddr(invalid); err == nil {
+			t.Errorf("parseProcNetAddr(%q) returned no error", invalid)
+		}
+	}
+}
+
+func TestNginxCollectorUpstreamTCPStates(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"nginx.conf": "http { include conf.d/*.conf; }\n",
+		"conf.d/a.conf": "upstream backend_pool { server 10.0.0.1:8080; server 10.0.0.2:8080; }\n" +
+			"server { location / { proxy_pass http://backend_pool; } }\n",
+		"proc/net/tcp": "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n" +
+			procNetTCPLine(procNetAddr(netip.MustParseAddrPort("10.0.0.1:8080"), false), "01") +
+			procNetTCPLine(procNetAddr(netip.MustParseAddrPort("10.0.0.1:8080"), false), "06") +
+			procNetTCPLine(procNetAddr(netip.MustParseAddrPort("10.0.0.2:8080"), false), "02") +
+			procNetTCPLine(procNetAddr(netip.MustParseAddrPort("10.0.0.2:9090"), false), "01") +
+			procNetTCPLine(procNetAddr(netip.MustParseAddrPort("10.0.0.1:8080"), false), "0A"),
+		"proc/net/tcp6": "  sl  local_address                         remote_address                        st\n" +
+			procNetTCPLine(procNetAddr(netip.MustParseAddrPort("10.0.0.1:8080"), true), "01"),
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := NewNginxCollector(fakeStubStatsClient{stats: &client.StubStats{}},
+		WithLogger(slog.New(slog.DiscardHandler)),
+		WithConfigPath(filepath.Join(dir, "nginx.conf")),
+		WithUpstreamTCPStates(true),
+		WithHealthChecks(HealthCheckConfig{Dial: func(context.Context, string, string) (net.Conn, error) {
+			return nil, errors.New("connection refused")
+		}}),
+	)
+	c.procRoot = filepath.Join(dir, "proc")
+
+	expected := `
+# HELP nginx_upstream_tcp_connections TCP sockets of the host toward proxy targets by state
+# TYPE nginx_upstream_tcp_connections gauge
+nginx_upstream_tcp_connections{state="established",target="10.0.0.1:8080"} 2
+nginx_upstream_tcp_connections{state="established",target="10.0.0.2:8080"} 0
+nginx_upstream_tcp_connections{state="syn_sent",target="10.0.0.1:8080"} 0
+nginx_upstream_tcp_connections{state="syn_sent",target="10.0.0.2:8080"} 1
+nginx_upstream_tcp_connections{state="time_wait",target="10.0.0.1:8080"} 1
+nginx_upstream_tcp_connections{state="time_wait",target="10.0.0.2:8080"} 0
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "nginx_upstream_tcp_connections"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,16 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// timestamped : enabled 이면 background 에서 수집한 시각 at 을 sample 에 붙인다. at 이 없으면 m 을 그대로 반환하여
+// Prometheus 가 scrape 시각을 사용하도록 한다.
+func timestamped(enabled bool, at time.Time, m prometheus.Metric) prometheus.Metric {
+	if !enabled || at.IsZero() {
+		return m
+	}
+	return prometheus.NewMetricWithTimestamp(at, m)
+}
@@ -0,0 +1,148 @@
+package collector
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultTopPathsWindow is the default window over which the top paths are computed.
+const DefaultTopPathsWindow = time.Minute
+
+// topPathsCapacityFactor is the number of paths tracked per reported path. The counts of
+// the space-saving algorithm are exact for paths more frequent than 1/capacity of the
+// requests.
+const topPathsCapacityFactor = 10
+
+// topPathLatencyBuckets are the upper bounds of the latency histogram of a tracked path,
+// from 1ms doubling to about 65s.
+var topPathLatencyBuckets = func() []float64 {
+	buckets := make([]float64, 17)
+	for i := range buckets {
+		buckets[i] = 0.001 * math.Pow(2, float64(i))
+	}
+	return buckets
+}()
+
+// topPath is a path with its number of requests or latency in a window.
+type topPath struct {
+	path  string
+	value float64
+}
+
+// topPathEntry is a path tracked by topPaths. count is an upper bound of its requests,
+// overestimated by the count of the path it replaced.
+type topPathEntry struct {
+	buckets []uint64
+	count   uint64
+}
+
+// topPaths tracks the most requested paths of a window, and their latency, in bounded
+// memory with the space-saving algorithm: when all entries are in use, a new path
+// replaces the least requested one and inherits its count.
+type topPaths struct {
+	entries  map[string]*topPathEntry
+	n        int
+	capacity int
+}
+
+func newTopPaths(n int) *topPaths {
+	return &topPaths{
+		entries:  make(map[string]*topPathEntry, n*topPathsCapacityFactor),
+		n:        n,
+		capacity: n * topPathsCapacityFactor,
+	}
+}
+
+// observe : path 의 요청을 기록한다. latency 가 음수이면 latency 는 기록하지 않는다.
+func (t *topPaths) observe(path string, latency float64) {
+	entry, ok := t.entries[path]
+	if !ok {
+		entry = &topPathEntry{buckets: make([]uint64, len(topPathLatencyBuckets)+1)}
+		if len(t.entries) >= t.capacity {
+			minPath, minCount := t.leastRequested()
+			delete(t.entries, minPath)
+			entry.count = minCount
+		}
+		t.entries[path] = entry
+	}
+	entry.count++
+	if latency >= 0 {
+		entry.buckets[sort.SearchFloat64s(topPathLatencyBuckets, latency)]++
+	}
+}
+
+func (t *topPaths) leastRequested() (string, uint64) {
+	var minPath string
+	minCount := uint64(math.MaxUint64)
+	for path, entry := range t.entries {
+		if entry.count < minCount || (entry.count == minCount && path < minPath) {
+			minPath, minCount = path, entry.count
+		}
+	}
+	return minPath, minCount
+}
+
+// rotate : 현재 window 의 요청 수 상위 n 개 path 와, 추적 중인 path 중 p99 latency 상위 n 개 path 를 반환하고 다음
+// window 를 시작한다.
+func (t *topPaths) rotate() (byRequests []topPath, byLatency []topPath) {
+	for path, entry := range t.entries {
+		byRequests = append(byRequests, topPath{path: path, value: float64(entry.count)})
+		if p99, ok := entry.quantile(0.99); ok {
+			byLatency = append(byLatency, topPath{path: path, value: p99})
+		}
+	}
+	t.entries = make(map[string]*topPathEntry, t.capacity)
+	return topN(byRequests, t.n), topN(byLatency, t.n)
+}
+
+// quantile returns the upper bound of the latency bucket of the quantile q. The last
+// bucket has no upper bound, so the bound of the bucket below is returned for it.
+func (e *topPathEntry) quantile(q float64) (float64, bool) {
+	var total uint64
+	for _, count := range e.buckets {
+		total += count
+	}
+	if total == 0 {
+		return 0, false
+	}
+	rank := uint64(math.Ceil(q * float64(total)))
+	var cumulative uint64
+	for i, count := range e.buckets {
+		cumulative += count
+		if cumulative >= rank {
+			return topPathLatencyBuckets[min(i, len(topPathLatencyBuckets)-1)], true
+		}
+	}
+	return topPathLatencyBuckets[len(topPathLatencyBuckets)-1], true
+}
+
+func topN(paths []topPath, n int) []topPath {
+	sort.Slice(paths, func(i, j int) bool {
+		if paths[i].value != paths[j].value {
+			return paths[i].value > paths[j].value
+		}
+		return paths[i].path < paths[j].path
+	})
+	if len(paths) > n {
+		paths = paths[:n]
+	}
+	return paths
+}
+
+// requestPath : 요청 path 를 $uri, $request_uri, $request 순으로 찾고 query string 은 제외한다.
+func requestPath(entry logEntry) string {
+	path := entry.get("uri")
+	if path == "" {
+		path = entry.get("request_uri")
+	}
+	if path == "" {
+		// $request 는 "GET /path?query HTTP/1.1" 형식이다.
+		if fields := strings.Fields(entry.get("request")); len(fields) >= 2 {
+			path = fields[1]
+		}
+	}
+	path, _, _ = strings.Cut(path, "?")
+	return path
+}
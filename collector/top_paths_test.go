@@ -0,0 +1,75 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTopPaths(t *testing.T) {
+	t.Parallel()
+
+	top := newTopPaths(2)
+	for range 50 {
+		top.observe("/api", 0.003)
+	}
+	for range 30 {
+		top.observe("/static", 0.0005)
+	}
+	top.observe("/static", -1)
+	for i := range 100 {
+		latency := 0.1
+		if i == 99 {
+			latency = 3
+		}
+		top.observe("/slow", latency)
+	}
+	// 추적 용량(20)을 넘는 드문 path 는 가장 적게 요청된 path 를 교체한다.
+	for i := range 40 {
+		top.observe("/rare/"+string(rune('a'+i%26))+string(rune('a'+i/26)), 0.001)
+	}
+	if len(top.entries) > top.capacity {
+		t.Fatalf("topPaths tracks %d paths, expected at most %d", len(top.entries), top.capacity)
+	}
+
+	byRequests, byLatency := top.rotate()
+	expectedRequests := []topPath{{path: "/slow", value: 100}, {path: "/api", value: 50}}
+	if !reflect.DeepEqual(byRequests, expectedRequests) {
+		t.Errorf("rotate() returned %v by requests, expected %v", byRequests, expectedRequests)
+	}
+	expectedLatency := []topPath{{path: "/slow", value: 0.128}, {path: "/api", value: 0.004}}
+	if !reflect.DeepEqual(byLatency, expectedLatency) {
+		t.Errorf("rotate() returned %v by latency, expected %v", byLatency, expectedLatency)
+	}
+
+	if byRequests, _ = top.rotate(); len(byRequests) != 0 {
+		t.Errorf("rotate() returned %v for an empty window", byRequests)
+	}
+}
+
+func TestRequestPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		format   string
+		line     string
+		expected string
+	}{
+		{format: `"$request"`, line: `"GET /a/b?c=d HTTP/1.1"`, expected: "/a/b"},
+		{format: `$request_uri "$request"`, line: `/x?y "GET /a HTTP/1.1"`, expected: "/x"},
+		{format: `$uri $request_uri`, line: `/index.html /?page=1`, expected: "/index.html"},
+		{format: `"$request"`, line: `"-"`, expected: ""},
+	}
+	for _, test := range tests {
+		format, err := parseLogFormat(test.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry, ok := format.parse(test.line)
+		if !ok {
+			t.Fatalf("parse(%q) did not match", test.line)
+		}
+		if got := requestPath(entry); got != test.expected {
+			t.Errorf("requestPath(%q) = %q, expected %q", test.line, got, test.expected)
+		}
+	}
+}
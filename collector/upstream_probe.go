@@ -0,0 +1,291 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
+)
+
+// UpstreamProbeConfig configures an UpstreamProber.
+type UpstreamProbeConfig struct {
+	// Transport sends the probes through NGINX. A clone of http.DefaultTransport is used
+	// when nil.
+	Transport *http.Transport
+	// Dial dials the upstream servers for the direct checks. net.Dialer is used when nil.
+	Dial DialContextFunc
+	// URL is the base URL of the local NGINX listener, e.g. http://127.0.0.1:80.
+	URL string
+	// Path is requested relative to the path of every location. The location path itself
+	// is requested when empty.
+	Path string
+	// Interval is the interval of the probes. DefaultProbeInterval is used when zero.
+	Interval time.Duration
+	// Timeout is the timeout of a probe. DefaultProbeTimeout is used when zero.
+	Timeout time.Duration
+}
+
+// upstreamLocation is a location whose proxy_pass points at an upstream block.
+type upstreamLocation struct {
+	upstream string
+	vhost    string
+	location string
+	path     string
+}
+
+// UpstreamProber checks the upstreams of the NGINX config in the background both
+// directly, by connecting to their servers, and through NGINX, by requesting every
+// location whose proxy_pass points at them. A location that fails while its upstream
+// is reachable is misrouted rather than backed by a dead upstream. It implements the
+// prometheus.Collector interface.
+type UpstreamProber struct {
+	logger       *slog.Logger
+	clients      *probeClients
+	dial         DialContextFunc
+	direct       map[string]bool
+	proxied      map[upstreamLocation]probeResult
+	directDesc   *prometheus.Desc
+	successDesc  *prometheus.Desc
+	statusDesc   *prometheus.Desc
+	durationDesc *prometheus.Desc
+	url          string
+	path         string
+	configPath   string
+	interval     time.Duration
+	directAt     time.Time
+	timeout      time.Duration
+	mutex        sync.RWMutex
+	timestamps   bool
+}
+
+// NewUpstreamProber creates an UpstreamProber for the upstreams of the config file of
+// WithConfigPath, configured by opts. Call Start to run the probes.
+func NewUpstreamProber(config UpstreamProbeConfig, opts ...Option) (*UpstreamProber, error) {
+	o := newOptions(DefaultNamespace, opts)
+	namespace, constLabels := o.namespace, o.constLabels
+
+	base, err := parseProbeURL(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	base.Path = ""
+
+	labels := []string{"upstream", "vhost", "location"}
+	p := &UpstreamProber{
+		logger:  o.logger,
+		dial:    config.Dial,
+		direct:  make(map[string]bool),
+		proxied: make(map[upstreamLocation]probeResult),
+		directDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "upstream", "direct_success"),
+			"Whether a server of an upstream accepted a TCP connection in the last probe",
+			[]string{"upstream"}, constLabels),
+		successDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "upstream", "proxy_probe_success"),
+			"Whether the last probe of a location proxying to an upstream through NGINX got a response below 500",
+			labels, constLabels),
+		statusDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "upstream", "proxy_probe_status_code"),
+			"HTTP status code of the last probe of a location proxying to an upstream, absent without response",
+			labels, constLabels),
+		durationDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "upstream", "proxy_probe_duration_seconds"),
+			"Duration of the last probe of a location proxying to an upstream through NGINX",
+			labels, constLabels),
+		url:        base.String(),
+		path:       config.Path,
+		configPath: o.configPath,
+		interval:   config.Interval,
+		timeout:    config.Timeout,
+		timestamps: o.sampleTimestamps,
+	}
+	if p.clients, err = newProbeClients(config.Transport, o.probeMaxIdleConns); err != nil {
+		return nil, err
+	}
+	if p.interval <= 0 {
+		p.interval = DefaultProbeInterval
+	}
+	if p.timeout <= 0 {
+		p.timeout = DefaultProbeTimeout
+	}
+	return p, nil
+}
+
+// Start runs the probes in the background until ctx is canceled.
+func (p *UpstreamProber) Start(ctx context.Context) {
+	context.AfterFunc(ctx, p.clients.close)
+	go runProbeLoop(ctx, p.interval, p.probeAll)
+}
+
+// probeAll : 설정 파일을 다시 읽어 upstream 별 server 에 직접 연결하고, upstream 으로 proxy 하는 location 에
+// NGINX 를 거쳐 요청한 뒤 결과를 교체한다.
+func (p *UpstreamProber) probeAll(ctx context.Context) {
+	cfg, err := nginxconf.Parse(p.configPath)
+	if err != nil {
+		p.logger.Warn("error parsing nginx config for upstream probes", "file", p.configPath, "error", err.Error())
+		return
+	}
+	upstreams, locations := upstreamLocations(cfg, p.path)
+
+	directAt := time.Now()
+	direct := make(map[string]bool, len(upstreams))
+	proxied := make(map[upstreamLocation]probeResult, len(locations))
+	var mutex sync.Mutex
+	var g errgroup.Group
+	g.SetLimit(probeConcurrency)
+	for upstream, servers := range upstreams {
+		if len(servers) == 0 {
+			continue
+		}
+		g.Go(func() error {
+			up := false
+			for _, server := range servers {
+				if result, _ := tcpTest(p.dial, server); result == 1 {
+					up = true
+					break
+				}
+			}
+			mutex.Lock()
+			direct[upstream] = up
+			mutex.Unlock()
+			return nil
+		})
+	}
+	for _, l := range locations {
+		g.Go(func() error {
+			result, err := runProbe(ctx, p.clients, httpProbe{url: p.url + l.path, host: l.vhost}, p.timeout)
+			if err != nil {
+				p.logger.Debug("upstream probe failed", "upstream", l.upstream, "location", l.location, "error", err.Error())
+			}
+			mutex.Lock()
+			proxied[l] = result
+			mutex.Unlock()
+			return nil
+		})
+	}
+	_ = g.Wait()
+	p.clients.sweep()
+
+	p.mutex.Lock()
+	p.direct, p.proxied, p.directAt = direct, proxied, directAt
+	p.mutex.Unlock()
+}
+
+// Describe sends the descriptors of the upstream probe metrics to the provided channel.
+func (p *UpstreamProber) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.directDesc
+	ch <- p.successDesc
+	ch <- p.statusDesc
+	ch <- p.durationDesc
+}
+
+// Collect sends the results of the last probes to the provided channel.
+func (p *UpstreamProber) Collect(ch chan<- prometheus.Metric) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	for upstream, up := range p.direct {
+		ch <- timestamped(p.timestamps, p.directAt, prometheus.MustNewConstMetric(p.directDesc, prometheus.GaugeValue, boolToFloat64(up), upstream))
+	}
+	for l, result := range p.proxied {
+		ch <- timestamped(p.timestamps, result.start, prometheus.MustNewConstMetric(p.successDesc, prometheus.GaugeValue, boolToFloat64(result.success), l.upstream, l.vhost, l.location))
+		ch <- timestamped(p.timestamps, result.start, prometheus.MustNewConstMetric(p.durationDesc, prometheus.GaugeValue, result.duration, l.upstream, l.vhost, l.location))
+		if result.status != 0 {
+			ch <- timestamped(p.timestamps, result.start, prometheus.MustNewConstMetric(p.statusDesc, prometheus.GaugeValue, float64(result.status), l.upstream, l.vhost, l.location))
+		}
+	}
+}
+
+// upstreamLocations : http 블록의 upstream 별 TCP server 주소와, proxy_pass 로 upstream 을 가리키는 prefix 또는 exact
+// location 을 찾는다. exact location 은 probePath 를 붙이지 않고 그 경로를 요청한다. 정규식과 named location 은 요청할 경로를 알 수 없으므로 제외한다. vhost 는 location 이 속한
+// server 의 첫 번째 server_name 이며, 없으면 listener 의 default server 로 요청한다.
+func upstreamLocations(cfg *nginxconf.Config, probePath string) (map[string][]string, []upstreamLocation) {
+	upstreams := make(map[string][]string)
+	for _, s := range configUpstreamServers(cfg) {
+		if s.block != "http" {
+			continue
+		}
+		if !strings.HasPrefix(s.address, "unix:") {
+			upstreams[s.upstream] = append(upstreams[s.upstream], s.address)
+		} else if _, ok := upstreams[s.upstream]; !ok {
+			upstreams[s.upstream] = nil
+		}
+	}
+
+	seen := make(map[upstreamLocation]bool)
+	var locations []upstreamLocation
+	cfg.Walk(func(d *nginxconf.Directive, parents []*nginxconf.Directive) {
+		if d.Name != "proxy_pass" || len(d.Args) == 0 || len(parents) == 0 || parents[len(parents)-1].Name != "location" {
+			return
+		}
+		upstream := proxyPassHost(d.Args[0])
+		if _, ok := upstreams[upstream]; !ok {
+			return
+		}
+		location, exact, ok := probeLocationPath(parents[len(parents)-1].Args)
+		if !ok {
+			return
+		}
+		l := upstreamLocation{upstream: upstream, location: location, path: location}
+		if !exact {
+			l.path = joinProbePath(location, probePath)
+		}
+		for _, parent := range parents {
+			if parent.Name == "server" {
+				for _, child := range parent.Block {
+					if child.Name == "server_name" {
+						if names := probeHostNames(child); len(names) > 0 && l.vhost == "" {
+							l.vhost = names[0]
+						}
+					}
+				}
+			}
+		}
+		if !seen[l] {
+			seen[l] = true
+			locations = append(locations, l)
+		}
+	})
+	sort.Slice(locations, func(i, j int) bool {
+		return fmt.Sprint(locations[i]) < fmt.Sprint(locations[j])
+	})
+	return upstreams, locations
+}
+
+// proxyPassHost returns the host of a proxy_pass URL, e.g. backend of http://backend/api.
+func proxyPassHost(arg string) string {
+	host := arg
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.IndexByte(host, '/'); i >= 0 {
+		host = host[:i]
+	}
+	return host
+}
+
+// probeLocationPath returns the path of a prefix or exact location. An exact location
+// only matches its own path, so the probe path is not appended to it.
+func probeLocationPath(args []string) (path string, exact bool, ok bool) {
+	switch {
+	case len(args) == 1 && strings.HasPrefix(args[0], "/"):
+		return args[0], false, true
+	case len(args) == 2 && args[0] == "^~" && strings.HasPrefix(args[1], "/"):
+		return args[1], false, true
+	case len(args) == 2 && args[0] == "=" && strings.HasPrefix(args[1], "/"):
+		return args[1], true, true
+	}
+	return "", false, false
+}
+
+// joinProbePath : location 경로 아래의 probePath 를 반환한다. probePath 가 없으면 location 경로 그대로 요청한다.
+func joinProbePath(location string, probePath string) string {
+	if probePath == "" {
+		return location
+	}
+	return strings.TrimSuffix(location, "/") + "/" + strings.TrimPrefix(probePath, "/")
+}
@@ -0,0 +1,115 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestUpstreamProber(t *testing.T) {
+	t.Parallel()
+
+	nginx := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Host == "www.example.com" && r.URL.Path == "/api/healthz":
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/status":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer nginx.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead := l.Addr().String()
+	_ = l.Close()
+
+	dir := t.TempDir()
+	config := fmt.Sprintf(`http {
+    upstream api_pool { server %s; server %s backup; }
+    upstream dead_pool { server %s; }
+    upstream socket_pool { server unix:/run/app.sock; }
+    server {
+        server_name www.example.com;
+        location /api/ { proxy_pass http://api_pool; }
+        location = /status { proxy_pass http://api_pool/status; }
+        location ~ \.php$ { proxy_pass http://api_pool; }
+        location @fallback { proxy_pass http://api_pool; }
+    }
+    server {
+        server_name _;
+        location / { proxy_pass http://dead_pool; }
+        location /socket/ { proxy_pass http://socket_pool; }
+        location /static/ { proxy_pass http://127.0.0.1:8080; }
+    }
+}
+`, dead, strings.TrimPrefix(nginx.URL, "http://"), dead)
+	if err := os.WriteFile(filepath.Join(dir, "nginx.conf"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewUpstreamProber(UpstreamProbeConfig{URL: nginx.URL, Path: "/healthz"},
+		WithConfigPath(filepath.Join(dir, "nginx.conf")),
+		WithLogger(slog.New(slog.DiscardHandler)),
+	)
+	if err != nil {
+		t.Fatalf("NewUpstreamProber() returned error: %v", err)
+	}
+	p.probeAll(context.Background())
+
+	expected := `
+# HELP nginx_upstream_direct_success Whether a server of an upstream accepted a TCP connection in the last probe
+# TYPE nginx_upstream_direct_success gauge
+nginx_upstream_direct_success{upstream="api_pool"} 1
+nginx_upstream_direct_success{upstream="dead_pool"} 0
+# HELP nginx_upstream_proxy_probe_status_code HTTP status code of the last probe of a location proxying to an upstream, absent without response
+# TYPE nginx_upstream_proxy_probe_status_code gauge
+nginx_upstream_proxy_probe_status_code{location="/",upstream="dead_pool",vhost=""} 502
+nginx_upstream_proxy_probe_status_code{location="/api/",upstream="api_pool",vhost="www.example.com"} 200
+nginx_upstream_proxy_probe_status_code{location="/socket/",upstream="socket_pool",vhost=""} 502
+nginx_upstream_proxy_probe_status_code{location="/status",upstream="api_pool",vhost="www.example.com"} 204
+# HELP nginx_upstream_proxy_probe_success Whether the last probe of a location proxying to an upstream through NGINX got a response below 500
+# TYPE nginx_upstream_proxy_probe_success gauge
+nginx_upstream_proxy_probe_success{location="/",upstream="dead_pool",vhost=""} 0
+nginx_upstream_proxy_probe_success{location="/api/",upstream="api_pool",vhost="www.example.com"} 1
+nginx_upstream_proxy_probe_success{location="/socket/",upstream="socket_pool",vhost=""} 0
+nginx_upstream_proxy_probe_success{location="/status",upstream="api_pool",vhost="www.example.com"} 1
+`
+	if err := testutil.CollectAndCompare(p, strings.NewReader(expected),
+		"nginx_upstream_direct_success", "nginx_upstream_proxy_probe_status_code", "nginx_upstream_proxy_probe_success"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestJoinProbePath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		location  string
+		probePath string
+		expected  string
+	}{
+		{location: "/api/", probePath: "/healthz", expected: "/api/healthz"},
+		{location: "/api", probePath: "healthz", expected: "/api/healthz"},
+		{location: "/", probePath: "/healthz", expected: "/healthz"},
+		{location: "/api/", probePath: "", expected: "/api/"},
+	}
+	for _, test := range tests {
+		if got := joinProbePath(test.location, test.probePath); got != test.expected {
+			t.Errorf("joinProbePath(%q, %q) = %q, expected %q", test.location, test.probePath, got, test.expected)
+		}
+	}
+}
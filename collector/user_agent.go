@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// otherUserAgentClass is the class of user agents that match no UserAgentClass.
+const otherUserAgentClass = "other"
+
+// UserAgentClass is a class of user agents, such as bots, whose requests are counted
+// together.
+type UserAgentClass struct {
+	// Name is the value of the class label.
+	Name string
+	// Regex matches the $http_user_agent of the class anywhere in it.
+	Regex string
+}
+
+// DefaultUserAgentClasses are the user agent classes used when none are configured.
+var DefaultUserAgentClasses = []UserAgentClass{
+	{Name: "monitor", Regex: `(?i)pingdom|uptimerobot|statuscake|site24x7|datadog|newrelicpinger|prometheus|blackbox|kube-probe|elb-healthchecker|googlehc|nagios|zabbix|check_http`},
+	{Name: "bot", Regex: `(?i)bot|crawl|spider|slurp|curl|wget|python-requests|go-http-client|okhttp|libwww|httpclient|headless`},
+	{Name: "mobile", Regex: `(?i)mobile|android|iphone|ipad|ipod|windows phone`},
+	{Name: "browser", Regex: `(?i)mozilla/|opera/`},
+}
+
+type userAgentClass struct {
+	re   *regexp.Regexp
+	name string
+}
+
+// compileUserAgentClasses : user agent class 의 정규식을 compile 한다. class 가 없으면 DefaultUserAgentClasses 를 사용한다.
+func compileUserAgentClasses(classes []UserAgentClass) ([]userAgentClass, error) {
+	if len(classes) == 0 {
+		classes = DefaultUserAgentClasses
+	}
+	compiled := make([]userAgentClass, 0, len(classes))
+	for _, class := range classes {
+		if class.Name == "" {
+			return nil, errors.New("user agent class without a name")
+		}
+		re, err := regexp.Compile(class.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex of user agent class %q: %w", class.Name, err)
+		}
+		compiled = append(compiled, userAgentClass{re: re, name: class.Name})
+	}
+	return compiled, nil
+}
+
+// classifyUserAgent returns the name of the first class matching userAgent, or
+// otherUserAgentClass.
+func classifyUserAgent(classes []userAgentClass, userAgent string) string {
+	for _, class := range classes {
+		if class.re.MatchString(userAgent) {
+			return class.name
+		}
+	}
+	return otherUserAgentClass
+}
@@ -0,0 +1,177 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nginx/nginx-prometheus-exporter/nginxconf"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
+)
+
+// VhostProbeConfig configures a VhostProber.
+type VhostProbeConfig struct {
+	// Transport sends the probes. A clone of http.DefaultTransport is used when nil.
+	Transport *http.Transport
+	// URL is the base URL of the local NGINX listener, e.g. http://127.0.0.1:80.
+	URL string
+	// Path is the path requested from every virtual host. "/" is used when empty.
+	Path string
+	// Interval is the interval of the probes. DefaultProbeInterval is used when zero.
+	Interval time.Duration
+	// Timeout is the timeout of a probe. DefaultProbeTimeout is used when zero.
+	Timeout time.Duration
+}
+
+// VhostProber probes every server_name of the NGINX config in the background by sending
+// a request with the name as Host header to the local NGINX listener, so that broken
+// virtual hosts are caught end-to-end. It implements the prometheus.Collector interface.
+type VhostProber struct {
+	logger       *slog.Logger
+	clients      *probeClients
+	results      map[string]probeResult
+	successDesc  *prometheus.Desc
+	statusDesc   *prometheus.Desc
+	durationDesc *prometheus.Desc
+	url          string
+	configPath   string
+	interval     time.Duration
+	timeout      time.Duration
+	mutex        sync.RWMutex
+	timestamps   bool
+}
+
+// NewVhostProber creates a VhostProber for the server names of the config file of
+// WithConfigPath, configured by opts. Call Start to run the probes.
+func NewVhostProber(config VhostProbeConfig, opts ...Option) (*VhostProber, error) {
+	o := newOptions(DefaultNamespace, opts)
+	namespace, constLabels := o.namespace, o.constLabels
+
+	base, err := parseProbeURL(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	path := config.Path
+	if path == "" {
+		path = "/"
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("invalid vhost probe path %q: must start with /", path)
+	}
+	base.Path = path
+
+	p := &VhostProber{
+		logger:  o.logger,
+		results: make(map[string]probeResult),
+		successDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "vhost_probe", "success"),
+			"Whether the last probe of a virtual host through NGINX got a response below 500",
+			[]string{"vhost"}, constLabels),
+		statusDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "vhost_probe", "status_code"),
+			"HTTP status code of the last probe of a virtual host through NGINX, absent without response",
+			[]string{"vhost"}, constLabels),
+		durationDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "vhost_probe", "duration_seconds"),
+			"Duration of the last probe of a virtual host through NGINX",
+			[]string{"vhost"}, constLabels),
+		url:        base.String(),
+		configPath: o.configPath,
+		interval:   config.Interval,
+		timeout:    config.Timeout,
+		timestamps: o.sampleTimestamps,
+	}
+	if p.clients, err = newProbeClients(config.Transport, o.probeMaxIdleConns); err != nil {
+		return nil, err
+	}
+	if p.interval <= 0 {
+		p.interval = DefaultProbeInterval
+	}
+	if p.timeout <= 0 {
+		p.timeout = DefaultProbeTimeout
+	}
+	return p, nil
+}
+
+// Start runs the probes in the background until ctx is canceled.
+func (p *VhostProber) Start(ctx context.Context) {
+	context.AfterFunc(ctx, p.clients.close)
+	go runProbeLoop(ctx, p.interval, p.probeAll)
+}
+
+// probeAll : 설정 파일을 다시 읽어 모든 server_name 을 probe 하고, 결과를 교체한다.
+// 설정에서 사라진 server_name 의 결과는 버린다.
+func (p *VhostProber) probeAll(ctx context.Context) {
+	cfg, err := nginxconf.Parse(p.configPath)
+	if err != nil {
+		p.logger.Warn("error parsing nginx config for vhost probes", "file", p.configPath, "error", err.Error())
+		return
+	}
+
+	vhosts := serverNames(cfg)
+	results := make(map[string]probeResult, len(vhosts))
+	var mutex sync.Mutex
+	var g errgroup.Group
+	g.SetLimit(probeConcurrency)
+	for _, vhost := range vhosts {
+		g.Go(func() error {
+			result, err := runProbe(ctx, p.clients, httpProbe{url: p.url, host: vhost}, p.timeout)
+			if err != nil {
+				p.logger.Debug("vhost probe failed", "vhost", vhost, "error", err.Error())
+			}
+			mutex.Lock()
+			results[vhost] = result
+			mutex.Unlock()
+			return nil
+		})
+	}
+	_ = g.Wait()
+	p.clients.sweep()
+
+	p.mutex.Lock()
+	p.results = results
+	p.mutex.Unlock()
+}
+
+// Describe sends the descriptors of the vhost probe metrics to the provided channel.
+func (p *VhostProber) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.successDesc
+	ch <- p.statusDesc
+	ch <- p.durationDesc
+}
+
+// Collect sends the results of the last probes to the provided channel.
+func (p *VhostProber) Collect(ch chan<- prometheus.Metric) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	for vhost, result := range p.results {
+		ch <- timestamped(p.timestamps, result.start, prometheus.MustNewConstMetric(p.successDesc, prometheus.GaugeValue, boolToFloat64(result.success), vhost))
+		ch <- timestamped(p.timestamps, result.start, prometheus.MustNewConstMetric(p.durationDesc, prometheus.GaugeValue, result.duration, vhost))
+		if result.status != 0 {
+			ch <- timestamped(p.timestamps, result.start, prometheus.MustNewConstMetric(p.statusDesc, prometheus.GaugeValue, float64(result.status), vhost))
+		}
+	}
+}
+
+// serverNames : http 블록 server 의 server_name 중 Host 헤더로 보낼 수 있는 이름을 중복 없이 반환한다.
+func serverNames(cfg *nginxconf.Config) []string {
+	seen := make(map[string]bool)
+	var names []string
+	cfg.Walk(func(d *nginxconf.Directive, parents []*nginxconf.Directive) {
+		if d.Name != "server_name" || len(parents) < 2 || parents[len(parents)-2].Name != "http" {
+			return
+		}
+		for _, name := range probeHostNames(d) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	})
+	sort.Strings(names)
+	return names
+}
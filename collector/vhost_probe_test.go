@@ -0,0 +1,159 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestVhostProber(t *testing.T) {
+	t.Parallel()
+
+	nginx := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Host {
+		case "www.example.com":
+			if r.URL.Path != "/healthz" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case "old.example.com":
+			http.Redirect(w, r, "https://www.example.com/", http.StatusMovedPermanently)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer nginx.Close()
+
+	dir := t.TempDir()
+	config := `http {
+    server { listen 80 default_server; server_name _; }
+    server { server_name www.example.com .old.example.com; }
+    server { server_name api.example.com *.example.com ~^(?<app>.+)\.example\.com$; }
+}
+stream { server { listen 12345; } }
+`
+	if err := os.WriteFile(filepath.Join(dir, "nginx.conf"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewVhostProber(VhostProbeConfig{URL: nginx.URL, Path: "/healthz"},
+		WithConfigPath(filepath.Join(dir, "nginx.conf")),
+		WithLogger(slog.New(slog.DiscardHandler)),
+	)
+	if err != nil {
+		t.Fatalf("NewVhostProber() returned error: %v", err)
+	}
+	p.probeAll(context.Background())
+
+	expected := `
+# HELP nginx_vhost_probe_status_code HTTP status code of the last probe of a virtual host through NGINX, absent without response
+# TYPE nginx_vhost_probe_status_code gauge
+nginx_vhost_probe_status_code{vhost="api.example.com"} 502
+nginx_vhost_probe_status_code{vhost="old.example.com"} 301
+nginx_vhost_probe_status_code{vhost="www.example.com"} 200
+# HELP nginx_vhost_probe_success Whether the last probe of a virtual host through NGINX got a response below 500
+# TYPE nginx_vhost_probe_success gauge
+nginx_vhost_probe_success{vhost="api.example.com"} 0
+nginx_vhost_probe_success{vhost="old.example.com"} 1
+nginx_vhost_probe_success{vhost="www.example.com"} 1
+`
+	if err := testutil.CollectAndCompare(p, strings.NewReader(expected), "nginx_vhost_probe_status_code", "nginx_vhost_probe_success"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewVhostProberInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	for _, config := range []VhostProbeConfig{
+		{URL: "127.0.0.1:80"},
+		{URL: "ftp://127.0.0.1"},
+		{URL: "http://127.0.0.1", Path: "healthz"},
+	} {
+		if _, err := NewVhostProber(config); err == nil {
+			t.Errorf("NewVhostProber(%+v) returned no error", config)
+		}
+	}
+}
+
+func TestVhostProberKeepAlive(t *testing.T) {
+	t.Parallel()
+
+	nginx := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	var connections atomic.Int32
+	nginx.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	nginx.StartTLS()
+	t.Cleanup(nginx.Close)
+	transport, ok := nginx.Client().Transport.(*http.Transport)
+	if !ok {
+		t.Fatal("the test server client has no *http.Transport")
+	}
+
+	configPath := filepath.Join(t.TempDir(), "nginx.conf")
+	writeConfig := func(config string) {
+		t.Helper()
+		if err := os.WriteFile(configPath, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("http { server { server_name a.example.com; } server { server_name b.example.com; } }\n")
+
+	tests := []struct {
+		name    string
+		maxIdle int
+		want    int32
+	}{
+		{name: "disabled", maxIdle: 0, want: 6},
+		{name: "enabled", maxIdle: 1, want: 2},
+	}
+	for _, tt := range tests {
+		connections.Store(0)
+		p, err := NewVhostProber(VhostProbeConfig{URL: nginx.URL, Transport: transport},
+			WithConfigPath(configPath),
+			WithLogger(slog.New(slog.DiscardHandler)),
+			WithProbeKeepAlive(tt.maxIdle),
+		)
+		if err != nil {
+			t.Fatalf("NewVhostProber() returned error: %v", err)
+		}
+		for range 3 {
+			p.probeAll(t.Context())
+		}
+		if got := connections.Load(); got != tt.want {
+			t.Errorf("%v: probes opened %d connections, want %d", tt.name, got, tt.want)
+		}
+		p.clients.close()
+	}
+
+	// The connections of a virtual host removed from the config are closed.
+	p, err := NewVhostProber(VhostProbeConfig{URL: nginx.URL, Transport: transport},
+		WithConfigPath(configPath),
+		WithLogger(slog.New(slog.DiscardHandler)),
+		WithProbeKeepAlive(1),
+	)
+	if err != nil {
+		t.Fatalf("NewVhostProber() returned error: %v", err)
+	}
+	p.probeAll(t.Context())
+	writeConfig("http { server { server_name a.example.com; } }\n")
+	p.probeAll(t.Context())
+	if _, ok := p.clients.clients["b.example.com"]; ok || len(p.clients.clients) != 1 {
+		t.Errorf("clients of %v remain after the virtual host was removed", p.clients.clients)
+	}
+}
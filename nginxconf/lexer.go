@@ -0,0 +1,179 @@
+package nginxconf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type token struct {
+	value  string
+	line   int
+	quoted bool
+}
+
+// isSpecial reports whether the token is an unquoted block or directive delimiter.
+func (t token) isSpecial(s string) bool {
+	return !t.quoted && t.value == s
+}
+
+// tokenize splits NGINX configuration content into words, quoted strings and the
+// special characters '{', '}' and ';'. Comments are dropped.
+func tokenize(content string) ([]token, error) {
+	var tokens []token
+	var word strings.Builder
+	line := 1
+	wordLine := 1
+
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, token{value: word.String(), line: wordLine})
+			word.Reset()
+		}
+	}
+
+	for i := 0; i < len(content); i++ {
+		ch := content[i]
+		switch {
+		case ch == '\n':
+			flush()
+			line++
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			flush()
+		case ch == '#' && word.Len() == 0:
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+			i--
+		case ch == '{' && word.Len() > 0 && strings.HasSuffix(word.String(), "$"):
+			// ${variable} 형태의 변수는 하나의 단어로 취급한다.
+			end := strings.IndexByte(content[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated variable", line)
+			}
+			word.WriteString(content[i : i+end+1])
+			i += end
+		case ch == '{' && isLuaBlock(tokens, word.String()):
+			// *_by_lua_block 의 내용은 NGINX 설정이 아닌 Lua 코드이므로, 짝이 맞는 } 까지를 하나의 인자로 취급한다.
+			flush()
+			end, err := luaBlockEnd(content, i+1)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			code := content[i+1 : end]
+			tokens = append(tokens, token{value: code, line: line, quoted: true}, token{value: ";", line: line})
+			line += strings.Count(code, "\n")
+			i = end
+		case ch == '{' || ch == '}' || ch == ';':
+			flush()
+			tokens = append(tokens, token{value: string(ch), line: line})
+		case (ch == '"' || ch == '\'') && word.Len() == 0:
+			start := line
+			var quoted strings.Builder
+			i++
+			for ; i < len(content) && content[i] != ch; i++ {
+				if content[i] == '\\' && i+1 < len(content) {
+					i++
+					if content[i] != ch && content[i] != '\\' {
+						quoted.WriteByte('\\')
+					}
+				}
+				if content[i] == '\n' {
+					line++
+				}
+				quoted.WriteByte(content[i])
+			}
+			if i >= len(content) {
+				return nil, fmt.Errorf("line %d: unterminated quoted string", start)
+			}
+			tokens = append(tokens, token{value: quoted.String(), line: start, quoted: true})
+		default:
+			if word.Len() == 0 {
+				wordLine = line
+			}
+			if ch == '\\' && i+1 < len(content) {
+				word.WriteByte(ch)
+				i++
+				ch = content[i]
+			}
+			word.WriteByte(ch)
+		}
+	}
+	flush()
+
+	return tokens, nil
+}
+
+// isLuaBlock reports whether the statement being tokenized, made of the tokens after
+// the last delimiter and the current word, is a *_by_lua_block directive of the Lua
+// module.
+func isLuaBlock(tokens []token, word string) bool {
+	name := word
+	for i := len(tokens) - 1; i >= 0; i-- {
+		t := tokens[i]
+		if t.isSpecial("{") || t.isSpecial("}") || t.isSpecial(";") {
+			break
+		}
+		name = t.value
+	}
+	return strings.HasSuffix(name, "_by_lua_block")
+}
+
+// luaBlockEnd returns the index of the '}' closing a Lua block that starts at start,
+// skipping braces in Lua strings and comments.
+func luaBlockEnd(content string, start int) (int, error) {
+	depth := 0
+	for i := start; i < len(content); i++ {
+		switch ch := content[i]; {
+		case ch == '{':
+			depth++
+		case ch == '}':
+			if depth == 0 {
+				return i, nil
+			}
+			depth--
+		case ch == '"' || ch == '\'':
+			for i++; i < len(content) && content[i] != ch; i++ {
+				if content[i] == '\\' {
+					i++
+				}
+			}
+		case ch == '[' && longBracketLevel(content[i:]) >= 0:
+			i = skipLongBracket(content, i)
+		case strings.HasPrefix(content[i:], "--"):
+			i += 2
+			if i < len(content) && content[i] == '[' && longBracketLevel(content[i:]) >= 0 {
+				i = skipLongBracket(content, i)
+				continue
+			}
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+		}
+	}
+	return 0, errors.New("unterminated Lua block")
+}
+
+// longBracketLevel returns the level of the Lua long bracket, [[ or [==[, at the start
+// of s, or -1 when s does not start with one.
+func longBracketLevel(s string) int {
+	level := 1
+	for level < len(s) && s[level] == '=' {
+		level++
+	}
+	if level < len(s) && s[level] == '[' {
+		return level - 1
+	}
+	return -1
+}
+
+// skipLongBracket returns the index of the last character of the Lua long string or
+// comment starting at i, or the end of content when it is not closed.
+func skipLongBracket(content string, i int) int {
+	closing := "]" + strings.Repeat("=", longBracketLevel(content[i:])) + "]"
+	end := strings.Index(content[i:], closing)
+	if end < 0 {
+		return len(content)
+	}
+	return i + end + len(closing) - 1
+}
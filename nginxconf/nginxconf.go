@@ -0,0 +1,328 @@
+// Package nginxconf parses NGINX configuration files and resolves their include directives.
+package nginxconf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxIncludeDepth bounds nested includes so that include cycles cannot recurse forever.
+const maxIncludeDepth = 32
+
+// Directive represents a single NGINX configuration directive.
+type Directive struct {
+	Name string
+	File string
+	Args []string
+	// Block holds the nested directives of a block directive (e.g. http, server, location).
+	// It is nil for simple directives.
+	Block []*Directive
+	Line  int
+}
+
+// IsBlock reports whether the directive has a nested block.
+func (d *Directive) IsBlock() bool {
+	return d.Block != nil
+}
+
+// MissingInclude represents an include directive that does not resolve to any file.
+type MissingInclude struct {
+	File    string
+	Pattern string
+	Line    int
+}
+
+// UnparsableFile represents an included file that was skipped because it could not be parsed.
+type UnparsableFile struct {
+	Err  error
+	File string
+}
+
+// Config is a parsed NGINX configuration with all include directives resolved.
+type Config struct {
+	// Directives holds the top level directives of the main configuration file.
+	// Directives from included files are inlined in place of the include directive.
+	Directives      []*Directive
+	Files           []string
+	MissingIncludes []MissingInclude
+	// UnparsableFiles holds the included files skipped by a tolerant parser.
+	UnparsableFiles []UnparsableFile
+	parser          Parser
+	root            string
+	seen            map[string]bool
+}
+
+// Parser parses NGINX configuration files. The zero value reads files from the
+// local file system and fails on any file that cannot be parsed.
+type Parser struct {
+	// Files, when not nil, holds the content of every configuration file keyed by
+	// its absolute path and is used instead of the local file system.
+	Files map[string][]byte
+	// Tolerant skips included files that cannot be parsed instead of failing and
+	// records them in UnparsableFiles. Errors in the main configuration file are
+	// still returned.
+	Tolerant bool
+}
+
+// Parse parses the NGINX configuration file at path and all files it includes.
+func Parse(path string) (*Config, error) {
+	return Parser{}.Parse(path)
+}
+
+// ParseTolerant parses like Parse, but skips included files that cannot be parsed.
+func ParseTolerant(path string) (*Config, error) {
+	return Parser{Tolerant: true}.Parse(path)
+}
+
+// Parse parses the NGINX configuration file at path and all files it includes.
+func (p Parser) Parse(path string) (*Config, error) {
+	cfg := &Config{
+		parser: p,
+		root:   filepath.Dir(path),
+		seen:   make(map[string]bool),
+	}
+
+	directives, err := cfg.parseFile(path, 0)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Directives = directives
+
+	return cfg, nil
+}
+
+// Root returns the directory relative include patterns are resolved against.
+func (c *Config) Root() string {
+	return c.root
+}
+
+func (c *Config) parseFile(path string, depth int) ([]*Directive, error) {
+	if depth > maxIncludeDepth {
+		return nil, fmt.Errorf("%v: includes nested too deeply", path)
+	}
+
+	content, err := c.parser.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !c.seen[path] {
+		c.seen[path] = true
+		c.Files = append(c.Files, path)
+	}
+
+	tokens, err := tokenize(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+
+	p := &parser{cfg: c, file: path, tokens: tokens, depth: depth}
+	directives, err := p.parseBlock(false)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+
+	return directives, nil
+}
+
+type parser struct {
+	cfg    *Config
+	file   string
+	tokens []token
+	pos    int
+	depth  int
+}
+
+func (p *parser) parseBlock(nested bool) ([]*Directive, error) {
+	directives := []*Directive{}
+
+	for p.pos < len(p.tokens) {
+		tok := p.tokens[p.pos]
+		p.pos++
+
+		if tok.isSpecial("}") {
+			if !nested {
+				return nil, fmt.Errorf("line %d: unexpected \"}\"", tok.line)
+			}
+			return directives, nil
+		}
+		if tok.isSpecial("{") || tok.isSpecial(";") {
+			return nil, fmt.Errorf("line %d: unexpected %q", tok.line, tok.value)
+		}
+
+		d := &Directive{Name: tok.value, File: p.file, Line: tok.line}
+		terminated := false
+		for p.pos < len(p.tokens) {
+			arg := p.tokens[p.pos]
+			p.pos++
+
+			if arg.isSpecial(";") {
+				terminated = true
+				break
+			}
+			if arg.isSpecial("{") {
+				block, err := p.parseBlock(true)
+				if err != nil {
+					return nil, err
+				}
+				d.Block = block
+				terminated = true
+				break
+			}
+			if arg.isSpecial("}") {
+				return nil, fmt.Errorf("line %d: unexpected \"}\"", arg.line)
+			}
+			d.Args = append(d.Args, arg.value)
+		}
+		if !terminated {
+			return nil, fmt.Errorf("line %d: directive %q is not terminated", d.Line, d.Name)
+		}
+
+		if d.Name == "include" && !d.IsBlock() && len(d.Args) == 1 {
+			included, err := p.include(d)
+			if err != nil {
+				return nil, err
+			}
+			directives = append(directives, included...)
+			continue
+		}
+
+		directives = append(directives, d)
+	}
+
+	if nested {
+		return nil, errors.New("unexpected end of file, expecting \"}\"")
+	}
+
+	return directives, nil
+}
+
+// include resolves the include directive and returns the directives of all matched files.
+func (p *parser) include(d *Directive) ([]*Directive, error) {
+	pattern := d.Args[0]
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(p.cfg.root, pattern)
+	}
+
+	matches, err := p.cfg.parser.glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", d.Line, err)
+	}
+
+	if len(matches) == 0 {
+		p.cfg.MissingIncludes = append(p.cfg.MissingIncludes, MissingInclude{File: p.file, Line: d.Line, Pattern: d.Args[0]})
+		return nil, nil
+	}
+
+	var directives []*Directive
+	for _, match := range matches {
+		included, err := p.cfg.parseFile(match, p.depth+1)
+		if err != nil {
+			if p.cfg.parser.Tolerant {
+				p.cfg.UnparsableFiles = append(p.cfg.UnparsableFiles, UnparsableFile{File: match, Err: err})
+				continue
+			}
+			return nil, err
+		}
+		directives = append(directives, included...)
+	}
+
+	return directives, nil
+}
+
+// Walk calls fn for every directive in the configuration, depth first. The parents
+// argument holds the enclosing block directives, outermost first.
+func (c *Config) Walk(fn func(d *Directive, parents []*Directive)) {
+	walk(c.Directives, nil, fn)
+}
+
+func walk(directives []*Directive, parents []*Directive, fn func(d *Directive, parents []*Directive)) {
+	for _, d := range directives {
+		fn(d, parents)
+		if d.IsBlock() {
+			walk(d.Block, append(parents[:len(parents):len(parents)], d), fn)
+		}
+	}
+}
+
+func (p Parser) readFile(path string) ([]byte, error) {
+	if p.Files == nil {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		return content, nil
+	}
+	content, ok := p.Files[filepath.Clean(path)]
+	if !ok {
+		return nil, fmt.Errorf("failed to read config file: %w", &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist})
+	}
+	return content, nil
+}
+
+// glob returns the regular files matching the include pattern, in lexical order.
+func (p Parser) glob(pattern string) ([]string, error) {
+	if p.Files != nil {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid include pattern %q: %w", pattern, err)
+		}
+		var matches []string
+		for name := range p.Files {
+			if ok, _ := filepath.Match(pattern, name); ok {
+				matches = append(matches, name)
+			}
+		}
+		sort.Strings(matches)
+		return matches, nil
+	}
+
+	matches := []string{pattern}
+	if strings.ContainsAny(pattern, "*?[") {
+		var err error
+		if matches, err = filepath.Glob(pattern); err != nil {
+			return nil, fmt.Errorf("invalid include pattern %q: %w", pattern, err)
+		}
+	}
+
+	files := matches[:0]
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && !info.IsDir() {
+			files = append(files, match)
+		}
+	}
+	return files, nil
+}
+
+// Hash returns a hex encoded SHA-256 hash of the resolved configuration. Comments,
+// whitespace, quoting and the way the configuration is split into included files
+// do not affect the hash.
+func (c *Config) Hash() string {
+	h := sha256.New()
+	writeDirectives(h, c.Directives)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeDirectives(w io.Writer, directives []*Directive) {
+	for _, d := range directives {
+		fmt.Fprint(w, strconv.Quote(d.Name))
+		for _, arg := range d.Args {
+			fmt.Fprint(w, " ", strconv.Quote(arg))
+		}
+		if d.IsBlock() {
+			fmt.Fprint(w, "{")
+			writeDirectives(w, d.Block)
+			fmt.Fprint(w, "}")
+		} else {
+			fmt.Fprint(w, ";")
+		}
+	}
+}
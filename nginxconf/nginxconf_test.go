@@ -0,0 +1,256 @@
+package nginxconf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	dir := writeFiles(t, map[string]string{
+		"nginx.conf": `# main config
+events {}
+http {
+    log_format main '$remote_addr "$request"';
+    include conf.d/*.conf;
+    include sites-enabled/*;
+    include missing.conf;
+}
+`,
+		"conf.d/app.conf": `upstream backend {
+    server 10.0.0.1:8080 weight=2;
+}
+server {
+    listen 80;
+    location /api { proxy_pass http://backend; }
+}
+`,
+	})
+
+	cfg, err := Parse(filepath.Join(dir, "nginx.conf"))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	wantFiles := []string{filepath.Join(dir, "nginx.conf"), filepath.Join(dir, "conf.d/app.conf")}
+	if !reflect.DeepEqual(cfg.Files, wantFiles) {
+		t.Errorf("Parse() files = %v, want %v", cfg.Files, wantFiles)
+	}
+
+	wantMissing := []MissingInclude{
+		{File: filepath.Join(dir, "nginx.conf"), Line: 6, Pattern: "sites-enabled/*"},
+		{File: filepath.Join(dir, "nginx.conf"), Line: 7, Pattern: "missing.conf"},
+	}
+	if !reflect.DeepEqual(cfg.MissingIncludes, wantMissing) {
+		t.Errorf("Parse() missing includes = %v, want %v", cfg.MissingIncludes, wantMissing)
+	}
+
+	var proxyPass *Directive
+	var proxyPassParents []string
+	cfg.Walk(func(d *Directive, parents []*Directive) {
+		if d.Name == "proxy_pass" {
+			proxyPass = d
+			for _, p := range parents {
+				proxyPassParents = append(proxyPassParents, p.Name)
+			}
+		}
+	})
+	if proxyPass == nil {
+		t.Fatal("Parse() did not return the proxy_pass directive from the included file")
+	}
+	if proxyPass.File != filepath.Join(dir, "conf.d/app.conf") || proxyPass.Line != 6 {
+		t.Errorf("proxy_pass position = %v:%v, want %v:6", proxyPass.File, proxyPass.Line, filepath.Join(dir, "conf.d/app.conf"))
+	}
+	if !reflect.DeepEqual(proxyPass.Args, []string{"http://backend"}) {
+		t.Errorf("proxy_pass args = %v, want [http://backend]", proxyPass.Args)
+	}
+	if !reflect.DeepEqual(proxyPassParents, []string{"http", "server", "location"}) {
+		t.Errorf("proxy_pass parents = %v, want [http server location]", proxyPassParents)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "unterminated block",
+			content: "http {\n server {}\n",
+		},
+		{
+			name:    "unterminated directive",
+			content: "worker_processes auto",
+		},
+		{
+			name:    "unexpected closing brace",
+			content: "}",
+		},
+		{
+			name:    "unterminated quote",
+			content: "log_format main '$remote_addr;",
+		},
+		{
+			name:    "unterminated Lua block",
+			content: "http { init_by_lua_block { local t = {} }",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := writeFiles(t, map[string]string{"nginx.conf": tt.content})
+			if _, err := Parse(filepath.Join(dir, "nginx.conf")); err == nil {
+				t.Errorf("Parse() did not return an error for %q", tt.content)
+			}
+		})
+	}
+}
+
+func TestParseLuaBlocks(t *testing.T) {
+	t.Parallel()
+
+	dir := writeFiles(t, map[string]string{
+		"nginx.conf": `http {
+    init_by_lua_block {
+        local cfg = {use_forwarded_headers = false} -- a } in a comment
+        local s = "}" .. '{' .. [==[ } ]==]
+        --[[ { ]]
+    }
+    server {
+        set_by_lua_block $upstream { return "backend" }
+        location / { content_by_lua_block{ ngx.say("{") } }
+        location /api { proxy_pass http://backend; }
+    }
+}
+`,
+	})
+
+	cfg, err := Parse(filepath.Join(dir, "nginx.conf"))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	var names []string
+	var lines []int
+	cfg.Walk(func(d *Directive, _ []*Directive) {
+		names = append(names, d.Name)
+		lines = append(lines, d.Line)
+	})
+	wantNames := []string{"http", "init_by_lua_block", "server", "set_by_lua_block", "location", "content_by_lua_block", "location", "proxy_pass"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("Parse() directives = %v, want %v", names, wantNames)
+	}
+	wantLines := []int{1, 2, 7, 8, 9, 9, 10, 10}
+	if !reflect.DeepEqual(lines, wantLines) {
+		t.Errorf("Parse() directive lines = %v, want %v", lines, wantLines)
+	}
+}
+
+func TestParseTolerant(t *testing.T) {
+	t.Parallel()
+
+	dir := writeFiles(t, map[string]string{
+		"nginx.conf":         "http {\n    include conf.d/*.conf;\n}\n",
+		"conf.d/broken.conf": "server {\n    listen 80;\n",
+		"conf.d/good.conf":   "server {\n    listen 8080;\n}\n",
+	})
+
+	if _, err := Parse(filepath.Join(dir, "nginx.conf")); err == nil {
+		t.Fatal("Parse() did not return an error for the unparsable included file")
+	}
+
+	cfg, err := ParseTolerant(filepath.Join(dir, "nginx.conf"))
+	if err != nil {
+		t.Fatalf("ParseTolerant() returned error: %v", err)
+	}
+	if len(cfg.UnparsableFiles) != 1 || cfg.UnparsableFiles[0].File != filepath.Join(dir, "conf.d/broken.conf") {
+		t.Errorf("ParseTolerant() unparsable files = %v, want %v", cfg.UnparsableFiles, filepath.Join(dir, "conf.d/broken.conf"))
+	}
+
+	var listens []string
+	cfg.Walk(func(d *Directive, _ []*Directive) {
+		if d.Name == "listen" {
+			listens = append(listens, d.Args...)
+		}
+	})
+	if !reflect.DeepEqual(listens, []string{"8080"}) {
+		t.Errorf("ParseTolerant() listen args = %v, want [8080]", listens)
+	}
+}
+
+func TestParserFiles(t *testing.T) {
+	t.Parallel()
+
+	files := map[string][]byte{
+		"/etc/nginx/nginx.conf":       []byte("http {\n    include conf.d/*.conf;\n    include /etc/nginx/missing.conf;\n}\n"),
+		"/etc/nginx/conf.d/b.conf":    []byte("server { listen 8081; }\n"),
+		"/etc/nginx/conf.d/a.conf":    []byte("server { listen 8080; }\n"),
+		"/etc/nginx/conf.d/extra.txt": []byte("not included\n"),
+	}
+
+	cfg, err := Parser{Files: files}.Parse("/etc/nginx/nginx.conf")
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	wantFiles := []string{"/etc/nginx/nginx.conf", "/etc/nginx/conf.d/a.conf", "/etc/nginx/conf.d/b.conf"}
+	if !reflect.DeepEqual(cfg.Files, wantFiles) {
+		t.Errorf("Parse() files = %v, want %v", cfg.Files, wantFiles)
+	}
+	wantMissing := []MissingInclude{{File: "/etc/nginx/nginx.conf", Line: 3, Pattern: "/etc/nginx/missing.conf"}}
+	if !reflect.DeepEqual(cfg.MissingIncludes, wantMissing) {
+		t.Errorf("Parse() missing includes = %v, want %v", cfg.MissingIncludes, wantMissing)
+	}
+}
+
+func TestConfigHash(t *testing.T) {
+	t.Parallel()
+
+	hash := func(files map[string][]byte) string {
+		t.Helper()
+		cfg, err := Parser{Files: files}.Parse("/etc/nginx/nginx.conf")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cfg.Hash()
+	}
+
+	base := hash(map[string][]byte{
+		"/etc/nginx/nginx.conf": []byte("http {\n    server { listen 80; }\n}\n"),
+	})
+	split := hash(map[string][]byte{
+		"/etc/nginx/nginx.conf":      []byte("# main\nhttp {\n  include conf.d/*.conf;\n}\n"),
+		"/etc/nginx/conf.d/app.conf": []byte("server {\n\tlisten \"80\";   # http\n}\n"),
+	})
+	changed := hash(map[string][]byte{
+		"/etc/nginx/nginx.conf": []byte("http {\n    server { listen 8080; }\n}\n"),
+	})
+
+	if base != split {
+		t.Errorf("Hash() = %v for the same config split into included files, want %v", split, base)
+	}
+	if base == changed {
+		t.Errorf("Hash() = %v for a different config, want a different hash", changed)
+	}
+}
@@ -0,0 +1,159 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// handshakeTimeout bounds how long a plugin may take to write its handshake.
+const handshakeTimeout = 10 * time.Second
+
+// DefaultTimeout bounds a Collect call of a plugin by default.
+const DefaultTimeout = 10 * time.Second
+
+// Client runs a plugin as a subprocess and gathers its metrics. It implements
+// prometheus.Gatherer.
+type Client struct {
+	cmd     *exec.Cmd
+	conn    *grpc.ClientConn
+	name    string
+	timeout time.Duration
+}
+
+// Start starts the plugin command, a path followed by its arguments, and connects to
+// it. Collect calls are bounded by timeout, or DefaultTimeout when zero. The standard
+// error of the plugin is passed through to the standard error of the exporter.
+func Start(name string, command []string, timeout time.Duration) (*Client, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("plugin %q has no command", name)
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	// #nosec G204
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Env = append(os.Environ(), MagicCookieKey+"="+MagicCookieValue)
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe of plugin %q: %w", name, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start plugin %q: %w", name, err)
+	}
+
+	h, err := readHandshake(stdout)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, fmt.Errorf("plugin %q: %w", name, err)
+	}
+
+	conn, err := dial(h)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, fmt.Errorf("plugin %q: %w", name, err)
+	}
+
+	return &Client{cmd: cmd, conn: conn, name: name, timeout: timeout}, nil
+}
+
+// readHandshake : plugin 의 첫 줄 출력을 handshake 로 읽는다. 나머지 출력은 버린다.
+func readHandshake(stdout io.Reader) (handshake, error) {
+	type result struct {
+		err  error
+		line string
+	}
+	lines := make(chan result, 1)
+	go func() {
+		reader := bufio.NewReader(stdout)
+		line, err := reader.ReadString('\n')
+		lines <- result{line: line, err: err}
+		// plugin 이 stdout 에 쓰다가 멈추지 않도록, 이후의 출력은 읽어서 버린다.
+		_, _ = io.Copy(io.Discard, reader)
+	}()
+
+	select {
+	case r := <-lines:
+		if r.err != nil {
+			return handshake{}, fmt.Errorf("failed to read handshake: %w", r.err)
+		}
+		return parseHandshake(r.line)
+	case <-time.After(handshakeTimeout):
+		return handshake{}, errors.New("timed out waiting for handshake")
+	}
+}
+
+func dial(h handshake) (*grpc.ClientConn, error) {
+	target := h.address
+	if h.network == "unix" {
+		target = "unix:" + h.address
+	}
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	return conn, nil
+}
+
+// Gather implements prometheus.Gatherer by calling Collect on the plugin.
+func (c *Client) Gather() ([]*dto.MetricFamily, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	families, err := collect(ctx, c.conn)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %q: %w", c.name, err)
+	}
+	return families, nil
+}
+
+// collect : Collect 를 호출하여 stream 으로 받은 metric family 를 모두 반환한다.
+func collect(ctx context.Context, conn grpc.ClientConnInterface) ([]*dto.MetricFamily, error) {
+	stream, err := conn.NewStream(ctx, &serviceDesc.Streams[0], collectMethod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Collect: %w", err)
+	}
+	if err := stream.SendMsg(&emptypb.Empty{}); err != nil {
+		return nil, fmt.Errorf("failed to send collect request: %w", err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, fmt.Errorf("failed to send collect request: %w", err)
+	}
+
+	var families []*dto.MetricFamily
+	for {
+		family := &dto.MetricFamily{}
+		err := stream.RecvMsg(family)
+		if errors.Is(err, io.EOF) {
+			return families, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to receive metric family: %w", err)
+		}
+		families = append(families, family)
+	}
+}
+
+// Close disconnects from the plugin and stops it.
+func (c *Client) Close() error {
+	_ = c.conn.Close()
+	if err := c.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("failed to stop plugin %q: %w", c.name, err)
+	}
+	_ = c.cmd.Wait()
+	return nil
+}
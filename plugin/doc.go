@@ -0,0 +1,25 @@
+// Package plugin implements the gRPC plugin protocol of the exporter. Plugins are
+// separate executables that export metrics of their own, e.g. for proprietary NGINX
+// modules, without being compiled into the exporter.
+//
+// The exporter starts a plugin as a subprocess with the MagicCookieKey environment
+// variable set to MagicCookieValue. The plugin listens on a loopback address and
+// writes a handshake line to its standard output:
+//
+//	1|1|tcp|127.0.0.1:41235|grpc
+//
+// holding the core protocol version, the plugin protocol version, the network, the
+// address and the protocol, in the format of hashicorp/go-plugin. The exporter then
+// calls the Collect method of the Collector service (see plugin.proto) on every
+// scrape and merges the returned metric families into its own.
+//
+// A plugin written in Go only needs to call Serve with a prometheus.Gatherer:
+//
+//	func main() {
+//		registry := prometheus.NewRegistry()
+//		registry.MustRegister(newModuleCollector())
+//		if err := plugin.Serve(registry); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+package plugin
@@ -0,0 +1,73 @@
+package plugin
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestParseHandshake(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		line    string
+		want    handshake
+		wantErr bool
+	}{
+		{line: "1|1|tcp|127.0.0.1:1234|grpc\n", want: handshake{network: "tcp", address: "127.0.0.1:1234"}},
+		{line: "1|1|unix|/tmp/plugin.sock|grpc", want: handshake{network: "unix", address: "/tmp/plugin.sock"}},
+		{line: "2|1|tcp|127.0.0.1:1234|grpc", wantErr: true},
+		{line: "1|2|tcp|127.0.0.1:1234|grpc", wantErr: true},
+		{line: "1|1|tcp|127.0.0.1:1234|netrpc", wantErr: true},
+		{line: "1|1|udp|127.0.0.1:1234|grpc", wantErr: true},
+		{line: "plugin starting", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseHandshake(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseHandshake() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseHandshake() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollect(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "module_sessions", Help: "Sessions of the module"})
+	gauge.Set(3)
+	registry.MustRegister(gauge)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() { _ = serve(listener, registry, io.Discard) }()
+
+	conn, err := dial(handshake{network: "tcp", address: listener.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	families, err := collect(ctx, conn)
+	if err != nil {
+		t.Fatalf("collect() returned error: %v", err)
+	}
+	if len(families) != 1 || families[0].GetName() != "module_sessions" || families[0].GetMetric()[0].GetGauge().GetValue() != 3 {
+		t.Errorf("collect() = %v, want module_sessions 3", families)
+	}
+}
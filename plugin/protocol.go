@@ -0,0 +1,111 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Versions of the handshake.
+const (
+	CoreProtocolVersion = 1
+	ProtocolVersion     = 1
+)
+
+// MagicCookieKey and MagicCookieValue are the environment variable the exporter sets
+// when starting a plugin, so that a plugin started by hand can tell it is not run by
+// the exporter.
+const (
+	MagicCookieKey   = "NGINX_EXPORTER_PLUGIN"
+	MagicCookieValue = "7c2b1f4e0d5a4e8c9b6a3f1d2e8c4b5a"
+)
+
+const (
+	serviceName   = "nginx_exporter.plugin.v1.Collector"
+	collectMethod = "/" + serviceName + "/Collect"
+)
+
+// collectorServer is implemented by the server of the Collector service.
+type collectorServer interface {
+	gatherer() prometheus.Gatherer
+}
+
+type server struct {
+	g prometheus.Gatherer
+}
+
+func (s *server) gatherer() prometheus.Gatherer {
+	return s.g
+}
+
+// serviceDesc describes the Collector service of plugin.proto. The messages are the
+// protobuf messages of the Prometheus client model, so no generated code is needed.
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*collectorServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Collect",
+			Handler:       collectHandler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "plugin.proto",
+}
+
+// collectHandler : Collect 요청마다 gatherer 의 metric family 를 stream 으로 전송한다.
+func collectHandler(srv any, stream grpc.ServerStream) error {
+	if err := stream.RecvMsg(&emptypb.Empty{}); err != nil {
+		return fmt.Errorf("failed to receive collect request: %w", err)
+	}
+	s, ok := srv.(collectorServer)
+	if !ok {
+		return errors.New("unexpected Collector service implementation")
+	}
+	families, err := s.gatherer().Gather()
+	if err != nil && len(families) == 0 {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+	for _, family := range families {
+		if err := stream.SendMsg(family); err != nil {
+			return fmt.Errorf("failed to send metric family: %w", err)
+		}
+	}
+	return nil
+}
+
+// handshake is the first line a plugin writes to its standard output.
+type handshake struct {
+	network string
+	address string
+}
+
+func (h handshake) String() string {
+	return fmt.Sprintf("%d|%d|%s|%s|grpc", CoreProtocolVersion, ProtocolVersion, h.network, h.address)
+}
+
+// parseHandshake : plugin 이 출력한 handshake 줄을 파싱하고, 버전과 protocol 을 검증한다.
+func parseHandshake(line string) (handshake, error) {
+	parts := strings.Split(strings.TrimSpace(line), "|")
+	if len(parts) != 5 {
+		return handshake{}, fmt.Errorf("invalid plugin handshake %q", line)
+	}
+	if core, err := strconv.Atoi(parts[0]); err != nil || core != CoreProtocolVersion {
+		return handshake{}, fmt.Errorf("unsupported plugin core protocol version %q", parts[0])
+	}
+	if version, err := strconv.Atoi(parts[1]); err != nil || version != ProtocolVersion {
+		return handshake{}, fmt.Errorf("unsupported plugin protocol version %q", parts[1])
+	}
+	if parts[2] != "tcp" && parts[2] != "unix" {
+		return handshake{}, fmt.Errorf("unsupported plugin network %q", parts[2])
+	}
+	if parts[4] != "grpc" {
+		return handshake{}, errors.New("plugin does not speak grpc")
+	}
+	return handshake{network: parts[2], address: parts[3]}, nil
+}
@@ -0,0 +1,43 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+)
+
+// Serve serves the metrics of gatherer to the exporter that started the plugin. It
+// listens on a loopback address, writes the handshake to standard output and blocks
+// until the exporter stops the plugin.
+func Serve(gatherer prometheus.Gatherer) error {
+	if os.Getenv(MagicCookieKey) != MagicCookieValue {
+		return errors.New("this binary is a plugin of nginx-prometheus-exporter and is not meant to be run directly")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	return serve(listener, gatherer, os.Stdout)
+}
+
+// serve : handshake 를 out 에 출력한 뒤, listener 에서 Collector 서비스를 제공한다.
+func serve(listener net.Listener, gatherer prometheus.Gatherer, out io.Writer) error {
+	srv := grpc.NewServer()
+	srv.RegisterService(&serviceDesc, &server{g: gatherer})
+
+	h := handshake{network: listener.Addr().Network(), address: listener.Addr().String()}
+	if _, err := fmt.Fprintln(out, h); err != nil {
+		return fmt.Errorf("failed to write handshake: %w", err)
+	}
+
+	if err := srv.Serve(listener); err != nil {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+	return nil
+}
@@ -3,5 +3,5 @@ set -e
 rm -rf completions
 mkdir completions
 for shell in bash zsh; do
-    go run . --completion-script-$shell >completions/nginx-prometheus-exporter.$shell
+    go run ./cmd/nginx-prometheus-exporter --completion-script-$shell >completions/nginx-prometheus-exporter.$shell
 done
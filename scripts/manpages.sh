@@ -2,4 +2,4 @@
 set -e
 rm -rf manpages
 mkdir manpages
-go run . --help-man | gzip -c -9 >manpages/nginx-prometheus-exporter.1.gz
+go run ./cmd/nginx-prometheus-exporter --help-man | gzip -c -9 >manpages/nginx-prometheus-exporter.1.gz